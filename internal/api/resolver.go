@@ -51,6 +51,12 @@ func (r *Resolver) Gallery() GalleryResolver {
 func (r *Resolver) GalleryChapter() GalleryChapterResolver {
 	return &galleryChapterResolver{r}
 }
+func (r *Resolver) DeletedStashID() DeletedStashIDResolver {
+	return &deletedStashIDResolver{r}
+}
+func (r *Resolver) IdentifyReview() IdentifyReviewResolver {
+	return &identifyReviewResolver{r}
+}
 func (r *Resolver) Mutation() MutationResolver {
 	return &mutationResolver{r}
 }
@@ -88,6 +94,8 @@ type subscriptionResolver struct{ *Resolver }
 
 type galleryResolver struct{ *Resolver }
 type galleryChapterResolver struct{ *Resolver }
+type deletedStashIDResolver struct{ *Resolver }
+type identifyReviewResolver struct{ *Resolver }
 type performerResolver struct{ *Resolver }
 type sceneResolver struct{ *Resolver }
 type sceneMarkerResolver struct{ *Resolver }
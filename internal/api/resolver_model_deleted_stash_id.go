@@ -0,0 +1,32 @@
+package api
+
+import (
+	"context"
+
+	"github.com/stashapp/stash/internal/api/loaders"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *deletedStashIDResolver) Scene(ctx context.Context, obj *models.DeletedStashID) (*models.Scene, error) {
+	if obj.SceneID == nil {
+		return nil, nil
+	}
+
+	return loaders.From(ctx).SceneByID.Load(*obj.SceneID)
+}
+
+func (r *deletedStashIDResolver) Performer(ctx context.Context, obj *models.DeletedStashID) (*models.Performer, error) {
+	if obj.PerformerID == nil {
+		return nil, nil
+	}
+
+	return loaders.From(ctx).PerformerByID.Load(*obj.PerformerID)
+}
+
+func (r *deletedStashIDResolver) Studio(ctx context.Context, obj *models.DeletedStashID) (*models.Studio, error) {
+	if obj.StudioID == nil {
+		return nil, nil
+	}
+
+	return loaders.From(ctx).StudioByID.Load(*obj.StudioID)
+}
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"context"
+
+	"github.com/stashapp/stash/internal/api/loaders"
+	"github.com/stashapp/stash/internal/identify"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scraper"
+)
+
+func (r *identifyReviewResolver) Scene(ctx context.Context, obj *models.IdentifyReview) (*models.Scene, error) {
+	return loaders.From(ctx).SceneByID.Load(obj.SceneID)
+}
+
+func (r *identifyReviewResolver) Scores(ctx context.Context, obj *models.IdentifyReview) ([]*identify.ConfidenceScore, error) {
+	data, err := identify.DecodeReviewData(obj.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	return data.Scores, nil
+}
+
+func (r *identifyReviewResolver) Scraped(ctx context.Context, obj *models.IdentifyReview) (*scraper.ScrapedScene, error) {
+	data, err := identify.DecodeReviewData(obj.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	return data.Scene(), nil
+}
@@ -340,6 +340,13 @@ func (r *mutationResolver) ConfigureGeneral(ctx context.Context, input ConfigGen
 		c.Set(config.StashBoxes, input.StashBoxes)
 	}
 
+	if input.StashServers != nil {
+		if err := c.ValidateStashServers(input.StashServers); err != nil {
+			return nil, err
+		}
+		c.Set(config.StashServers, input.StashServers)
+	}
+
 	if input.PythonPath != nil {
 		c.Set(config.PythonPath, input.PythonPath)
 	}
@@ -497,6 +504,10 @@ func (r *mutationResolver) ConfigureDlna(ctx context.Context, input ConfigDLNAIn
 		c.Set(config.DLNADefaultIPWhitelist, input.WhitelistedIPs)
 	}
 
+	if input.ActivityExcludedIPs != nil {
+		c.Set(config.DLNAActivityExcludedIPs, input.ActivityExcludedIPs)
+	}
+
 	if input.VideoSortOrder != nil {
 		c.Set(config.DLNAVideoSortOrder, input.VideoSortOrder)
 	}
@@ -541,6 +552,31 @@ func (r *mutationResolver) ConfigureScraping(ctx context.Context, input ConfigSc
 		refreshScraperCache = true
 	}
 
+	if input.ScraperCDPPoolMin != nil {
+		if *input.ScraperCDPPoolMin < 0 {
+			return makeConfigScrapingResult(), errors.New("scraperCDPPoolMin must not be negative")
+		}
+		c.Set(config.ScraperCDPPoolMin, input.ScraperCDPPoolMin)
+	}
+
+	if input.ScraperCDPPoolMax != nil {
+		if *input.ScraperCDPPoolMax < 1 {
+			return makeConfigScrapingResult(), errors.New("scraperCDPPoolMax must be at least 1")
+		}
+		c.Set(config.ScraperCDPPoolMax, input.ScraperCDPPoolMax)
+	}
+
+	if input.ScraperCDPIdleTimeout != nil {
+		if *input.ScraperCDPIdleTimeout < 1 {
+			return makeConfigScrapingResult(), errors.New("scraperCDPIdleTimeout must be at least 1")
+		}
+		c.Set(config.ScraperCDPIdleTimeout, input.ScraperCDPIdleTimeout)
+	}
+
+	if input.ScraperCDPBlockResources != nil {
+		c.Set(config.ScraperCDPBlockResources, input.ScraperCDPBlockResources)
+	}
+
 	if input.ExcludeTagPatterns != nil {
 		for _, r := range input.ExcludeTagPatterns {
 			_, err := regexp.Compile(r)
@@ -555,6 +591,17 @@ func (r *mutationResolver) ConfigureScraping(ctx context.Context, input ConfigSc
 		c.Set(config.ScraperCertCheck, input.ScraperCertCheck)
 	}
 
+	var changedCredentials []string
+	if input.Credentials != nil {
+		var creds []*models.ScraperCredentials
+		var err error
+		creds, changedCredentials, err = mergeScraperCredentials(c.GetAllScraperCredentials(), input.Credentials)
+		if err != nil {
+			return makeConfigScrapingResult(), err
+		}
+		c.Set(config.ScraperCredentials, creds)
+	}
+
 	if refreshScraperCache {
 		manager.GetInstance().RefreshScraperCache()
 	}
@@ -562,9 +609,69 @@ func (r *mutationResolver) ConfigureScraping(ctx context.Context, input ConfigSc
 		return makeConfigScrapingResult(), err
 	}
 
+	// sessions logged in with the old credentials are discarded
+	for _, id := range changedCredentials {
+		if err := manager.GetInstance().ScraperCache.ClearSession(id); err != nil {
+			logger.Warnf("error clearing session of scraper %s: %v", id, err)
+		}
+	}
+
 	return makeConfigScrapingResult(), nil
 }
 
+// mergeScraperCredentials returns the credentials to store, keeping the
+// stored password of scrapers where none is given, and the ids of the
+// scrapers whose credentials changed.
+func mergeScraperCredentials(existing []*models.ScraperCredentials, input []*ScraperCredentialsInput) ([]*models.ScraperCredentials, []string, error) {
+	existingMap := make(map[string]*models.ScraperCredentials)
+	for _, e := range existing {
+		existingMap[e.ScraperID] = e
+	}
+
+	var ret []*models.ScraperCredentials
+	var changed []string
+	seen := make(map[string]bool)
+	for _, i := range input {
+		if i.ScraperID == "" {
+			return nil, nil, errors.New("scraper id cannot be blank")
+		}
+		if i.Username == "" {
+			return nil, nil, fmt.Errorf("username for scraper %s cannot be blank", i.ScraperID)
+		}
+		if seen[i.ScraperID] {
+			return nil, nil, fmt.Errorf("duplicate credentials for scraper %s", i.ScraperID)
+		}
+		seen[i.ScraperID] = true
+
+		e := existingMap[i.ScraperID]
+		creds := &models.ScraperCredentials{
+			ScraperID: i.ScraperID,
+			Username:  i.Username,
+		}
+
+		switch {
+		case i.Password != nil:
+			creds.Password = *i.Password
+		case e != nil:
+			creds.Password = e.Password
+		}
+
+		if e == nil || *e != *creds {
+			changed = append(changed, i.ScraperID)
+		}
+
+		ret = append(ret, creds)
+	}
+
+	for _, e := range existing {
+		if !seen[e.ScraperID] {
+			changed = append(changed, e.ScraperID)
+		}
+	}
+
+	return ret, changed, nil
+}
+
 func (r *mutationResolver) ConfigureDefaults(ctx context.Context, input ConfigDefaultSettingsInput) (*ConfigDefaultSettingsResult, error) {
 	c := config.GetInstance()
 
@@ -2,9 +2,11 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/stashapp/stash/internal/manager"
+	"github.com/stashapp/stash/pkg/sliceutil/stringslice"
 )
 
 func (r *mutationResolver) EnableDlna(ctx context.Context, input EnableDLNAInput) (bool, error) {
@@ -30,6 +32,73 @@ func (r *mutationResolver) RemoveTempDlnaip(ctx context.Context, input RemoveTem
 	return ret, nil
 }
 
+func (r *mutationResolver) DlnaCast(ctx context.Context, input DLNACastInput) (bool, error) {
+	sceneIDs, err := stringslice.StringSliceToIntSlice(input.SceneIds)
+	if err != nil {
+		return false, err
+	}
+
+	var start time.Duration
+	if input.Start != nil {
+		start = parseSeconds(*input.Start)
+	}
+
+	if err := manager.GetInstance().DLNAService.Cast(ctx, input.RendererID, sceneIDs, start); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *mutationResolver) DlnaPlay(ctx context.Context, rendererID string) (bool, error) {
+	if err := manager.GetInstance().DLNAService.CastPlay(ctx, rendererID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *mutationResolver) DlnaPause(ctx context.Context, rendererID string) (bool, error) {
+	if err := manager.GetInstance().DLNAService.CastPause(ctx, rendererID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *mutationResolver) DlnaStop(ctx context.Context, rendererID string) (bool, error) {
+	if err := manager.GetInstance().DLNAService.CastStop(ctx, rendererID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *mutationResolver) DlnaNext(ctx context.Context, rendererID string) (bool, error) {
+	if err := manager.GetInstance().DLNAService.CastNext(ctx, rendererID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *mutationResolver) DlnaSeek(ctx context.Context, input DLNASeekInput) (bool, error) {
+	if err := manager.GetInstance().DLNAService.CastSeek(ctx, input.RendererID, parseSeconds(input.Position)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *mutationResolver) DlnaSetVolume(ctx context.Context, input DLNASetVolumeInput) (bool, error) {
+	if input.Volume < 0 || input.Volume > 100 {
+		return false, fmt.Errorf("volume must be between 0 and 100")
+	}
+
+	if err := manager.GetInstance().DLNAService.CastSetVolume(ctx, input.RendererID, input.Volume); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func parseSeconds(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
 func parseMinutes(minutes *int) *time.Duration {
 	var ret *time.Duration
 	if minutes != nil {
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/stashapp/stash/internal/identify"
+	"github.com/stashapp/stash/internal/manager"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *mutationResolver) AcceptReview(ctx context.Context, id string) (bool, error) {
+	reviewID, err := strconv.Atoi(id)
+	if err != nil {
+		return false, err
+	}
+
+	var review *models.IdentifyReview
+	var scene *models.Scene
+	if err := r.withReadTxn(ctx, func(ctx context.Context) error {
+		review, err = r.repository.IdentifyReview.Find(ctx, reviewID)
+		if err != nil {
+			return err
+		}
+		if review == nil {
+			return fmt.Errorf("review with id %d not found", reviewID)
+		}
+
+		scene, err = r.repository.Scene.Find(ctx, review.SceneID)
+		if err != nil {
+			return err
+		}
+		if scene == nil {
+			return fmt.Errorf("scene with id %d not found", review.SceneID)
+		}
+
+		return nil
+	}); err != nil {
+		return false, err
+	}
+
+	task := identify.SceneIdentifier{
+		SceneReaderUpdater:          r.repository.Scene,
+		StudioCreator:               r.repository.Studio,
+		PerformerCreator:            r.repository.Performer,
+		TagCreator:                  r.repository.Tag,
+		SceneUpdatePostHookExecutor: manager.GetInstance().PluginCache,
+	}
+
+	if err := task.ApplyReview(ctx, r.txnManager, scene, review); err != nil {
+		return false, err
+	}
+
+	if err := r.withTxn(ctx, func(ctx context.Context) error {
+		return r.repository.IdentifyReview.Destroy(ctx, reviewID)
+	}); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *mutationResolver) RejectReview(ctx context.Context, id string) (bool, error) {
+	reviewID, err := strconv.Atoi(id)
+	if err != nil {
+		return false, err
+	}
+
+	if err := r.withTxn(ctx, func(ctx context.Context) error {
+		return r.repository.IdentifyReview.Destroy(ctx, reviewID)
+	}); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
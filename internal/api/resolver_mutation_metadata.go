@@ -96,6 +96,33 @@ func (r *mutationResolver) MetadataIdentify(ctx context.Context, input identify.
 	return strconv.Itoa(jobID), nil
 }
 
+func (r *mutationResolver) MetadataDetectIntros(ctx context.Context, input manager.DetectIntrosInput) (string, error) {
+	jobID, err := manager.GetInstance().DetectIntros(ctx, input)
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.Itoa(jobID), nil
+}
+
+func (r *mutationResolver) MetadataReencode(ctx context.Context, input manager.ReencodeInput) (string, error) {
+	jobID, err := manager.GetInstance().Reencode(ctx, input)
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.Itoa(jobID), nil
+}
+
+func (r *mutationResolver) MetadataRemux(ctx context.Context, input manager.RemuxInput) (string, error) {
+	jobID, err := manager.GetInstance().Remux(ctx, input)
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.Itoa(jobID), nil
+}
+
 func (r *mutationResolver) MetadataClean(ctx context.Context, input manager.CleanMetadataInput) (string, error) {
 	jobID := manager.GetInstance().Clean(ctx, input)
 	return strconv.Itoa(jobID), nil
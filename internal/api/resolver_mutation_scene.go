@@ -548,6 +548,24 @@ func (r *mutationResolver) SceneAssignFile(ctx context.Context, input AssignScen
 	return true, nil
 }
 
+func (r *mutationResolver) SceneSplit(ctx context.Context, input manager.SceneSplitInput) (string, error) {
+	jobID, err := manager.GetInstance().SplitScene(ctx, input)
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.Itoa(jobID), nil
+}
+
+func (r *mutationResolver) SceneTrim(ctx context.Context, input manager.SceneTrimInput) (string, error) {
+	jobID, err := manager.GetInstance().TrimScene(ctx, input)
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.Itoa(jobID), nil
+}
+
 func (r *mutationResolver) SceneMerge(ctx context.Context, input SceneMergeInput) (*models.Scene, error) {
 	srcIDs, err := stringslice.StringSliceToIntSlice(input.Source)
 	if err != nil {
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/stashapp/stash/internal/manager"
+	"github.com/stashapp/stash/pkg/scraper"
 )
 
 func (r *mutationResolver) ReloadScrapers(ctx context.Context) (bool, error) {
@@ -15,3 +16,7 @@ func (r *mutationResolver) ReloadScrapers(ctx context.Context) (bool, error) {
 
 	return true, nil
 }
+
+func (r *mutationResolver) TestScraper(ctx context.Context, input scraper.ScraperTestInput) (*scraper.ScraperTestResult, error) {
+	return r.scraperCache().TestScraper(ctx, input)
+}
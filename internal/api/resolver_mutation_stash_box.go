@@ -17,6 +17,7 @@ func (r *Resolver) stashboxRepository() stashbox.Repository {
 		Performer: r.repository.Performer,
 		Tag:       r.repository.Tag,
 		Studio:    r.repository.Studio,
+		File:      r.repository.File,
 	}
 }
 
@@ -37,6 +38,56 @@ func (r *mutationResolver) StashBoxBatchPerformerTag(ctx context.Context, input
 	return strconv.Itoa(jobID), nil
 }
 
+func (r *mutationResolver) StashBoxBatchStudioTag(ctx context.Context, input manager.StashBoxBatchStudioTagInput) (string, error) {
+	jobID := manager.GetInstance().StashBoxBatchStudioTag(ctx, input)
+	return strconv.Itoa(jobID), nil
+}
+
+func (r *mutationResolver) StashBoxSync(ctx context.Context, input manager.StashBoxSyncInput) (string, error) {
+	t, err := manager.CreateStashBoxSyncJob(input)
+	if err != nil {
+		return "", err
+	}
+
+	jobID := manager.GetInstance().JobManager.Add(ctx, "Syncing with stash-box...", t)
+	return strconv.Itoa(jobID), nil
+}
+
+func (r *mutationResolver) StashBoxTagSync(ctx context.Context, input manager.StashBoxTagSyncInput) (string, error) {
+	t, err := manager.CreateStashBoxTagSyncJob(input)
+	if err != nil {
+		return "", err
+	}
+
+	jobID := manager.GetInstance().JobManager.Add(ctx, "Syncing tags with stash-box...", t)
+	return strconv.Itoa(jobID), nil
+}
+
+func (r *mutationResolver) StashSync(ctx context.Context, input manager.StashSyncInput) (string, error) {
+	t, err := manager.CreateStashSyncJob(input)
+	if err != nil {
+		return "", err
+	}
+
+	jobID := manager.GetInstance().JobManager.Add(ctx, "Syncing with stash server...", t)
+	return strconv.Itoa(jobID), nil
+}
+
+func (r *mutationResolver) DismissDeletedStashID(ctx context.Context, id string) (bool, error) {
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		return false, err
+	}
+
+	if err := r.withTxn(ctx, func(ctx context.Context) error {
+		return r.repository.DeletedStashID.Destroy(ctx, idInt)
+	}); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
 func (r *mutationResolver) SubmitStashBoxSceneDraft(ctx context.Context, input StashBoxDraftSubmissionInput) (*string, error) {
 	boxes := config.GetInstance().GetStashBoxes()
 
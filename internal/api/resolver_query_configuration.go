@@ -127,6 +127,7 @@ func makeConfigGeneralResult() *ConfigGeneralResult {
 		ScraperCertCheck:              config.GetScraperCertCheck(),
 		ScraperCDPPath:                &scraperCDPPath,
 		StashBoxes:                    config.GetStashBoxes(),
+		StashServers:                  config.GetStashServers(),
 		PythonPath:                    config.GetPythonPath(),
 		TranscodeInputArgs:            config.GetTranscodeInputArgs(),
 		TranscodeOutputArgs:           config.GetTranscodeOutputArgs(),
@@ -199,11 +200,12 @@ func makeConfigDLNAResult() *ConfigDLNAResult {
 	config := config.GetInstance()
 
 	return &ConfigDLNAResult{
-		ServerName:     config.GetDLNAServerName(),
-		Enabled:        config.GetDLNADefaultEnabled(),
-		WhitelistedIPs: config.GetDLNADefaultIPWhitelist(),
-		Interfaces:     config.GetDLNAInterfaces(),
-		VideoSortOrder: config.GetVideoSortOrder(),
+		ServerName:          config.GetDLNAServerName(),
+		Enabled:             config.GetDLNADefaultEnabled(),
+		WhitelistedIPs:      config.GetDLNADefaultIPWhitelist(),
+		ActivityExcludedIPs: config.GetDLNAActivityExcludedIPs(),
+		Interfaces:          config.GetDLNAInterfaces(),
+		VideoSortOrder:      config.GetVideoSortOrder(),
 	}
 }
 
@@ -213,11 +215,25 @@ func makeConfigScrapingResult() *ConfigScrapingResult {
 	scraperUserAgent := config.GetScraperUserAgent()
 	scraperCDPPath := config.GetScraperCDPPath()
 
+	// passwords are never returned
+	credentials := []*models.ScraperCredentials{}
+	for _, c := range config.GetAllScraperCredentials() {
+		credentials = append(credentials, &models.ScraperCredentials{
+			ScraperID: c.ScraperID,
+			Username:  c.Username,
+		})
+	}
+
 	return &ConfigScrapingResult{
-		ScraperUserAgent:   &scraperUserAgent,
-		ScraperCertCheck:   config.GetScraperCertCheck(),
-		ScraperCDPPath:     &scraperCDPPath,
-		ExcludeTagPatterns: config.GetScraperExcludeTagPatterns(),
+		ScraperUserAgent:         &scraperUserAgent,
+		ScraperCertCheck:         config.GetScraperCertCheck(),
+		ScraperCDPPath:           &scraperCDPPath,
+		ScraperCDPPoolMin:        config.GetScraperCDPPoolMin(),
+		ScraperCDPPoolMax:        config.GetScraperCDPPoolMax(),
+		ScraperCDPIdleTimeout:    config.GetScraperCDPIdleTimeout(),
+		ScraperCDPBlockResources: config.GetScraperCDPBlockResources(),
+		ExcludeTagPatterns:       config.GetScraperExcludeTagPatterns(),
+		Credentials:              credentials,
 	}
 }
 
@@ -0,0 +1,21 @@
+package api
+
+import (
+	"context"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *queryResolver) DeletedStashIDs(ctx context.Context, endpoint *string) (ret []*models.DeletedStashID, err error) {
+	if err := r.withReadTxn(ctx, func(ctx context.Context) error {
+		if endpoint != nil {
+			ret, err = r.repository.DeletedStashID.FindByEndpoint(ctx, *endpoint)
+		} else {
+			ret, err = r.repository.DeletedStashID.All(ctx)
+		}
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
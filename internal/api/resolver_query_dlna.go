@@ -10,3 +10,7 @@ import (
 func (r *queryResolver) DlnaStatus(ctx context.Context) (*dlna.Status, error) {
 	return manager.GetInstance().DLNAService.Status(), nil
 }
+
+func (r *queryResolver) DlnaRenderers(ctx context.Context, refresh *bool) ([]*dlna.Renderer, error) {
+	return manager.GetInstance().DLNAService.Renderers(ctx, refresh != nil && *refresh)
+}
@@ -0,0 +1,17 @@
+package api
+
+import (
+	"context"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *queryResolver) IdentifyPendingReviews(ctx context.Context) (ret []*models.IdentifyReview, err error) {
+	if err := r.withReadTxn(ctx, func(ctx context.Context) error {
+		ret, err = r.repository.IdentifyReview.All(ctx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
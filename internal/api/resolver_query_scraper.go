@@ -218,6 +218,15 @@ func (r *queryResolver) ScrapeGalleryURL(ctx context.Context, url string) (*scra
 	return marshalScrapedGallery(content)
 }
 
+func (r *queryResolver) ScrapeImageURL(ctx context.Context, url string) (*scraper.ScrapedImage, error) {
+	content, err := r.scraperCache().ScrapeURL(ctx, url, scraper.ScrapeContentTypeImage)
+	if err != nil {
+		return nil, err
+	}
+
+	return marshalScrapedImage(content)
+}
+
 func (r *queryResolver) ScrapeMovieURL(ctx context.Context, url string) (*models.ScrapedMovie, error) {
 	content, err := r.scraperCache().ScrapeURL(ctx, url, scraper.ScrapeContentTypeMovie)
 	if err != nil {
@@ -227,6 +236,15 @@ func (r *queryResolver) ScrapeMovieURL(ctx context.Context, url string) (*models
 	return marshalScrapedMovie(content)
 }
 
+func (r *queryResolver) ScrapeStudioURL(ctx context.Context, url string) (*models.ScrapedStudio, error) {
+	content, err := r.scraperCache().ScrapeURL(ctx, url, scraper.ScrapeContentTypeStudio)
+	if err != nil {
+		return nil, err
+	}
+
+	return marshalScrapedStudio(content)
+}
+
 func (r *queryResolver) getStashBoxClient(index int) (*stashbox.Client, error) {
 	boxes := config.GetInstance().GetStashBoxes()
 
@@ -426,6 +444,187 @@ func (r *queryResolver) ScrapeSingleGallery(ctx context.Context, source scraper.
 	}
 }
 
+func (r *queryResolver) ScrapeSingleImage(ctx context.Context, source scraper.Source, input ScrapeSingleImageInput) ([]*scraper.ScrapedImage, error) {
+	if source.StashBoxIndex != nil || source.StashBoxEndpoint != nil {
+		return nil, ErrNotSupported
+	}
+
+	if source.ScraperID == nil {
+		return nil, fmt.Errorf("%w: scraper_id must be set", ErrInput)
+	}
+
+	var c scraper.ScrapedContent
+
+	switch {
+	case input.ImageID != nil:
+		imageID, err := strconv.Atoi(*input.ImageID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: image id is not an integer: '%s'", ErrInput, *input.ImageID)
+		}
+		c, err = r.scraperCache().ScrapeID(ctx, *source.ScraperID, imageID, scraper.ScrapeContentTypeImage)
+		if err != nil {
+			return nil, err
+		}
+		return marshalScrapedImages([]scraper.ScrapedContent{c})
+	case input.ImageInput != nil:
+		c, err := r.scraperCache().ScrapeFragment(ctx, *source.ScraperID, scraper.Input{Image: input.ImageInput})
+		if err != nil {
+			return nil, err
+		}
+		return marshalScrapedImages([]scraper.ScrapedContent{c})
+	default:
+		return nil, ErrNotImplemented
+	}
+}
+
 func (r *queryResolver) ScrapeSingleMovie(ctx context.Context, source scraper.Source, input ScrapeSingleMovieInput) ([]*models.ScrapedMovie, error) {
 	return nil, ErrNotSupported
 }
+
+func (r *queryResolver) ScrapeSingleStudio(ctx context.Context, source scraper.Source, input ScrapeSingleStudioInput) ([]*models.ScrapedStudio, error) {
+	var studio *models.Studio
+	var stashIDs []models.StashID
+	if input.StudioID != nil {
+		studioID, err := strconv.Atoi(*input.StudioID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: studio id is not an integer: '%s'", ErrInput, *input.StudioID)
+		}
+
+		if err := r.withReadTxn(ctx, func(ctx context.Context) error {
+			studio, err = r.repository.Studio.Find(ctx, studioID)
+			if err != nil || studio == nil {
+				return err
+			}
+
+			stashIDs, err = r.repository.Studio.GetStashIDs(ctx, studioID)
+			return err
+		}); err != nil {
+			return nil, err
+		}
+
+		if studio == nil {
+			return nil, fmt.Errorf("%w: studio with id %s not found", ErrInput, *input.StudioID)
+		}
+	}
+
+	switch {
+	case source.ScraperID != nil:
+		var query string
+		switch {
+		case input.Query != nil:
+			query = *input.Query
+		case studio != nil:
+			query = studio.Name
+		default:
+			return nil, fmt.Errorf("%w: studio_id or query must be set", ErrInput)
+		}
+
+		content, err := r.scraperCache().ScrapeName(ctx, *source.ScraperID, query, scraper.ScrapeContentTypeStudio)
+		if err != nil {
+			return nil, err
+		}
+
+		return marshalScrapedStudios(content)
+	case source.StashBoxIndex != nil:
+		client, err := r.getStashBoxClient(*source.StashBoxIndex)
+		if err != nil {
+			return nil, err
+		}
+
+		var ret *models.ScrapedStudio
+		switch {
+		case input.Query != nil:
+			return client.QueryStashBoxStudio(ctx, *input.Query)
+		case studio != nil:
+			// prefer the stash id for this endpoint if the studio has one
+			endpoint := config.GetInstance().GetStashBoxes()[*source.StashBoxIndex].Endpoint
+			remoteID := ""
+			for _, id := range stashIDs {
+				if id.Endpoint == endpoint {
+					remoteID = id.StashID
+				}
+			}
+
+			if remoteID != "" {
+				ret, err = client.FindStashBoxStudio(ctx, remoteID)
+			} else {
+				ret, err = client.FindStashBoxStudioByName(ctx, studio.Name)
+			}
+		default:
+			return nil, fmt.Errorf("%w: studio_id or query must be set", ErrInput)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if ret != nil {
+			return []*models.ScrapedStudio{ret}, nil
+		}
+
+		return nil, nil
+	}
+
+	return nil, errors.New("scraper_id or stash_box_index must be set")
+}
+
+func (r *queryResolver) ScrapeSingleTag(ctx context.Context, source scraper.Source, input ScrapeSingleTagInput) ([]*models.ScrapedTag, error) {
+	var query string
+	switch {
+	case input.Query != nil:
+		query = *input.Query
+	case input.TagID != nil:
+		tagID, err := strconv.Atoi(*input.TagID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: tag id is not an integer: '%s'", ErrInput, *input.TagID)
+		}
+
+		var t *models.Tag
+		if err := r.withReadTxn(ctx, func(ctx context.Context) error {
+			t, err = r.repository.Tag.Find(ctx, tagID)
+			return err
+		}); err != nil {
+			return nil, err
+		}
+
+		if t == nil {
+			return nil, fmt.Errorf("%w: tag with id %s not found", ErrInput, *input.TagID)
+		}
+
+		query = t.Name
+	default:
+		return nil, fmt.Errorf("%w: tag_id or query must be set", ErrInput)
+	}
+
+	switch {
+	case source.ScraperID != nil:
+		content, err := r.scraperCache().ScrapeName(ctx, *source.ScraperID, query, scraper.ScrapeContentTypeTag)
+		if err != nil {
+			return nil, err
+		}
+
+		return marshalScrapedTags(content)
+	case source.StashBoxIndex != nil:
+		client, err := r.getStashBoxClient(*source.StashBoxIndex)
+		if err != nil {
+			return nil, err
+		}
+
+		if input.Query != nil {
+			return client.QueryStashBoxTag(ctx, query)
+		}
+
+		ret, err := client.FindStashBoxTagByName(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+
+		if ret != nil {
+			return []*models.ScrapedTag{ret}, nil
+		}
+
+		return nil, nil
+	}
+
+	return nil, errors.New("scraper_id or stash_box_index must be set")
+}
@@ -0,0 +1,12 @@
+package api
+
+import (
+	"context"
+
+	"github.com/stashapp/stash/internal/dlna"
+	"github.com/stashapp/stash/internal/manager"
+)
+
+func (r *subscriptionResolver) DlnaTransportSubscribe(ctx context.Context) (<-chan *dlna.TransportState, error) {
+	return manager.GetInstance().DLNAService.SubscribeTransportState(ctx), nil
+}
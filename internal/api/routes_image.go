@@ -3,18 +3,12 @@ package api
 import (
 	"context"
 	"errors"
-	"io"
-	"io/fs"
 	"net/http"
-	"os/exec"
 	"strconv"
 
 	"github.com/go-chi/chi"
 	"github.com/stashapp/stash/internal/manager"
-	"github.com/stashapp/stash/internal/static"
 	"github.com/stashapp/stash/pkg/file"
-	"github.com/stashapp/stash/pkg/fsutil"
-	"github.com/stashapp/stash/pkg/image"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/txn"
@@ -50,57 +44,9 @@ func (rs imageRoutes) Routes() chi.Router {
 
 func (rs imageRoutes) Thumbnail(w http.ResponseWriter, r *http.Request) {
 	img := r.Context().Value(imageKey).(*models.Image)
-	filepath := manager.GetInstance().Paths.Generated.GetThumbnailPath(img.Checksum, models.DefaultGthumbWidth)
-
-	// if the thumbnail doesn't exist, encode on the fly
-	exists, _ := fsutil.FileExists(filepath)
-	if exists {
-		utils.ServeStaticFile(w, r, filepath)
-	} else {
-		const useDefault = true
-
-		f := img.Files.Primary()
-		if f == nil {
-			rs.serveImage(w, r, img, useDefault)
-			return
-		}
-
-		clipPreviewOptions := image.ClipPreviewOptions{
-			InputArgs:  manager.GetInstance().Config.GetTranscodeInputArgs(),
-			OutputArgs: manager.GetInstance().Config.GetTranscodeOutputArgs(),
-			Preset:     manager.GetInstance().Config.GetPreviewPreset().String(),
-		}
-
-		encoder := image.NewThumbnailEncoder(manager.GetInstance().FFMPEG, manager.GetInstance().FFProbe, clipPreviewOptions)
-		data, err := encoder.GetThumbnail(f, models.DefaultGthumbWidth)
-		if err != nil {
-			// don't log for unsupported image format
-			// don't log for file not found - can optionally be logged in serveImage
-			if !errors.Is(err, image.ErrNotSupportedForThumbnail) && !errors.Is(err, fs.ErrNotExist) {
-				logger.Errorf("error generating thumbnail for %s: %v", f.Base().Path, err)
-
-				var exitErr *exec.ExitError
-				if errors.As(err, &exitErr) {
-					logger.Errorf("stderr: %s", string(exitErr.Stderr))
-				}
-			}
-
-			// backwards compatibility - fallback to original image instead
-			rs.serveImage(w, r, img, useDefault)
-			return
-		}
 
-		// write the generated thumbnail to disk if enabled
-		if manager.GetInstance().Config.IsWriteImageThumbnails() {
-			logger.Debugf("writing thumbnail to disk: %s", img.Path)
-			if err := fsutil.WriteFile(filepath, data); err == nil {
-				utils.ServeStaticFile(w, r, filepath)
-				return
-			}
-			logger.Errorf("error writing thumbnail for image %s: %v", img.Path, err)
-		}
-		utils.ServeStaticContent(w, r, data)
-	}
+	var is manager.ImageServer
+	is.ServeThumbnail(img, w, r)
 }
 
 func (rs imageRoutes) Preview(w http.ResponseWriter, r *http.Request) {
@@ -114,38 +60,8 @@ func (rs imageRoutes) Preview(w http.ResponseWriter, r *http.Request) {
 func (rs imageRoutes) Image(w http.ResponseWriter, r *http.Request) {
 	i := r.Context().Value(imageKey).(*models.Image)
 
-	const useDefault = false
-	rs.serveImage(w, r, i, useDefault)
-}
-
-func (rs imageRoutes) serveImage(w http.ResponseWriter, r *http.Request, i *models.Image, useDefault bool) {
-	const defaultImageImage = "image/image.svg"
-
-	if i.Files.Primary() != nil {
-		err := i.Files.Primary().Base().Serve(&file.OsFS{}, w, r)
-		if err == nil {
-			return
-		}
-
-		if !useDefault {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		// only log in debug since it can get noisy
-		logger.Debugf("Error serving %s: %v", i.DisplayName(), err)
-	}
-
-	if !useDefault {
-		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
-		return
-	}
-
-	// fall back to static image
-	f, _ := static.Image.Open(defaultImageImage)
-	defer f.Close()
-	image, _ := io.ReadAll(f)
-	utils.ServeImage(w, r, image)
+	var is manager.ImageServer
+	is.ServeImage(i, w, r)
 }
 
 // endregion
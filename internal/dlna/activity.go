@@ -0,0 +1,172 @@
+package dlna
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/txn"
+)
+
+const (
+	// a play session ends if no scene requests are made by the client for
+	// this long
+	playSessionTimeout = 5 * time.Minute
+
+	// a session is counted as a play once the scene has been streamed for
+	// this long. Renderers make short requests when probing files, which
+	// should not be counted.
+	minPlayDuration = 30 * time.Second
+)
+
+type playSessionKey struct {
+	ip      string
+	sceneID int
+}
+
+type playSession struct {
+	// number of requests currently streaming the scene
+	active      int
+	activeSince time.Time
+	lastActive  time.Time
+	played      time.Duration
+	counted     bool
+}
+
+// activityTracker records scene play counts and play durations from the
+// requests made by renderers to stream scenes. Concurrent and consecutive
+// requests for the same scene from the same client are treated as a single
+// play session.
+type activityTracker struct {
+	txnManager  txn.Manager
+	sceneWriter SceneActivityWriter
+	config      Config
+
+	// overridden in tests
+	now func() time.Time
+
+	sessions map[playSessionKey]*playSession
+	mutex    sync.Mutex
+}
+
+func newActivityTracker(txnManager txn.Manager, sceneWriter SceneActivityWriter, config Config) *activityTracker {
+	return &activityTracker{
+		txnManager:  txnManager,
+		sceneWriter: sceneWriter,
+		config:      config,
+		now:         time.Now,
+		sessions:    make(map[playSessionKey]*playSession),
+	}
+}
+
+// trackingEnabled returns false if activity tracking is disabled for the
+// client address.
+func (t *activityTracker) trackingEnabled(addr string) bool {
+	for _, a := range t.config.GetDLNAActivityExcludedIPs() {
+		if a == wildcard || a == addr {
+			return false
+		}
+	}
+
+	return true
+}
+
+// startRequest records the start of a request streaming the scene. The
+// returned function must be called when the request is finished.
+func (t *activityTracker) startRequest(addr string, sceneID int) func() {
+	if !t.trackingEnabled(addr) {
+		return func() {}
+	}
+
+	key := playSessionKey{ip: addr, sceneID: sceneID}
+
+	t.mutex.Lock()
+	now := t.now()
+	t.removeExpiredSessions(now)
+
+	s := t.sessions[key]
+	if s == nil {
+		s = &playSession{}
+		t.sessions[key] = s
+	}
+
+	if s.active == 0 {
+		s.activeSince = now
+	}
+	s.active++
+	t.mutex.Unlock()
+
+	return func() {
+		t.endRequest(key, s)
+	}
+}
+
+func (t *activityTracker) endRequest(key playSessionKey, s *playSession) {
+	t.mutex.Lock()
+	now := t.now()
+	s.active--
+	if s.active > 0 {
+		t.mutex.Unlock()
+		return
+	}
+
+	elapsed := now.Sub(s.activeSince)
+	s.played += elapsed
+	s.lastActive = now
+
+	incrementPlayCount := !s.counted && s.played >= minPlayDuration
+	if incrementPlayCount {
+		s.counted = true
+	}
+	t.mutex.Unlock()
+
+	t.saveActivity(key.sceneID, elapsed, incrementPlayCount)
+}
+
+// removeExpiredSessions removes idle sessions that have timed out. Assumes
+// the mutex is held.
+func (t *activityTracker) removeExpiredSessions(now time.Time) {
+	for k, s := range t.sessions {
+		if s.active == 0 && now.Sub(s.lastActive) > playSessionTimeout {
+			delete(t.sessions, k)
+		}
+	}
+}
+
+func (t *activityTracker) saveActivity(sceneID int, elapsed time.Duration, incrementPlayCount bool) {
+	if elapsed <= 0 && !incrementPlayCount {
+		return
+	}
+
+	playDuration := elapsed.Seconds()
+
+	if err := txn.WithTxn(context.Background(), t.txnManager, func(ctx context.Context) error {
+		if _, err := t.sceneWriter.SaveActivity(ctx, sceneID, nil, &playDuration); err != nil {
+			return err
+		}
+
+		if incrementPlayCount {
+			if _, err := t.sceneWriter.IncrementWatchCount(ctx, sceneID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		logger.Warnf("[dlna] error saving activity for scene %d: %v", sceneID, err)
+	}
+}
+
+// setResumeTime sets the resume time of the scene, unless tracking is
+// disabled for the client address.
+func (t *activityTracker) setResumeTime(addr string, sceneID int, resumeTime float64) error {
+	if !t.trackingEnabled(addr) {
+		return nil
+	}
+
+	return txn.WithTxn(context.Background(), t.txnManager, func(ctx context.Context) error {
+		_, err := t.sceneWriter.SaveActivity(ctx, sceneID, &resumeTime, nil)
+		return err
+	})
+}
@@ -0,0 +1,88 @@
+package dlna
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type testActivityConfig struct {
+	Config
+	excludedIPs []string
+}
+
+func (c testActivityConfig) GetDLNAActivityExcludedIPs() []string {
+	return c.excludedIPs
+}
+
+func TestActivityTracker(t *testing.T) {
+	const (
+		sceneID = 1
+		ip      = "192.168.1.2"
+	)
+
+	sceneWriter := &mocks.SceneReaderWriter{}
+	tracker := newActivityTracker(&mocks.TxnManager{}, sceneWriter, testActivityConfig{})
+
+	now := time.Now()
+	tracker.now = func() time.Time { return now }
+
+	playDuration := func(d time.Duration) interface{} {
+		return mock.MatchedBy(func(v *float64) bool {
+			return v != nil && *v == d.Seconds()
+		})
+	}
+
+	// short probe is not counted as a play
+	sceneWriter.On("SaveActivity", mock.Anything, sceneID, (*float64)(nil), playDuration(time.Second)).Return(true, nil).Once()
+	done := tracker.startRequest(ip, sceneID)
+	now = now.Add(time.Second)
+	done()
+	sceneWriter.AssertNotCalled(t, "IncrementWatchCount", mock.Anything, sceneID)
+
+	// overlapping requests are counted once
+	sceneWriter.On("SaveActivity", mock.Anything, sceneID, (*float64)(nil), playDuration(40*time.Second)).Return(true, nil).Once()
+	sceneWriter.On("IncrementWatchCount", mock.Anything, sceneID).Return(1, nil).Once()
+	done1 := tracker.startRequest(ip, sceneID)
+	now = now.Add(10 * time.Second)
+	done2 := tracker.startRequest(ip, sceneID)
+	now = now.Add(10 * time.Second)
+	done1()
+	now = now.Add(20 * time.Second)
+	done2()
+
+	// seeking in the same session does not increment the play count again
+	sceneWriter.On("SaveActivity", mock.Anything, sceneID, (*float64)(nil), playDuration(time.Minute)).Return(true, nil).Once()
+	now = now.Add(time.Minute)
+	done = tracker.startRequest(ip, sceneID)
+	now = now.Add(time.Minute)
+	done()
+
+	// a new session after the timeout is counted again
+	sceneWriter.On("SaveActivity", mock.Anything, sceneID, (*float64)(nil), playDuration(time.Minute)).Return(true, nil).Once()
+	sceneWriter.On("IncrementWatchCount", mock.Anything, sceneID).Return(2, nil).Once()
+	now = now.Add(playSessionTimeout + time.Second)
+	done = tracker.startRequest(ip, sceneID)
+	now = now.Add(time.Minute)
+	done()
+
+	sceneWriter.AssertExpectations(t)
+}
+
+func TestActivityTrackerExcludedIP(t *testing.T) {
+	sceneWriter := &mocks.SceneReaderWriter{}
+	tracker := newActivityTracker(&mocks.TxnManager{}, sceneWriter, testActivityConfig{
+		excludedIPs: []string{"192.168.1.2"},
+	})
+
+	done := tracker.startRequest("192.168.1.2", 1)
+	done()
+
+	assert.NoError(t, tracker.setResumeTime("192.168.1.2", 1, 10))
+	assert.Len(t, tracker.sessions, 0)
+
+	sceneWriter.AssertNotCalled(t, "SaveActivity", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,220 @@
+package dlna
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/anacrolix/dms/upnpav"
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/file/video"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/txn"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+const (
+	captionPath = "/caption"
+
+	// Samsung renderers request the caption URL using this header when
+	// requesting the video
+	getCaptionInfoHeader = "getCaptionInfo.sec"
+	captionInfoHeader    = "CaptionInfo.sec"
+
+	captionFormatSRT = "srt"
+	captionFormatVTT = "vtt"
+)
+
+var captionMimeTypes = map[string]string{
+	captionFormatSRT: "text/srt",
+	captionFormatVTT: "text/vtt",
+}
+
+// captionInfoEx is the Samsung caption element of a video item.
+type captionInfoEx struct {
+	Type string `xml:"sec:type,attr"`
+	URL  string `xml:",chardata"`
+}
+
+// captionLanguages returns a single caption for each language, preferring
+// the caption types in the order of video.CaptionExts.
+func captionLanguages(captions []*models.VideoCaption) []*models.VideoCaption {
+	var ret []*models.VideoCaption
+	found := make(map[string]int)
+
+	priority := func(c *models.VideoCaption) int {
+		for i, ext := range video.CaptionExts {
+			if ext == c.CaptionType {
+				return i
+			}
+		}
+		return len(video.CaptionExts)
+	}
+
+	for _, c := range captions {
+		i, ok := found[c.LanguageCode]
+		if !ok {
+			found[c.LanguageCode] = len(ret)
+			ret = append(ret, c)
+			continue
+		}
+
+		if priority(c) < priority(ret[i]) {
+			ret[i] = c
+		}
+	}
+
+	return ret
+}
+
+func captionURL(host string, sceneID int, lang string, format string) string {
+	return (&url.URL{
+		Scheme: "http",
+		Host:   host,
+		Path:   captionPath,
+		RawQuery: url.Values{
+			"scene":  {strconv.Itoa(sceneID)},
+			"lang":   {lang},
+			"format": {format},
+		}.Encode(),
+	}).String()
+}
+
+// captionResources returns SRT and VTT resources for each caption language.
+func captionResources(sceneID int, captions []*models.VideoCaption, client browseClient) []upnpav.Resource {
+	var ret []upnpav.Resource
+	for _, c := range captionLanguages(captions) {
+		for _, format := range []string{captionFormatSRT, captionFormatVTT} {
+			ret = append(ret, upnpav.Resource{
+				URL:          captionURL(client.host, sceneID, c.LanguageCode, format),
+				ProtocolInfo: "http-get:*:" + captionMimeTypes[format] + ":*",
+			})
+		}
+	}
+
+	return ret
+}
+
+// defaultCaptionURL returns the SRT URL of the first caption language, or an
+// empty string if there are no captions. Used for renderers that only
+// support a single caption.
+func defaultCaptionURL(host string, sceneID int, captions []*models.VideoCaption) string {
+	langs := captionLanguages(captions)
+	if len(langs) == 0 {
+		return ""
+	}
+
+	return captionURL(host, sceneID, langs[0].LanguageCode, captionFormatSRT)
+}
+
+// getSceneCaptions returns the captions of the primary file of the scene.
+// The primary file must be loaded.
+func getSceneCaptions(ctx context.Context, r Repository, scene *models.Scene) ([]*models.VideoCaption, error) {
+	f := scene.Files.Primary()
+	if f == nil {
+		return nil, nil
+	}
+
+	return r.CaptionFinder.GetCaptions(ctx, f.ID)
+}
+
+// getScenesCaptions returns the captions of the primary file of each scene,
+// in the order of the scenes. The primary files must be loaded.
+func getScenesCaptions(ctx context.Context, r Repository, scenes []*models.Scene) ([][]*models.VideoCaption, error) {
+	var fileIDs []file.ID
+	index := make([]int, len(scenes))
+	for i, s := range scenes {
+		index[i] = -1
+		if f := s.Files.Primary(); f != nil {
+			index[i] = len(fileIDs)
+			fileIDs = append(fileIDs, f.ID)
+		}
+	}
+
+	captions, err := r.CaptionFinder.GetManyCaptions(ctx, fileIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([][]*models.VideoCaption, len(scenes))
+	for i, j := range index {
+		if j != -1 {
+			ret[i] = captions[j]
+		}
+	}
+
+	return ret, nil
+}
+
+func (me *Server) serveCaption(w http.ResponseWriter, r *http.Request) {
+	sceneID, err := strconv.Atoi(r.URL.Query().Get("scene"))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	lang := r.URL.Query().Get("lang")
+	format := r.URL.Query().Get("format")
+	if _, ok := captionMimeTypes[format]; !ok {
+		http.Error(w, "unsupported caption format", http.StatusBadRequest)
+		return
+	}
+
+	var scene *models.Scene
+	var captions []*models.VideoCaption
+	if err := txn.WithReadTxn(r.Context(), me.txnManager, func(ctx context.Context) error {
+		scene, err = me.repository.SceneFinder.Find(ctx, sceneID)
+		if err != nil || scene == nil {
+			return err
+		}
+
+		if err := scene.LoadPrimaryFile(ctx, me.repository.FileFinder); err != nil {
+			return err
+		}
+
+		captions, err = getSceneCaptions(ctx, me.repository, scene)
+		return err
+	}); err != nil {
+		logger.Warnf("[dlna] error getting captions for scene %d: %v", sceneID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if scene == nil {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	for _, c := range captionLanguages(captions) {
+		if c.LanguageCode != lang {
+			continue
+		}
+
+		sub, err := video.ReadSubs(c.Path(scene.Path))
+		if err != nil {
+			logger.Warnf("[dlna] error reading captions %s: %v", c.Filename, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		var buf bytes.Buffer
+		if format == captionFormatSRT {
+			err = sub.WriteToSRT(&buf)
+		} else {
+			err = sub.WriteToWebVTT(&buf)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", captionMimeTypes[format])
+		utils.ServeStaticContent(w, r, buf.Bytes())
+		return
+	}
+
+	http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+}
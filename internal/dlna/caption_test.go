@@ -0,0 +1,38 @@
+package dlna
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCaptionLanguages(t *testing.T) {
+	enSRT := &models.VideoCaption{LanguageCode: "en", Filename: "a.en.srt", CaptionType: "srt"}
+	enVTT := &models.VideoCaption{LanguageCode: "en", Filename: "a.en.vtt", CaptionType: "vtt"}
+	deSRT := &models.VideoCaption{LanguageCode: "de", Filename: "a.de.srt", CaptionType: "srt"}
+
+	assert.Equal(t, []*models.VideoCaption{enVTT, deSRT}, captionLanguages([]*models.VideoCaption{enSRT, deSRT, enVTT}))
+	assert.Nil(t, captionLanguages(nil))
+}
+
+func TestSceneToContainerCaptions(t *testing.T) {
+	scene := &models.Scene{ID: 1, Title: "test", Files: models.NewRelatedVideoFiles(nil)}
+	captions := []*models.VideoCaption{
+		{LanguageCode: "en", Filename: "a.en.vtt", CaptionType: "vtt"},
+	}
+
+	client := browseClient{host: "localhost:1338", profile: defaultProfile}
+	out, err := xml.Marshal(sceneToContainer(scene, captions, "0", client))
+	assert.NoError(t, err)
+
+	s := string(out)
+	assert.Contains(t, s, `<res protocolInfo="http-get:*:text/srt:*">http://localhost:1338/caption?format=srt&amp;lang=en&amp;scene=1</res>`)
+	assert.Contains(t, s, `<res protocolInfo="http-get:*:text/vtt:*">http://localhost:1338/caption?format=vtt&amp;lang=en&amp;scene=1</res>`)
+	assert.Contains(t, s, `<sec:CaptionInfoEx sec:type="srt">http://localhost:1338/caption?format=srt&amp;lang=en&amp;scene=1</sec:CaptionInfoEx>`)
+
+	out, err = xml.Marshal(sceneToContainer(scene, nil, "0", client))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(out), "CaptionInfoEx")
+}
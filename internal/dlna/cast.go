@@ -0,0 +1,580 @@
+package dlna
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/txn"
+)
+
+const (
+	// interval between polling the transport state of renderers
+	castPollInterval = time.Second
+	// casting stops if the renderer cannot be reached this many times in a row
+	castMaxPollErrors = 5
+
+	transportStatePlaying = "PLAYING"
+	transportStateStopped = "STOPPED"
+	transportStateNoMedia = "NO_MEDIA_PRESENT"
+
+	transportStateTransitioning = "TRANSITIONING"
+)
+
+var (
+	ErrRendererNotFound = errors.New("renderer not found")
+	ErrNotCasting       = errors.New("not casting to renderer")
+	ErrDLNANotRunning   = errors.New("DLNA service must be running to cast")
+)
+
+// TransportState is the playback state of a renderer being cast to.
+type TransportState struct {
+	RendererID string `json:"rendererID"`
+	// UPnP transport state, such as PLAYING or STOPPED
+	State    string   `json:"state"`
+	SceneID  *string  `json:"sceneID"`
+	Position *float64 `json:"position"`
+	Duration *float64 `json:"duration"`
+	Volume   *int     `json:"volume"`
+	// scenes queued on the renderer, including the current scene
+	Queue []string `json:"queue"`
+}
+
+type castSession struct {
+	renderer *Renderer
+	queue    []int
+	current  int
+	// true if the user stopped playback, so that the queue is not advanced
+	stopped bool
+	state   TransportState
+	cancel  context.CancelFunc
+	// closed when polling has stopped
+	done chan struct{}
+}
+
+// castManager acts as a UPnP control point, sending scenes to renderers and
+// tracking their transport state.
+type castManager struct {
+	txnManager txn.Manager
+	repository Repository
+	// returns the host and port that the renderer can use to reach the
+	// DLNA server
+	serverHost func(r *Renderer) (string, error)
+
+	renderers     map[string]*Renderer
+	sessions      map[string]*castSession
+	subscriptions []chan *TransportState
+	mutex         sync.Mutex
+}
+
+func (m *castManager) getRenderers(ctx context.Context, refresh bool) ([]*Renderer, error) {
+	m.mutex.Lock()
+	if !refresh && len(m.renderers) > 0 {
+		ret := m.rendererList()
+		m.mutex.Unlock()
+		return ret, nil
+	}
+	m.mutex.Unlock()
+
+	found, err := discoverRenderers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.renderers = make(map[string]*Renderer)
+	for _, r := range found {
+		m.renderers[r.ID] = r
+	}
+
+	// keep renderers that are being cast to
+	for id, s := range m.sessions {
+		if _, ok := m.renderers[id]; !ok {
+			m.renderers[id] = s.renderer
+		}
+	}
+
+	return m.rendererList(), nil
+}
+
+// rendererList assumes the mutex is held.
+func (m *castManager) rendererList() []*Renderer {
+	var ret []*Renderer
+	for _, r := range m.renderers {
+		ret = append(ret, r)
+	}
+	return ret
+}
+
+func (m *castManager) getRenderer(id string) (*Renderer, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	r := m.renderers[id]
+	if r == nil {
+		return nil, fmt.Errorf("%w: %s", ErrRendererNotFound, id)
+	}
+	return r, nil
+}
+
+func (m *castManager) getSession(id string) (*castSession, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	s := m.sessions[id]
+	if s == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotCasting, id)
+	}
+	return s, nil
+}
+
+// cast plays the scenes in order on the renderer, starting the first scene
+// at start.
+func (m *castManager) cast(ctx context.Context, rendererID string, sceneIDs []int, start time.Duration) error {
+	if len(sceneIDs) == 0 {
+		return errors.New("no scenes to cast")
+	}
+
+	r, err := m.getRenderer(rendererID)
+	if err != nil {
+		return err
+	}
+
+	// the previous session must not advance its queue after the new scenes
+	// are sent
+	m.mutex.Lock()
+	existing := m.sessions[rendererID]
+	delete(m.sessions, rendererID)
+	m.mutex.Unlock()
+
+	if existing != nil {
+		existing.stop()
+	}
+
+	pollCtx, cancel := context.WithCancel(context.Background())
+	s := &castSession{
+		renderer: r,
+		queue:    sceneIDs,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+
+	if err := m.playScene(ctx, s, 0, start); err != nil {
+		cancel()
+		return err
+	}
+
+	m.mutex.Lock()
+	if m.sessions == nil {
+		m.sessions = make(map[string]*castSession)
+	}
+	m.sessions[rendererID] = s
+	m.mutex.Unlock()
+
+	go m.poll(pollCtx, s)
+
+	return nil
+}
+
+// stop cancels polling of the session and waits for it to finish.
+func (s *castSession) stop() {
+	s.cancel()
+	<-s.done
+}
+
+// stopAll stops polling all sessions. Renderers keep playing the current
+// scene, but the queues are no longer advanced.
+func (m *castManager) stopAll() {
+	m.mutex.Lock()
+	sessions := m.sessions
+	m.sessions = nil
+	m.mutex.Unlock()
+
+	for _, s := range sessions {
+		s.stop()
+
+		m.mutex.Lock()
+		state := s.state
+		m.mutex.Unlock()
+
+		state.State = transportStateStopped
+		m.notify(&state)
+	}
+}
+
+// playScene sends the scene at index of the session queue to the renderer
+// and starts playback.
+func (m *castManager) playScene(ctx context.Context, s *castSession, index int, start time.Duration) error {
+	host, err := m.serverHost(s.renderer)
+	if err != nil {
+		return err
+	}
+
+	sceneID := s.queue[index]
+	var obj interface{}
+	if err := txn.WithReadTxn(ctx, m.txnManager, func(ctx context.Context) error {
+		scene, err := m.repository.SceneFinder.Find(ctx, sceneID)
+		if err != nil {
+			return err
+		}
+		if scene == nil {
+			return fmt.Errorf("scene %d not found", sceneID)
+		}
+
+		client := browseClient{host: host, profile: defaultProfile}
+		obj, err = loadSceneContainer(ctx, m.repository, scene, "-1", client)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	item := obj.(videoItem)
+	if len(item.Res) == 0 {
+		return fmt.Errorf("scene %d has no files", sceneID)
+	}
+
+	metadata, err := xml.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	r := s.renderer
+	if err := r.setAVTransportURI(ctx, item.Res[0].URL, didl_lite(string(metadata))); err != nil {
+		return err
+	}
+	if err := r.play(ctx); err != nil {
+		return err
+	}
+
+	if start > 0 {
+		if err := r.seek(ctx, start); err != nil {
+			logger.Warnf("[dlna] error seeking on %s: %v", r.Name, err)
+		}
+	}
+
+	m.mutex.Lock()
+	s.current = index
+	s.stopped = false
+	// the renderer may briefly report STOPPED while loading the scene, which
+	// must not advance the queue
+	s.state.State = transportStateTransitioning
+	m.mutex.Unlock()
+
+	return nil
+}
+
+func (m *castManager) poll(ctx context.Context, s *castSession) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(castPollInterval)
+	defer ticker.Stop()
+
+	errCount := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		state, err := m.getTransportState(ctx, s)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			errCount++
+			logger.Debugf("[dlna] error getting transport state of %s: %v", s.renderer.Name, err)
+			if errCount >= castMaxPollErrors {
+				logger.Warnf("[dlna] lost connection to %s: %v", s.renderer.Name, err)
+				m.endSession(s)
+				return
+			}
+			continue
+		}
+		errCount = 0
+
+		m.mutex.Lock()
+		prev := s.state
+		s.state = *state
+		next := -1
+		ended := state.State == transportStateStopped || state.State == transportStateNoMedia
+		if ended && prev.State == transportStatePlaying && !s.stopped && s.current+1 < len(s.queue) {
+			next = s.current + 1
+		}
+		m.mutex.Unlock()
+
+		if !reflect.DeepEqual(prev, *state) {
+			m.notify(state)
+		}
+
+		if next != -1 && ctx.Err() == nil {
+			if err := m.playScene(ctx, s, next, 0); err != nil {
+				logger.Warnf("[dlna] error playing next scene on %s: %v", s.renderer.Name, err)
+			}
+		}
+	}
+}
+
+func (m *castManager) getTransportState(ctx context.Context, s *castSession) (*TransportState, error) {
+	r := s.renderer
+	info, err := r.getTransportInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	ret := &TransportState{
+		RendererID: r.ID,
+		State:      info.CurrentTransportState,
+	}
+	for _, id := range s.queue {
+		ret.Queue = append(ret.Queue, strconv.Itoa(id))
+	}
+	sceneID := ret.Queue[s.current]
+	ret.SceneID = &sceneID
+	m.mutex.Unlock()
+
+	// position and volume are optional
+	if pos, err := r.getPositionInfo(ctx); err == nil {
+		ret.Position = parseTransportTime(pos.RelTime)
+		ret.Duration = parseTransportTime(pos.TrackDuration)
+	}
+
+	if v, err := r.getVolume(ctx); err == nil {
+		ret.Volume = &v
+	}
+
+	return ret, nil
+}
+
+// parseTransportTime returns the H+:MM:SS[.F+] time in seconds, or nil if
+// the time is not valid. Renderers return NOT_IMPLEMENTED if the time is
+// not known.
+func parseTransportTime(v string) *float64 {
+	parts := strings.Split(v, ":")
+	if len(parts) != 3 {
+		return nil
+	}
+
+	h, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil
+	}
+	m, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil
+	}
+	sec, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return nil
+	}
+
+	ret := float64(h*3600+m*60) + sec
+	return &ret
+}
+
+func (m *castManager) endSession(s *castSession) {
+	s.cancel()
+
+	m.mutex.Lock()
+	if m.sessions[s.renderer.ID] == s {
+		delete(m.sessions, s.renderer.ID)
+	}
+	state := s.state
+	state.State = transportStateStopped
+	m.mutex.Unlock()
+
+	m.notify(&state)
+}
+
+func (m *castManager) play(ctx context.Context, rendererID string) error {
+	s, err := m.getSession(rendererID)
+	if err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	s.stopped = false
+	m.mutex.Unlock()
+
+	return s.renderer.play(ctx)
+}
+
+func (m *castManager) pause(ctx context.Context, rendererID string) error {
+	s, err := m.getSession(rendererID)
+	if err != nil {
+		return err
+	}
+
+	return s.renderer.pause(ctx)
+}
+
+func (m *castManager) stop(ctx context.Context, rendererID string) error {
+	s, err := m.getSession(rendererID)
+	if err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	s.stopped = true
+	m.mutex.Unlock()
+
+	return s.renderer.stop(ctx)
+}
+
+func (m *castManager) next(ctx context.Context, rendererID string) error {
+	s, err := m.getSession(rendererID)
+	if err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	next := s.current + 1
+	m.mutex.Unlock()
+
+	if next >= len(s.queue) {
+		return errors.New("no more scenes in queue")
+	}
+
+	return m.playScene(ctx, s, next, 0)
+}
+
+func (m *castManager) seek(ctx context.Context, rendererID string, position time.Duration) error {
+	s, err := m.getSession(rendererID)
+	if err != nil {
+		return err
+	}
+
+	return s.renderer.seek(ctx, position)
+}
+
+func (m *castManager) setVolume(ctx context.Context, rendererID string, volume int) error {
+	r, err := m.getRenderer(rendererID)
+	if err != nil {
+		return err
+	}
+
+	return r.setVolume(ctx, volume)
+}
+
+func (m *castManager) subscribe(ctx context.Context) <-chan *TransportState {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	c := make(chan *TransportState, 10)
+	m.subscriptions = append(m.subscriptions, c)
+
+	go func() {
+		<-ctx.Done()
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+		close(c)
+
+		for i, s := range m.subscriptions {
+			if s == c {
+				m.subscriptions = append(m.subscriptions[:i], m.subscriptions[i+1:]...)
+				break
+			}
+		}
+	}()
+
+	return c
+}
+
+func (m *castManager) notify(state *TransportState) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, s := range m.subscriptions {
+		// don't block on slow subscribers
+		select {
+		case s <- state:
+		default:
+		}
+	}
+}
+
+// localHost returns the local address used to reach the renderer, joined
+// with port.
+func localHost(r *Renderer, port int) (string, error) {
+	conn, err := net.Dial("udp", net.JoinHostPort(r.Address, "1900"))
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	ip := conn.LocalAddr().(*net.UDPAddr).IP
+	return net.JoinHostPort(ip.String(), strconv.Itoa(port)), nil
+}
+
+// serverHost returns the host and port of the DLNA server reachable by the
+// renderer.
+func (s *Service) serverHost(r *Renderer) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.running || s.server.HTTPConn == nil {
+		return "", ErrDLNANotRunning
+	}
+
+	addr, ok := s.server.HTTPConn.Addr().(*net.TCPAddr)
+	if !ok {
+		return "", ErrDLNANotRunning
+	}
+
+	return localHost(r, addr.Port)
+}
+
+// Renderers returns the media renderers on the network. Renderers are only
+// searched for if refresh is true or no renderers have been found.
+func (s *Service) Renderers(ctx context.Context, refresh bool) ([]*Renderer, error) {
+	return s.castMgr.getRenderers(ctx, refresh)
+}
+
+// Cast plays the scenes in order on the renderer, starting the first scene
+// at start.
+func (s *Service) Cast(ctx context.Context, rendererID string, sceneIDs []int, start time.Duration) error {
+	return s.castMgr.cast(ctx, rendererID, sceneIDs, start)
+}
+
+func (s *Service) CastPlay(ctx context.Context, rendererID string) error {
+	return s.castMgr.play(ctx, rendererID)
+}
+
+func (s *Service) CastPause(ctx context.Context, rendererID string) error {
+	return s.castMgr.pause(ctx, rendererID)
+}
+
+func (s *Service) CastStop(ctx context.Context, rendererID string) error {
+	return s.castMgr.stop(ctx, rendererID)
+}
+
+// CastNext plays the next scene in the queue of the renderer.
+func (s *Service) CastNext(ctx context.Context, rendererID string) error {
+	return s.castMgr.next(ctx, rendererID)
+}
+
+func (s *Service) CastSeek(ctx context.Context, rendererID string, position time.Duration) error {
+	return s.castMgr.seek(ctx, rendererID, position)
+}
+
+func (s *Service) CastSetVolume(ctx context.Context, rendererID string, volume int) error {
+	return s.castMgr.setVolume(ctx, rendererID, volume)
+}
+
+// SubscribeTransportState returns a channel that receives the transport
+// state of renderers being cast to when it changes.
+func (s *Service) SubscribeTransportState(ctx context.Context) <-chan *TransportState {
+	return s.castMgr.subscribe(ctx)
+}
@@ -0,0 +1,47 @@
+package dlna
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCastManager_stopAll(t *testing.T) {
+	_, r := newTestRenderer(t)
+
+	m := &castManager{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	states := m.subscribe(ctx)
+
+	pollCtx, pollCancel := context.WithCancel(context.Background())
+	s := &castSession{
+		renderer: r,
+		queue:    []int{1, 2},
+		cancel:   pollCancel,
+		done:     make(chan struct{}),
+	}
+	m.sessions = map[string]*castSession{r.ID: s}
+	go m.poll(pollCtx, s)
+
+	m.stopAll()
+
+	select {
+	case <-s.done:
+	default:
+		t.Fatal("session still polling after stopAll")
+	}
+
+	_, err := m.getSession(r.ID)
+	assert.ErrorIs(t, err, ErrNotCasting)
+
+	select {
+	case state := <-states:
+		assert.Equal(t, transportStateStopped, state.State)
+	case <-time.After(time.Second):
+		t.Fatal("stopped state not sent")
+	}
+}
@@ -373,6 +373,31 @@ const contentDirectoryServiceDescription = `<?xml version="1.0"?>
         </argument>
       </argumentList>
     </action>
+    <action>
+      <name>X_SetBookmark</name>
+      <argumentList>
+        <argument>
+          <name>CategoryType</name>
+          <direction>in</direction>
+          <relatedStateVariable>A_ARG_TYPE_CategoryType</relatedStateVariable>
+        </argument>
+        <argument>
+          <name>RID</name>
+          <direction>in</direction>
+          <relatedStateVariable>A_ARG_TYPE_RID</relatedStateVariable>
+        </argument>
+        <argument>
+          <name>ObjectID</name>
+          <direction>in</direction>
+          <relatedStateVariable>A_ARG_TYPE_ObjectID</relatedStateVariable>
+        </argument>
+        <argument>
+          <name>PosSecond</name>
+          <direction>in</direction>
+          <relatedStateVariable>A_ARG_TYPE_PosSec</relatedStateVariable>
+        </argument>
+      </argumentList>
+    </action>
   </actionList>
   <serviceStateTable>
     <stateVariable sendEvents="no">
@@ -473,5 +498,17 @@ const contentDirectoryServiceDescription = `<?xml version="1.0"?>
       <name>A_ARG_TYPE_URI</name>
       <dataType>uri</dataType>
     </stateVariable>
+    <stateVariable sendEvents="no">
+      <name>A_ARG_TYPE_CategoryType</name>
+      <dataType>ui4</dataType>
+    </stateVariable>
+    <stateVariable sendEvents="no">
+      <name>A_ARG_TYPE_RID</name>
+      <dataType>ui4</dataType>
+    </stateVariable>
+    <stateVariable sendEvents="no">
+      <name>A_ARG_TYPE_PosSec</name>
+      <dataType>ui4</dataType>
+    </stateVariable>
   </serviceStateTable>
 </scpd>`
@@ -25,6 +25,8 @@ import (
 	"context"
 	"encoding/xml"
 	"fmt"
+	"mime"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -37,6 +39,8 @@ import (
 	"github.com/anacrolix/dms/dlna"
 	"github.com/anacrolix/dms/upnp"
 	"github.com/anacrolix/dms/upnpav"
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/image"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/scene"
@@ -54,6 +58,18 @@ type browse struct {
 	RequestedCount int
 }
 
+// setBookmark holds the arguments of the Samsung X_SetBookmark action.
+type setBookmark struct {
+	ObjectID  string
+	PosSecond int
+}
+
+// browseClient holds the details of the renderer making a request.
+type browseClient struct {
+	host    string
+	profile *rendererProfile
+}
+
 type contentDirectoryService struct {
 	*Server
 	upnp.Eventing
@@ -77,12 +93,55 @@ func (me *contentDirectoryService) updateIDString() string {
 	return fmt.Sprintf("%d", uint32(os.Getpid()))
 }
 
-func sceneToContainer(scene *models.Scene, parent string, host string) interface{} {
+// videoItem is a video item with the playback position used by renderers to
+// resume playback.
+type videoItem struct {
+	upnpav.Item
+	LastPlaybackPosition string `xml:"dlna:lastPlaybackPosition,omitempty"`
+	// Samsung renderers read the bookmark from this element
+	DCMInfo       string         `xml:"sec:dcmInfo,omitempty"`
+	CaptionInfoEx *captionInfoEx `xml:"sec:CaptionInfoEx,omitempty"`
+}
+
+// loadSceneContainer loads the primary file and captions of the scene and
+// returns its upnp object.
+func loadSceneContainer(ctx context.Context, r Repository, scene *models.Scene, parent string, client browseClient) (interface{}, error) {
+	ret, err := loadSceneContainers(ctx, r, []*models.Scene{scene}, parent, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret[0], nil
+}
+
+// loadSceneContainers loads the primary files of the scenes and returns
+// their upnp objects. The captions of all scenes are loaded at once.
+func loadSceneContainers(ctx context.Context, r Repository, scenes []*models.Scene, parent string, client browseClient) ([]interface{}, error) {
+	for _, s := range scenes {
+		if err := s.LoadPrimaryFile(ctx, r.FileFinder); err != nil {
+			return nil, err
+		}
+	}
+
+	captions, err := getScenesCaptions(ctx, r, scenes)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]interface{}, len(scenes))
+	for i, s := range scenes {
+		ret[i] = sceneToContainer(s, captions[i], parent, client)
+	}
+
+	return ret, nil
+}
+
+func sceneToContainer(scene *models.Scene, captions []*models.VideoCaption, parent string, client browseClient) interface{} {
 	// make stash server URL
 	// TODO - fix this
 	iconURI := (&url.URL{
 		Scheme: "http",
-		Host:   host,
+		Host:   client.host,
 		Path:   iconPath,
 		RawQuery: url.Values{
 			"scene": {strconv.Itoa(scene.ID)},
@@ -102,45 +161,160 @@ func sceneToContainer(scene *models.Scene, parent string, host string) interface
 	}
 
 	// Wrap up
-	item := upnpav.Item{
-		Object: obj,
-		Res:    make([]upnpav.Resource, 0, 1),
+	item := videoItem{
+		Item: upnpav.Item{
+			Object: obj,
+			Res:    make([]upnpav.Resource, 0, 1),
+		},
 	}
 
-	mimeType := "video/mp4"
-	var (
-		size     int
-		bitrate  uint
-		duration int64
-	)
+	if scene.ResumeTime > 0 {
+		resumeTime := time.Duration(scene.ResumeTime * float64(time.Second))
+		item.LastPlaybackPosition = formatDurationSexagesimal(resumeTime)
+		item.DCMInfo = fmt.Sprintf("BOOKMARK=%d", int(resumeTime.Seconds()))
+	}
 
 	f := scene.Files.Primary()
 	if f != nil {
-		size = int(f.Size)
-		bitrate = uint(f.BitRate)
-		duration = int64(f.Duration)
+		item.Res = append(item.Res, videoResources(scene.ID, f, client)...)
 	}
 
 	item.Res = append(item.Res, upnpav.Resource{
+		URL:          iconURI,
+		ProtocolInfo: "http-get:*:image/jpeg:DLNA.ORG_PN=JPEG_MED",
+	})
+
+	if len(captions) > 0 {
+		item.Res = append(item.Res, captionResources(scene.ID, captions, client)...)
+		item.CaptionInfoEx = &captionInfoEx{
+			Type: captionFormatSRT,
+			URL:  defaultCaptionURL(client.host, scene.ID, captions),
+		}
+	}
+
+	return item
+}
+
+// videoResources returns the resources for the scene file. The file is served
+// as is if the renderer supports it, with an alternate resource that is
+// transcoded to a format the renderer supports. Renderers generally play the
+// first resource, so the transcode is listed first if the file is not
+// supported.
+func videoResources(sceneID int, f *file.VideoFile, client browseClient) []upnpav.Resource {
+	duration := formatDurationSexagesimal(time.Duration(f.Duration * float64(time.Second)))
+
+	direct := upnpav.Resource{
 		URL: (&url.URL{
 			Scheme: "http",
-			Host:   host,
+			Host:   client.host,
 			Path:   resPath,
 			RawQuery: url.Values{
-				"scene": {strconv.Itoa(scene.ID)},
+				"scene": {strconv.Itoa(sceneID)},
 			}.Encode(),
 		}).String(),
-		ProtocolInfo: fmt.Sprintf("http-get:*:%s:%s", mimeType, dlna.ContentFeatures{
+		ProtocolInfo: directProtocolInfo(f),
+		Bitrate:      uint(f.BitRate),
+		Duration:     duration,
+		Size:         uint64(f.Size),
+		Resolution:   fmt.Sprintf("%dx%d", f.Width, f.Height),
+	}
+
+	profile := client.profile
+	if profile == nil {
+		profile = defaultProfile
+	}
+
+	streamType := profile.TranscodeType
+	resolution := profile.transcodeResolution(f)
+	transcode := upnpav.Resource{
+		URL: (&url.URL{
+			Scheme: "http",
+			Host:   client.host,
+			Path:   resPath,
+			RawQuery: url.Values{
+				"scene":      {strconv.Itoa(sceneID)},
+				"transcode":  {transcodeTypeName(streamType)},
+				"resolution": {resolution.String()},
+			}.Encode(),
+		}).String(),
+		ProtocolInfo: transcodeProtocolInfo(f, streamType, resolution),
+		Duration:     duration,
+	}
+
+	if profile.supports(f) {
+		return []upnpav.Resource{direct, transcode}
+	}
+
+	return []upnpav.Resource{transcode, direct}
+}
+
+// imageObjectPrefix is the prefix of image object IDs, to distinguish them
+// from scene IDs.
+const imageObjectPrefix = "image/"
+
+func imageToItem(img *models.Image, parent string, client browseClient) interface{} {
+	imageURL := func(thumbnail bool) string {
+		q := url.Values{
+			"image": {strconv.Itoa(img.ID)},
+		}
+		if thumbnail {
+			q.Set("thumbnail", "true")
+		}
+
+		return (&url.URL{
+			Scheme:   "http",
+			Host:     client.host,
+			Path:     imagePath,
+			RawQuery: q.Encode(),
+		}).String()
+	}
+
+	thumbnailURI := imageURL(true)
+
+	item := upnpav.Item{
+		Object: upnpav.Object{
+			ID:          imageObjectPrefix + strconv.Itoa(img.ID),
+			Restricted:  1,
+			ParentID:    parent,
+			Title:       img.GetTitle(),
+			Class:       "object.item.imageItem.photo",
+			Icon:        thumbnailURI,
+			AlbumArtURI: thumbnailURI,
+		},
+	}
+
+	if f := img.Files.Primary(); f != nil {
+		if _, isVideo := f.(*file.VideoFile); isVideo {
+			item.Class = "object.item.videoItem"
+		}
+
+		mimeType := mime.TypeByExtension(filepath.Ext(f.Base().Basename))
+		if mimeType == "" {
+			mimeType = "application/octet-stream"
+		}
+
+		res := upnpav.Resource{
+			URL:  imageURL(false),
+			Size: uint64(f.Base().Size),
+		}
+
+		var width, height int
+		if vf, ok := f.(file.VisualFile); ok {
+			width = vf.GetWidth()
+			height = vf.GetHeight()
+			res.Resolution = fmt.Sprintf("%dx%d", width, height)
+		}
+
+		res.ProtocolInfo = protocolInfo(mimeType, dlna.ContentFeatures{
+			ProfileName:  imageProfileName(mimeType, width, height),
 			SupportRange: true,
-		}.String()),
-		Bitrate:  bitrate,
-		Duration: formatDurationSexagesimal(time.Duration(duration) * time.Second),
-		Size:     uint64(size),
-		// Resolution: resolution,
-	})
+		})
+
+		item.Res = append(item.Res, res)
+	}
 
 	item.Res = append(item.Res, upnpav.Resource{
-		URL:          iconURI,
+		URL:          thumbnailURI,
 		ProtocolInfo: "http-get:*:image/jpeg:DLNA.ORG_PN=JPEG_MED",
 	})
 
@@ -175,8 +349,10 @@ func childPath(paths []string) []string {
 }
 
 func (me *contentDirectoryService) Handle(action string, argsXML []byte, r *http.Request) (map[string]string, error) {
-	host := r.Host
-	// userAgent := r.UserAgent()
+	client := browseClient{
+		host:    r.Host,
+		profile: getRendererProfile(r),
+	}
 	switch action {
 	case "GetSystemUpdateID":
 		return map[string]string{
@@ -199,16 +375,23 @@ func (me *contentDirectoryService) Handle(action string, argsXML []byte, r *http
 
 		switch browse.BrowseFlag {
 		case "BrowseDirectChildren":
-			return me.handleBrowseDirectChildren(obj, host)
+			return me.handleBrowseDirectChildren(obj, client)
 		case "BrowseMetadata":
-			return me.handleBrowseMetadata(obj, host)
+			return me.handleBrowseMetadata(obj, client)
 		default:
 			return nil, upnp.Errorf(upnp.ArgumentValueInvalidErrorCode, "unhandled browse flag: %v", browse.BrowseFlag)
 		}
 	case "GetSearchCapabilities":
 		return map[string]string{
-			"SearchCaps": "",
+			"SearchCaps": searchCapabilities,
 		}, nil
+	case "Search":
+		var search search
+		if err := xml.Unmarshal([]byte(argsXML), &search); err != nil {
+			return nil, upnp.Errorf(upnp.ArgumentValueInvalidErrorCode, "cannot unmarshal search argument: %s", err.Error())
+		}
+
+		return me.handleSearch(search, client)
 	// from https://github.com/rclone/rclone/blob/master/cmd/serve/dlna/cds.go
 	// Samsung Extensions
 	case "X_GetFeatureList":
@@ -221,14 +404,35 @@ func (me *contentDirectoryService) Handle(action string, argsXML []byte, r *http
 	</Feature>
 	</Features>`}, nil
 	case "X_SetBookmark":
-		// just ignore
-		return map[string]string{}, nil
+		var bookmark setBookmark
+		if err := xml.Unmarshal([]byte(argsXML), &bookmark); err != nil {
+			return nil, upnp.Errorf(upnp.ArgumentValueInvalidErrorCode, "cannot unmarshal bookmark argument: %s", err.Error())
+		}
+
+		return me.handleSetBookmark(bookmark, r)
 	default:
 		return nil, upnp.InvalidActionError
 	}
 }
 
-func (me *contentDirectoryService) handleBrowseDirectChildren(obj object, host string) (map[string]string, error) {
+// handleSetBookmark stores the bookmark position as the resume time of the
+// scene.
+func (me *contentDirectoryService) handleSetBookmark(bookmark setBookmark, r *http.Request) (map[string]string, error) {
+	sceneID, err := strconv.Atoi(bookmark.ObjectID)
+	if err != nil {
+		// only scenes can be bookmarked
+		return nil, upnp.Errorf(upnpav.NoSuchObjectErrorCode, "invalid scene id %q", bookmark.ObjectID)
+	}
+
+	clientIp, _, _ := net.SplitHostPort(r.RemoteAddr)
+	if err := me.activityTracker.setResumeTime(net.ParseIP(clientIp).String(), sceneID, float64(bookmark.PosSecond)); err != nil {
+		return nil, upnp.Errorf(upnp.ActionFailedErrorCode, "could not save bookmark: %s", err.Error())
+	}
+
+	return map[string]string{}, nil
+}
+
+func (me *contentDirectoryService) handleBrowseDirectChildren(obj object, client browseClient) (map[string]string, error) {
 	// Read folder and return children
 	// TODO: check if obj == 0 and return root objects
 	// TODO: check if special path and return files
@@ -243,53 +447,24 @@ func (me *contentDirectoryService) handleBrowseDirectChildren(obj object, host s
 
 	// All videos
 	if obj.Path == "all" {
-		objs = me.getAllScenes(host)
+		objs = me.getAllScenes(client)
 	}
 
 	if strings.HasPrefix(obj.Path, "all/") {
 		page := getPageFromID(paths)
 		if page != nil {
-			objs = me.getPageVideos(&models.SceneFilterType{}, "all", *page, host)
-		}
-	}
-
-	// Saved searches
-	// if obj.Path == "saved-searches" {
-	// 	var savedPlaylists []models.Playlist
-	// 	db, _ := models.GetDB()
-	// 	db.Where("is_deo_enabled = ?", true).Order("ordering asc").Find(&savedPlaylists)
-	// 	db.Close()
-
-	// 	for _, playlist := range savedPlaylists {
-	// 		objs = append(objs, upnpav.Container{Object: upnpav.Object{
-	// 			ID:         "saved-searches/" + strconv.Itoa(int(playlist.ID)),
-	// 			Restricted: 1,
-	// 			ParentID:   "saved-searches",
-	// 			Class:      "object.container.storageFolder",
-	// 			Title:      playlist.Name,
-	// 		}})
-	// 	}
-	// }
-
-	// if strings.HasPrefix(obj.Path, "saved-searches/") {
-	// 	id := strings.Split(obj.Path, "/")
-
-	// 	var savedPlaylist models.Playlist
-	// 	db, _ := models.GetDB()
-	// 	db.Where("id = ?", id[1]).First(&savedPlaylist)
-	// 	db.Close()
+			objs = me.getPageVideos(&models.SceneFilterType{}, "all", *page, client)
+		}
+	}
 
-	// 	var r models.RequestSceneList
-	// 	if err := json.Unmarshal([]byte(savedPlaylist.SearchParams), &r); err == nil {
-	// 		r.IsAccessible = optional.NewBool(true)
-	// 		r.IsAvailable = optional.NewBool(true)
-	// 		data := models.QueryScenesFull(r)
+	// Saved filters
+	if obj.Path == "saved-filters" {
+		objs = me.getSavedFilters()
+	}
 
-	// 		for i := range data.Scenes {
-	// 			objs = append(objs, me.sceneToContainer(data.Scenes[i], "sites/"+id[1], host))
-	// 		}
-	// 	}
-	// }
+	if strings.HasPrefix(obj.Path, "saved-filters/") {
+		objs = me.getSavedFilterScenes(childPath(paths), client)
+	}
 
 	// Studios
 	if obj.Path == "studios" {
@@ -297,7 +472,7 @@ func (me *contentDirectoryService) handleBrowseDirectChildren(obj object, host s
 	}
 
 	if strings.HasPrefix(obj.Path, "studios/") {
-		objs = me.getStudioScenes(childPath(paths), host)
+		objs = me.getStudioScenes(childPath(paths), client)
 	}
 
 	// Tags
@@ -306,7 +481,7 @@ func (me *contentDirectoryService) handleBrowseDirectChildren(obj object, host s
 	}
 
 	if strings.HasPrefix(obj.Path, "tags/") {
-		objs = me.getTagScenes(childPath(paths), host)
+		objs = me.getTagScenes(childPath(paths), client)
 	}
 
 	// Performers
@@ -315,7 +490,7 @@ func (me *contentDirectoryService) handleBrowseDirectChildren(obj object, host s
 	}
 
 	if strings.HasPrefix(obj.Path, "performers/") {
-		objs = me.getPerformerScenes(childPath(paths), host)
+		objs = me.getPerformerScenes(childPath(paths), client)
 	}
 
 	// Movies
@@ -324,7 +499,7 @@ func (me *contentDirectoryService) handleBrowseDirectChildren(obj object, host s
 	}
 
 	if strings.HasPrefix(obj.Path, "movies/") {
-		objs = me.getMovieScenes(childPath(paths), host)
+		objs = me.getMovieScenes(childPath(paths), client)
 	}
 
 	// Rating
@@ -333,16 +508,41 @@ func (me *contentDirectoryService) handleBrowseDirectChildren(obj object, host s
 	}
 
 	if strings.HasPrefix(obj.Path, "rating/") {
-		objs = me.getRatingScenes(childPath(paths), host)
+		objs = me.getRatingScenes(childPath(paths), client)
+	}
+
+	// Images
+	if obj.Path == "images" {
+		objs = me.getImages(&models.ImageFilterType{}, "images", client)
+	}
+
+	if strings.HasPrefix(obj.Path, "images/") {
+		page := getPageFromID(paths)
+		if page != nil {
+			objs = me.getPageImages(&models.ImageFilterType{}, "images", *page, client)
+		}
+	}
+
+	// Galleries
+	if obj.Path == "galleries" {
+		objs = me.getGalleries()
+	}
+
+	if strings.HasPrefix(obj.Path, "galleries/") {
+		objs = me.getGalleryImages(childPath(paths), client)
 	}
 
 	return makeBrowseResult(objs, me.updateIDString())
 }
 
-func (me *contentDirectoryService) handleBrowseMetadata(obj object, host string) (map[string]string, error) {
+func (me *contentDirectoryService) handleBrowseMetadata(obj object, client browseClient) (map[string]string, error) {
 	var objs []interface{}
 	var updateID string
 
+	if strings.HasPrefix(obj.Path, imageObjectPrefix) {
+		return me.handleBrowseImageMetadata(obj, client)
+	}
+
 	// if numeric, then must be scene, otherwise handle as if path
 	sceneID, err := strconv.Atoi(obj.Path)
 	if err != nil {
@@ -359,11 +559,12 @@ func (me *contentDirectoryService) handleBrowseMetadata(obj object, host string)
 		updateID = me.updateIDString()
 	} else {
 		var scene *models.Scene
+		var upnpObject interface{}
 
 		if err := txn.WithReadTxn(context.TODO(), me.txnManager, func(ctx context.Context) error {
 			scene, err = me.repository.SceneFinder.Find(ctx, sceneID)
 			if scene != nil {
-				err = scene.LoadPrimaryFile(ctx, me.repository.FileFinder)
+				upnpObject, err = loadSceneContainer(ctx, me.repository, scene, "-1", client)
 			}
 
 			if err != nil {
@@ -375,8 +576,7 @@ func (me *contentDirectoryService) handleBrowseMetadata(obj object, host string)
 			logger.Error(err.Error())
 		}
 
-		if scene != nil {
-			upnpObject := sceneToContainer(scene, "-1", host)
+		if upnpObject != nil {
 			objs = []interface{}{upnpObject}
 
 			// http://upnp.org/specs/av/UPnP-av-ContentDirectory-v1-Service.pdf
@@ -391,6 +591,35 @@ func (me *contentDirectoryService) handleBrowseMetadata(obj object, host string)
 	return makeBrowseResult(objs, updateID)
 }
 
+func (me *contentDirectoryService) handleBrowseImageMetadata(obj object, client browseClient) (map[string]string, error) {
+	imageID, err := strconv.Atoi(strings.TrimPrefix(obj.Path, imageObjectPrefix))
+	if err != nil {
+		return nil, upnp.Errorf(upnpav.NoSuchObjectErrorCode, "invalid image id")
+	}
+
+	var img *models.Image
+	if err := txn.WithReadTxn(context.TODO(), me.txnManager, func(ctx context.Context) error {
+		img, err = me.repository.ImageFinder.Find(ctx, imageID)
+		if img != nil {
+			err = img.LoadPrimaryFile(ctx, me.repository.FileFinder)
+		}
+
+		return err
+	}); err != nil {
+		logger.Error(err.Error())
+	}
+
+	if img == nil {
+		return nil, upnp.Errorf(upnpav.NoSuchObjectErrorCode, "image not found")
+	}
+
+	// maximum update ID is 2**32, then rolls back to 0
+	const maxUpdateID int64 = 1 << 32
+	updateID := fmt.Sprint(img.UpdatedAt.Unix() % maxUpdateID)
+
+	return makeBrowseResult([]interface{}{imageToItem(img, "-1", client)}, updateID)
+}
+
 func makeBrowseResult(objs []interface{}, updateID string) (map[string]string, error) {
 	result, err := xml.Marshal(objs)
 	if err != nil {
@@ -431,11 +660,14 @@ func getRootObjects() []interface{} {
 	var objs []interface{}
 
 	objs = append(objs, makeStorageFolder("all", "all", rootID))
+	objs = append(objs, makeStorageFolder("saved-filters", "Saved filters", rootID))
 	objs = append(objs, makeStorageFolder("performers", "performers", rootID))
 	objs = append(objs, makeStorageFolder("tags", "tags", rootID))
 	objs = append(objs, makeStorageFolder("studios", "studios", rootID))
 	objs = append(objs, makeStorageFolder("movies", "movies", rootID))
 	objs = append(objs, makeStorageFolder("rating", "rating", rootID))
+	objs = append(objs, makeStorageFolder("images", "images", rootID))
+	objs = append(objs, makeStorageFolder("galleries", "galleries", rootID))
 
 	return objs
 }
@@ -449,26 +681,53 @@ func getSortDirection(sceneFilter *models.SceneFilterType, sort string) models.S
 	return direction
 }
 
-func (me *contentDirectoryService) getVideos(sceneFilter *models.SceneFilterType, parentID string, host string) []interface{} {
+// sceneQuery holds the arguments of a scene query. Empty sort values use the
+// configured video sort order.
+type sceneQuery struct {
+	sceneFilter *models.SceneFilterType
+	q           string
+	sort        string
+	direction   models.SortDirectionEnum
+}
+
+func (me *contentDirectoryService) getVideos(sceneFilter *models.SceneFilterType, parentID string, client browseClient) []interface{} {
+	return me.queryVideos(&sceneQuery{sceneFilter: sceneFilter}, parentID, client)
+}
+
+// getSort returns the sort and direction of the query, falling back to the
+// configured video sort order.
+func (me *contentDirectoryService) getSort(query *sceneQuery) (string, models.SortDirectionEnum) {
+	if query.sort != "" {
+		return query.sort, query.direction
+	}
+
+	sort := me.VideoSortOrder
+	return sort, getSortDirection(query.sceneFilter, sort)
+}
+
+func (me *contentDirectoryService) queryVideos(query *sceneQuery, parentID string, client browseClient) []interface{} {
 	var objs []interface{}
 
 	if err := txn.WithReadTxn(context.TODO(), me.txnManager, func(ctx context.Context) error {
-		sort := me.VideoSortOrder
-		direction := getSortDirection(sceneFilter, sort)
+		sort, direction := me.getSort(query)
 		findFilter := &models.FindFilterType{
 			PerPage:   &pageSize,
 			Sort:      &sort,
 			Direction: &direction,
 		}
+		if query.q != "" {
+			findFilter.Q = &query.q
+		}
 
-		scenes, total, err := scene.QueryWithCount(ctx, me.repository.SceneFinder, sceneFilter, findFilter)
+		scenes, total, err := scene.QueryWithCount(ctx, me.repository.SceneFinder, query.sceneFilter, findFilter)
 		if err != nil {
 			return err
 		}
 
 		if total > pageSize {
 			pager := scenePager{
-				sceneFilter: sceneFilter,
+				sceneFilter: query.sceneFilter,
+				q:           query.q,
 				parentID:    parentID,
 			}
 
@@ -477,12 +736,9 @@ func (me *contentDirectoryService) getVideos(sceneFilter *models.SceneFilterType
 				return err
 			}
 		} else {
-			for _, s := range scenes {
-				if err := s.LoadPrimaryFile(ctx, me.repository.FileFinder); err != nil {
-					return err
-				}
-
-				objs = append(objs, sceneToContainer(s, parentID, host))
+			objs, err = loadSceneContainers(ctx, me.repository, scenes, parentID, client)
+			if err != nil {
+				return err
 			}
 		}
 
@@ -494,19 +750,23 @@ func (me *contentDirectoryService) getVideos(sceneFilter *models.SceneFilterType
 	return objs
 }
 
-func (me *contentDirectoryService) getPageVideos(sceneFilter *models.SceneFilterType, parentID string, page int, host string) []interface{} {
+func (me *contentDirectoryService) getPageVideos(sceneFilter *models.SceneFilterType, parentID string, page int, client browseClient) []interface{} {
+	return me.queryPageVideos(&sceneQuery{sceneFilter: sceneFilter}, parentID, page, client)
+}
+
+func (me *contentDirectoryService) queryPageVideos(query *sceneQuery, parentID string, page int, client browseClient) []interface{} {
 	var objs []interface{}
 
 	if err := txn.WithReadTxn(context.TODO(), me.txnManager, func(ctx context.Context) error {
 		pager := scenePager{
-			sceneFilter: sceneFilter,
+			sceneFilter: query.sceneFilter,
+			q:           query.q,
 			parentID:    parentID,
 		}
 
-		sort := me.VideoSortOrder
-		direction := getSortDirection(sceneFilter, sort)
+		sort, direction := me.getSort(query)
 		var err error
-		objs, err = pager.getPageVideos(ctx, me.repository.SceneFinder, me.repository.FileFinder, page, host, sort, direction)
+		objs, err = pager.getPageVideos(ctx, me.repository, page, client, sort, direction)
 		if err != nil {
 			return err
 		}
@@ -533,8 +793,78 @@ func getPageFromID(paths []string) *int {
 	return &ret
 }
 
-func (me *contentDirectoryService) getAllScenes(host string) []interface{} {
-	return me.getVideos(&models.SceneFilterType{}, "all", host)
+func (me *contentDirectoryService) getAllScenes(client browseClient) []interface{} {
+	return me.getVideos(&models.SceneFilterType{}, "all", client)
+}
+
+func (me *contentDirectoryService) getSavedFilters() []interface{} {
+	var objs []interface{}
+
+	if err := txn.WithReadTxn(context.TODO(), me.txnManager, func(ctx context.Context) error {
+		filters, err := me.repository.SavedFilterFinder.FindByMode(ctx, models.FilterModeScenes)
+		if err != nil {
+			return err
+		}
+
+		for _, f := range filters {
+			// default filters have no name
+			if f.Name == "" {
+				continue
+			}
+
+			objs = append(objs, makeStorageFolder("saved-filters/"+strconv.Itoa(f.ID), f.Name, "saved-filters"))
+		}
+
+		return nil
+	}); err != nil {
+		logger.Errorf(err.Error())
+	}
+
+	return objs
+}
+
+// getSavedFilterQuery returns the scene query of the saved filter with the
+// given id. Returns nil if the saved filter is not found.
+func (me *contentDirectoryService) getSavedFilterQuery(id string) (*sceneQuery, error) {
+	filterID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid saved filter id %q", id)
+	}
+
+	var f *models.SavedFilter
+	if err := txn.WithReadTxn(context.TODO(), me.txnManager, func(ctx context.Context) error {
+		f, err = me.repository.SavedFilterFinder.Find(ctx, filterID)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	if f == nil || f.Mode != models.FilterModeScenes {
+		return nil, nil
+	}
+
+	return parseSavedSceneFilter(f)
+}
+
+func (me *contentDirectoryService) getSavedFilterScenes(paths []string, client browseClient) []interface{} {
+	query, err := me.getSavedFilterQuery(paths[0])
+	if err != nil {
+		logger.Errorf(err.Error())
+		return nil
+	}
+
+	if query == nil {
+		return nil
+	}
+
+	parentID := "saved-filters/" + strings.Join(paths, "/")
+
+	page := getPageFromID(paths)
+	if page != nil {
+		return me.queryPageVideos(query, parentID, *page, client)
+	}
+
+	return me.queryVideos(query, parentID, client)
 }
 
 func (me *contentDirectoryService) getStudios() []interface{} {
@@ -558,7 +888,7 @@ func (me *contentDirectoryService) getStudios() []interface{} {
 	return objs
 }
 
-func (me *contentDirectoryService) getStudioScenes(paths []string, host string) []interface{} {
+func (me *contentDirectoryService) getStudioScenes(paths []string, client browseClient) []interface{} {
 	sceneFilter := &models.SceneFilterType{
 		Studios: &models.HierarchicalMultiCriterionInput{
 			Modifier: models.CriterionModifierIncludes,
@@ -570,10 +900,10 @@ func (me *contentDirectoryService) getStudioScenes(paths []string, host string)
 
 	page := getPageFromID(paths)
 	if page != nil {
-		return me.getPageVideos(sceneFilter, parentID, *page, host)
+		return me.getPageVideos(sceneFilter, parentID, *page, client)
 	}
 
-	return me.getVideos(sceneFilter, parentID, host)
+	return me.getVideos(sceneFilter, parentID, client)
 }
 
 func (me *contentDirectoryService) getTags() []interface{} {
@@ -597,7 +927,7 @@ func (me *contentDirectoryService) getTags() []interface{} {
 	return objs
 }
 
-func (me *contentDirectoryService) getTagScenes(paths []string, host string) []interface{} {
+func (me *contentDirectoryService) getTagScenes(paths []string, client browseClient) []interface{} {
 	sceneFilter := &models.SceneFilterType{
 		Tags: &models.HierarchicalMultiCriterionInput{
 			Modifier: models.CriterionModifierIncludes,
@@ -609,10 +939,10 @@ func (me *contentDirectoryService) getTagScenes(paths []string, host string) []i
 
 	page := getPageFromID(paths)
 	if page != nil {
-		return me.getPageVideos(sceneFilter, parentID, *page, host)
+		return me.getPageVideos(sceneFilter, parentID, *page, client)
 	}
 
-	return me.getVideos(sceneFilter, parentID, host)
+	return me.getVideos(sceneFilter, parentID, client)
 }
 
 func (me *contentDirectoryService) getPerformers() []interface{} {
@@ -636,7 +966,7 @@ func (me *contentDirectoryService) getPerformers() []interface{} {
 	return objs
 }
 
-func (me *contentDirectoryService) getPerformerScenes(paths []string, host string) []interface{} {
+func (me *contentDirectoryService) getPerformerScenes(paths []string, client browseClient) []interface{} {
 	sceneFilter := &models.SceneFilterType{
 		Performers: &models.MultiCriterionInput{
 			Modifier: models.CriterionModifierIncludes,
@@ -648,10 +978,10 @@ func (me *contentDirectoryService) getPerformerScenes(paths []string, host strin
 
 	page := getPageFromID(paths)
 	if page != nil {
-		return me.getPageVideos(sceneFilter, parentID, *page, host)
+		return me.getPageVideos(sceneFilter, parentID, *page, client)
 	}
 
-	return me.getVideos(sceneFilter, parentID, host)
+	return me.getVideos(sceneFilter, parentID, client)
 }
 
 func (me *contentDirectoryService) getMovies() []interface{} {
@@ -675,7 +1005,7 @@ func (me *contentDirectoryService) getMovies() []interface{} {
 	return objs
 }
 
-func (me *contentDirectoryService) getMovieScenes(paths []string, host string) []interface{} {
+func (me *contentDirectoryService) getMovieScenes(paths []string, client browseClient) []interface{} {
 	sceneFilter := &models.SceneFilterType{
 		Movies: &models.MultiCriterionInput{
 			Modifier: models.CriterionModifierIncludes,
@@ -687,10 +1017,10 @@ func (me *contentDirectoryService) getMovieScenes(paths []string, host string) [
 
 	page := getPageFromID(paths)
 	if page != nil {
-		return me.getPageVideos(sceneFilter, parentID, *page, host)
+		return me.getPageVideos(sceneFilter, parentID, *page, client)
 	}
 
-	return me.getVideos(sceneFilter, parentID, host)
+	return me.getVideos(sceneFilter, parentID, client)
 }
 
 func (me *contentDirectoryService) getRating() []interface{} {
@@ -704,7 +1034,7 @@ func (me *contentDirectoryService) getRating() []interface{} {
 	return objs
 }
 
-func (me *contentDirectoryService) getRatingScenes(paths []string, host string) []interface{} {
+func (me *contentDirectoryService) getRatingScenes(paths []string, client browseClient) []interface{} {
 	r, err := strconv.Atoi(paths[0])
 	if err != nil {
 		return nil
@@ -721,10 +1051,135 @@ func (me *contentDirectoryService) getRatingScenes(paths []string, host string)
 
 	page := getPageFromID(paths)
 	if page != nil {
-		return me.getPageVideos(sceneFilter, parentID, *page, host)
+		return me.getPageVideos(sceneFilter, parentID, *page, client)
+	}
+
+	return me.getVideos(sceneFilter, parentID, client)
+}
+
+func (me *contentDirectoryService) getImages(imageFilter *models.ImageFilterType, parentID string, client browseClient) []interface{} {
+	var objs []interface{}
+
+	if err := txn.WithReadTxn(context.TODO(), me.txnManager, func(ctx context.Context) error {
+		sort := "path"
+		direction := models.SortDirectionEnumAsc
+		findFilter := &models.FindFilterType{
+			PerPage:   &pageSize,
+			Sort:      &sort,
+			Direction: &direction,
+		}
+
+		result, err := me.repository.ImageFinder.Query(ctx, image.QueryOptions(imageFilter, findFilter, true))
+		if err != nil {
+			return err
+		}
+
+		if result.Count > pageSize {
+			objs = makePageFolders(parentID, result.Count)
+			return nil
+		}
+
+		images, err := result.Resolve(ctx)
+		if err != nil {
+			return err
+		}
+
+		objs, err = me.imagesToItems(ctx, images, parentID, client)
+		return err
+	}); err != nil {
+		logger.Error(err.Error())
+	}
+
+	return objs
+}
+
+func (me *contentDirectoryService) getPageImages(imageFilter *models.ImageFilterType, parentID string, page int, client browseClient) []interface{} {
+	var objs []interface{}
+
+	if err := txn.WithReadTxn(context.TODO(), me.txnManager, func(ctx context.Context) error {
+		sort := "path"
+		direction := models.SortDirectionEnumAsc
+		findFilter := &models.FindFilterType{
+			PerPage:   &pageSize,
+			Page:      &page,
+			Sort:      &sort,
+			Direction: &direction,
+		}
+
+		images, err := image.Query(ctx, me.repository.ImageFinder, imageFilter, findFilter)
+		if err != nil {
+			return err
+		}
+
+		objs, err = me.imagesToItems(ctx, images, parentID+"/page/"+strconv.Itoa(page), client)
+		return err
+	}); err != nil {
+		logger.Error(err.Error())
+	}
+
+	return objs
+}
+
+func (me *contentDirectoryService) imagesToItems(ctx context.Context, images []*models.Image, parentID string, client browseClient) ([]interface{}, error) {
+	var objs []interface{}
+	for _, img := range images {
+		if err := img.LoadPrimaryFile(ctx, me.repository.FileFinder); err != nil {
+			return nil, err
+		}
+
+		objs = append(objs, imageToItem(img, parentID, client))
+	}
+
+	return objs, nil
+}
+
+func (me *contentDirectoryService) getGalleries() []interface{} {
+	var objs []interface{}
+
+	if err := txn.WithReadTxn(context.TODO(), me.txnManager, func(ctx context.Context) error {
+		// same default order as the galleries page
+		perPage := -1
+		sort := "path"
+		direction := models.SortDirectionEnumAsc
+		findFilter := &models.FindFilterType{
+			PerPage:   &perPage,
+			Sort:      &sort,
+			Direction: &direction,
+		}
+
+		galleries, _, err := me.repository.GalleryFinder.Query(ctx, &models.GalleryFilterType{}, findFilter)
+		if err != nil {
+			return err
+		}
+
+		for _, g := range galleries {
+			objs = append(objs, makeStorageFolder("galleries/"+strconv.Itoa(g.ID), g.DisplayName(), "galleries"))
+		}
+
+		return nil
+	}); err != nil {
+		logger.Error(err.Error())
+	}
+
+	return objs
+}
+
+func (me *contentDirectoryService) getGalleryImages(paths []string, client browseClient) []interface{} {
+	imageFilter := &models.ImageFilterType{
+		Galleries: &models.MultiCriterionInput{
+			Modifier: models.CriterionModifierIncludes,
+			Value:    []string{paths[0]},
+		},
+	}
+
+	parentID := "galleries/" + paths[0]
+
+	page := getPageFromID(paths)
+	if page != nil {
+		return me.getPageImages(imageFilter, parentID, *page, client)
 	}
 
-	return me.getVideos(sceneFilter, parentID, host)
+	return me.getImages(imageFilter, parentID, client)
 }
 
 // Represents a ContentDirectory object.
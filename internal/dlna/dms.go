@@ -42,10 +42,15 @@ import (
 	"strings"
 	"time"
 
+	"github.com/anacrolix/dms/dlna"
 	"github.com/anacrolix/dms/soap"
 	"github.com/anacrolix/dms/ssdp"
 	"github.com/anacrolix/dms/upnp"
 
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/gallery"
+	"github.com/stashapp/stash/pkg/image"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/scene"
@@ -57,27 +62,56 @@ type SceneFinder interface {
 	scene.IDFinder
 }
 
+type SceneActivityWriter interface {
+	SaveActivity(ctx context.Context, id int, resumeTime *float64, playDuration *float64) (bool, error)
+	IncrementWatchCount(ctx context.Context, id int) (int, error)
+}
+
+type CaptionFinder interface {
+	GetCaptions(ctx context.Context, fileID file.ID) ([]*models.VideoCaption, error)
+	GetManyCaptions(ctx context.Context, fileIDs []file.ID) ([][]*models.VideoCaption, error)
+}
+
+type ImageFinder interface {
+	image.Queryer
+	Find(ctx context.Context, id int) (*models.Image, error)
+}
+
+type GalleryFinder interface {
+	gallery.Queryer
+}
+
 type StudioFinder interface {
 	All(ctx context.Context) ([]*models.Studio, error)
 }
 
 type TagFinder interface {
 	All(ctx context.Context) ([]*models.Tag, error)
+	FindByNames(ctx context.Context, names []string, nocase bool) ([]*models.Tag, error)
+	Query(ctx context.Context, tagFilter *models.TagFilterType, findFilter *models.FindFilterType) ([]*models.Tag, int, error)
 }
 
 type PerformerFinder interface {
 	All(ctx context.Context) ([]*models.Performer, error)
+	FindByNames(ctx context.Context, names []string, nocase bool) ([]*models.Performer, error)
+	Query(ctx context.Context, performerFilter *models.PerformerFilterType, findFilter *models.FindFilterType) ([]*models.Performer, int, error)
 }
 
 type MovieFinder interface {
 	All(ctx context.Context) ([]*models.Movie, error)
 }
 
+type SavedFilterFinder interface {
+	Find(ctx context.Context, id int) (*models.SavedFilter, error)
+	FindByMode(ctx context.Context, mode models.FilterMode) ([]*models.SavedFilter, error)
+}
+
 const (
 	serverField                 = "Linux/3.4 DLNADOC/1.50 UPnP/1.0 DMS/1.0"
 	rootDeviceType              = "urn:schemas-upnp-org:device:MediaServer:1"
 	rootDeviceModelName         = "dms 1.0xb"
 	resPath                     = "/res"
+	imagePath                   = "/image"
 	iconPath                    = "/icon"
 	rootDescPath                = "/rootDesc.xml"
 	contentDirectoryEventSubURL = "/evt/ContentDirectory"
@@ -275,7 +309,9 @@ type Server struct {
 	txnManager         txn.Manager
 	repository         Repository
 	sceneServer        sceneServer
+	imageServer        imageServer
 	ipWhitelistManager *ipWhitelistManager
+	activityTracker    *activityTracker
 	VideoSortOrder     string
 }
 
@@ -577,27 +613,9 @@ func (me *Server) initMux(mux *http.ServeMux) {
 	})
 	mux.HandleFunc(contentDirectoryEventSubURL, me.contentDirectoryEventSubHandler)
 	mux.HandleFunc(iconPath, me.serveIcon)
-	mux.HandleFunc(resPath, func(w http.ResponseWriter, r *http.Request) {
-		sceneId := r.URL.Query().Get("scene")
-		var scene *models.Scene
-		err := txn.WithReadTxn(r.Context(), me.txnManager, func(ctx context.Context) error {
-			sceneIdInt, err := strconv.Atoi(sceneId)
-			if err != nil {
-				return nil
-			}
-			scene, _ = me.repository.SceneFinder.Find(ctx, sceneIdInt)
-			return nil
-		})
-		if err != nil {
-			logger.Warnf("failed to execute read transaction for scene id (%v): %v", sceneId, err)
-		}
-
-		if scene == nil {
-			return
-		}
-
-		me.sceneServer.StreamSceneDirect(scene, w, r)
-	})
+	mux.HandleFunc(resPath, me.serveScene)
+	mux.HandleFunc(imagePath, me.serveImage)
+	mux.HandleFunc(captionPath, me.serveCaption)
 	mux.HandleFunc(rootDescPath, func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("content-type", `text/xml; charset="utf-8"`)
 		w.Header().Set("content-length", fmt.Sprint(len(me.rootDescXML)))
@@ -617,6 +635,117 @@ func (me *Server) initMux(mux *http.ServeMux) {
 	}
 }
 
+func (me *Server) serveScene(w http.ResponseWriter, r *http.Request) {
+	sceneId := r.URL.Query().Get("scene")
+	var scene *models.Scene
+	var captions []*models.VideoCaption
+	err := txn.WithReadTxn(r.Context(), me.txnManager, func(ctx context.Context) error {
+		sceneIdInt, err := strconv.Atoi(sceneId)
+		if err != nil {
+			return nil
+		}
+		scene, _ = me.repository.SceneFinder.Find(ctx, sceneIdInt)
+		if scene == nil {
+			return nil
+		}
+		if err := scene.LoadPrimaryFile(ctx, me.repository.FileFinder); err != nil {
+			return err
+		}
+		if r.Header.Get(getCaptionInfoHeader) == "1" {
+			captions, err = getSceneCaptions(ctx, me.repository, scene)
+		}
+		return err
+	})
+	if err != nil {
+		logger.Warnf("failed to execute read transaction for scene id (%v): %v", sceneId, err)
+	}
+
+	if scene == nil {
+		return
+	}
+
+	// HEAD requests are made when probing the file
+	if r.Method != http.MethodHead {
+		clientIp, _, _ := net.SplitHostPort(r.RemoteAddr)
+		done := me.activityTracker.startRequest(net.ParseIP(clientIp).String(), scene.ID)
+		defer done()
+	}
+
+	if u := defaultCaptionURL(r.Host, scene.ID, captions); u != "" {
+		w.Header().Set(captionInfoHeader, u)
+	}
+
+	f := scene.Files.Primary()
+	transcode := r.URL.Query().Get("transcode")
+	streamType, ok := transcodeTypes[transcode]
+	if !ok || f == nil {
+		if f != nil {
+			w.Header().Set(dlna.ContentFeaturesDomain, directContentFeatures(f).String())
+		}
+		w.Header().Set(dlna.TransferModeDomain, "Streaming")
+		me.sceneServer.StreamSceneDirect(scene, w, r)
+		return
+	}
+
+	options := ffmpeg.TranscodeOptions{
+		StreamType: streamType,
+		Resolution: r.URL.Query().Get("resolution"),
+	}
+
+	// renderers seek in transcoded streams using the time seek range header
+	if seekRange := r.Header.Get(dlna.TimeSeekRangeDomain); seekRange != "" {
+		start, err := parseTimeSeekRange(seekRange)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		options.StartTime = start.Seconds()
+		duration := formatNPT(time.Duration(f.Duration * float64(time.Second)))
+		w.Header().Set(dlna.TimeSeekRangeDomain, fmt.Sprintf("npt=%s-%s/%s", formatNPT(start), duration, duration))
+	}
+
+	resolution := models.StreamingResolutionEnum(options.Resolution)
+	w.Header().Set(dlna.ContentFeaturesDomain, transcodeContentFeatures(f, streamType, resolution).String())
+	w.Header().Set(dlna.TransferModeDomain, "Streaming")
+
+	logger.Debugf("[dlna] transcoding scene %d as %s", scene.ID, streamType.MimeType)
+	me.sceneServer.StreamSceneTranscode(scene, w, r, options)
+}
+
+func (me *Server) serveImage(w http.ResponseWriter, r *http.Request) {
+	imageID, err := strconv.Atoi(r.URL.Query().Get("image"))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	var img *models.Image
+	if err := txn.WithReadTxn(r.Context(), me.txnManager, func(ctx context.Context) error {
+		img, err = me.repository.ImageFinder.Find(ctx, imageID)
+		if err != nil || img == nil {
+			return err
+		}
+		return img.LoadPrimaryFile(ctx, me.repository.FileFinder)
+	}); err != nil {
+		logger.Warnf("failed to execute read transaction for image id (%v): %v", imageID, err)
+		img = nil
+	}
+
+	if img == nil {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set(dlna.TransferModeDomain, "Interactive")
+	if r.URL.Query().Has("thumbnail") {
+		me.imageServer.ServeThumbnail(img, w, r)
+		return
+	}
+
+	me.imageServer.ServeImage(img, w, r)
+}
+
 func (me *Server) initServices() {
 	me.services = map[string]UPnPService{
 		"ContentDirectory": &contentDirectoryService{
@@ -712,7 +841,8 @@ func didl_lite(chardata string) string {
 		` xmlns:dc="http://purl.org/dc/elements/1.1/"` +
 		` xmlns:upnp="urn:schemas-upnp-org:metadata-1-0/upnp/"` +
 		` xmlns="urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/"` +
-		` xmlns:dlna="urn:schemas-dlna-org:metadata-1-0/">` +
+		` xmlns:dlna="urn:schemas-dlna-org:metadata-1-0/"` +
+		` xmlns:sec="http://www.sec.co.kr/">` +
 		chardata +
 		`</DIDL-Lite>`
 }
@@ -6,16 +6,25 @@ import (
 	"math"
 	"strconv"
 
-	"github.com/stashapp/stash/pkg/file"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/scene"
 )
 
 type scenePager struct {
 	sceneFilter *models.SceneFilterType
+	q           string
 	parentID    string
 }
 
+func (p *scenePager) findFilter() *models.FindFilterType {
+	ret := &models.FindFilterType{}
+	if p.q != "" {
+		q := p.q
+		ret.Q = &q
+	}
+	return ret
+}
+
 func (p *scenePager) getPageID(page int) string {
 	return p.parentID + "/page/" + strconv.Itoa(page)
 }
@@ -28,10 +37,9 @@ func (p *scenePager) getPages(ctx context.Context, r scene.Queryer, total int) (
 
 	singlePageSize := 1
 	sort := "title"
-	findFilter := &models.FindFilterType{
-		PerPage: &singlePageSize,
-		Sort:    &sort,
-	}
+	findFilter := p.findFilter()
+	findFilter.PerPage = &singlePageSize
+	findFilter.Sort = &sort
 
 	for page := 1; page <= pages; page++ {
 		// TODO - this is really slow. Not sure if there's a better way
@@ -60,28 +68,29 @@ func (p *scenePager) getPages(ctx context.Context, r scene.Queryer, total int) (
 	return objs, nil
 }
 
-func (p *scenePager) getPageVideos(ctx context.Context, r SceneFinder, f file.Finder, page int, host string, sort string, direction models.SortDirectionEnum) ([]interface{}, error) {
+// makePageFolders returns a folder for each page of the parent folder.
+func makePageFolders(parentID string, total int) []interface{} {
 	var objs []interface{}
 
-	findFilter := &models.FindFilterType{
-		PerPage:   &pageSize,
-		Page:      &page,
-		Sort:      &sort,
-		Direction: &direction,
+	pages := int(math.Ceil(float64(total) / float64(pageSize)))
+	for page := 1; page <= pages; page++ {
+		objs = append(objs, makeStorageFolder(parentID+"/page/"+strconv.Itoa(page), fmt.Sprintf("Page %d", page), parentID))
 	}
 
-	scenes, err := scene.Query(ctx, r, p.sceneFilter, findFilter)
-	if err != nil {
-		return nil, err
-	}
+	return objs
+}
 
-	for _, s := range scenes {
-		if err := s.LoadPrimaryFile(ctx, f); err != nil {
-			return nil, err
-		}
+func (p *scenePager) getPageVideos(ctx context.Context, r Repository, page int, client browseClient, sort string, direction models.SortDirectionEnum) ([]interface{}, error) {
+	findFilter := p.findFilter()
+	findFilter.PerPage = &pageSize
+	findFilter.Page = &page
+	findFilter.Sort = &sort
+	findFilter.Direction = &direction
 
-		objs = append(objs, sceneToContainer(s, p.parentID, host))
+	scenes, err := scene.Query(ctx, r.SceneFinder, p.sceneFilter, findFilter)
+	if err != nil {
+		return nil, err
 	}
 
-	return objs, nil
+	return loadSceneContainers(ctx, r, scenes, p.parentID, client)
 }
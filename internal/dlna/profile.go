@@ -0,0 +1,374 @@
+package dlna
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anacrolix/dms/dlna"
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/sliceutil/stringslice"
+)
+
+const clientInfoHeader = "X-AV-Client-Info"
+
+// rendererProfile describes the files a DLNA renderer can play natively.
+// Files that are not supported are offered through a live transcode.
+type rendererProfile struct {
+	Name string
+	// UserAgent and ClientInfo are matched against the User-Agent and
+	// X-AV-Client-Info request headers. Either must match.
+	UserAgent  *regexp.Regexp
+	ClientInfo *regexp.Regexp
+
+	// supported containers, video and audio codecs. Nil means any.
+	Containers  []ffmpeg.Container
+	VideoCodecs []string
+	AudioCodecs []string
+	// MaxResolution is the maximum size of the smaller dimension of the
+	// video. Zero means no limit.
+	MaxResolution int
+
+	// TranscodeType is the format used when transcoding unsupported files
+	TranscodeType ffmpeg.StreamFormat
+}
+
+var (
+	commonAudioCodecs = []string{"aac", "mp3", "ac3", "eac3"}
+
+	// defaultProfile is used for renderers that are not recognised. It only
+	// allows files that are playable by practically all renderers.
+	defaultProfile = &rendererProfile{
+		Name:          "Default",
+		Containers:    []ffmpeg.Container{ffmpeg.Mp4, ffmpeg.M4v, ffmpeg.Mpegts},
+		VideoCodecs:   []string{ffmpeg.H264},
+		AudioCodecs:   []string{"aac", "mp3", "ac3"},
+		MaxResolution: 1080,
+		TranscodeType: ffmpeg.StreamTypeMPEGTS,
+	}
+
+	rendererProfiles = []*rendererProfile{
+		{
+			Name:          "Samsung TV",
+			UserAgent:     regexp.MustCompile(`(?i)SEC_HHP|Samsung|Tizen`),
+			ClientInfo:    regexp.MustCompile(`(?i)Samsung`),
+			Containers:    []ffmpeg.Container{ffmpeg.Mp4, ffmpeg.M4v, ffmpeg.Mov, ffmpeg.Matroska, ffmpeg.Avi, ffmpeg.Mpegts, ffmpeg.Wmv},
+			VideoCodecs:   []string{ffmpeg.H264, ffmpeg.Hevc, "mpeg2video", "mpeg4", ffmpeg.Vp9, "wmv3", "vc1"},
+			AudioCodecs:   append([]string{"wmav2", "wmapro", "dts"}, commonAudioCodecs...),
+			MaxResolution: 2160,
+			TranscodeType: ffmpeg.StreamTypeMPEGTS,
+		},
+		{
+			Name:          "LG TV",
+			UserAgent:     regexp.MustCompile(`(?i)LGE|webOS|NetCast`),
+			Containers:    []ffmpeg.Container{ffmpeg.Mp4, ffmpeg.M4v, ffmpeg.Mov, ffmpeg.Matroska, ffmpeg.Avi, ffmpeg.Mpegts},
+			VideoCodecs:   []string{ffmpeg.H264, ffmpeg.Hevc, "mpeg2video", "mpeg4", ffmpeg.Vp9},
+			AudioCodecs:   append([]string{"dts"}, commonAudioCodecs...),
+			MaxResolution: 2160,
+			TranscodeType: ffmpeg.StreamTypeMPEGTS,
+		},
+		{
+			Name:          "Sony Bravia",
+			UserAgent:     regexp.MustCompile(`(?i)BRAVIA`),
+			ClientInfo:    regexp.MustCompile(`(?i)BRAVIA`),
+			Containers:    []ffmpeg.Container{ffmpeg.Mp4, ffmpeg.M4v, ffmpeg.Matroska, ffmpeg.Mpegts},
+			VideoCodecs:   []string{ffmpeg.H264, ffmpeg.Hevc, "mpeg2video"},
+			AudioCodecs:   commonAudioCodecs,
+			MaxResolution: 2160,
+			TranscodeType: ffmpeg.StreamTypeMPEGTS,
+		},
+		{
+			Name:          "PlayStation",
+			ClientInfo:    regexp.MustCompile(`(?i)PLAYSTATION`),
+			Containers:    []ffmpeg.Container{ffmpeg.Mp4, ffmpeg.M4v, ffmpeg.Mpegts, ffmpeg.Avi},
+			VideoCodecs:   []string{ffmpeg.H264, "mpeg2video", "mpeg4"},
+			AudioCodecs:   commonAudioCodecs,
+			MaxResolution: 1080,
+			TranscodeType: ffmpeg.StreamTypeMPEGTS,
+		},
+		{
+			Name:          "Xbox",
+			UserAgent:     regexp.MustCompile(`(?i)Xbox|Xenon`),
+			Containers:    []ffmpeg.Container{ffmpeg.Mp4, ffmpeg.M4v, ffmpeg.Mov, ffmpeg.Matroska, ffmpeg.Avi, ffmpeg.Mpegts, ffmpeg.Wmv},
+			VideoCodecs:   []string{ffmpeg.H264, ffmpeg.Hevc, "mpeg4", "wmv3", "vc1"},
+			AudioCodecs:   append([]string{"wmav2", "wmapro"}, commonAudioCodecs...),
+			MaxResolution: 2160,
+			TranscodeType: ffmpeg.StreamTypeMP4,
+		},
+		{
+			// software players play anything ffmpeg can decode
+			Name:          "Software player",
+			UserAgent:     regexp.MustCompile(`(?i)VLC|Kodi|XBMC|mpv|BubbleUPnP`),
+			TranscodeType: ffmpeg.StreamTypeMP4,
+		},
+	}
+)
+
+// getRendererProfile returns the profile matching the renderer that made the
+// request, or the default profile if none match.
+func getRendererProfile(r *http.Request) *rendererProfile {
+	userAgent := r.UserAgent()
+	clientInfo := r.Header.Get(clientInfoHeader)
+
+	for _, p := range rendererProfiles {
+		if p.UserAgent != nil && p.UserAgent.MatchString(userAgent) {
+			return p
+		}
+		if p.ClientInfo != nil && clientInfo != "" && p.ClientInfo.MatchString(clientInfo) {
+			return p
+		}
+	}
+
+	return defaultProfile
+}
+
+// supports returns true if the renderer can play the file without
+// transcoding.
+func (p *rendererProfile) supports(f *file.VideoFile) bool {
+	if p.Containers != nil {
+		supported := false
+		for _, c := range p.Containers {
+			if ffmpeg.Container(f.Format) == c {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return false
+		}
+	}
+
+	if p.VideoCodecs != nil && !stringslice.StrInclude(p.VideoCodecs, f.VideoCodec) {
+		return false
+	}
+
+	if p.AudioCodecs != nil && f.AudioCodec != "" && !stringslice.StrInclude(p.AudioCodecs, f.AudioCodec) {
+		return false
+	}
+
+	if p.MaxResolution != 0 && videoResolution(f) > p.MaxResolution {
+		return false
+	}
+
+	return true
+}
+
+// transcodeResolution returns the streaming resolution to transcode the file
+// to, so that it fits within the maximum resolution of the renderer.
+func (p *rendererProfile) transcodeResolution(f *file.VideoFile) models.StreamingResolutionEnum {
+	if p.MaxResolution == 0 || videoResolution(f) <= p.MaxResolution {
+		return models.StreamingResolutionEnumOriginal
+	}
+
+	ret := models.StreamingResolutionEnumLow
+	for _, res := range models.AllStreamingResolutionEnum {
+		max := res.GetMaxResolution()
+		if max != 0 && max <= p.MaxResolution && max > ret.GetMaxResolution() {
+			ret = res
+		}
+	}
+
+	return ret
+}
+
+// videoMimeType returns the DLNA mime type for the container of the file.
+func videoMimeType(container ffmpeg.Container) string {
+	switch container {
+	case ffmpeg.Mp4, ffmpeg.M4v:
+		return ffmpeg.MimeMp4Video
+	case ffmpeg.Mov:
+		return "video/quicktime"
+	case ffmpeg.Matroska:
+		return ffmpeg.MimeMkvVideo
+	case ffmpeg.Webm:
+		return ffmpeg.MimeWebmVideo
+	case ffmpeg.Avi:
+		return "video/avi"
+	case ffmpeg.Wmv:
+		return "video/x-ms-wmv"
+	case ffmpeg.Flv:
+		return "video/x-flv"
+	default:
+		return ffmpeg.MimeMpegVideo
+	}
+}
+
+// videoProfileName returns the DLNA.ORG_PN value for a video with the given
+// properties. Returns an empty string if there is no matching DLNA media
+// format profile, in which case the PN parameter is omitted.
+func videoProfileName(container ffmpeg.Container, videoCodec string, audioCodec string, resolution int) string {
+	hd := resolution > 576
+
+	switch container {
+	case ffmpeg.Mp4, ffmpeg.M4v:
+		if videoCodec == ffmpeg.H264 && (audioCodec == "aac" || audioCodec == "") {
+			if hd {
+				return "AVC_MP4_HP_HD_AAC"
+			}
+			return "AVC_MP4_MP_SD_AAC_MULT5"
+		}
+	case ffmpeg.Mpegts:
+		switch videoCodec {
+		case ffmpeg.H264:
+			switch audioCodec {
+			case "aac", "":
+				if hd {
+					return "AVC_TS_HD_EU_ISO"
+				}
+				return "AVC_TS_MP_SD_AAC_MULT5_ISO"
+			case "ac3":
+				if hd {
+					return "AVC_TS_HD_50_AC3_ISO"
+				}
+				return "AVC_TS_MP_SD_AC3_ISO"
+			}
+		case "mpeg2video":
+			if hd {
+				return "MPEG_TS_HD_NA_ISO"
+			}
+			return "MPEG_TS_SD_EU_ISO"
+		}
+	case ffmpeg.Wmv:
+		if videoCodec == "wmv3" {
+			if hd {
+				return "WMVHIGH_FULL"
+			}
+			return "WMVMED_FULL"
+		}
+	}
+
+	return ""
+}
+
+// imageProfileName returns the DLNA.ORG_PN value for an image with the given
+// mime type and dimensions, or an empty string if there is no matching
+// profile.
+func imageProfileName(mimeType string, width int, height int) string {
+	fits := func(maxWidth, maxHeight int) bool {
+		return width <= maxWidth && height <= maxHeight
+	}
+
+	switch mimeType {
+	case "image/jpeg":
+		switch {
+		case fits(640, 480):
+			return "JPEG_SM"
+		case fits(1024, 768):
+			return "JPEG_MED"
+		case fits(4096, 4096):
+			return "JPEG_LRG"
+		}
+	case "image/png":
+		if fits(4096, 4096) {
+			return "PNG_LRG"
+		}
+	case "image/gif":
+		if fits(1600, 1200) {
+			return "GIF_LRG"
+		}
+	}
+
+	return ""
+}
+
+// directContentFeatures returns the DLNA content features of the file served
+// as is.
+func directContentFeatures(f *file.VideoFile) dlna.ContentFeatures {
+	container := ffmpeg.Container(f.Format)
+	return dlna.ContentFeatures{
+		ProfileName:  videoProfileName(container, f.VideoCodec, f.AudioCodec, videoResolution(f)),
+		SupportRange: true,
+	}
+}
+
+// transcodeContentFeatures returns the DLNA content features of the file
+// transcoded to the given stream type and resolution.
+func transcodeContentFeatures(f *file.VideoFile, streamType ffmpeg.StreamFormat, resolution models.StreamingResolutionEnum) dlna.ContentFeatures {
+	size := videoResolution(f)
+	if max := resolution.GetMaxResolution(); max != 0 && max < size {
+		size = max
+	}
+
+	audioCodec := "aac"
+	if f.AudioCodec == "" {
+		audioCodec = ""
+	}
+
+	return dlna.ContentFeatures{
+		ProfileName:     videoProfileName(transcodeContainer(streamType), ffmpeg.H264, audioCodec, size),
+		SupportTimeSeek: true,
+		Transcoded:      true,
+	}
+}
+
+func directProtocolInfo(f *file.VideoFile) string {
+	return protocolInfo(videoMimeType(ffmpeg.Container(f.Format)), directContentFeatures(f))
+}
+
+func transcodeProtocolInfo(f *file.VideoFile, streamType ffmpeg.StreamFormat, resolution models.StreamingResolutionEnum) string {
+	return protocolInfo(streamType.MimeType, transcodeContentFeatures(f, streamType, resolution))
+}
+
+func protocolInfo(mimeType string, features dlna.ContentFeatures) string {
+	return "http-get:*:" + mimeType + ":" + features.String()
+}
+
+// transcodeTypes maps the transcode query parameter of resource URLs to
+// stream types.
+var transcodeTypes = map[string]ffmpeg.StreamFormat{
+	"mp4":    ffmpeg.StreamTypeMP4,
+	"mpegts": ffmpeg.StreamTypeMPEGTS,
+}
+
+func transcodeTypeName(streamType ffmpeg.StreamFormat) string {
+	for name, t := range transcodeTypes {
+		if t.MimeType == streamType.MimeType {
+			return name
+		}
+	}
+
+	return ""
+}
+
+func transcodeContainer(streamType ffmpeg.StreamFormat) ffmpeg.Container {
+	switch streamType.MimeType {
+	case ffmpeg.MimeMp4Video:
+		return ffmpeg.Mp4
+	case ffmpeg.MimeMpegVideo:
+		return ffmpeg.Mpegts
+	}
+
+	return ""
+}
+
+// parseTimeSeekRange returns the start time of a TimeSeekRange.dlna.org
+// header value. Times may be in seconds or in hh:mm:ss format.
+func parseTimeSeekRange(v string) (time.Duration, error) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "npt=")
+	start, _, _ := strings.Cut(v, "-")
+
+	if secs, err := strconv.ParseFloat(start, 64); err == nil {
+		return time.Duration(secs * float64(time.Second)), nil
+	}
+
+	if !strings.Contains(start, ".") {
+		start += ".0"
+	}
+	return dlna.ParseNPTTime(start)
+}
+
+func formatNPT(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
+
+// videoResolution returns the smaller dimension of the video.
+func videoResolution(f *file.VideoFile) int {
+	if f.Width < f.Height {
+		return f.Width
+	}
+	return f.Height
+}
@@ -0,0 +1,123 @@
+package dlna
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRendererProfile(t *testing.T) {
+	tests := []struct {
+		name       string
+		userAgent  string
+		clientInfo string
+		want       string
+	}{
+		{"samsung", "DLNADOC/1.50 SEC_HHP_[TV] UE55NU7100/1.0 UPnP/1.0", "", "Samsung TV"},
+		{"sony client info", "UPnP/1.0", "av=5.0; cn=\"Sony Corporation\"; mn=\"BRAVIA KDL-40W600B\"", "Sony Bravia"},
+		{"playstation", "UPnP/1.0 DLNADOC/1.50", "av=5.0; cn=\"Sony Computer Entertainment Inc.\"; mn=\"PLAYSTATION 3\"", "PlayStation"},
+		{"vlc", "VLC/3.0.18 LibVLC/3.0.18", "", "Software player"},
+		{"unknown", "SomeRenderer/1.0", "", "Default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &http.Request{Header: http.Header{}}
+			r.Header.Set("User-Agent", tt.userAgent)
+			if tt.clientInfo != "" {
+				r.Header.Set(clientInfoHeader, tt.clientInfo)
+			}
+
+			assert.Equal(t, tt.want, getRendererProfile(r).Name)
+		})
+	}
+}
+
+func TestRendererProfileSupports(t *testing.T) {
+	videoFile := func(format, videoCodec, audioCodec string, width, height int) *file.VideoFile {
+		return &file.VideoFile{
+			Format:     format,
+			VideoCodec: videoCodec,
+			AudioCodec: audioCodec,
+			Width:      width,
+			Height:     height,
+		}
+	}
+
+	tests := []struct {
+		name string
+		f    *file.VideoFile
+		want bool
+	}{
+		{"h264 mp4", videoFile("mp4", "h264", "aac", 1920, 1080), true},
+		{"no audio", videoFile("mp4", "h264", "", 1920, 1080), true},
+		{"mkv", videoFile("matroska", "h264", "aac", 1920, 1080), false},
+		{"hevc", videoFile("mp4", "hevc", "aac", 1920, 1080), false},
+		{"opus", videoFile("mp4", "h264", "opus", 1920, 1080), false},
+		{"4k", videoFile("mp4", "h264", "aac", 3840, 2160), false},
+		{"portrait", videoFile("mp4", "h264", "aac", 1080, 1920), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, defaultProfile.supports(tt.f))
+		})
+	}
+}
+
+func TestRendererProfileTranscodeResolution(t *testing.T) {
+	p := &rendererProfile{MaxResolution: 1080}
+
+	assert.Equal(t, models.StreamingResolutionEnumOriginal, p.transcodeResolution(&file.VideoFile{Width: 1920, Height: 1080}))
+	assert.Equal(t, models.StreamingResolutionEnumFullHd, p.transcodeResolution(&file.VideoFile{Width: 3840, Height: 2160}))
+
+	p = &rendererProfile{MaxResolution: 600}
+	assert.Equal(t, models.StreamingResolutionEnumStandard, p.transcodeResolution(&file.VideoFile{Width: 1280, Height: 720}))
+}
+
+func TestVideoProfileName(t *testing.T) {
+	assert.Equal(t, "AVC_MP4_HP_HD_AAC", videoProfileName(ffmpeg.Mp4, ffmpeg.H264, "aac", 1080))
+	assert.Equal(t, "AVC_TS_MP_SD_AC3_ISO", videoProfileName(ffmpeg.Mpegts, ffmpeg.H264, "ac3", 480))
+	assert.Equal(t, "", videoProfileName(ffmpeg.Matroska, ffmpeg.H264, "aac", 1080))
+	assert.Equal(t, "", videoProfileName(ffmpeg.Mp4, ffmpeg.Hevc, "aac", 1080))
+}
+
+func TestParseTimeSeekRange(t *testing.T) {
+	tests := []struct {
+		v       string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"npt=0-", 0, false},
+		{"npt=123.5-", 123500 * time.Millisecond, false},
+		{"npt=00:01:05.250-", 65250 * time.Millisecond, false},
+		{"npt=00:01:05-00:02:00", 65 * time.Second, false},
+		{"npt=abc-", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.v, func(t *testing.T) {
+			got, err := parseTimeSeekRange(tt.v)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestImageProfileName(t *testing.T) {
+	assert.Equal(t, "JPEG_SM", imageProfileName("image/jpeg", 640, 480))
+	assert.Equal(t, "JPEG_LRG", imageProfileName("image/jpeg", 1920, 1080))
+	assert.Equal(t, "", imageProfileName("image/jpeg", 8000, 6000))
+	assert.Equal(t, "PNG_LRG", imageProfileName("image/png", 1920, 1080))
+	assert.Equal(t, "", imageProfileName("image/webp", 1920, 1080))
+}
@@ -0,0 +1,371 @@
+package dlna
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/anacrolix/dms/ssdp"
+	"github.com/anacrolix/dms/upnp"
+	"github.com/stashapp/stash/pkg/logger"
+)
+
+const (
+	mediaRendererType    = "urn:schemas-upnp-org:device:MediaRenderer:1"
+	avTransportType      = "urn:schemas-upnp-org:service:AVTransport:1"
+	renderingControlType = "urn:schemas-upnp-org:service:RenderingControl:1"
+
+	// time to wait for renderers to respond to a search
+	discoveryTimeout = 3 * time.Second
+	// MX value of the search request. Must be less than discoveryTimeout.
+	discoveryMaxWait = 2
+
+	soapEnvelopeNS = "http://schemas.xmlsoap.org/soap/envelope/"
+	soapEncoding   = "http://schemas.xmlsoap.org/soap/encoding/"
+)
+
+// Renderer is a UPnP media renderer found on the network.
+type Renderer struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Manufacturer string `json:"manufacturer"`
+	ModelName    string `json:"modelName"`
+	Address      string `json:"address"`
+
+	client              *http.Client
+	avTransportURL      string
+	renderingControlURL string
+}
+
+// discoverRenderers searches the network for media renderers.
+func discoverRenderers(ctx context.Context) ([]*Renderer, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return nil, fmt.Errorf("listening for search responses: %w", err)
+	}
+	defer conn.Close()
+
+	msg := strings.Join([]string{
+		"M-SEARCH * HTTP/1.1",
+		"HOST: " + ssdp.AddrString,
+		`MAN: "ssdp:discover"`,
+		fmt.Sprintf("MX: %d", discoveryMaxWait),
+		"ST: " + mediaRendererType,
+		"", "",
+	}, "\r\n")
+
+	// send twice since UDP is unreliable
+	for i := 0; i < 2; i++ {
+		if _, err := conn.WriteTo([]byte(msg), ssdp.NetAddr); err != nil {
+			return nil, fmt.Errorf("sending search request: %w", err)
+		}
+	}
+
+	deadline := time.Now().Add(discoveryTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	var locations []string
+	found := make(map[string]bool)
+	buf := make([]byte, 8192)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				break
+			}
+			return nil, err
+		}
+
+		location, err := parseSearchResponse(buf[:n])
+		if err != nil {
+			logger.Debugf("[dlna] ignoring search response: %v", err)
+			continue
+		}
+
+		if !found[location] {
+			found[location] = true
+			locations = append(locations, location)
+		}
+	}
+
+	var ret []*Renderer
+	client := &http.Client{Timeout: 5 * time.Second}
+	for _, location := range locations {
+		r, err := loadRenderer(ctx, client, location)
+		if err != nil {
+			logger.Warnf("[dlna] error loading renderer description from %s: %v", location, err)
+			continue
+		}
+
+		ret = append(ret, r)
+	}
+
+	return ret, nil
+}
+
+// parseSearchResponse returns the device description location of an SSDP
+// search response.
+func parseSearchResponse(b []byte) (string, error) {
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(b)), nil)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return "", errors.New("missing location")
+	}
+
+	return location, nil
+}
+
+type rendererDesc struct {
+	URLBase string      `xml:"URLBase"`
+	Device  upnp.Device `xml:"device"`
+}
+
+// loadRenderer reads the device description at location.
+func loadRenderer(ctx context.Context, client *http.Client, location string) (*Renderer, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var desc rendererDesc
+	if err := xml.NewDecoder(resp.Body).Decode(&desc); err != nil {
+		return nil, fmt.Errorf("parsing device description: %w", err)
+	}
+
+	base, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+	if desc.URLBase != "" {
+		if base, err = url.Parse(desc.URLBase); err != nil {
+			return nil, fmt.Errorf("invalid URLBase: %w", err)
+		}
+	}
+
+	d := desc.Device
+	ret := &Renderer{
+		ID:           d.UDN,
+		Name:         d.FriendlyName,
+		Manufacturer: d.Manufacturer,
+		ModelName:    d.ModelName,
+		Address:      base.Hostname(),
+		client:       client,
+	}
+
+	for _, s := range d.ServiceList {
+		controlURL, err := base.Parse(s.ControlURL)
+		if err != nil {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(s.ServiceType, strings.TrimSuffix(avTransportType, "1")):
+			ret.avTransportURL = controlURL.String()
+		case strings.HasPrefix(s.ServiceType, strings.TrimSuffix(renderingControlType, "1")):
+			ret.renderingControlURL = controlURL.String()
+		}
+	}
+
+	if ret.avTransportURL == "" {
+		return nil, fmt.Errorf("%s has no AVTransport service", d.FriendlyName)
+	}
+
+	return ret, nil
+}
+
+type soapResponse struct {
+	Body struct {
+		Inner []byte `xml:",innerxml"`
+		Fault *struct {
+			String string `xml:"faultstring"`
+			Error  struct {
+				Code        uint   `xml:"errorCode"`
+				Description string `xml:"errorDescription"`
+			} `xml:"detail>UPnPError"`
+		} `xml:"Fault"`
+	} `xml:"Body"`
+}
+
+// soapCall calls the action of the service at the control URL. The action
+// response is unmarshalled into out if not nil.
+func (r *Renderer) soapCall(ctx context.Context, controlURL string, serviceType string, action string, args [][2]string, out interface{}) error {
+	var body bytes.Buffer
+	body.WriteString(`<?xml version="1.0" encoding="utf-8"?>`)
+	fmt.Fprintf(&body, `<s:Envelope xmlns:s="%s" s:encodingStyle="%s"><s:Body>`, soapEnvelopeNS, soapEncoding)
+	fmt.Fprintf(&body, `<u:%s xmlns:u="%s">`, action, serviceType)
+	for _, a := range args {
+		body.WriteString("<" + a[0] + ">")
+		if err := xml.EscapeText(&body, []byte(a[1])); err != nil {
+			return err
+		}
+		body.WriteString("</" + a[0] + ">")
+	}
+	fmt.Fprintf(&body, `</u:%s></s:Body></s:Envelope>`, action)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, controlURL, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPACTION", fmt.Sprintf(`"%s#%s"`, serviceType, action))
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var env soapResponse
+	if err := xml.Unmarshal(data, &env); err != nil {
+		return fmt.Errorf("%s: parsing response: %w", action, err)
+	}
+
+	if f := env.Body.Fault; f != nil {
+		if f.Error.Code != 0 {
+			return fmt.Errorf("%s: upnp error %d: %s", action, f.Error.Code, f.Error.Description)
+		}
+		return fmt.Errorf("%s: %s", action, f.String)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: unexpected status %s", action, resp.Status)
+	}
+
+	if out != nil {
+		if err := xml.Unmarshal(env.Body.Inner, out); err != nil {
+			return fmt.Errorf("%s: parsing response: %w", action, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *Renderer) avTransport(ctx context.Context, action string, args [][2]string, out interface{}) error {
+	args = append([][2]string{{"InstanceID", "0"}}, args...)
+	return r.soapCall(ctx, r.avTransportURL, avTransportType, action, args, out)
+}
+
+func (r *Renderer) renderingControl(ctx context.Context, action string, args [][2]string, out interface{}) error {
+	if r.renderingControlURL == "" {
+		return fmt.Errorf("%s has no RenderingControl service", r.Name)
+	}
+
+	args = append([][2]string{{"InstanceID", "0"}}, args...)
+	return r.soapCall(ctx, r.renderingControlURL, renderingControlType, action, args, out)
+}
+
+func (r *Renderer) setAVTransportURI(ctx context.Context, uri string, metadata string) error {
+	return r.avTransport(ctx, "SetAVTransportURI", [][2]string{
+		{"CurrentURI", uri},
+		{"CurrentURIMetaData", metadata},
+	}, nil)
+}
+
+func (r *Renderer) play(ctx context.Context) error {
+	return r.avTransport(ctx, "Play", [][2]string{{"Speed", "1"}}, nil)
+}
+
+func (r *Renderer) pause(ctx context.Context) error {
+	return r.avTransport(ctx, "Pause", nil, nil)
+}
+
+func (r *Renderer) stop(ctx context.Context) error {
+	return r.avTransport(ctx, "Stop", nil, nil)
+}
+
+func (r *Renderer) seek(ctx context.Context, position time.Duration) error {
+	return r.avTransport(ctx, "Seek", [][2]string{
+		{"Unit", "REL_TIME"},
+		{"Target", formatSeekTarget(position)},
+	}, nil)
+}
+
+// formatSeekTarget formats the duration as H+:MM:SS.
+func formatSeekTarget(d time.Duration) string {
+	s := int(d.Seconds())
+	return fmt.Sprintf("%d:%02d:%02d", s/3600, (s/60)%60, s%60)
+}
+
+type transportInfo struct {
+	CurrentTransportState string
+}
+
+func (r *Renderer) getTransportInfo(ctx context.Context) (*transportInfo, error) {
+	var ret transportInfo
+	if err := r.avTransport(ctx, "GetTransportInfo", nil, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+type positionInfo struct {
+	TrackDuration string
+	RelTime       string
+}
+
+func (r *Renderer) getPositionInfo(ctx context.Context) (*positionInfo, error) {
+	var ret positionInfo
+	if err := r.avTransport(ctx, "GetPositionInfo", nil, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (r *Renderer) getVolume(ctx context.Context) (int, error) {
+	var ret struct {
+		CurrentVolume int
+	}
+	if err := r.renderingControl(ctx, "GetVolume", [][2]string{{"Channel", "Master"}}, &ret); err != nil {
+		return 0, err
+	}
+
+	return ret.CurrentVolume, nil
+}
+
+func (r *Renderer) setVolume(ctx context.Context, volume int) error {
+	return r.renderingControl(ctx, "SetVolume", [][2]string{
+		{"Channel", "Master"},
+		{"DesiredVolume", fmt.Sprint(volume)},
+	}, nil)
+}
@@ -0,0 +1,217 @@
+package dlna
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testRendererDesc = `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+  <specVersion><major>1</major><minor>0</minor></specVersion>
+  <device>
+    <deviceType>urn:schemas-upnp-org:device:MediaRenderer:1</deviceType>
+    <friendlyName>Living Room TV</friendlyName>
+    <manufacturer>Acme</manufacturer>
+    <modelName>TV 3000</modelName>
+    <UDN>uuid:test-renderer</UDN>
+    <serviceList>
+      <service>
+        <serviceType>urn:schemas-upnp-org:service:AVTransport:1</serviceType>
+        <serviceId>urn:upnp-org:serviceId:AVTransport</serviceId>
+        <controlURL>/AVTransport/control</controlURL>
+      </service>
+      <service>
+        <serviceType>urn:schemas-upnp-org:service:RenderingControl:1</serviceType>
+        <serviceId>urn:upnp-org:serviceId:RenderingControl</serviceId>
+        <controlURL>RenderingControl/control</controlURL>
+      </service>
+    </serviceList>
+  </device>
+</root>`
+
+// testRenderer is a stand-in for a UPnP media renderer.
+type testRenderer struct {
+	mutex   sync.Mutex
+	actions []string
+	args    map[string]map[string]string
+	volume  int
+	state   string
+}
+
+func (tr *testRenderer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/desc.xml" {
+		_, _ = io.WriteString(w, testRendererDesc)
+		return
+	}
+
+	soapAction := strings.Trim(r.Header.Get("SOAPACTION"), `"`)
+	_, action, _ := strings.Cut(soapAction, "#")
+
+	var env struct {
+		Body struct {
+			Action struct {
+				XMLName xml.Name
+				Args    []struct {
+					XMLName xml.Name
+					Value   string `xml:",chardata"`
+				} `xml:",any"`
+			} `xml:",any"`
+		} `xml:"Body"`
+	}
+	if err := xml.NewDecoder(r.Body).Decode(&env); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	args := make(map[string]string)
+	for _, a := range env.Body.Action.Args {
+		args[a.XMLName.Local] = a.Value
+	}
+
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+
+	tr.actions = append(tr.actions, action)
+	tr.args[action] = args
+
+	var out string
+	switch action {
+	case "Play":
+		tr.state = transportStatePlaying
+	case "Stop":
+		tr.state = transportStateStopped
+	case "Seek":
+		if args["Unit"] != "REL_TIME" {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = io.WriteString(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><s:Fault>
+<faultcode>s:Client</faultcode><faultstring>UPnPError</faultstring>
+<detail><UPnPError xmlns="urn:schemas-upnp-org:control-1-0"><errorCode>710</errorCode><errorDescription>Seek mode not supported</errorDescription></UPnPError></detail>
+</s:Fault></s:Body></s:Envelope>`)
+			return
+		}
+	case "GetTransportInfo":
+		out = fmt.Sprintf("<CurrentTransportState>%s</CurrentTransportState>", tr.state)
+	case "GetPositionInfo":
+		out = "<TrackDuration>0:10:00</TrackDuration><RelTime>0:01:30.500</RelTime>"
+	case "GetVolume":
+		out = fmt.Sprintf("<CurrentVolume>%d</CurrentVolume>", tr.volume)
+	case "SetVolume":
+		_, _ = fmt.Sscan(args["DesiredVolume"], &tr.volume)
+	}
+
+	_, _ = fmt.Fprintf(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><u:%sResponse xmlns:u="%s">%s</u:%sResponse></s:Body></s:Envelope>`,
+		action, env.Body.Action.XMLName.Space, out, action)
+}
+
+func newTestRenderer(t *testing.T) (*testRenderer, *Renderer) {
+	tr := &testRenderer{
+		args:  make(map[string]map[string]string),
+		state: transportStateNoMedia,
+	}
+	srv := httptest.NewServer(tr)
+	t.Cleanup(srv.Close)
+
+	r, err := loadRenderer(context.Background(), srv.Client(), srv.URL+"/desc.xml")
+	if err != nil {
+		t.Fatalf("loadRenderer: %v", err)
+	}
+
+	return tr, r
+}
+
+func TestLoadRenderer(t *testing.T) {
+	_, r := newTestRenderer(t)
+
+	assert.Equal(t, "uuid:test-renderer", r.ID)
+	assert.Equal(t, "Living Room TV", r.Name)
+	assert.Equal(t, "Acme", r.Manufacturer)
+	assert.Equal(t, "TV 3000", r.ModelName)
+	assert.Equal(t, "127.0.0.1", r.Address)
+	assert.True(t, strings.HasSuffix(r.avTransportURL, "/AVTransport/control"))
+	assert.True(t, strings.HasSuffix(r.renderingControlURL, "/RenderingControl/control"))
+}
+
+func TestRendererControl(t *testing.T) {
+	ctx := context.Background()
+	tr, r := newTestRenderer(t)
+
+	const uri = "http://192.168.1.2:1338/res?scene=1&profile=default"
+	assert.NoError(t, r.setAVTransportURI(ctx, uri, `<DIDL-Lite></DIDL-Lite>`))
+	assert.Equal(t, map[string]string{
+		"InstanceID":         "0",
+		"CurrentURI":         uri,
+		"CurrentURIMetaData": `<DIDL-Lite></DIDL-Lite>`,
+	}, tr.args["SetAVTransportURI"])
+
+	assert.NoError(t, r.play(ctx))
+	assert.Equal(t, "1", tr.args["Play"]["Speed"])
+
+	info, err := r.getTransportInfo(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, transportStatePlaying, info.CurrentTransportState)
+
+	assert.NoError(t, r.seek(ctx, 3723*time.Second))
+	assert.Equal(t, "1:02:03", tr.args["Seek"]["Target"])
+
+	pos, err := r.getPositionInfo(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 90.5, *parseTransportTime(pos.RelTime))
+	assert.Equal(t, 600.0, *parseTransportTime(pos.TrackDuration))
+
+	assert.NoError(t, r.setVolume(ctx, 25))
+	v, err := r.getVolume(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 25, v)
+
+	assert.NoError(t, r.stop(ctx))
+	assert.Equal(t, []string{"SetAVTransportURI", "Play", "GetTransportInfo", "Seek", "GetPositionInfo", "SetVolume", "GetVolume", "Stop"}, tr.actions)
+}
+
+func TestRendererFault(t *testing.T) {
+	_, r := newTestRenderer(t)
+
+	err := r.avTransport(context.Background(), "Seek", [][2]string{{"Unit", "ABS_TIME"}, {"Target", "0:00:10"}}, nil)
+	assert.EqualError(t, err, "Seek: upnp error 710: Seek mode not supported")
+}
+
+func TestParseSearchResponse(t *testing.T) {
+	resp := "HTTP/1.1 200 OK\r\n" +
+		"CACHE-CONTROL: max-age=1800\r\n" +
+		"LOCATION: http://192.168.1.10:49152/desc.xml\r\n" +
+		"ST: urn:schemas-upnp-org:device:MediaRenderer:1\r\n" +
+		"USN: uuid:test-renderer::urn:schemas-upnp-org:device:MediaRenderer:1\r\n" +
+		"\r\n"
+
+	location, err := parseSearchResponse([]byte(resp))
+	assert.NoError(t, err)
+	assert.Equal(t, "http://192.168.1.10:49152/desc.xml", location)
+
+	_, err = parseSearchResponse([]byte("HTTP/1.1 200 OK\r\n\r\n"))
+	assert.Error(t, err)
+
+	_, err = parseSearchResponse([]byte("NOTIFY * HTTP/1.1\r\n\r\n"))
+	assert.Error(t, err)
+}
+
+func TestFormatSeekTarget(t *testing.T) {
+	assert.Equal(t, "0:00:00", formatSeekTarget(0))
+	assert.Equal(t, "0:01:30", formatSeekTarget(90500*time.Millisecond))
+	assert.Equal(t, "10:00:01", formatSeekTarget(36001*time.Second))
+}
+
+func TestParseTransportTime(t *testing.T) {
+	assert.Equal(t, 0.0, *parseTransportTime("0:00:00"))
+	assert.Equal(t, 3723.25, *parseTransportTime("01:02:03.25"))
+	assert.Nil(t, parseTransportTime("NOT_IMPLEMENTED"))
+	assert.Nil(t, parseTransportTime(""))
+}
@@ -0,0 +1,238 @@
+package dlna
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// savedFilter is the format used by the UI when storing saved filters.
+type savedFilter struct {
+	Q        string   `json:"q"`
+	SortBy   string   `json:"sortby"`
+	SortDir  string   `json:"sortdir"`
+	Criteria []string `json:"c"`
+}
+
+type savedCriterion struct {
+	Type     string                   `json:"type"`
+	Modifier models.CriterionModifier `json:"modifier"`
+	Value    json.RawMessage          `json:"value"`
+}
+
+type savedRangeValue struct {
+	Value  json.RawMessage `json:"value"`
+	Value2 json.RawMessage `json:"value2"`
+}
+
+type savedLabeledID struct {
+	ID string `json:"id"`
+}
+
+type savedHierarchicalValue struct {
+	Items    []savedLabeledID `json:"items"`
+	Excluded []savedLabeledID `json:"excluded"`
+	Depth    *int             `json:"depth"`
+}
+
+// parseSavedSceneFilter converts a saved scene filter into a scene filter and
+// query options. Criteria that cannot be converted are logged and ignored.
+func parseSavedSceneFilter(f *models.SavedFilter) (*sceneQuery, error) {
+	var sf savedFilter
+	if err := json.Unmarshal([]byte(f.Filter), &sf); err != nil {
+		return nil, fmt.Errorf("parsing saved filter %q: %w", f.Name, err)
+	}
+
+	ret := &sceneQuery{
+		sceneFilter: &models.SceneFilterType{},
+		q:           sf.Q,
+		sort:        sf.SortBy,
+	}
+
+	// match the default sort directions of the UI
+	ret.direction = models.SortDirectionEnumAsc
+	if (sf.SortBy == "date" && sf.SortDir != "asc") || sf.SortDir == "desc" {
+		ret.direction = models.SortDirectionEnumDesc
+	}
+
+	for _, c := range sf.Criteria {
+		if err := setSceneCriterion(ret.sceneFilter, c); err != nil {
+			logger.Warnf("[dlna] ignoring criterion in saved filter %q: %v", f.Name, err)
+		}
+	}
+
+	return ret, nil
+}
+
+func setSceneCriterion(sceneFilter *models.SceneFilterType, criterionJSON string) error {
+	var c savedCriterion
+	if err := json.Unmarshal([]byte(criterionJSON), &c); err != nil {
+		return err
+	}
+
+	field, found := sceneFilterField(sceneFilter, c.Type)
+	if !found {
+		return fmt.Errorf("unsupported criterion type %q", c.Type)
+	}
+
+	var v interface{}
+	var err error
+	switch field.Interface().(type) {
+	case *bool:
+		v, err = boolCriterion(c)
+	case *string:
+		var s string
+		err = json.Unmarshal(c.Value, &s)
+		v = &s
+	case *models.StringCriterionInput:
+		var s string
+		err = json.Unmarshal(c.Value, &s)
+		v = &models.StringCriterionInput{Value: s, Modifier: c.Modifier}
+	case *models.IntCriterionInput:
+		v, err = intCriterion(c)
+	case *models.DateCriterionInput:
+		var value, value2 *string
+		value, value2, err = stringRangeCriterion(c)
+		if err == nil {
+			v = &models.DateCriterionInput{Value: *value, Value2: value2, Modifier: c.Modifier}
+		}
+	case *models.TimestampCriterionInput:
+		var value, value2 *string
+		value, value2, err = stringRangeCriterion(c)
+		if err == nil {
+			v = &models.TimestampCriterionInput{Value: *value, Value2: value2, Modifier: c.Modifier}
+		}
+	case *models.MultiCriterionInput:
+		v, err = multiCriterion(c)
+	case *models.HierarchicalMultiCriterionInput:
+		v, err = hierarchicalCriterion(c)
+	default:
+		return fmt.Errorf("unsupported criterion type %q", c.Type)
+	}
+
+	if err != nil {
+		return fmt.Errorf("criterion %q: %w", c.Type, err)
+	}
+
+	field.Set(reflect.ValueOf(v))
+	return nil
+}
+
+// sceneFilterField returns the field of the scene filter with the json name.
+func sceneFilterField(sceneFilter *models.SceneFilterType, name string) (reflect.Value, bool) {
+	v := reflect.ValueOf(sceneFilter).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if tag == name && name != "AND" && name != "OR" && name != "NOT" {
+			return v.Field(i), true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+func boolCriterion(c savedCriterion) (*bool, error) {
+	var s string
+	if err := json.Unmarshal(c.Value, &s); err != nil {
+		return nil, err
+	}
+
+	b := s == "true"
+	return &b, nil
+}
+
+func intCriterion(c savedCriterion) (*models.IntCriterionInput, error) {
+	var r savedRangeValue
+	if err := json.Unmarshal(c.Value, &r); err != nil {
+		return nil, err
+	}
+
+	ret := &models.IntCriterionInput{Modifier: c.Modifier}
+	if len(r.Value) > 0 {
+		if err := json.Unmarshal(r.Value, &ret.Value); err != nil {
+			return nil, err
+		}
+	}
+	if len(r.Value2) > 0 {
+		if err := json.Unmarshal(r.Value2, &ret.Value2); err != nil {
+			return nil, err
+		}
+	}
+
+	return ret, nil
+}
+
+func stringRangeCriterion(c savedCriterion) (*string, *string, error) {
+	var r savedRangeValue
+	if err := json.Unmarshal(c.Value, &r); err != nil {
+		return nil, nil, err
+	}
+
+	var value string
+	var value2 *string
+	if len(r.Value) > 0 {
+		if err := json.Unmarshal(r.Value, &value); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(r.Value2) > 0 {
+		if err := json.Unmarshal(r.Value2, &value2); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return &value, value2, nil
+}
+
+func labeledIDs(l []savedLabeledID) []string {
+	var ret []string
+	for _, v := range l {
+		ret = append(ret, v.ID)
+	}
+	return ret
+}
+
+func multiCriterion(c savedCriterion) (*models.MultiCriterionInput, error) {
+	ret := &models.MultiCriterionInput{Modifier: c.Modifier}
+
+	// value may be a list of ids or a hierarchical value
+	var l []savedLabeledID
+	if err := json.Unmarshal(c.Value, &l); err == nil {
+		ret.Value = labeledIDs(l)
+		return ret, nil
+	}
+
+	var h savedHierarchicalValue
+	if err := json.Unmarshal(c.Value, &h); err != nil {
+		return nil, err
+	}
+
+	ret.Value = labeledIDs(h.Items)
+	ret.Excludes = labeledIDs(h.Excluded)
+	return ret, nil
+}
+
+func hierarchicalCriterion(c savedCriterion) (*models.HierarchicalMultiCriterionInput, error) {
+	ret := &models.HierarchicalMultiCriterionInput{Modifier: c.Modifier}
+
+	var h savedHierarchicalValue
+	if err := json.Unmarshal(c.Value, &h); err == nil {
+		ret.Value = labeledIDs(h.Items)
+		ret.Excludes = labeledIDs(h.Excluded)
+		ret.Depth = h.Depth
+		return ret, nil
+	}
+
+	var l []savedLabeledID
+	if err := json.Unmarshal(c.Value, &l); err != nil {
+		return nil, err
+	}
+
+	ret.Value = labeledIDs(l)
+	return ret, nil
+}
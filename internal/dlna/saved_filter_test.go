@@ -0,0 +1,71 @@
+package dlna
+
+import (
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSavedSceneFilter(t *testing.T) {
+	depth := -1
+	value2 := 10
+
+	f := &models.SavedFilter{
+		Mode: models.FilterModeScenes,
+		Name: "test",
+		Filter: `{"perPage":40,"sortby":"title","sortdir":"desc","q":"foo","c":[` +
+			`"{\"type\":\"organized\",\"value\":\"true\",\"modifier\":\"EQUALS\"}",` +
+			`"{\"type\":\"title\",\"value\":\"bar\",\"modifier\":\"INCLUDES\"}",` +
+			`"{\"type\":\"rating100\",\"value\":{\"value\":5,\"value2\":10},\"modifier\":\"BETWEEN\"}",` +
+			`"{\"type\":\"performers\",\"value\":[{\"id\":\"1\",\"label\":\"a\"}],\"modifier\":\"INCLUDES_ALL\"}",` +
+			`"{\"type\":\"tags\",\"value\":{\"items\":[{\"id\":\"2\",\"label\":\"b\"}],\"excluded\":[{\"id\":\"3\",\"label\":\"c\"}],\"depth\":-1},\"modifier\":\"INCLUDES\"}",` +
+			`"{\"type\":\"unknown\",\"value\":\"x\",\"modifier\":\"EQUALS\"}"` +
+			`]}`,
+	}
+
+	got, err := parseSavedSceneFilter(f)
+	assert.NoError(t, err)
+
+	organized := true
+	assert.Equal(t, &sceneQuery{
+		sceneFilter: &models.SceneFilterType{
+			Organized: &organized,
+			Title:     &models.StringCriterionInput{Value: "bar", Modifier: models.CriterionModifierIncludes},
+			Rating100: &models.IntCriterionInput{Value: 5, Value2: &value2, Modifier: models.CriterionModifierBetween},
+			Performers: &models.MultiCriterionInput{
+				Value:    []string{"1"},
+				Modifier: models.CriterionModifierIncludesAll,
+			},
+			Tags: &models.HierarchicalMultiCriterionInput{
+				Value:    []string{"2"},
+				Excludes: []string{"3"},
+				Depth:    &depth,
+				Modifier: models.CriterionModifierIncludes,
+			},
+		},
+		q:         "foo",
+		sort:      "title",
+		direction: models.SortDirectionEnumDesc,
+	}, got)
+}
+
+func TestParseSavedSceneFilterDirection(t *testing.T) {
+	tests := []struct {
+		filter string
+		want   models.SortDirectionEnum
+	}{
+		{`{"sortby":"date"}`, models.SortDirectionEnumDesc},
+		{`{"sortby":"date","sortdir":"asc"}`, models.SortDirectionEnumAsc},
+		{`{"sortby":"title"}`, models.SortDirectionEnumAsc},
+		{`{"sortby":"title","sortdir":"desc"}`, models.SortDirectionEnumDesc},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			got, err := parseSavedSceneFilter(&models.SavedFilter{Filter: tt.filter})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.direction)
+		})
+	}
+}
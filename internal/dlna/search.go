@@ -0,0 +1,588 @@
+package dlna
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/anacrolix/dms/upnp"
+	"github.com/anacrolix/dms/upnpav"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scene"
+	"github.com/stashapp/stash/pkg/txn"
+)
+
+// searchCapabilities are the properties supported in search criteria.
+const searchCapabilities = "dc:title,upnp:artist,upnp:genre,upnp:class"
+
+const videoItemClass = "object.item.videoItem"
+
+// invalidSearchCriteriaErrorCode is returned when the search criteria are
+// not supported.
+const invalidSearchCriteriaErrorCode = 708
+
+var errUnsupportedSearch = errors.New("unsupported search criteria")
+
+type search struct {
+	ContainerID    string
+	SearchCriteria string
+	Filter         string
+	StartingIndex  int
+	RequestedCount int
+}
+
+// handleSearch returns the scenes matching the search criteria. Searches in
+// a saved filter folder are restricted to the scenes of the saved filter,
+// otherwise all scenes are searched.
+func (me *contentDirectoryService) handleSearch(s search, client browseClient) (map[string]string, error) {
+	query := &sceneQuery{}
+
+	if strings.HasPrefix(s.ContainerID, "saved-filters/") {
+		id := strings.Split(s.ContainerID, "/")[1]
+		var err error
+		query, err = me.getSavedFilterQuery(id)
+		if err != nil {
+			return nil, upnp.Errorf(upnp.ActionFailedErrorCode, err.Error())
+		}
+		if query == nil {
+			return nil, upnp.Errorf(upnpav.NoSuchObjectErrorCode, "saved filter not found")
+		}
+	}
+
+	count := s.RequestedCount
+	if count <= 0 {
+		count = pageSize
+	}
+	start := s.StartingIndex
+	if start < 0 {
+		start = 0
+	}
+
+	var objs []interface{}
+	var total int
+	if err := txn.WithReadTxn(context.TODO(), me.txnManager, func(ctx context.Context) error {
+		sceneFilter, err := parseSearchCriteria(s.SearchCriteria, repositoryNameMatcher{ctx: ctx, repository: me.repository})
+		if err != nil {
+			return err
+		}
+
+		if query.sceneFilter != nil {
+			sceneFilter, err = andSceneFilters(query.sceneFilter, sceneFilter)
+			if err != nil {
+				return err
+			}
+		}
+
+		// criteria cannot match any scenes
+		if sceneFilter == nil {
+			return nil
+		}
+
+		sort, direction := me.getSort(&sceneQuery{sceneFilter: sceneFilter, sort: query.sort, direction: query.direction})
+		perPage := start + count
+		findFilter := &models.FindFilterType{
+			PerPage:   &perPage,
+			Sort:      &sort,
+			Direction: &direction,
+		}
+		if query.q != "" {
+			findFilter.Q = &query.q
+		}
+
+		var scenes []*models.Scene
+		scenes, total, err = scene.QueryWithCount(ctx, me.repository.SceneFinder, sceneFilter, findFilter)
+		if err != nil {
+			return err
+		}
+
+		if start >= len(scenes) {
+			return nil
+		}
+
+		objs, err = loadSceneContainers(ctx, me.repository, scenes[start:], s.ContainerID, client)
+		return err
+	}); err != nil {
+		if errors.Is(err, errUnsupportedSearch) {
+			return nil, upnp.Errorf(invalidSearchCriteriaErrorCode, err.Error())
+		}
+
+		logger.Errorf("[dlna] error searching: %v", err)
+		return nil, upnp.Errorf(upnp.ActionFailedErrorCode, err.Error())
+	}
+
+	result, err := xml.Marshal(objs)
+	if err != nil {
+		return nil, upnp.Errorf(upnp.ActionFailedErrorCode, "could not marshal objects: %s", err.Error())
+	}
+
+	return map[string]string{
+		"TotalMatches":   strconv.Itoa(total),
+		"NumberReturned": strconv.Itoa(len(objs)),
+		"Result":         didl_lite(string(result)),
+		"UpdateID":       me.updateIDString(),
+	}, nil
+}
+
+// searchNameMatcher resolves performer and tag names to IDs.
+type searchNameMatcher interface {
+	performerIDs(name string, op string) ([]string, error)
+	tagIDs(name string, op string) ([]string, error)
+}
+
+// parseSearchCriteria converts ContentDirectory search criteria into a scene
+// filter. Returns a nil filter if the criteria cannot match any scenes.
+//
+// Only expressions where each and/or has a simple expression on at least one
+// side are supported, since scene filters only allow a single sub-filter.
+func parseSearchCriteria(criteria string, m searchNameMatcher) (*models.SceneFilterType, error) {
+	criteria = strings.TrimSpace(criteria)
+	if criteria == "" || criteria == "*" {
+		return &models.SceneFilterType{}, nil
+	}
+
+	tokens, err := tokenizeSearch(criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &searchParser{tokens: tokens, matcher: m}
+	ret, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("%w: unexpected %q", errUnsupportedSearch, p.tokens[p.pos].value)
+	}
+
+	return ret, nil
+}
+
+type searchToken struct {
+	value  string
+	quoted bool
+}
+
+func tokenizeSearch(s string) ([]searchToken, error) {
+	var ret []searchToken
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			ret = append(ret, searchToken{value: string(r)})
+			i++
+		case r == '"':
+			var sb strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("%w: unterminated string", errUnsupportedSearch)
+			}
+			i++
+			ret = append(ret, searchToken{value: sb.String(), quoted: true})
+		default:
+			start := i
+			for ; i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' && runes[i] != '"'; i++ {
+			}
+			ret = append(ret, searchToken{value: string(runes[start:i])})
+		}
+	}
+
+	return ret, nil
+}
+
+type searchParser struct {
+	tokens  []searchToken
+	pos     int
+	matcher searchNameMatcher
+}
+
+func (p *searchParser) peek() *searchToken {
+	if p.pos >= len(p.tokens) {
+		return nil
+	}
+	return &p.tokens[p.pos]
+}
+
+func (p *searchParser) next() (searchToken, error) {
+	t := p.peek()
+	if t == nil {
+		return searchToken{}, fmt.Errorf("%w: unexpected end of criteria", errUnsupportedSearch)
+	}
+	p.pos++
+	return *t, nil
+}
+
+func (p *searchParser) isKeyword(keyword string) bool {
+	t := p.peek()
+	return t != nil && !t.quoted && strings.EqualFold(t.value, keyword)
+}
+
+func (p *searchParser) parseOr() (*models.SceneFilterType, error) {
+	ret, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isKeyword("or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		ret, err = orSceneFilters(ret, right)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ret, nil
+}
+
+func (p *searchParser) parseAnd() (*models.SceneFilterType, error) {
+	ret, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isKeyword("and") {
+		p.pos++
+		right, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+
+		ret, err = andSceneFilters(ret, right)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ret, nil
+}
+
+func (p *searchParser) parseExpression() (*models.SceneFilterType, error) {
+	if p.isKeyword("(") {
+		p.pos++
+		ret, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if t, err := p.next(); err != nil || t.value != ")" {
+			return nil, fmt.Errorf("%w: missing )", errUnsupportedSearch)
+		}
+
+		return ret, nil
+	}
+
+	property, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	op, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	value, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	return p.relationalFilter(property.value, op.value, value.value)
+}
+
+// relationalFilter returns the filter for a single property comparison.
+func (p *searchParser) relationalFilter(property, op, value string) (*models.SceneFilterType, error) {
+	if strings.EqualFold(op, "exists") {
+		return existsFilter(property, value)
+	}
+
+	switch property {
+	case "upnp:class":
+		return classFilter(op, value)
+	case "dc:title":
+		modifier, err := stringModifier(op)
+		if err != nil {
+			return nil, err
+		}
+
+		return &models.SceneFilterType{
+			Title: &models.StringCriterionInput{
+				Value:    value,
+				Modifier: modifier,
+			},
+		}, nil
+	case "upnp:artist", "dc:creator", "upnp:actor":
+		op, modifier := nameModifier(op)
+		ids, err := p.matcher.performerIDs(value, op)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			return emptyNameFilter(modifier), nil
+		}
+
+		return &models.SceneFilterType{
+			Performers: &models.MultiCriterionInput{
+				Value:    ids,
+				Modifier: modifier,
+			},
+		}, nil
+	case "upnp:genre":
+		op, modifier := nameModifier(op)
+		ids, err := p.matcher.tagIDs(value, op)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			return emptyNameFilter(modifier), nil
+		}
+
+		return &models.SceneFilterType{
+			Tags: &models.HierarchicalMultiCriterionInput{
+				Value:    ids,
+				Modifier: modifier,
+			},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: unsupported property %s", errUnsupportedSearch, property)
+}
+
+// existsFilter returns the filter for scenes which have the property, or
+// which do not if value is false.
+func existsFilter(property, value string) (*models.SceneFilterType, error) {
+	var exists bool
+	switch strings.ToLower(value) {
+	case "true":
+		exists = true
+	case "false":
+		exists = false
+	default:
+		return nil, fmt.Errorf("%w: invalid exists value %s", errUnsupportedSearch, value)
+	}
+
+	modifier := models.CriterionModifierIsNull
+	if exists {
+		modifier = models.CriterionModifierNotNull
+	}
+
+	switch property {
+	case "upnp:class", "dc:title":
+		// all scenes have these properties
+		if exists {
+			return &models.SceneFilterType{}, nil
+		}
+		return nil, nil
+	case "upnp:artist", "dc:creator", "upnp:actor":
+		return &models.SceneFilterType{
+			Performers: &models.MultiCriterionInput{
+				Modifier: modifier,
+			},
+		}, nil
+	case "upnp:genre":
+		return &models.SceneFilterType{
+			Tags: &models.HierarchicalMultiCriterionInput{
+				Modifier: modifier,
+			},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: unsupported property %s", errUnsupportedSearch, property)
+}
+
+// classFilter returns an empty filter if scenes match the class criterion,
+// otherwise nil.
+func classFilter(op, value string) (*models.SceneFilterType, error) {
+	var match bool
+	switch strings.ToLower(op) {
+	case "=":
+		match = value == videoItemClass
+	case "!=":
+		match = value != videoItemClass
+	case "derivedfrom":
+		match = value == videoItemClass || strings.HasPrefix(videoItemClass, value+".")
+	case "contains":
+		match = strings.Contains(videoItemClass, value)
+	default:
+		return nil, fmt.Errorf("%w: unsupported operator %s", errUnsupportedSearch, op)
+	}
+
+	if match {
+		return &models.SceneFilterType{}, nil
+	}
+	return nil, nil
+}
+
+// nameModifier returns the operator to match performer and tag names with,
+// and the modifier to filter scenes by the matching ids. Negated operators
+// match names positively and exclude scenes with the matching ids.
+func nameModifier(op string) (string, models.CriterionModifier) {
+	switch strings.ToLower(op) {
+	case "!=":
+		return "=", models.CriterionModifierExcludes
+	case "doesnotcontain":
+		return "contains", models.CriterionModifierExcludes
+	}
+
+	return op, models.CriterionModifierIncludes
+}
+
+// emptyNameFilter returns the filter when no performer or tag names match.
+// No scenes are excluded, and none are included.
+func emptyNameFilter(modifier models.CriterionModifier) *models.SceneFilterType {
+	if modifier == models.CriterionModifierExcludes {
+		return &models.SceneFilterType{}
+	}
+	return nil
+}
+
+func stringModifier(op string) (models.CriterionModifier, error) {
+	switch strings.ToLower(op) {
+	case "=":
+		return models.CriterionModifierEquals, nil
+	case "!=":
+		return models.CriterionModifierNotEquals, nil
+	case "contains":
+		return models.CriterionModifierIncludes, nil
+	case "doesnotcontain":
+		return models.CriterionModifierExcludes, nil
+	}
+
+	return "", fmt.Errorf("%w: unsupported operator %s", errUnsupportedSearch, op)
+}
+
+// repositoryNameMatcher matches performer and tag names using the repository.
+type repositoryNameMatcher struct {
+	ctx        context.Context
+	repository Repository
+}
+
+func (m repositoryNameMatcher) performerIDs(name string, op string) ([]string, error) {
+	var performers []*models.Performer
+	var err error
+	if op == "=" {
+		performers, err = m.repository.PerformerFinder.FindByNames(m.ctx, []string{name}, true)
+	} else {
+		performers, _, err = m.repository.PerformerFinder.Query(m.ctx, &models.PerformerFilterType{
+			Name: containsCriterion(name),
+		}, allFindFilter())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []string
+	for _, p := range performers {
+		ret = append(ret, strconv.Itoa(p.ID))
+	}
+
+	return ret, nil
+}
+
+func (m repositoryNameMatcher) tagIDs(name string, op string) ([]string, error) {
+	var tags []*models.Tag
+	var err error
+	if op == "=" {
+		tags, err = m.repository.TagFinder.FindByNames(m.ctx, []string{name}, true)
+	} else {
+		tags, _, err = m.repository.TagFinder.Query(m.ctx, &models.TagFilterType{
+			Name: containsCriterion(name),
+		}, allFindFilter())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []string
+	for _, t := range tags {
+		ret = append(ret, strconv.Itoa(t.ID))
+	}
+
+	return ret, nil
+}
+
+// containsCriterion returns a criterion matching names containing the
+// value. The value is quoted so that it is not split into words.
+func containsCriterion(value string) *models.StringCriterionInput {
+	return &models.StringCriterionInput{
+		Value:    `"` + value + `"`,
+		Modifier: models.CriterionModifierIncludes,
+	}
+}
+
+func allFindFilter() *models.FindFilterType {
+	perPage := -1
+	return &models.FindFilterType{
+		PerPage: &perPage,
+	}
+}
+
+func isEmptySceneFilter(f *models.SceneFilterType) bool {
+	return reflect.ValueOf(*f).IsZero()
+}
+
+func hasSubFilter(f *models.SceneFilterType) bool {
+	return f.And != nil || f.Or != nil || f.Not != nil
+}
+
+// andSceneFilters combines two filters. A nil filter matches nothing.
+func andSceneFilters(a, b *models.SceneFilterType) (*models.SceneFilterType, error) {
+	switch {
+	case a == nil || b == nil:
+		return nil, nil
+	case isEmptySceneFilter(a):
+		return b, nil
+	case isEmptySceneFilter(b):
+		return a, nil
+	case !hasSubFilter(a):
+		ret := *a
+		ret.And = b
+		return &ret, nil
+	case !hasSubFilter(b):
+		ret := *b
+		ret.And = a
+		return &ret, nil
+	}
+
+	return nil, fmt.Errorf("%w: expression too complex", errUnsupportedSearch)
+}
+
+// orSceneFilters combines two filters. A nil filter matches nothing.
+func orSceneFilters(a, b *models.SceneFilterType) (*models.SceneFilterType, error) {
+	switch {
+	case a == nil:
+		return b, nil
+	case b == nil:
+		return a, nil
+	case isEmptySceneFilter(a) || isEmptySceneFilter(b):
+		return &models.SceneFilterType{}, nil
+	case !hasSubFilter(a):
+		ret := *a
+		ret.Or = b
+		return &ret, nil
+	case !hasSubFilter(b):
+		ret := *b
+		ret.Or = a
+		return &ret, nil
+	}
+
+	return nil, fmt.Errorf("%w: expression too complex", errUnsupportedSearch)
+}
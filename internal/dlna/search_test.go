@@ -0,0 +1,118 @@
+package dlna
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// matchName returns true if name matches value using the search operator,
+// which is either = or contains, in the same way as the repository.
+func matchName(name, value, op string) bool {
+	name = strings.ToLower(name)
+	value = strings.ToLower(value)
+
+	if op == "=" {
+		return name == value
+	}
+	return strings.Contains(name, value)
+}
+
+type testNameMatcher struct{}
+
+func (testNameMatcher) performerIDs(name string, op string) ([]string, error) {
+	if matchName("Jane Doe", name, op) {
+		return []string{"1"}, nil
+	}
+	return nil, nil
+}
+
+func (testNameMatcher) tagIDs(name string, op string) ([]string, error) {
+	if matchName("Outdoor", name, op) {
+		return []string{"2"}, nil
+	}
+	return nil, nil
+}
+
+func TestParseSearchCriteria(t *testing.T) {
+	title := func(v string, m models.CriterionModifier) *models.StringCriterionInput {
+		return &models.StringCriterionInput{Value: v, Modifier: m}
+	}
+	performers := &models.MultiCriterionInput{Value: []string{"1"}, Modifier: models.CriterionModifierIncludes}
+	tags := &models.HierarchicalMultiCriterionInput{Value: []string{"2"}, Modifier: models.CriterionModifierIncludes}
+	excludePerformers := &models.MultiCriterionInput{Value: []string{"1"}, Modifier: models.CriterionModifierExcludes}
+	excludeTags := &models.HierarchicalMultiCriterionInput{Value: []string{"2"}, Modifier: models.CriterionModifierExcludes}
+
+	tests := []struct {
+		name     string
+		criteria string
+		want     *models.SceneFilterType
+		wantErr  bool
+	}{
+		{"all", "*", &models.SceneFilterType{}, false},
+		{"title contains", `dc:title contains "foo"`, &models.SceneFilterType{Title: title("foo", models.CriterionModifierIncludes)}, false},
+		{"escaped quote", `dc:title = "a \"b\""`, &models.SceneFilterType{Title: title(`a "b"`, models.CriterionModifierEquals)}, false},
+		{"video class", `upnp:class derivedfrom "object.item.videoItem"`, &models.SceneFilterType{}, false},
+		{"audio class", `upnp:class derivedfrom "object.item.audioItem"`, nil, false},
+		{
+			"class and title",
+			`(upnp:class derivedfrom "object.item" and dc:title contains "foo")`,
+			&models.SceneFilterType{Title: title("foo", models.CriterionModifierIncludes)},
+			false,
+		},
+		{"performer", `upnp:artist = "jane doe"`, &models.SceneFilterType{Performers: performers}, false},
+		{"unknown performer", `upnp:artist = "john"`, nil, false},
+		{"not performer", `upnp:artist != "Jane Doe"`, &models.SceneFilterType{Performers: excludePerformers}, false},
+		{"not unknown performer", `upnp:artist != "john"`, &models.SceneFilterType{}, false},
+		{"performer does not contain", `upnp:artist doesNotContain "jane"`, &models.SceneFilterType{Performers: excludePerformers}, false},
+		{"not genre", `upnp:genre != "outdoor"`, &models.SceneFilterType{Tags: excludeTags}, false},
+		{"genre does not contain", `upnp:genre doesNotContain "door"`, &models.SceneFilterType{Tags: excludeTags}, false},
+		{"genre does not contain unknown", `upnp:genre doesNotContain "indoor"`, &models.SceneFilterType{}, false},
+		{
+			"title or genre",
+			`dc:title contains "foo" or upnp:genre contains "door"`,
+			&models.SceneFilterType{Title: title("foo", models.CriterionModifierIncludes), Or: &models.SceneFilterType{Tags: tags}},
+			false,
+		},
+		{
+			"and binds tighter than or",
+			`dc:title contains "a" or upnp:artist contains "jane" and upnp:genre = "outdoor"`,
+			&models.SceneFilterType{
+				Title: title("a", models.CriterionModifierIncludes),
+				Or:    &models.SceneFilterType{Performers: performers, And: &models.SceneFilterType{Tags: tags}},
+			},
+			false,
+		},
+		{"unknown performer or title", `upnp:artist = "john" or dc:title = "x"`, &models.SceneFilterType{Title: title("x", models.CriterionModifierEquals)}, false},
+		{"unsupported property", `dc:date > "2020"`, nil, true},
+		{"title exists", `dc:title exists true`, &models.SceneFilterType{}, false},
+		{"title does not exist", `dc:title exists false`, nil, false},
+		{"performer exists", `upnp:artist exists true`, &models.SceneFilterType{Performers: &models.MultiCriterionInput{Modifier: models.CriterionModifierNotNull}}, false},
+		{"genre does not exist", `upnp:genre exists false`, &models.SceneFilterType{Tags: &models.HierarchicalMultiCriterionInput{Modifier: models.CriterionModifierIsNull}}, false},
+		{"unsupported exists property", `dc:date exists true`, nil, true},
+		{"invalid exists value", `dc:title exists "yes"`, nil, true},
+		{"unterminated", `dc:title = "foo`, nil, true},
+		{"missing paren", `(dc:title = "foo"`, nil, true},
+		{
+			"too complex",
+			`(dc:title = "a" or dc:title = "b") and (dc:title = "c" or dc:title = "d")`,
+			nil,
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSearchCriteria(tt.criteria, testNameMatcher{})
+			if tt.wantErr {
+				assert.ErrorIs(t, err, errUnsupportedSearch)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/stashapp/stash/pkg/ffmpeg"
 	"github.com/stashapp/stash/pkg/file"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
@@ -15,12 +16,17 @@ import (
 )
 
 type Repository struct {
-	SceneFinder     SceneFinder
-	FileFinder      file.Finder
-	StudioFinder    StudioFinder
-	TagFinder       TagFinder
-	PerformerFinder PerformerFinder
-	MovieFinder     MovieFinder
+	SceneFinder       SceneFinder
+	SceneWriter       SceneActivityWriter
+	FileFinder        file.Finder
+	CaptionFinder     CaptionFinder
+	ImageFinder       ImageFinder
+	GalleryFinder     GalleryFinder
+	StudioFinder      StudioFinder
+	TagFinder         TagFinder
+	PerformerFinder   PerformerFinder
+	MovieFinder       MovieFinder
+	SavedFilterFinder SavedFilterFinder
 }
 
 type Status struct {
@@ -50,22 +56,32 @@ type dmsConfig struct {
 
 type sceneServer interface {
 	StreamSceneDirect(scene *models.Scene, w http.ResponseWriter, r *http.Request)
+	StreamSceneTranscode(scene *models.Scene, w http.ResponseWriter, r *http.Request, options ffmpeg.TranscodeOptions)
 	ServeScreenshot(scene *models.Scene, w http.ResponseWriter, r *http.Request)
 }
 
+type imageServer interface {
+	ServeImage(img *models.Image, w http.ResponseWriter, r *http.Request)
+	ServeThumbnail(img *models.Image, w http.ResponseWriter, r *http.Request)
+}
+
 type Config interface {
 	GetDLNAInterfaces() []string
 	GetDLNAServerName() string
 	GetDLNADefaultIPWhitelist() []string
+	GetDLNAActivityExcludedIPs() []string
 	GetVideoSortOrder() string
 }
 
 type Service struct {
-	txnManager     txn.Manager
-	repository     Repository
-	config         Config
-	sceneServer    sceneServer
-	ipWhitelistMgr *ipWhitelistManager
+	txnManager      txn.Manager
+	repository      Repository
+	config          Config
+	sceneServer     sceneServer
+	imageServer     imageServer
+	ipWhitelistMgr  *ipWhitelistManager
+	activityTracker *activityTracker
+	castMgr         *castManager
 
 	server  *Server
 	running bool
@@ -136,8 +152,10 @@ func (s *Service) init() error {
 	s.server = &Server{
 		txnManager:         s.txnManager,
 		sceneServer:        s.sceneServer,
+		imageServer:        s.imageServer,
 		repository:         s.repository,
 		ipWhitelistManager: s.ipWhitelistMgr,
+		activityTracker:    s.activityTracker,
 		Interfaces:         interfaces,
 		HTTPConn: func() net.Listener {
 			conn, err := net.Listen("tcp", dmsConfig.Http)
@@ -198,16 +216,24 @@ func (s *Service) init() error {
 // }
 
 // NewService initialises and returns a new DLNA service.
-func NewService(txnManager txn.Manager, repo Repository, cfg Config, sceneServer sceneServer) *Service {
+func NewService(txnManager txn.Manager, repo Repository, cfg Config, sceneServer sceneServer, imageServer imageServer) *Service {
 	ret := &Service{
 		txnManager:  txnManager,
 		repository:  repo,
 		sceneServer: sceneServer,
+		imageServer: imageServer,
 		config:      cfg,
 		ipWhitelistMgr: &ipWhitelistManager{
 			config: cfg,
 		},
-		mutex: sync.Mutex{},
+		activityTracker: newActivityTracker(txnManager, repo.SceneWriter, cfg),
+		mutex:           sync.Mutex{},
+	}
+
+	ret.castMgr = &castManager{
+		txnManager: txnManager,
+		repository: repo,
+		serverHost: ret.serverHost,
 	}
 
 	return ret
@@ -260,13 +286,24 @@ func (s *Service) Start(duration *time.Duration) error {
 }
 
 // Stop stops the DLNA service. If duration is provided, then the service
-// is started after the duration has elapsed.
+// is started after the duration has elapsed. Casting to renderers is
+// stopped.
 func (s *Service) Stop(duration *time.Duration) {
+	// casting sessions use the service lock to reach the server, so they
+	// are stopped once it is released
+	stopCasts := false
+	defer func() {
+		if stopCasts {
+			s.castMgr.stopAll()
+		}
+	}()
+
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
 	if s.running {
 		logger.Info("Stopping DLNA")
+		stopCasts = true
 		err := s.server.Close()
 		if err != nil {
 			logger.Error(err)
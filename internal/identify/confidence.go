@@ -0,0 +1,297 @@
+package identify
+
+import (
+	"math"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scraper"
+	"github.com/stashapp/stash/pkg/sliceutil/intslice"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+const (
+	ConfidenceSignalFingerprint = "fingerprint"
+	ConfidenceSignalDuration    = "duration"
+	ConfidenceSignalTitle       = "title"
+	ConfidenceSignalDate        = "date"
+	ConfidenceSignalStudio      = "studio"
+	ConfidenceSignalPerformers  = "performers"
+)
+
+// confidenceWeights are the weights of each signal in the overall
+// confidence. A matching fingerprint is the strongest signal.
+var confidenceWeights = map[string]float64{
+	ConfidenceSignalFingerprint: 4,
+	ConfidenceSignalDuration:    1,
+	ConfidenceSignalTitle:       2,
+	ConfidenceSignalDate:        1,
+	ConfidenceSignalStudio:      1,
+	ConfidenceSignalPerformers:  1,
+}
+
+// durations within this many seconds are considered the same
+const durationTolerance = 5
+
+// durations this many seconds apart or more score zero
+const durationMaxDelta = 120
+
+// ConfidenceScore is the score of a single signal, in 0-1 scale.
+type ConfidenceScore struct {
+	Signal string  `json:"signal"`
+	Score  float64 `json:"score"`
+}
+
+// sceneConfidence scores how likely the scraped scene is to be a match for
+// the scene. Signals which cannot be compared are omitted, and the confidence
+// is the weighted mean of the remaining scores. The scene files and performer
+// ids must be loaded.
+func sceneConfidence(s *models.Scene, scraped *scraper.ScrapedScene) (float64, []*ConfidenceScore) {
+	var scores []*ConfidenceScore
+	add := func(signal string, score float64, ok bool) {
+		if ok {
+			scores = append(scores, &ConfidenceScore{
+				Signal: signal,
+				Score:  math.Max(0, math.Min(1, score)),
+			})
+		}
+	}
+
+	files := s.Files.List()
+
+	score, ok := fingerprintScore(files, scraped.Fingerprints)
+	add(ConfidenceSignalFingerprint, score, ok)
+	score, ok = durationScore(files, scraped.Duration)
+	add(ConfidenceSignalDuration, score, ok)
+	score, ok = titleScore(s, scraped.Title)
+	add(ConfidenceSignalTitle, score, ok)
+	score, ok = dateScore(s, scraped.Date)
+	add(ConfidenceSignalDate, score, ok)
+	score, ok = studioScore(s, scraped.Studio)
+	add(ConfidenceSignalStudio, score, ok)
+	score, ok = performersScore(s, scraped.Performers)
+	add(ConfidenceSignalPerformers, score, ok)
+
+	var total, weights float64
+	for _, sc := range scores {
+		w := confidenceWeights[sc.Signal]
+		total += sc.Score * w
+		weights += w
+	}
+
+	if weights == 0 {
+		return 0, scores
+	}
+
+	return total / weights, scores
+}
+
+// fingerprintScore returns 1 if any of the scraped fingerprints match a
+// fingerprint of the scene files.
+func fingerprintScore(files []*file.VideoFile, fingerprints []*models.StashBoxFingerprint) (float64, bool) {
+	if len(fingerprints) == 0 || len(files) == 0 {
+		return 0, false
+	}
+
+	for _, f := range files {
+		for _, fp := range fingerprints {
+			var hash string
+			switch strings.ToLower(fp.Algorithm) {
+			case file.FingerprintTypeMD5:
+				hash = f.Fingerprints.GetString(file.FingerprintTypeMD5)
+			case file.FingerprintTypeOshash:
+				hash = f.Fingerprints.GetString(file.FingerprintTypeOshash)
+			case file.FingerprintTypePhash:
+				if phash := f.Fingerprints.GetInt64(file.FingerprintTypePhash); phash != 0 {
+					hash = utils.PhashToString(phash)
+				}
+			}
+
+			if hash != "" && strings.EqualFold(hash, fp.Hash) {
+				return 1, true
+			}
+		}
+	}
+
+	return 0, true
+}
+
+// durationScore compares the scraped duration to the closest file duration.
+func durationScore(files []*file.VideoFile, duration *int) (float64, bool) {
+	if duration == nil || *duration <= 0 || len(files) == 0 {
+		return 0, false
+	}
+
+	delta := math.MaxFloat64
+	for _, f := range files {
+		delta = math.Min(delta, math.Abs(f.Duration-float64(*duration)))
+	}
+
+	if delta <= durationTolerance {
+		return 1, true
+	}
+
+	return 1 - (delta-durationTolerance)/(durationMaxDelta-durationTolerance), true
+}
+
+// titleScore compares the scraped title to the scene title, or the file name
+// if the scene has no title.
+func titleScore(s *models.Scene, title *string) (float64, bool) {
+	if title == nil || *title == "" {
+		return 0, false
+	}
+
+	existing := s.Title
+	if existing == "" {
+		existing = strings.TrimSuffix(filepath.Base(s.Path), filepath.Ext(s.Path))
+	}
+	if existing == "" {
+		return 0, false
+	}
+
+	return tokenSimilarity(*title, existing), true
+}
+
+// tokenSimilarity returns the proportion of the words of a that are in b.
+func tokenSimilarity(a, b string) float64 {
+	aTokens := tokenize(a)
+	if len(aTokens) == 0 {
+		return 0
+	}
+
+	bTokens := make(map[string]bool)
+	for _, t := range tokenize(b) {
+		bTokens[t] = true
+	}
+
+	found := 0
+	for _, t := range aTokens {
+		if bTokens[t] {
+			found++
+		}
+	}
+
+	return float64(found) / float64(len(aTokens))
+}
+
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// matches dates such as 2006-01-02, 2006.01.02 and 06.01.02 in file names
+var pathDateRE = regexp.MustCompile(`(?:^|\D)((?:\d{2})?\d{2})[.\-_ ](\d{2})[.\-_ ](\d{2})(?:\D|$)`)
+
+// dateScore compares the scraped date to the scene date, or a date in the
+// file name if the scene has no date.
+func dateScore(s *models.Scene, date *string) (float64, bool) {
+	if date == nil || *date == "" {
+		return 0, false
+	}
+
+	scraped := models.NewDate(*date).String()
+
+	if s.Date != nil {
+		if s.Date.String() == scraped {
+			return 1, true
+		}
+		return 0, true
+	}
+
+	m := pathDateRE.FindStringSubmatch(filepath.Base(s.Path))
+	if m == nil {
+		return 0, false
+	}
+
+	year := m[1]
+	if len(year) == 2 {
+		// two digit years are taken to be in the century of the scraped date
+		year = scraped[:2] + year
+	}
+
+	if year+"-"+m[2]+"-"+m[3] == scraped {
+		return 1, true
+	}
+
+	return 0, true
+}
+
+// studioScore compares the scraped studio to the scene studio, or checks the
+// path for the studio name if the scene has no studio.
+func studioScore(s *models.Scene, studio *models.ScrapedStudio) (float64, bool) {
+	if studio == nil {
+		return 0, false
+	}
+
+	if s.StudioID != nil {
+		if studio.StoredID != nil && *studio.StoredID == strconv.Itoa(*s.StudioID) {
+			return 1, true
+		}
+		return 0, true
+	}
+
+	// only a found name counts - most paths don't contain the studio
+	if containsWords(s.Path, studio.Name) {
+		return 1, true
+	}
+
+	return 0, false
+}
+
+// performersScore returns the proportion of the scraped performers which are
+// set on the scene, or whose names are in the path if the scene has no
+// performers.
+func performersScore(s *models.Scene, performers []*models.ScrapedPerformer) (float64, bool) {
+	if len(performers) == 0 {
+		return 0, false
+	}
+
+	existing := s.PerformerIDs.List()
+	if len(existing) > 0 {
+		found := 0
+		for _, p := range performers {
+			if p.StoredID == nil {
+				continue
+			}
+
+			id, err := strconv.Atoi(*p.StoredID)
+			if err == nil && intslice.IntInclude(existing, id) {
+				found++
+			}
+		}
+
+		return float64(found) / float64(len(performers)), true
+	}
+
+	found := 0
+	for _, p := range performers {
+		if p.Name != nil && containsWords(s.Path, *p.Name) {
+			found++
+		}
+	}
+
+	// only found names count - most paths don't contain performer names
+	if found == 0 {
+		return 0, false
+	}
+
+	return float64(found) / float64(len(performers)), true
+}
+
+// containsWords returns true if the words of name appear consecutively in s,
+// ignoring case and separators.
+func containsWords(s string, name string) bool {
+	nameTokens := tokenize(name)
+	if len(nameTokens) == 0 {
+		return false
+	}
+
+	joined := " " + strings.Join(tokenize(s), " ") + " "
+	return strings.Contains(joined, " "+strings.Join(nameTokens, " ")+" ")
+}
@@ -0,0 +1,215 @@
+package identify
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scraper"
+)
+
+func Test_durationScore(t *testing.T) {
+	files := []*file.VideoFile{
+		{Duration: 600},
+		{Duration: 1200},
+	}
+
+	tests := []struct {
+		name     string
+		duration *int
+		want     float64
+		wantOK   bool
+	}{
+		{"nil", nil, 0, false},
+		{"zero", intPtr(0), 0, false},
+		{"exact", intPtr(1200), 1, true},
+		{"within tolerance", intPtr(604), 1, true},
+		{"max delta", intPtr(720), 0, true},
+		{"halfway", intPtr(600 + durationTolerance + (durationMaxDelta-durationTolerance)/2), 0.5, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := durationScore(files, tt.duration)
+			if ok != tt.wantOK || math.Abs(got-tt.want) > 0.01 {
+				t.Errorf("durationScore() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func Test_fingerprintScore(t *testing.T) {
+	files := []*file.VideoFile{
+		{
+			BaseFile: &file.BaseFile{
+				Fingerprints: file.Fingerprints{
+					{Type: file.FingerprintTypeOshash, Fingerprint: "abcdef"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		fingerprints []*models.StashBoxFingerprint
+		want         float64
+		wantOK       bool
+	}{
+		{"none", nil, 0, false},
+		{"match", []*models.StashBoxFingerprint{{Algorithm: "OSHASH", Hash: "ABCDEF"}}, 1, true},
+		{"no match", []*models.StashBoxFingerprint{{Algorithm: "OSHASH", Hash: "123456"}}, 0, true},
+		{"other algorithm", []*models.StashBoxFingerprint{{Algorithm: "MD5", Hash: "abcdef"}}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := fingerprintScore(files, tt.fingerprints)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("fingerprintScore() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func Test_titleScore(t *testing.T) {
+	tests := []struct {
+		name   string
+		scene  *models.Scene
+		title  *string
+		want   float64
+		wantOK bool
+	}{
+		{"nil", &models.Scene{Title: "title"}, nil, 0, false},
+		{"same", &models.Scene{Title: "A Scene Title"}, strPtr("a scene title"), 1, true},
+		{"partial", &models.Scene{Title: "A Scene"}, strPtr("A Scene Title Here"), 0.5, true},
+		{"from path", &models.Scene{Path: "/stash/Studio.A.Scene.Title.mp4"}, strPtr("A Scene Title"), 1, true},
+		{"different", &models.Scene{Title: "other"}, strPtr("title"), 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := titleScore(tt.scene, tt.title)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("titleScore() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func Test_dateScore(t *testing.T) {
+	date := models.NewDate("2021-03-04")
+
+	tests := []struct {
+		name   string
+		scene  *models.Scene
+		date   *string
+		want   float64
+		wantOK bool
+	}{
+		{"nil", &models.Scene{Date: &date}, nil, 0, false},
+		{"same", &models.Scene{Date: &date}, strPtr("2021-03-04"), 1, true},
+		{"different", &models.Scene{Date: &date}, strPtr("2021-03-05"), 0, true},
+		{"from path", &models.Scene{Path: "/stash/studio.2021.03.04.title.mp4"}, strPtr("2021-03-04"), 1, true},
+		{"two digit year in path", &models.Scene{Path: "/stash/studio.21.03.04.title.mp4"}, strPtr("2021-03-04"), 1, true},
+		{"different in path", &models.Scene{Path: "/stash/studio.21.03.05.title.mp4"}, strPtr("2021-03-04"), 0, true},
+		{"no date in path", &models.Scene{Path: "/stash/title.mp4"}, strPtr("2021-03-04"), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := dateScore(tt.scene, tt.date)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("dateScore() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func Test_studioScore(t *testing.T) {
+	studioID := 1
+
+	tests := []struct {
+		name   string
+		scene  *models.Scene
+		studio *models.ScrapedStudio
+		want   float64
+		wantOK bool
+	}{
+		{"nil", &models.Scene{StudioID: &studioID}, nil, 0, false},
+		{"same", &models.Scene{StudioID: &studioID}, &models.ScrapedStudio{StoredID: strPtr("1")}, 1, true},
+		{"different", &models.Scene{StudioID: &studioID}, &models.ScrapedStudio{StoredID: strPtr("2")}, 0, true},
+		{"in path", &models.Scene{Path: "/stash/Studio Name/title.mp4"}, &models.ScrapedStudio{Name: "studio name"}, 1, true},
+		{"not in path", &models.Scene{Path: "/stash/title.mp4"}, &models.ScrapedStudio{Name: "studio name"}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := studioScore(tt.scene, tt.studio)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("studioScore() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func Test_performersScore(t *testing.T) {
+	performers := []*models.ScrapedPerformer{
+		{Name: strPtr("Performer One"), StoredID: strPtr("1")},
+		{Name: strPtr("Performer Two")},
+	}
+
+	tests := []struct {
+		name   string
+		scene  *models.Scene
+		want   float64
+		wantOK bool
+	}{
+		{"existing", &models.Scene{PerformerIDs: models.NewRelatedIDs([]int{1, 3})}, 0.5, true},
+		{"none existing", &models.Scene{PerformerIDs: models.NewRelatedIDs([]int{3})}, 0, true},
+		{"in path", &models.Scene{Path: "/stash/performer.one.performer.two.mp4", PerformerIDs: models.NewRelatedIDs([]int{})}, 1, true},
+		{"not in path", &models.Scene{Path: "/stash/title.mp4", PerformerIDs: models.NewRelatedIDs([]int{})}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := performersScore(tt.scene, performers)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("performersScore() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func Test_sceneConfidence(t *testing.T) {
+	s := &models.Scene{
+		Title: "A Scene Title",
+		Path:  "/stash/a.scene.title.mp4",
+		Files: models.NewRelatedVideoFiles([]*file.VideoFile{
+			{Duration: 600},
+		}),
+		PerformerIDs: models.NewRelatedIDs([]int{}),
+	}
+
+	// title matches (weight 2), duration is far off (weight 1)
+	got, scores := sceneConfidence(s, &scraper.ScrapedScene{
+		Title:    strPtr("A Scene Title"),
+		Duration: intPtr(1200),
+	})
+
+	if len(scores) != 2 {
+		t.Fatalf("sceneConfidence() returned %d scores, want 2", len(scores))
+	}
+
+	want := 2.0 / 3.0
+	if math.Abs(got-want) > 0.001 {
+		t.Errorf("sceneConfidence() = %v, want %v", got, want)
+	}
+
+	got, scores = sceneConfidence(s, &scraper.ScrapedScene{})
+	if got != 0 || len(scores) != 0 {
+		t.Errorf("sceneConfidence() with no signals = %v, %v, want 0, []", got, scores)
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func strPtr(s string) *string {
+	return &s
+}
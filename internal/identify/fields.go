@@ -0,0 +1,134 @@
+package identify
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// getSourceOptions returns the options of the source followed by the
+// defaults, in order of precedence.
+func getSourceOptions(source ScraperSource, defaults *MetadataOptions) []MetadataOptions {
+	var options []MetadataOptions
+	if source.Options != nil {
+		options = append(options, *source.Options)
+	}
+	if defaults != nil {
+		options = append(options, *defaults)
+	}
+
+	return options
+}
+
+func getIncludeMalePerformers(options []MetadataOptions) bool {
+	for _, o := range options {
+		if o.IncludeMalePerformers != nil {
+			return *o.IncludeMalePerformers
+		}
+	}
+
+	return true
+}
+
+func getSetCoverImage(options []MetadataOptions) bool {
+	for _, o := range options {
+		if o.SetCoverImage != nil {
+			return *o.SetCoverImage
+		}
+	}
+
+	return false
+}
+
+func getOptionalString(fieldOptions map[string]*FieldOptions, field string, existing string, scraped *string) models.OptionalString {
+	if scraped == nil || *scraped == "" || existing == *scraped {
+		return models.OptionalString{}
+	}
+
+	if !shouldSetSingleValueField(fieldOptions[field], existing != "") {
+		return models.OptionalString{}
+	}
+
+	return models.NewOptionalString(*scraped)
+}
+
+func getOptionalDate(fieldOptions map[string]*FieldOptions, field string, existing *models.Date, scraped *string) models.OptionalDate {
+	if scraped == nil || *scraped == "" || (existing != nil && existing.String() == *scraped) {
+		return models.OptionalDate{}
+	}
+
+	if !shouldSetSingleValueField(fieldOptions[field], existing != nil) {
+		return models.OptionalDate{}
+	}
+
+	return models.NewOptionalDate(models.NewDate(*scraped))
+}
+
+// getOptionalInt sets the int field from the scraped string. Values which
+// are not valid integers are ignored.
+func getOptionalInt(fieldOptions map[string]*FieldOptions, field string, existing *int, scraped *string) models.OptionalInt {
+	if scraped == nil {
+		return models.OptionalInt{}
+	}
+
+	v, err := strconv.Atoi(*scraped)
+	if err != nil || (existing != nil && *existing == v) {
+		return models.OptionalInt{}
+	}
+
+	if !shouldSetSingleValueField(fieldOptions[field], existing != nil) {
+		return models.OptionalInt{}
+	}
+
+	return models.NewOptionalInt(v)
+}
+
+// getOptionalFloat64 sets the float field from the scraped string. Values
+// which are not valid numbers are ignored.
+func getOptionalFloat64(fieldOptions map[string]*FieldOptions, field string, existing *float64, scraped *string) models.OptionalFloat64 {
+	if scraped == nil {
+		return models.OptionalFloat64{}
+	}
+
+	v, err := strconv.ParseFloat(*scraped, 64)
+	if err != nil || (existing != nil && *existing == v) {
+		return models.OptionalFloat64{}
+	}
+
+	if !shouldSetSingleValueField(fieldOptions[field], existing != nil) {
+		return models.OptionalFloat64{}
+	}
+
+	return models.NewOptionalFloat64(v)
+}
+
+// updatedFields records the values set by an update, keyed by the json
+// field name. It is passed as the input to the post-update hooks.
+type updatedFields map[string]interface{}
+
+func (f updatedFields) add(field string, set bool, value interface{}) {
+	if set {
+		f[field] = value
+	}
+}
+
+func (f updatedFields) fields() []string {
+	ret := make([]string, 0, len(f))
+	for k := range f {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// hookInput returns the input to pass to the post-update hooks.
+func (f updatedFields) hookInput(id int) map[string]interface{} {
+	ret := map[string]interface{}{
+		"id": strconv.Itoa(id),
+	}
+	for k, v := range f {
+		ret[k] = v
+	}
+	return ret
+}
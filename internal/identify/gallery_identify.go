@@ -0,0 +1,163 @@
+package identify
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/plugin"
+	"github.com/stashapp/stash/pkg/scraper"
+	"github.com/stashapp/stash/pkg/sliceutil/intslice"
+	"github.com/stashapp/stash/pkg/txn"
+)
+
+type GalleryReaderUpdater interface {
+	UpdatePartial(ctx context.Context, id int, updatedGallery models.GalleryPartial) (*models.Gallery, error)
+	models.PerformerIDLoader
+	models.TagIDLoader
+}
+
+// GalleryIdentifier identifies galleries using the first source which finds
+// a match.
+type GalleryIdentifier struct {
+	GalleryReaderUpdater GalleryReaderUpdater
+	StudioCreator        StudioCreator
+	PerformerCreator     PerformerCreator
+	TagCreator           TagCreator
+
+	DefaultOptions   *MetadataOptions
+	Sources          []ScraperSource
+	PostHookExecutor PostHookExecutor
+}
+
+func (t *GalleryIdentifier) Identify(ctx context.Context, txnManager txn.Manager, g *models.Gallery) error {
+	scraped, source := t.scrapeGallery(ctx, g)
+	if scraped == nil {
+		logger.Debugf("Unable to identify gallery %s", g.DisplayName())
+		return nil
+	}
+
+	updated := updatedFields{}
+	if err := txn.WithTxn(ctx, txnManager, func(ctx context.Context) error {
+		if err := g.LoadPerformerIDs(ctx, t.GalleryReaderUpdater); err != nil {
+			return err
+		}
+		if err := g.LoadTagIDs(ctx, t.GalleryReaderUpdater); err != nil {
+			return err
+		}
+
+		partial, err := t.getGalleryPartial(ctx, g, scraped, source, updated)
+		if err != nil {
+			return err
+		}
+
+		// don't update anything if nothing was set
+		if len(updated) == 0 {
+			logger.Debugf("Nothing to set for gallery %s", g.DisplayName())
+			return nil
+		}
+
+		if _, err := t.GalleryReaderUpdater.UpdatePartial(ctx, g.ID, partial); err != nil {
+			return fmt.Errorf("error updating gallery: %w", err)
+		}
+
+		logger.Infof("Successfully identified gallery %s using %s", g.DisplayName(), source.Name)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("error modifying gallery: %w", err)
+	}
+
+	if len(updated) > 0 && t.PostHookExecutor != nil {
+		t.PostHookExecutor.ExecutePostHooks(ctx, g.ID, plugin.GalleryUpdatePost, updated.hookInput(g.ID), updated.fields())
+	}
+
+	return nil
+}
+
+func (t *GalleryIdentifier) scrapeGallery(ctx context.Context, g *models.Gallery) (*scraper.ScrapedGallery, ScraperSource) {
+	for _, source := range t.Sources {
+		// skip sources which cannot identify galleries
+		if source.GalleryScraper == nil {
+			continue
+		}
+
+		scraped, err := source.GalleryScraper.ScrapeGallery(ctx, g)
+		if err != nil {
+			logger.Errorf("error scraping gallery from %s: %v", source.Name, err)
+			continue
+		}
+
+		if scraped != nil {
+			return scraped, source
+		}
+	}
+
+	return nil, ScraperSource{}
+}
+
+func (t *GalleryIdentifier) getGalleryPartial(ctx context.Context, g *models.Gallery, scraped *scraper.ScrapedGallery, source ScraperSource, updated updatedFields) (models.GalleryPartial, error) {
+	options := getSourceOptions(source, t.DefaultOptions)
+	fieldOptions := getFieldOptions(options)
+
+	partial := models.NewGalleryPartial()
+	partial.Title = getOptionalString(fieldOptions, "title", g.Title, scraped.Title)
+	updated.add("title", partial.Title.Set, partial.Title.Value)
+	partial.URL = getOptionalString(fieldOptions, "url", g.URL, scraped.URL)
+	updated.add("url", partial.URL.Set, partial.URL.Value)
+	partial.Date = getOptionalDate(fieldOptions, "date", g.Date, scraped.Date)
+	updated.add("date", partial.Date.Set, partial.Date.Value.String())
+	partial.Details = getOptionalString(fieldOptions, "details", g.Details, scraped.Details)
+	updated.add("details", partial.Details.Set, partial.Details.Value)
+
+	for _, o := range options {
+		if o.SetOrganized != nil {
+			if *o.SetOrganized && !g.Organized {
+				partial.Organized = models.NewOptionalBool(true)
+				updated.add("organized", true, true)
+			}
+			break
+		}
+	}
+
+	studioID, err := getStudioID(ctx, t.StudioCreator, source.RemoteSite, g.StudioID, scraped.Studio, fieldOptions["studio"])
+	if err != nil {
+		return partial, fmt.Errorf("error getting studio: %w", err)
+	}
+	if studioID != nil {
+		partial.StudioID = models.NewOptionalInt(*studioID)
+		updated.add("studio_id", true, strconv.Itoa(*studioID))
+	}
+
+	performerIDs, err := getPerformerIDs(ctx, t.PerformerCreator, g.PerformerIDs.List(), []scrapedPerformers{
+		{
+			endpoint:   source.RemoteSite,
+			performers: scraped.Performers,
+		},
+	}, fieldOptions["performers"], !getIncludeMalePerformers(options))
+	if err != nil {
+		return partial, err
+	}
+	if performerIDs != nil {
+		partial.PerformerIDs = &models.UpdateIDs{
+			IDs:  performerIDs,
+			Mode: models.RelationshipUpdateModeSet,
+		}
+		updated.add("performer_ids", true, intslice.IntSliceToStringSlice(performerIDs))
+	}
+
+	tagIDs, err := getTagIDs(ctx, t.TagCreator, g.TagIDs.List(), [][]*models.ScrapedTag{scraped.Tags}, fieldOptions["tags"])
+	if err != nil {
+		return partial, err
+	}
+	if tagIDs != nil {
+		partial.TagIDs = &models.UpdateIDs{
+			IDs:  tagIDs,
+			Mode: models.RelationshipUpdateModeSet,
+		}
+		updated.add("tag_ids", true, intslice.IntSliceToStringSlice(tagIDs))
+	}
+
+	return partial, nil
+}
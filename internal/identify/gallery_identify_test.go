@@ -0,0 +1,138 @@
+package identify
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stashapp/stash/pkg/plugin"
+	"github.com/stashapp/stash/pkg/scraper"
+	"github.com/stashapp/stash/pkg/sliceutil/intslice"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockGalleryScraper struct {
+	errIDs  []int
+	results map[int]*scraper.ScrapedGallery
+}
+
+func (s mockGalleryScraper) ScrapeGallery(ctx context.Context, g *models.Gallery) (*scraper.ScrapedGallery, error) {
+	if intslice.IntInclude(s.errIDs, g.ID) {
+		return nil, errors.New("scrape gallery error")
+	}
+	return s.results[g.ID], nil
+}
+
+type mockPostHookExecutor struct {
+	fields []string
+}
+
+func (s *mockPostHookExecutor) ExecutePostHooks(ctx context.Context, id int, hookType plugin.HookTriggerEnum, input interface{}, inputFields []string) {
+	s.fields = inputFields
+}
+
+func TestGalleryIdentifier_Identify(t *testing.T) {
+	const (
+		errID = iota + 1
+		missingID
+		foundID
+		unchangedID
+	)
+
+	var (
+		scrapedTitle = "scrapedTitle"
+		existingURL  = "existingURL"
+		scrapedURL   = "scrapedURL"
+		tagID        = "5"
+	)
+
+	sources := []ScraperSource{
+		{
+			// scene only source is skipped
+			Scraper: mockSceneScraper{},
+		},
+		{
+			Name: "first",
+			GalleryScraper: mockGalleryScraper{
+				errIDs: []int{errID},
+			},
+		},
+		{
+			Name: "second",
+			GalleryScraper: mockGalleryScraper{
+				results: map[int]*scraper.ScrapedGallery{
+					foundID: {
+						Title: &scrapedTitle,
+						URL:   &scrapedURL,
+						Tags:  []*models.ScrapedTag{{StoredID: &tagID}},
+					},
+					unchangedID: {
+						URL: &existingURL,
+					},
+				},
+			},
+		},
+	}
+
+	mockGalleryReaderWriter := &mocks.GalleryReaderWriter{}
+	mockGalleryReaderWriter.On("UpdatePartial", mock.Anything, foundID, mock.MatchedBy(func(p models.GalleryPartial) bool {
+		// url is already set so is not overwritten
+		return p.Title.Value == scrapedTitle && !p.URL.Set && p.TagIDs != nil && p.TagIDs.IDs[0] == 5
+	})).Return(nil, nil).Once()
+
+	tests := []struct {
+		name       string
+		galleryID  int
+		wantFields []string
+	}{
+		{
+			"error scraping",
+			errID,
+			nil,
+		},
+		{
+			"not found",
+			missingID,
+			nil,
+		},
+		{
+			"found in second scraper",
+			foundID,
+			[]string{"tag_ids", "title"},
+		},
+		{
+			"nothing to set",
+			unchangedID,
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hookExecutor := &mockPostHookExecutor{}
+			identifier := GalleryIdentifier{
+				GalleryReaderUpdater: mockGalleryReaderWriter,
+				DefaultOptions:       &MetadataOptions{},
+				Sources:              sources,
+				PostHookExecutor:     hookExecutor,
+			}
+
+			g := &models.Gallery{
+				ID:           tt.galleryID,
+				URL:          existingURL,
+				PerformerIDs: models.NewRelatedIDs([]int{}),
+				TagIDs:       models.NewRelatedIDs([]int{}),
+			}
+			if err := identifier.Identify(testCtx, &mocks.TxnManager{}, g); err != nil {
+				t.Errorf("GalleryIdentifier.Identify() error = %v", err)
+			}
+
+			assert.Equal(t, tt.wantFields, hookExecutor.fields)
+		})
+	}
+
+	mockGalleryReaderWriter.AssertExpectations(t)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/plugin"
 	"github.com/stashapp/stash/pkg/scene"
 	"github.com/stashapp/stash/pkg/scraper"
 	"github.com/stashapp/stash/pkg/txn"
@@ -16,15 +17,41 @@ type SceneScraper interface {
 	ScrapeScene(ctx context.Context, sceneID int) (*scraper.ScrapedScene, error)
 }
 
+type GalleryScraper interface {
+	ScrapeGallery(ctx context.Context, gallery *models.Gallery) (*scraper.ScrapedGallery, error)
+}
+
+type PerformerScraper interface {
+	ScrapePerformer(ctx context.Context, performer *models.Performer) (*models.ScrapedPerformer, error)
+}
+
+type MovieScraper interface {
+	ScrapeMovie(ctx context.Context, movie *models.Movie) (*models.ScrapedMovie, error)
+}
+
+type StudioScraper interface {
+	ScrapeStudio(ctx context.Context, studio *models.Studio) (*models.ScrapedStudio, error)
+}
+
 type SceneUpdatePostHookExecutor interface {
 	ExecuteSceneUpdatePostHooks(ctx context.Context, input models.SceneUpdateInput, inputFields []string)
 }
 
+type PostHookExecutor interface {
+	ExecutePostHooks(ctx context.Context, id int, hookType plugin.HookTriggerEnum, input interface{}, inputFields []string)
+}
+
+// ScraperSource is a source used to identify objects. The scrapers for
+// object types the source does not support are nil.
 type ScraperSource struct {
-	Name       string
-	Options    *MetadataOptions
-	Scraper    SceneScraper
-	RemoteSite string
+	Name             string
+	Options          *MetadataOptions
+	Scraper          SceneScraper
+	GalleryScraper   GalleryScraper
+	PerformerScraper PerformerScraper
+	MovieScraper     MovieScraper
+	StudioScraper    StudioScraper
+	RemoteSite       string
 }
 
 type SceneIdentifier struct {
@@ -36,10 +63,28 @@ type SceneIdentifier struct {
 	DefaultOptions              *MetadataOptions
 	Sources                     []ScraperSource
 	SceneUpdatePostHookExecutor SceneUpdatePostHookExecutor
+
+	// If true, all sources are queried and their results are merged per
+	// field, rather than using the first source that finds a match.
+	MergeSources bool
+	// Indexes of the sources each field is taken from when merging, in
+	// priority order. Fields not listed use all sources in order.
+	FieldSourcePriority map[string][]int
+
+	// If greater than zero, results with a confidence below this are queued
+	// for review rather than applied.
+	ConfidenceThreshold float64
+	ReviewCreator       ReviewCreator
 }
 
 func (t *SceneIdentifier) Identify(ctx context.Context, txnManager txn.Manager, scene *models.Scene) error {
-	result, err := t.scrapeScene(ctx, scene)
+	var result *scrapeResult
+	var err error
+	if t.MergeSources {
+		result, err = t.scrapeAllSources(ctx, scene.ID)
+	} else {
+		result, err = t.scrapeScene(ctx, scene)
+	}
 	if err != nil {
 		return err
 	}
@@ -49,6 +94,17 @@ func (t *SceneIdentifier) Identify(ctx context.Context, txnManager txn.Manager,
 		return nil
 	}
 
+	if t.ConfidenceThreshold > 0 {
+		queued, err := t.queueForReview(ctx, txnManager, scene, result)
+		if err != nil {
+			return fmt.Errorf("error queueing for review: %w", err)
+		}
+
+		if queued {
+			return nil
+		}
+	}
+
 	// results were found, modify the scene
 	if err := t.modifyScene(ctx, txnManager, scene, result); err != nil {
 		return fmt.Errorf("error modifying scene: %v", err)
@@ -60,11 +116,22 @@ func (t *SceneIdentifier) Identify(ctx context.Context, txnManager txn.Manager,
 type scrapeResult struct {
 	result *scraper.ScrapedScene
 	source ScraperSource
+	// index of the source in SceneIdentifier.Sources
+	index int
+
+	// results of all sources that found a match, set when merging
+	merged   []*scrapeResult
+	priority map[string][]int
 }
 
 func (t *SceneIdentifier) scrapeScene(ctx context.Context, scene *models.Scene) (*scrapeResult, error) {
 	// iterate through the input sources
-	for _, source := range t.Sources {
+	for i, source := range t.Sources {
+		// skip sources which cannot identify scenes
+		if source.Scraper == nil {
+			continue
+		}
+
 		// scrape using the source
 		scraped, err := source.Scraper.ScrapeScene(ctx, scene.ID)
 		if err != nil {
@@ -77,6 +144,7 @@ func (t *SceneIdentifier) scrapeScene(ctx context.Context, scene *models.Scene)
 			return &scrapeResult{
 				result: scraped,
 				source: source,
+				index:  i,
 			}, nil
 		}
 	}
@@ -90,7 +158,13 @@ func (t *SceneIdentifier) getSceneUpdater(ctx context.Context, s *models.Scene,
 	}
 
 	options := []MetadataOptions{}
-	if result.source.Options != nil {
+	if result.merged != nil {
+		for _, r := range result.merged {
+			if r.source.Options != nil {
+				options = append(options, *r.source.Options)
+			}
+		}
+	} else if result.source.Options != nil {
 		options = append(options, *result.source.Options)
 	}
 	if t.DefaultOptions != nil {
@@ -98,6 +172,11 @@ func (t *SceneIdentifier) getSceneUpdater(ctx context.Context, s *models.Scene,
 	}
 
 	fieldOptions := getFieldOptions(options)
+	scraped := result.result
+	if result.merged != nil {
+		fieldOptions = result.mergedFieldOptions(t.DefaultOptions)
+		scraped = result.mergedScene()
+	}
 
 	setOrganized := false
 	for _, o := range options {
@@ -107,8 +186,6 @@ func (t *SceneIdentifier) getSceneUpdater(ctx context.Context, s *models.Scene,
 		}
 	}
 
-	scraped := result.result
-
 	rel := sceneRelationships{
 		sceneReader:      t.SceneReaderUpdater,
 		studioCreator:    t.StudioCreator,
@@ -224,7 +301,11 @@ func (t *SceneIdentifier) modifyScene(ctx context.Context, txnManager txn.Manage
 		if title.Ptr() != nil {
 			as = fmt.Sprintf(" as %s", title.Value)
 		}
-		logger.Infof("Successfully identified %s%s using %s", s.Path, as, result.source.Name)
+		if result.merged != nil {
+			logger.Infof("Successfully identified %s%s by merging sources: %s", s.Path, as, result.provenance())
+		} else {
+			logger.Infof("Successfully identified %s%s using %s", s.Path, as, result.source.Name)
+		}
 
 		return nil
 	}); err != nil {
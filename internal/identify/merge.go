@@ -0,0 +1,224 @@
+package identify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/scraper"
+)
+
+// mergeFields are the scene fields which can be taken from different
+// sources when merging.
+var mergeFields = []string{
+	"title",
+	"date",
+	"details",
+	"url",
+	"director",
+	"code",
+	"studio",
+	"performers",
+	"tags",
+	"stash_ids",
+	"cover_image",
+}
+
+// scrapeAllSources queries every source and returns the merged result, or
+// nil if no source found a match.
+func (t *SceneIdentifier) scrapeAllSources(ctx context.Context, sceneID int) (*scrapeResult, error) {
+	var merged []*scrapeResult
+	for i, source := range t.Sources {
+		// skip sources which cannot identify scenes
+		if source.Scraper == nil {
+			continue
+		}
+
+		scraped, err := source.Scraper.ScrapeScene(ctx, sceneID)
+		if err != nil {
+			logger.Errorf("error scraping from %v: %v", source.Scraper, err)
+			continue
+		}
+
+		if scraped != nil {
+			merged = append(merged, &scrapeResult{
+				result: scraped,
+				source: source,
+				index:  i,
+			})
+		}
+	}
+
+	if len(merged) == 0 {
+		return nil, nil
+	}
+
+	// the first result stands in for the merged result where a single
+	// source is needed
+	return &scrapeResult{
+		result:   merged[0].result,
+		source:   merged[0].source,
+		index:    merged[0].index,
+		merged:   merged,
+		priority: t.FieldSourcePriority,
+	}, nil
+}
+
+// fieldResults returns the results the field is taken from, in priority
+// order. If not merging, this is the result itself.
+func (r *scrapeResult) fieldResults(field string) []*scrapeResult {
+	if r.merged == nil {
+		return []*scrapeResult{r}
+	}
+
+	indexes, found := r.priority[field]
+	if !found {
+		return r.merged
+	}
+
+	var ret []*scrapeResult
+	for _, i := range indexes {
+		for _, m := range r.merged {
+			if m.index == i {
+				ret = append(ret, m)
+			}
+		}
+	}
+
+	return ret
+}
+
+// fieldProvider returns the highest priority result which has a value for
+// the field.
+func (r *scrapeResult) fieldProvider(field string) *scrapeResult {
+	for _, rr := range r.fieldResults(field) {
+		if hasSceneField(rr, field) {
+			return rr
+		}
+	}
+
+	return nil
+}
+
+func hasSceneField(r *scrapeResult, field string) bool {
+	s := r.result
+	switch field {
+	case "title":
+		return s.Title != nil
+	case "date":
+		return s.Date != nil
+	case "details":
+		return s.Details != nil
+	case "url":
+		return s.URL != nil
+	case "director":
+		return s.Director != nil
+	case "code":
+		return s.Code != nil
+	case "studio":
+		return s.Studio != nil
+	case "performers":
+		return len(s.Performers) > 0
+	case "tags":
+		return len(s.Tags) > 0
+	case "stash_ids":
+		return s.RemoteSiteID != nil && r.source.RemoteSite != ""
+	case "cover_image":
+		return s.Image != nil
+	}
+
+	return false
+}
+
+// isMultiValueField returns true if the values of all sources are combined
+// for the field, rather than taken from a single source.
+func isMultiValueField(field string) bool {
+	switch field {
+	case "performers", "tags", "stash_ids":
+		return true
+	}
+
+	return false
+}
+
+// mergedScene returns a scraped scene with the single-value fields taken
+// from the highest priority source which has a value.
+func (r *scrapeResult) mergedScene() *scraper.ScrapedScene {
+	ret := *r.result
+
+	ret.Title = nil
+	if p := r.fieldProvider("title"); p != nil {
+		ret.Title = p.result.Title
+	}
+	ret.Date = nil
+	if p := r.fieldProvider("date"); p != nil {
+		ret.Date = p.result.Date
+	}
+	ret.Details = nil
+	if p := r.fieldProvider("details"); p != nil {
+		ret.Details = p.result.Details
+	}
+	ret.URL = nil
+	if p := r.fieldProvider("url"); p != nil {
+		ret.URL = p.result.URL
+	}
+	ret.Director = nil
+	if p := r.fieldProvider("director"); p != nil {
+		ret.Director = p.result.Director
+	}
+	ret.Code = nil
+	if p := r.fieldProvider("code"); p != nil {
+		ret.Code = p.result.Code
+	}
+
+	return &ret
+}
+
+// mergedFieldOptions returns the field options to use when merging. Each
+// field uses the options of the source it is taken from, then the defaults.
+func (r *scrapeResult) mergedFieldOptions(defaults *MetadataOptions) map[string]*FieldOptions {
+	var options []MetadataOptions
+	if defaults != nil {
+		options = append(options, *defaults)
+	}
+	ret := getFieldOptions(options)
+
+	for _, field := range mergeFields {
+		p := r.fieldProvider(field)
+		if p == nil || p.source.Options == nil {
+			continue
+		}
+
+		for _, f := range p.source.Options.FieldOptions {
+			if f.Field == field {
+				ret[field] = f
+				break
+			}
+		}
+	}
+
+	return ret
+}
+
+// provenance describes which sources each field was taken from.
+func (r *scrapeResult) provenance() string {
+	var fields []string
+	for _, field := range mergeFields {
+		var names []string
+		for _, rr := range r.fieldResults(field) {
+			if hasSceneField(rr, field) {
+				names = append(names, rr.source.Name)
+				if !isMultiValueField(field) {
+					break
+				}
+			}
+		}
+
+		if len(names) > 0 {
+			fields = append(fields, fmt.Sprintf("%s from %s", field, strings.Join(names, ", ")))
+		}
+	}
+
+	return strings.Join(fields, "; ")
+}
@@ -0,0 +1,106 @@
+package identify
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scraper"
+)
+
+func TestSceneIdentifier_mergeSources(t *testing.T) {
+	const sceneID = 1
+
+	var (
+		boxTitle    = "box title"
+		siteTitle   = "site title"
+		siteDetails = "site details"
+		remoteID    = "remote id"
+		performer1  = "1"
+		performer2  = "2"
+		tag10       = "10"
+		tag11       = "11"
+		endpoint    = "https://box.example.com/graphql"
+	)
+
+	identifier := SceneIdentifier{
+		Sources: []ScraperSource{
+			{
+				Name:       "box",
+				RemoteSite: endpoint,
+				Scraper: mockSceneScraper{
+					results: map[int]*scraper.ScrapedScene{
+						sceneID: {
+							Title:        &boxTitle,
+							RemoteSiteID: &remoteID,
+							Performers:   []*models.ScrapedPerformer{{StoredID: &performer1}},
+							Tags:         []*models.ScrapedTag{{StoredID: &tag10}},
+						},
+					},
+				},
+			},
+			{
+				Name: "site",
+				Scraper: mockSceneScraper{
+					results: map[int]*scraper.ScrapedScene{
+						sceneID: {
+							Title:      &siteTitle,
+							Details:    &siteDetails,
+							Performers: []*models.ScrapedPerformer{{StoredID: &performer2}},
+							Tags:       []*models.ScrapedTag{{StoredID: &tag11}, {StoredID: &tag10}},
+						},
+					},
+				},
+			},
+		},
+		MergeSources: true,
+		FieldSourcePriority: map[string][]int{
+			"title":      {1, 0},
+			"performers": {0},
+		},
+	}
+
+	result, err := identifier.scrapeAllSources(testCtx, sceneID)
+	if err != nil {
+		t.Fatalf("scrapeAllSources: %v", err)
+	}
+	if result == nil || len(result.merged) != 2 {
+		t.Fatalf("expected results of both sources, got %+v", result)
+	}
+
+	scene := &models.Scene{
+		ID:           sceneID,
+		PerformerIDs: models.NewRelatedIDs([]int{}),
+		TagIDs:       models.NewRelatedIDs([]int{}),
+		StashIDs:     models.NewRelatedStashIDs([]models.StashID{}),
+	}
+
+	updater, err := identifier.getSceneUpdater(testCtx, scene, result)
+	if err != nil {
+		t.Fatalf("getSceneUpdater: %v", err)
+	}
+
+	partial := updater.Partial
+	if partial.Title.Value != siteTitle {
+		t.Errorf("title = %q, want %q", partial.Title.Value, siteTitle)
+	}
+	if partial.Details.Value != siteDetails {
+		t.Errorf("details = %q, want %q", partial.Details.Value, siteDetails)
+	}
+	if partial.PerformerIDs == nil || !reflect.DeepEqual(partial.PerformerIDs.IDs, []int{1}) {
+		t.Errorf("performers = %v, want [1]", partial.PerformerIDs)
+	}
+	if partial.TagIDs == nil || !reflect.DeepEqual(partial.TagIDs.IDs, []int{10, 11}) {
+		t.Errorf("tags = %v, want [10 11]", partial.TagIDs)
+	}
+
+	wantStashIDs := []models.StashID{{StashID: remoteID, Endpoint: endpoint}}
+	if partial.StashIDs == nil || !reflect.DeepEqual(partial.StashIDs.StashIDs, wantStashIDs) {
+		t.Errorf("stash ids = %v, want %v", partial.StashIDs, wantStashIDs)
+	}
+
+	const wantProvenance = "title from site; details from site; performers from box; tags from box, site; stash_ids from box"
+	if got := result.provenance(); got != wantProvenance {
+		t.Errorf("provenance = %q, want %q", got, wantProvenance)
+	}
+}
@@ -0,0 +1,198 @@
+package identify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/plugin"
+	"github.com/stashapp/stash/pkg/txn"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+type MovieReaderUpdater interface {
+	UpdatePartial(ctx context.Context, id int, updatedMovie models.MoviePartial) (*models.Movie, error)
+	GetFrontImage(ctx context.Context, movieID int) ([]byte, error)
+	GetBackImage(ctx context.Context, movieID int) ([]byte, error)
+	UpdateFrontImage(ctx context.Context, movieID int, frontImage []byte) error
+	UpdateBackImage(ctx context.Context, movieID int, backImage []byte) error
+}
+
+// MovieIdentifier identifies movies using the first source which finds a
+// match. The movie name is never changed.
+type MovieIdentifier struct {
+	MovieReaderUpdater MovieReaderUpdater
+	StudioCreator      StudioCreator
+
+	DefaultOptions   *MetadataOptions
+	Sources          []ScraperSource
+	PostHookExecutor PostHookExecutor
+}
+
+func (t *MovieIdentifier) Identify(ctx context.Context, txnManager txn.Manager, m *models.Movie) error {
+	scraped, source := t.scrapeMovie(ctx, m)
+	if scraped == nil {
+		logger.Debugf("Unable to identify movie %s", m.Name)
+		return nil
+	}
+
+	updated := updatedFields{}
+	if err := txn.WithTxn(ctx, txnManager, func(ctx context.Context) error {
+		options := getSourceOptions(source, t.DefaultOptions)
+		partial, err := t.getMoviePartial(ctx, m, scraped, source, options, updated)
+		if err != nil {
+			return err
+		}
+
+		var frontImage, backImage []byte
+		if getSetCoverImage(options) {
+			frontImage, err = t.image(ctx, m.ID, scraped.FrontImage, t.MovieReaderUpdater.GetFrontImage)
+			if err != nil {
+				return err
+			}
+			if frontImage != nil {
+				updated.add("front_image", true, *scraped.FrontImage)
+			}
+
+			backImage, err = t.image(ctx, m.ID, scraped.BackImage, t.MovieReaderUpdater.GetBackImage)
+			if err != nil {
+				return err
+			}
+			if backImage != nil {
+				updated.add("back_image", true, *scraped.BackImage)
+			}
+		}
+
+		// don't update anything if nothing was set
+		if len(updated) == 0 {
+			logger.Debugf("Nothing to set for movie %s", m.Name)
+			return nil
+		}
+
+		if _, err := t.MovieReaderUpdater.UpdatePartial(ctx, m.ID, partial); err != nil {
+			return fmt.Errorf("error updating movie: %w", err)
+		}
+
+		if frontImage != nil {
+			if err := t.MovieReaderUpdater.UpdateFrontImage(ctx, m.ID, frontImage); err != nil {
+				return fmt.Errorf("error updating movie front image: %w", err)
+			}
+		}
+		if backImage != nil {
+			if err := t.MovieReaderUpdater.UpdateBackImage(ctx, m.ID, backImage); err != nil {
+				return fmt.Errorf("error updating movie back image: %w", err)
+			}
+		}
+
+		logger.Infof("Successfully identified movie %s using %s", m.Name, source.Name)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("error modifying movie: %w", err)
+	}
+
+	if len(updated) > 0 && t.PostHookExecutor != nil {
+		t.PostHookExecutor.ExecutePostHooks(ctx, m.ID, plugin.MovieUpdatePost, updated.hookInput(m.ID), updated.fields())
+	}
+
+	return nil
+}
+
+func (t *MovieIdentifier) scrapeMovie(ctx context.Context, m *models.Movie) (*models.ScrapedMovie, ScraperSource) {
+	for _, source := range t.Sources {
+		// skip sources which cannot identify movies
+		if source.MovieScraper == nil {
+			continue
+		}
+
+		scraped, err := source.MovieScraper.ScrapeMovie(ctx, m)
+		if err != nil {
+			logger.Errorf("error scraping movie from %s: %v", source.Name, err)
+			continue
+		}
+
+		if scraped != nil {
+			return scraped, source
+		}
+	}
+
+	return nil, ScraperSource{}
+}
+
+func (t *MovieIdentifier) getMoviePartial(ctx context.Context, m *models.Movie, scraped *models.ScrapedMovie, source ScraperSource, options []MetadataOptions, updated updatedFields) (models.MoviePartial, error) {
+	fieldOptions := getFieldOptions(options)
+	partial := models.NewMoviePartial()
+
+	partial.Aliases = getOptionalString(fieldOptions, "aliases", m.Aliases, scraped.Aliases)
+	updated.add("aliases", partial.Aliases.Set, partial.Aliases.Value)
+	partial.Date = getOptionalDate(fieldOptions, "date", m.Date, scraped.Date)
+	updated.add("date", partial.Date.Set, partial.Date.Value.String())
+	partial.Director = getOptionalString(fieldOptions, "director", m.Director, scraped.Director)
+	updated.add("director", partial.Director.Set, partial.Director.Value)
+	partial.Synopsis = getOptionalString(fieldOptions, "synopsis", m.Synopsis, scraped.Synopsis)
+	updated.add("synopsis", partial.Synopsis.Set, partial.Synopsis.Value)
+	partial.URL = getOptionalString(fieldOptions, "url", m.URL, scraped.URL)
+	updated.add("url", partial.URL.Set, partial.URL.Value)
+
+	if duration, ok := parseDuration(scraped.Duration); ok {
+		d := strconv.Itoa(duration)
+		partial.Duration = getOptionalInt(fieldOptions, "duration", m.Duration, &d)
+		updated.add("duration", partial.Duration.Set, partial.Duration.Value)
+	}
+
+	studioID, err := getStudioID(ctx, t.StudioCreator, source.RemoteSite, m.StudioID, scraped.Studio, fieldOptions["studio"])
+	if err != nil {
+		return partial, fmt.Errorf("error getting studio: %w", err)
+	}
+	if studioID != nil {
+		partial.StudioID = models.NewOptionalInt(*studioID)
+		updated.add("studio_id", true, strconv.Itoa(*studioID))
+	}
+
+	return partial, nil
+}
+
+// parseDuration parses a scraped duration in seconds, or in the form
+// [[hh:]mm:]ss.
+func parseDuration(v *string) (int, bool) {
+	if v == nil || *v == "" {
+		return 0, false
+	}
+
+	ret := 0
+	for _, p := range strings.Split(strings.TrimSpace(*v), ":") {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return 0, false
+		}
+		ret = ret*60 + n
+	}
+
+	return ret, true
+}
+
+func (t *MovieIdentifier) image(ctx context.Context, movieID int, scraped *string, getExisting func(ctx context.Context, movieID int) ([]byte, error)) ([]byte, error) {
+	if scraped == nil {
+		return nil, nil
+	}
+
+	existing, err := getExisting(ctx, movieID)
+	if err != nil {
+		logger.Errorf("Error getting movie image: %v", err)
+	}
+
+	data, err := utils.ProcessImageInput(ctx, *scraped)
+	if err != nil {
+		return nil, fmt.Errorf("error processing image input: %w", err)
+	}
+
+	// only return if different
+	if !bytes.Equal(existing, data) {
+		return data, nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,28 @@
+package identify
+
+import "testing"
+
+func Test_parseDuration(t *testing.T) {
+	tests := []struct {
+		v      string
+		want   int
+		wantOK bool
+	}{
+		{"", 0, false},
+		{"90", 90, true},
+		{"1:30", 90, true},
+		{"1:02:03", 3723, true},
+		{" 5:00 ", 300, true},
+		{"1h30m", 0, false},
+		{"-5", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.v, func(t *testing.T) {
+			v := tt.v
+			got, ok := parseDuration(&v)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("parseDuration(%q) = %v, %v; want %v, %v", tt.v, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 	"strconv"
 
+	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/scraper"
 )
 
@@ -15,14 +16,35 @@ type Source struct {
 }
 
 type Options struct {
-	// An ordered list of sources to identify items with. Only the first source that finds a match is used.
+	// An ordered list of sources to identify items with. Only the first source that finds a match is used,
+	// unless mergeSources is true.
 	Sources []*Source `json:"sources"`
+	// If true, all sources are queried and their results are merged per field
+	MergeSources *bool `json:"mergeSources"`
+	// The sources each field is taken from when merging, in priority order.
+	// Fields not listed are taken from all sources in order.
+	FieldSourcePriority []*FieldSourcePriority `json:"fieldSourcePriority"`
 	// Options defined here override the configured defaults
 	Options *MetadataOptions `json:"options"`
+	// Scene results with a confidence (0-1) below this value are queued for
+	// review instead of being applied. If nil, all results are applied.
+	ConfidenceThreshold *float64 `json:"confidenceThreshold"`
 	// scene ids to identify
 	SceneIDs []string `json:"sceneIDs"`
 	// paths of scenes to identify - ignored if scene ids are set
 	Paths []string `json:"paths"`
+	// gallery ids to identify
+	GalleryIDs []string `json:"galleryIDs"`
+	// filter of galleries to identify - ignored if gallery ids are set
+	GalleryFilter *models.GalleryFilterType `json:"galleryFilter"`
+	// performer ids to identify
+	PerformerIDs []string `json:"performerIDs"`
+	// filter of performers to identify - ignored if performer ids are set
+	PerformerFilter *models.PerformerFilterType `json:"performerFilter"`
+	// movie ids to identify
+	MovieIDs []string `json:"movieIDs"`
+	// filter of movies to identify - ignored if movie ids are set
+	MovieFilter *models.MovieFilterType `json:"movieFilter"`
 }
 
 type MetadataOptions struct {
@@ -42,6 +64,44 @@ type FieldOptions struct {
 	CreateMissing *bool `json:"createMissing"`
 }
 
+// GetFieldOptions returns the options of the given field, or nil if the
+// field has no options.
+func (o MetadataOptions) GetFieldOptions(field string) *FieldOptions {
+	for _, f := range o.FieldOptions {
+		if f.Field == field {
+			return f
+		}
+	}
+
+	return nil
+}
+
+// GetStrategy returns the field strategy, defaulting to MERGE if unset.
+func (o *FieldOptions) GetStrategy() FieldStrategy {
+	if o == nil || !o.Strategy.IsValid() {
+		return FieldStrategyMerge
+	}
+
+	return o.Strategy
+}
+
+// ShouldSetSingleValue returns true if a single-value field should be set,
+// given whether it already has a value.
+func (o *FieldOptions) ShouldSetSingleValue(hasExistingValue bool) bool {
+	return shouldSetSingleValueField(o, hasExistingValue)
+}
+
+// ShouldCreateMissing returns true if missing objects should be created.
+func (o *FieldOptions) ShouldCreateMissing() bool {
+	return o != nil && o.CreateMissing != nil && *o.CreateMissing
+}
+
+type FieldSourcePriority struct {
+	Field string `json:"field"`
+	// Sources the field is taken from, in priority order. Each must be one of the identify sources.
+	Sources []*scraper.Source `json:"sources"`
+}
+
 type FieldStrategy string
 
 const (
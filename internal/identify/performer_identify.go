@@ -0,0 +1,236 @@
+package identify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/plugin"
+	"github.com/stashapp/stash/pkg/sliceutil/intslice"
+	"github.com/stashapp/stash/pkg/txn"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+type PerformerReaderUpdater interface {
+	UpdatePartial(ctx context.Context, id int, updatedPerformer models.PerformerPartial) (*models.Performer, error)
+	GetImage(ctx context.Context, performerID int) ([]byte, error)
+	UpdateImage(ctx context.Context, performerID int, image []byte) error
+	models.AliasLoader
+	models.TagIDLoader
+	models.StashIDLoader
+}
+
+// PerformerIdentifier identifies performers using the first source which
+// finds a match. The performer name is never changed.
+type PerformerIdentifier struct {
+	PerformerReaderUpdater PerformerReaderUpdater
+	TagCreator             TagCreator
+
+	DefaultOptions   *MetadataOptions
+	Sources          []ScraperSource
+	PostHookExecutor PostHookExecutor
+}
+
+func (t *PerformerIdentifier) Identify(ctx context.Context, txnManager txn.Manager, p *models.Performer) error {
+	scraped, source := t.scrapePerformer(ctx, p)
+	if scraped == nil {
+		logger.Debugf("Unable to identify performer %s", p.Name)
+		return nil
+	}
+
+	updated := updatedFields{}
+	if err := txn.WithTxn(ctx, txnManager, func(ctx context.Context) error {
+		if err := p.LoadAliases(ctx, t.PerformerReaderUpdater); err != nil {
+			return err
+		}
+		if err := p.LoadTagIDs(ctx, t.PerformerReaderUpdater); err != nil {
+			return err
+		}
+		if err := p.LoadStashIDs(ctx, t.PerformerReaderUpdater); err != nil {
+			return err
+		}
+
+		options := getSourceOptions(source, t.DefaultOptions)
+		partial, err := t.getPerformerPartial(ctx, p, scraped, source, options, updated)
+		if err != nil {
+			return err
+		}
+
+		var image []byte
+		if getSetCoverImage(options) {
+			image, err = t.image(ctx, p, scraped)
+			if err != nil {
+				return err
+			}
+			if image != nil {
+				updated.add("image", true, *scraped.Image)
+			}
+		}
+
+		// don't update anything if nothing was set
+		if len(updated) == 0 {
+			logger.Debugf("Nothing to set for performer %s", p.Name)
+			return nil
+		}
+
+		if _, err := t.PerformerReaderUpdater.UpdatePartial(ctx, p.ID, partial); err != nil {
+			return fmt.Errorf("error updating performer: %w", err)
+		}
+
+		if image != nil {
+			if err := t.PerformerReaderUpdater.UpdateImage(ctx, p.ID, image); err != nil {
+				return fmt.Errorf("error updating performer image: %w", err)
+			}
+		}
+
+		logger.Infof("Successfully identified performer %s using %s", p.Name, source.Name)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("error modifying performer: %w", err)
+	}
+
+	if len(updated) > 0 && t.PostHookExecutor != nil {
+		t.PostHookExecutor.ExecutePostHooks(ctx, p.ID, plugin.PerformerUpdatePost, updated.hookInput(p.ID), updated.fields())
+	}
+
+	return nil
+}
+
+func (t *PerformerIdentifier) scrapePerformer(ctx context.Context, p *models.Performer) (*models.ScrapedPerformer, ScraperSource) {
+	for _, source := range t.Sources {
+		// skip sources which cannot identify performers
+		if source.PerformerScraper == nil {
+			continue
+		}
+
+		scraped, err := source.PerformerScraper.ScrapePerformer(ctx, p)
+		if err != nil {
+			logger.Errorf("error scraping performer from %s: %v", source.Name, err)
+			continue
+		}
+
+		if scraped != nil {
+			return scraped, source
+		}
+	}
+
+	return nil, ScraperSource{}
+}
+
+// scrapedEnum converts a scraped value such as "Transgender Female" to
+// the enum form.
+func scrapedEnum(v *string) *string {
+	if v == nil {
+		return nil
+	}
+
+	ret := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(*v), " ", "_"))
+	return &ret
+}
+
+func (t *PerformerIdentifier) getPerformerPartial(ctx context.Context, p *models.Performer, scraped *models.ScrapedPerformer, source ScraperSource, options []MetadataOptions, updated updatedFields) (models.PerformerPartial, error) {
+	fieldOptions := getFieldOptions(options)
+	partial := models.NewPerformerPartial()
+
+	setString := func(field string, dest *models.OptionalString, existing string, scraped *string) {
+		*dest = getOptionalString(fieldOptions, field, existing, scraped)
+		updated.add(field, dest.Set, dest.Value)
+	}
+
+	setString("disambiguation", &partial.Disambiguation, p.Disambiguation, scraped.Disambiguation)
+	setString("url", &partial.URL, p.URL, scraped.URL)
+	setString("twitter", &partial.Twitter, p.Twitter, scraped.Twitter)
+	setString("instagram", &partial.Instagram, p.Instagram, scraped.Instagram)
+	setString("ethnicity", &partial.Ethnicity, p.Ethnicity, scraped.Ethnicity)
+	setString("country", &partial.Country, p.Country, scraped.Country)
+	setString("eye_color", &partial.EyeColor, p.EyeColor, scraped.EyeColor)
+	setString("hair_color", &partial.HairColor, p.HairColor, scraped.HairColor)
+	setString("measurements", &partial.Measurements, p.Measurements, scraped.Measurements)
+	setString("fake_tits", &partial.FakeTits, p.FakeTits, scraped.FakeTits)
+	setString("career_length", &partial.CareerLength, p.CareerLength, scraped.CareerLength)
+	setString("tattoos", &partial.Tattoos, p.Tattoos, scraped.Tattoos)
+	setString("piercings", &partial.Piercings, p.Piercings, scraped.Piercings)
+	setString("details", &partial.Details, p.Details, scraped.Details)
+
+	if gender := scrapedEnum(scraped.Gender); gender != nil && models.GenderEnum(*gender).IsValid() {
+		existing := ""
+		if p.Gender != nil {
+			existing = p.Gender.String()
+		}
+		setString("gender", &partial.Gender, existing, gender)
+	}
+	if circumcised := scrapedEnum(scraped.Circumcised); circumcised != nil && models.CircumisedEnum(*circumcised).IsValid() {
+		existing := ""
+		if p.Circumcised != nil {
+			existing = p.Circumcised.String()
+		}
+		setString("circumcised", &partial.Circumcised, existing, circumcised)
+	}
+
+	partial.Birthdate = getOptionalDate(fieldOptions, "birthdate", p.Birthdate, scraped.Birthdate)
+	updated.add("birthdate", partial.Birthdate.Set, partial.Birthdate.Value.String())
+	partial.DeathDate = getOptionalDate(fieldOptions, "death_date", p.DeathDate, scraped.DeathDate)
+	updated.add("death_date", partial.DeathDate.Set, partial.DeathDate.Value.String())
+	partial.Height = getOptionalInt(fieldOptions, "height", p.Height, scraped.Height)
+	updated.add("height_cm", partial.Height.Set, partial.Height.Value)
+	partial.Weight = getOptionalInt(fieldOptions, "weight", p.Weight, scraped.Weight)
+	updated.add("weight", partial.Weight.Set, partial.Weight.Value)
+	partial.PenisLength = getOptionalFloat64(fieldOptions, "penis_length", p.PenisLength, scraped.PenisLength)
+	updated.add("penis_length", partial.PenisLength.Set, partial.PenisLength.Value)
+
+	if aliases := getAliases(p.Name, p.Aliases.List(), scraped.Aliases, fieldOptions["aliases"]); aliases != nil {
+		partial.Aliases = &models.UpdateStrings{
+			Values: aliases,
+			Mode:   models.RelationshipUpdateModeSet,
+		}
+		updated.add("alias_list", true, aliases)
+	}
+
+	tagIDs, err := getTagIDs(ctx, t.TagCreator, p.TagIDs.List(), [][]*models.ScrapedTag{scraped.Tags}, fieldOptions["tags"])
+	if err != nil {
+		return partial, err
+	}
+	if tagIDs != nil {
+		partial.TagIDs = &models.UpdateIDs{
+			IDs:  tagIDs,
+			Mode: models.RelationshipUpdateModeSet,
+		}
+		updated.add("tag_ids", true, intslice.IntSliceToStringSlice(tagIDs))
+	}
+
+	if stashIDs := getStashIDs(p.StashIDs.List(), source.RemoteSite, scraped.RemoteSiteID, fieldOptions["stash_ids"]); stashIDs != nil {
+		partial.StashIDs = &models.UpdateStashIDs{
+			StashIDs: stashIDs,
+			Mode:     models.RelationshipUpdateModeSet,
+		}
+		updated.add("stash_ids", true, stashIDs)
+	}
+
+	return partial, nil
+}
+
+func (t *PerformerIdentifier) image(ctx context.Context, p *models.Performer, scraped *models.ScrapedPerformer) ([]byte, error) {
+	if scraped.Image == nil {
+		return nil, nil
+	}
+
+	existing, err := t.PerformerReaderUpdater.GetImage(ctx, p.ID)
+	if err != nil {
+		logger.Errorf("Error getting performer image: %v", err)
+	}
+
+	data, err := utils.ProcessImageInput(ctx, *scraped.Image)
+	if err != nil {
+		return nil, fmt.Errorf("error processing image input: %w", err)
+	}
+
+	// only return if different
+	if !bytes.Equal(existing, data) {
+		return data, nil
+	}
+
+	return nil, nil
+}
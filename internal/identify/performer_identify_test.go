@@ -0,0 +1,57 @@
+package identify
+
+import (
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPerformerIdentifier_getPerformerPartial(t *testing.T) {
+	var (
+		endpoint     = "endpoint"
+		remoteSiteID = "remoteSiteID"
+		gender       = "Transgender Female"
+		invalid      = "invalid"
+		height       = "170"
+		aliases      = "Alias 1, Name, alias 2"
+		country      = "scraped country"
+	)
+
+	existingCountry := "existing country"
+	p := &models.Performer{
+		ID:       1,
+		Name:     "name",
+		Country:  existingCountry,
+		Aliases:  models.NewRelatedStrings([]string{"alias 2"}),
+		TagIDs:   models.NewRelatedIDs([]int{}),
+		StashIDs: models.NewRelatedStashIDs([]models.StashID{}),
+	}
+
+	scraped := &models.ScrapedPerformer{
+		Gender:       &gender,
+		Circumcised:  &invalid,
+		Height:       &height,
+		Weight:       &invalid,
+		Aliases:      &aliases,
+		Country:      &country,
+		RemoteSiteID: &remoteSiteID,
+	}
+
+	identifier := PerformerIdentifier{}
+	updated := updatedFields{}
+	partial, err := identifier.getPerformerPartial(testCtx, p, scraped, ScraperSource{RemoteSite: endpoint}, nil, updated)
+	if err != nil {
+		t.Fatalf("getPerformerPartial() error = %v", err)
+	}
+
+	assert.Equal(t, models.NewOptionalString(models.GenderEnumTransgenderFemale.String()), partial.Gender)
+	assert.False(t, partial.Circumcised.Set)
+	assert.Equal(t, models.NewOptionalInt(170), partial.Height)
+	assert.False(t, partial.Weight.Set)
+	// country is already set and strategy defaults to merge
+	assert.False(t, partial.Country.Set)
+	assert.Equal(t, []string{"alias 2", "Alias 1"}, partial.Aliases.Values)
+	assert.Equal(t, []models.StashID{{Endpoint: endpoint, StashID: remoteSiteID}}, partial.StashIDs.StashIDs)
+	assert.Equal(t, []string{"alias_list", "gender", "height_cm", "stash_ids"}, updated.fields())
+}
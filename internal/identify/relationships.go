@@ -0,0 +1,264 @@
+package identify
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/sliceutil"
+	"github.com/stashapp/stash/pkg/sliceutil/intslice"
+	"github.com/stashapp/stash/pkg/sliceutil/stringslice"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// scrapedPerformers are the performers found by a single source.
+type scrapedPerformers struct {
+	endpoint   string
+	performers []*models.ScrapedPerformer
+}
+
+// getStudioID returns the ID of the scraped studio, creating it if needed.
+// Returns nil if the studio should not be set or is unchanged.
+func getStudioID(ctx context.Context, w StudioCreator, endpoint string, existingID *int, scraped *models.ScrapedStudio, fieldStrategy *FieldOptions) (*int, error) {
+	if scraped == nil || !shouldSetSingleValueField(fieldStrategy, existingID != nil) {
+		return nil, nil
+	}
+
+	createMissing := fieldStrategy != nil && utils.IsTrue(fieldStrategy.CreateMissing)
+
+	if scraped.StoredID != nil {
+		// existing studio, just set it
+		studioID, err := strconv.Atoi(*scraped.StoredID)
+		if err != nil {
+			return nil, fmt.Errorf("error converting studio ID %s: %w", *scraped.StoredID, err)
+		}
+
+		// only return value if different to current
+		if existingID == nil || *existingID != studioID {
+			return &studioID, nil
+		}
+	} else if createMissing {
+		return createMissingStudio(ctx, endpoint, w, scraped)
+	}
+
+	return nil, nil
+}
+
+// getPerformerIDs returns the performer IDs to set from the scraped
+// performers, creating missing performers if needed. Returns nil if the
+// performers should not be set or are unchanged.
+func getPerformerIDs(ctx context.Context, w PerformerCreator, originalPerformerIDs []int, scraped []scrapedPerformers, fieldStrategy *FieldOptions, ignoreMale bool) ([]int, error) {
+	found := false
+	for _, s := range scraped {
+		if len(s.performers) > 0 {
+			found = true
+			break
+		}
+	}
+
+	// just check if ignored
+	if !found || !shouldSetSingleValueField(fieldStrategy, false) {
+		return nil, nil
+	}
+
+	createMissing := fieldStrategy != nil && utils.IsTrue(fieldStrategy.CreateMissing)
+	strategy := FieldStrategyMerge
+	if fieldStrategy != nil {
+		strategy = fieldStrategy.Strategy
+	}
+
+	var performerIDs []int
+
+	if strategy == FieldStrategyMerge {
+		// add to existing
+		performerIDs = originalPerformerIDs
+	}
+
+	// performers found by several sources are only created once
+	created := make(map[string]int)
+
+	for _, s := range scraped {
+		for _, p := range s.performers {
+			if ignoreMale && p.Gender != nil && strings.EqualFold(*p.Gender, models.GenderEnumMale.String()) {
+				continue
+			}
+
+			var key string
+			if p.Name != nil {
+				key = strings.ToLower(*p.Name)
+			}
+			if id, found := created[key]; found && p.StoredID == nil {
+				performerIDs = intslice.IntAppendUnique(performerIDs, id)
+				continue
+			}
+
+			performerID, err := getPerformerID(ctx, s.endpoint, w, p, createMissing)
+			if err != nil {
+				return nil, err
+			}
+
+			if performerID != nil {
+				if p.StoredID == nil && key != "" {
+					created[key] = *performerID
+				}
+				performerIDs = intslice.IntAppendUnique(performerIDs, *performerID)
+			}
+		}
+	}
+
+	// don't return if nothing was added
+	if sliceutil.SliceSame(originalPerformerIDs, performerIDs) {
+		return nil, nil
+	}
+
+	return performerIDs, nil
+}
+
+// getTagIDs returns the tag IDs to set from the scraped tags, creating
+// missing tags if needed. Returns nil if the tags should not be set or are
+// unchanged.
+func getTagIDs(ctx context.Context, w TagCreator, originalTagIDs []int, scraped [][]*models.ScrapedTag, fieldStrategy *FieldOptions) ([]int, error) {
+	found := false
+	for _, s := range scraped {
+		if len(s) > 0 {
+			found = true
+			break
+		}
+	}
+
+	// just check if ignored
+	if !found || !shouldSetSingleValueField(fieldStrategy, false) {
+		return nil, nil
+	}
+
+	createMissing := fieldStrategy != nil && utils.IsTrue(fieldStrategy.CreateMissing)
+	strategy := FieldStrategyMerge
+	if fieldStrategy != nil {
+		strategy = fieldStrategy.Strategy
+	}
+
+	var tagIDs []int
+
+	if strategy == FieldStrategyMerge {
+		// add to existing
+		tagIDs = originalTagIDs
+	}
+
+	// tags found by several sources are only created once
+	created := make(map[string]int)
+
+	for _, s := range scraped {
+		for _, t := range s {
+			if t.StoredID != nil {
+				// existing tag, just add it
+				tagID, err := strconv.ParseInt(*t.StoredID, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("error converting tag ID %s: %w", *t.StoredID, err)
+				}
+
+				tagIDs = intslice.IntAppendUnique(tagIDs, int(tagID))
+			} else if createMissing {
+				key := strings.ToLower(t.Name)
+				if id, found := created[key]; found {
+					tagIDs = intslice.IntAppendUnique(tagIDs, id)
+					continue
+				}
+
+				now := time.Now()
+				newTag := models.Tag{
+					Name:      t.Name,
+					CreatedAt: now,
+					UpdatedAt: now,
+				}
+				err := w.Create(ctx, &newTag)
+				if err != nil {
+					return nil, fmt.Errorf("error creating tag: %w", err)
+				}
+
+				created[key] = newTag.ID
+				tagIDs = append(tagIDs, newTag.ID)
+			}
+		}
+	}
+
+	// don't return if nothing was added
+	if sliceutil.SliceSame(originalTagIDs, tagIDs) {
+		return nil, nil
+	}
+
+	return tagIDs, nil
+}
+
+// getStashIDs returns the stash IDs with the remote site ID set for the
+// endpoint. Returns nil if the stash IDs should not be set or are unchanged.
+func getStashIDs(originalStashIDs []models.StashID, endpoint string, remoteSiteID *string, fieldStrategy *FieldOptions) []models.StashID {
+	// just check if ignored
+	if endpoint == "" || remoteSiteID == nil || !shouldSetSingleValueField(fieldStrategy, false) {
+		return nil
+	}
+
+	strategy := FieldStrategyMerge
+	if fieldStrategy != nil {
+		strategy = fieldStrategy.Strategy
+	}
+
+	var stashIDs []models.StashID
+	if strategy == FieldStrategyMerge {
+		// make a copy so we don't modify the original
+		stashIDs = append(stashIDs, originalStashIDs...)
+	}
+
+	found := false
+	for i, stashID := range stashIDs {
+		if endpoint == stashID.Endpoint {
+			// replace the stash id
+			stashIDs[i].StashID = *remoteSiteID
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		stashIDs = append(stashIDs, models.StashID{
+			StashID:  *remoteSiteID,
+			Endpoint: endpoint,
+		})
+	}
+
+	if sliceutil.SliceSame(originalStashIDs, stashIDs) {
+		return nil
+	}
+
+	return stashIDs
+}
+
+// getAliases returns the aliases to set from the comma-separated scraped
+// aliases, or nil if unchanged. Aliases matching the name are never added.
+func getAliases(name string, original []string, scraped *string, fieldStrategy *FieldOptions) []string {
+	if scraped == nil || !shouldSetSingleValueField(fieldStrategy, false) {
+		return nil
+	}
+
+	scrapedAliases := stringslice.StrFilter(stringslice.FromString(*scraped, ","), func(s string) bool {
+		return s != "" && !strings.EqualFold(s, name)
+	})
+	if len(scrapedAliases) == 0 {
+		return nil
+	}
+
+	var aliases []string
+	if fieldStrategy == nil || fieldStrategy.Strategy != FieldStrategyOverwrite {
+		// add to existing
+		aliases = append(aliases, original...)
+	}
+	aliases = stringslice.StrAppendUniques(aliases, scrapedAliases)
+
+	if sliceutil.SliceSame(original, aliases) {
+		return nil
+	}
+
+	return aliases
+}
@@ -0,0 +1,221 @@
+package identify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scraper"
+	"github.com/stashapp/stash/pkg/txn"
+)
+
+type ReviewCreator interface {
+	Create(ctx context.Context, newReview *models.IdentifyReview) error
+	DestroyBySceneID(ctx context.Context, sceneID int) error
+}
+
+// ReviewData is stored with a pending review. It contains everything needed
+// to apply the result if the review is accepted.
+type ReviewData struct {
+	Scores  []*ConfidenceScore `json:"scores"`
+	Results []*ReviewResult    `json:"results"`
+	// True if the results of all sources were merged
+	Merged              bool             `json:"merged"`
+	FieldSourcePriority map[string][]int `json:"field_source_priority,omitempty"`
+	DefaultOptions      *MetadataOptions `json:"default_options,omitempty"`
+}
+
+// ReviewResult is the result of a single source.
+type ReviewResult struct {
+	Source     string                `json:"source"`
+	RemoteSite string                `json:"remote_site,omitempty"`
+	Index      int                   `json:"index"`
+	Options    *MetadataOptions      `json:"options,omitempty"`
+	Scene      *scraper.ScrapedScene `json:"scene"`
+}
+
+// DecodeReviewData decodes the data stored with a pending review.
+func DecodeReviewData(data string) (*ReviewData, error) {
+	var ret ReviewData
+	if err := json.Unmarshal([]byte(data), &ret); err != nil {
+		return nil, fmt.Errorf("decoding review data: %w", err)
+	}
+
+	if len(ret.Results) == 0 {
+		return nil, fmt.Errorf("review data has no results")
+	}
+
+	return &ret, nil
+}
+
+func newReviewData(result *scrapeResult, scores []*ConfidenceScore, defaults *MetadataOptions) *ReviewData {
+	newResult := func(r *scrapeResult) *ReviewResult {
+		return &ReviewResult{
+			Source:     r.source.Name,
+			RemoteSite: r.source.RemoteSite,
+			Index:      r.index,
+			Options:    r.source.Options,
+			Scene:      r.result,
+		}
+	}
+
+	ret := &ReviewData{
+		Scores:         scores,
+		DefaultOptions: defaults,
+	}
+
+	if result.merged != nil {
+		ret.Merged = true
+		ret.FieldSourcePriority = result.priority
+		for _, r := range result.merged {
+			ret.Results = append(ret.Results, newResult(r))
+		}
+	} else {
+		ret.Results = []*ReviewResult{newResult(result)}
+	}
+
+	return ret
+}
+
+func (d *ReviewData) scrapeResult() *scrapeResult {
+	var results []*scrapeResult
+	for _, r := range d.Results {
+		results = append(results, &scrapeResult{
+			result: r.Scene,
+			source: ScraperSource{
+				Name:       r.Source,
+				Options:    r.Options,
+				RemoteSite: r.RemoteSite,
+			},
+			index: r.Index,
+		})
+	}
+
+	if !d.Merged {
+		return results[0]
+	}
+
+	return &scrapeResult{
+		result:   results[0].result,
+		source:   results[0].source,
+		index:    results[0].index,
+		merged:   results,
+		priority: d.FieldSourcePriority,
+	}
+}
+
+// Scene returns the scraped scene which is applied if the review is
+// accepted. Multi-value fields of merged results are combined from all
+// sources.
+func (d *ReviewData) Scene() *scraper.ScrapedScene {
+	r := d.scrapeResult()
+	if r.merged == nil {
+		return r.result
+	}
+
+	ret := r.mergedScene()
+	ret.Studio = nil
+	if p := r.fieldProvider("studio"); p != nil {
+		ret.Studio = p.result.Studio
+	}
+	ret.Image = nil
+	if p := r.fieldProvider("cover_image"); p != nil {
+		ret.Image = p.result.Image
+	}
+	ret.Performers = nil
+	for _, rr := range r.fieldResults("performers") {
+		ret.Performers = append(ret.Performers, rr.result.Performers...)
+	}
+	ret.Tags = nil
+	for _, rr := range r.fieldResults("tags") {
+		ret.Tags = append(ret.Tags, rr.result.Tags...)
+	}
+
+	return ret
+}
+
+// Sources returns the names of the sources of the result.
+func (d *ReviewData) Sources() string {
+	var names []string
+	for _, r := range d.Results {
+		names = append(names, r.Source)
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// queueForReview scores the result and queues it for review if its
+// confidence is below the threshold. Returns true if the result was queued.
+// Any existing pending reviews of the scene are replaced.
+func (t *SceneIdentifier) queueForReview(ctx context.Context, txnManager txn.Manager, s *models.Scene, result *scrapeResult) (bool, error) {
+	queued := false
+	if err := txn.WithTxn(ctx, txnManager, func(ctx context.Context) error {
+		if err := s.LoadFiles(ctx, t.SceneReaderUpdater); err != nil {
+			return err
+		}
+		if err := s.LoadPerformerIDs(ctx, t.SceneReaderUpdater); err != nil {
+			return err
+		}
+
+		data := newReviewData(result, nil, t.DefaultOptions)
+
+		var confidence float64
+		confidence, data.Scores = sceneConfidence(s, data.Scene())
+
+		if err := t.ReviewCreator.DestroyBySceneID(ctx, s.ID); err != nil {
+			return fmt.Errorf("removing existing reviews: %w", err)
+		}
+
+		if confidence >= t.ConfidenceThreshold {
+			logger.Debugf("Identify result for %s has confidence %.2f", s.Path, confidence)
+			return nil
+		}
+
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("encoding review data: %w", err)
+		}
+
+		if err := t.ReviewCreator.Create(ctx, &models.IdentifyReview{
+			SceneID:    s.ID,
+			Source:     data.Sources(),
+			Confidence: confidence,
+			Data:       string(encoded),
+			CreatedAt:  time.Now(),
+		}); err != nil {
+			return fmt.Errorf("creating review: %w", err)
+		}
+
+		queued = true
+		logger.Infof("Queued identify result for %s for review: confidence %.2f is below threshold %.2f", s.Path, confidence, t.ConfidenceThreshold)
+		return nil
+	}); err != nil {
+		return false, err
+	}
+
+	return queued, nil
+}
+
+// ApplyReview applies the result of a pending review to the scene,
+// regardless of its confidence. The options stored with the review are used
+// in place of the identifier's options.
+func (t *SceneIdentifier) ApplyReview(ctx context.Context, txnManager txn.Manager, s *models.Scene, review *models.IdentifyReview) error {
+	data, err := DecodeReviewData(review.Data)
+	if err != nil {
+		return err
+	}
+
+	t.DefaultOptions = data.DefaultOptions
+	t.MergeSources = data.Merged
+	t.FieldSourcePriority = data.FieldSourcePriority
+
+	if err := t.modifyScene(ctx, txnManager, s, data.scrapeResult()); err != nil {
+		return fmt.Errorf("error modifying scene: %w", err)
+	}
+
+	return nil
+}
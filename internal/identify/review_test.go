@@ -0,0 +1,128 @@
+package identify
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stashapp/stash/pkg/scraper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockReviewCreator struct {
+	created   []*models.IdentifyReview
+	destroyed []int
+}
+
+func (c *mockReviewCreator) Create(ctx context.Context, newReview *models.IdentifyReview) error {
+	c.created = append(c.created, newReview)
+	return nil
+}
+
+func (c *mockReviewCreator) DestroyBySceneID(ctx context.Context, sceneID int) error {
+	c.destroyed = append(c.destroyed, sceneID)
+	return nil
+}
+
+func TestSceneIdentifier_Identify_review(t *testing.T) {
+	const (
+		matchingID = iota + 1
+		differentID
+	)
+
+	var (
+		matchingTitle  = "A Scene Title"
+		differentTitle = "Something Else"
+	)
+
+	sources := []ScraperSource{
+		{
+			Name: "source",
+			Scraper: mockSceneScraper{
+				results: map[int]*scraper.ScrapedScene{
+					matchingID:  {Title: &matchingTitle},
+					differentID: {Title: &differentTitle},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		sceneID     int
+		wantQueued  bool
+		wantUpdated bool
+	}{
+		{"confident result is applied", matchingID, false, true},
+		{"unconfident result is queued", differentID, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSceneReaderWriter := &mocks.SceneReaderWriter{}
+			mockSceneReaderWriter.On("UpdatePartial", mock.Anything, tt.sceneID, mock.Anything).Return(nil, nil)
+
+			reviews := &mockReviewCreator{}
+			identifier := SceneIdentifier{
+				SceneReaderUpdater:          mockSceneReaderWriter,
+				DefaultOptions:              &MetadataOptions{},
+				Sources:                     sources,
+				SceneUpdatePostHookExecutor: mockHookExecutor{},
+				ConfidenceThreshold:         0.5,
+				ReviewCreator:               reviews,
+			}
+
+			scene := &models.Scene{
+				ID:           tt.sceneID,
+				Path:         "/stash/A.Scene.Title.mp4",
+				Files:        models.NewRelatedVideoFiles([]*file.VideoFile{}),
+				PerformerIDs: models.NewRelatedIDs([]int{}),
+				TagIDs:       models.NewRelatedIDs([]int{}),
+				StashIDs:     models.NewRelatedStashIDs([]models.StashID{}),
+			}
+
+			if err := identifier.Identify(testCtx, &mocks.TxnManager{}, scene); err != nil {
+				t.Errorf("SceneIdentifier.Identify() error = %v", err)
+				return
+			}
+
+			assert.Equal(t, []int{tt.sceneID}, reviews.destroyed)
+			assert.Equal(t, tt.wantQueued, len(reviews.created) == 1)
+			if tt.wantUpdated {
+				mockSceneReaderWriter.AssertCalled(t, "UpdatePartial", mock.Anything, tt.sceneID, mock.Anything)
+			} else {
+				mockSceneReaderWriter.AssertNotCalled(t, "UpdatePartial", mock.Anything, tt.sceneID, mock.Anything)
+			}
+
+			if !tt.wantQueued {
+				return
+			}
+
+			review := reviews.created[0]
+			assert.Equal(t, "source", review.Source)
+			assert.Less(t, review.Confidence, 0.5)
+
+			data, err := DecodeReviewData(review.Data)
+			if err != nil {
+				t.Errorf("DecodeReviewData() error = %v", err)
+				return
+			}
+			assert.Equal(t, differentTitle, *data.Scene().Title)
+
+			// accepting the review applies the stored result
+			applier := SceneIdentifier{
+				SceneReaderUpdater:          mockSceneReaderWriter,
+				SceneUpdatePostHookExecutor: mockHookExecutor{},
+			}
+			if err := applier.ApplyReview(testCtx, &mocks.TxnManager{}, scene, review); err != nil {
+				t.Errorf("SceneIdentifier.ApplyReview() error = %v", err)
+				return
+			}
+			mockSceneReaderWriter.AssertCalled(t, "UpdatePartial", mock.Anything, tt.sceneID, mock.MatchedBy(func(p models.ScenePartial) bool {
+				return p.Title.Value == differentTitle
+			}))
+		})
+	}
+}
@@ -4,21 +4,18 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"strconv"
-	"strings"
-	"time"
 
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/scene"
 	"github.com/stashapp/stash/pkg/sliceutil"
-	"github.com/stashapp/stash/pkg/sliceutil/intslice"
 	"github.com/stashapp/stash/pkg/utils"
 )
 
 type SceneReaderUpdater interface {
 	GetCover(ctx context.Context, sceneID int) ([]byte, error)
 	scene.Updater
+	models.VideoFileLoader
 	models.PerformerIDLoader
 	models.TagIDLoader
 	models.StashIDLoader
@@ -39,149 +36,41 @@ type sceneRelationships struct {
 }
 
 func (g sceneRelationships) studio(ctx context.Context) (*int, error) {
-	existingID := g.scene.StudioID
-	fieldStrategy := g.fieldOptions["studio"]
-	createMissing := fieldStrategy != nil && utils.IsTrue(fieldStrategy.CreateMissing)
-
-	scraped := g.result.result.Studio
-	endpoint := g.result.source.RemoteSite
-
-	if scraped == nil || !shouldSetSingleValueField(fieldStrategy, existingID != nil) {
+	provider := g.result.fieldProvider("studio")
+	if provider == nil {
 		return nil, nil
 	}
 
-	if scraped.StoredID != nil {
-		// existing studio, just set it
-		studioID, err := strconv.Atoi(*scraped.StoredID)
-		if err != nil {
-			return nil, fmt.Errorf("error converting studio ID %s: %w", *scraped.StoredID, err)
-		}
-
-		// only return value if different to current
-		if existingID == nil || *existingID != studioID {
-			return &studioID, nil
-		}
-	} else if createMissing {
-		return createMissingStudio(ctx, endpoint, g.studioCreator, scraped)
-	}
-
-	return nil, nil
+	return getStudioID(ctx, g.studioCreator, provider.source.RemoteSite, g.scene.StudioID, provider.result.Studio, g.fieldOptions["studio"])
 }
 
 func (g sceneRelationships) performers(ctx context.Context, ignoreMale bool) ([]int, error) {
-	fieldStrategy := g.fieldOptions["performers"]
-	scraped := g.result.result.Performers
-
-	// just check if ignored
-	if len(scraped) == 0 || !shouldSetSingleValueField(fieldStrategy, false) {
-		return nil, nil
-	}
-
-	createMissing := fieldStrategy != nil && utils.IsTrue(fieldStrategy.CreateMissing)
-	strategy := FieldStrategyMerge
-	if fieldStrategy != nil {
-		strategy = fieldStrategy.Strategy
-	}
-
-	endpoint := g.result.source.RemoteSite
-
-	var performerIDs []int
-	originalPerformerIDs := g.scene.PerformerIDs.List()
-
-	if strategy == FieldStrategyMerge {
-		// add to existing
-		performerIDs = originalPerformerIDs
+	var scraped []scrapedPerformers
+	for _, r := range g.result.fieldResults("performers") {
+		scraped = append(scraped, scrapedPerformers{
+			endpoint:   r.source.RemoteSite,
+			performers: r.result.Performers,
+		})
 	}
 
-	for _, p := range scraped {
-		if ignoreMale && p.Gender != nil && strings.EqualFold(*p.Gender, models.GenderEnumMale.String()) {
-			continue
-		}
-
-		performerID, err := getPerformerID(ctx, endpoint, g.performerCreator, p, createMissing)
-		if err != nil {
-			return nil, err
-		}
-
-		if performerID != nil {
-			performerIDs = intslice.IntAppendUnique(performerIDs, *performerID)
-		}
-	}
-
-	// don't return if nothing was added
-	if sliceutil.SliceSame(originalPerformerIDs, performerIDs) {
-		return nil, nil
-	}
-
-	return performerIDs, nil
+	return getPerformerIDs(ctx, g.performerCreator, g.scene.PerformerIDs.List(), scraped, g.fieldOptions["performers"], ignoreMale)
 }
 
 func (g sceneRelationships) tags(ctx context.Context) ([]int, error) {
-	fieldStrategy := g.fieldOptions["tags"]
-	scraped := g.result.result.Tags
-	target := g.scene
-
-	// just check if ignored
-	if len(scraped) == 0 || !shouldSetSingleValueField(fieldStrategy, false) {
-		return nil, nil
+	var scraped [][]*models.ScrapedTag
+	for _, r := range g.result.fieldResults("tags") {
+		scraped = append(scraped, r.result.Tags)
 	}
 
-	createMissing := fieldStrategy != nil && utils.IsTrue(fieldStrategy.CreateMissing)
-	strategy := FieldStrategyMerge
-	if fieldStrategy != nil {
-		strategy = fieldStrategy.Strategy
-	}
-
-	var tagIDs []int
-	originalTagIDs := target.TagIDs.List()
-
-	if strategy == FieldStrategyMerge {
-		// add to existing
-		tagIDs = originalTagIDs
-	}
-
-	for _, t := range scraped {
-		if t.StoredID != nil {
-			// existing tag, just add it
-			tagID, err := strconv.ParseInt(*t.StoredID, 10, 64)
-			if err != nil {
-				return nil, fmt.Errorf("error converting tag ID %s: %w", *t.StoredID, err)
-			}
-
-			tagIDs = intslice.IntAppendUnique(tagIDs, int(tagID))
-		} else if createMissing {
-			now := time.Now()
-			newTag := models.Tag{
-				Name:      t.Name,
-				CreatedAt: now,
-				UpdatedAt: now,
-			}
-			err := g.tagCreator.Create(ctx, &newTag)
-			if err != nil {
-				return nil, fmt.Errorf("error creating tag: %w", err)
-			}
-
-			tagIDs = append(tagIDs, newTag.ID)
-		}
-	}
-
-	// don't return if nothing was added
-	if sliceutil.SliceSame(originalTagIDs, tagIDs) {
-		return nil, nil
-	}
-
-	return tagIDs, nil
+	return getTagIDs(ctx, g.tagCreator, g.scene.TagIDs.List(), scraped, g.fieldOptions["tags"])
 }
 
 func (g sceneRelationships) stashIDs(ctx context.Context) ([]models.StashID, error) {
-	remoteSiteID := g.result.result.RemoteSiteID
 	fieldStrategy := g.fieldOptions["stash_ids"]
 	target := g.scene
 
-	endpoint := g.result.source.RemoteSite
-
 	// just check if ignored
-	if remoteSiteID == nil || endpoint == "" || !shouldSetSingleValueField(fieldStrategy, false) {
+	if g.result.fieldProvider("stash_ids") == nil || !shouldSetSingleValueField(fieldStrategy, false) {
 		return nil, nil
 	}
 
@@ -199,26 +88,33 @@ func (g sceneRelationships) stashIDs(ctx context.Context) ([]models.StashID, err
 		stashIDs = append(stashIDs, originalStashIDs...)
 	}
 
-	for i, stashID := range stashIDs {
-		if endpoint == stashID.Endpoint {
-			// if stashID is the same, then don't set
-			if stashID.StashID == *remoteSiteID {
-				return nil, nil
+	for _, r := range g.result.fieldResults("stash_ids") {
+		if !hasSceneField(r, "stash_ids") {
+			continue
+		}
+
+		remoteSiteID := *r.result.RemoteSiteID
+		endpoint := r.source.RemoteSite
+
+		found := false
+		for i, stashID := range stashIDs {
+			if endpoint == stashID.Endpoint {
+				// replace the stash id
+				stashIDs[i].StashID = remoteSiteID
+				found = true
+				break
 			}
+		}
 
-			// replace the stash id and return
-			stashID.StashID = *remoteSiteID
-			stashIDs[i] = stashID
-			return stashIDs, nil
+		if !found {
+			// not found, create new entry
+			stashIDs = append(stashIDs, models.StashID{
+				StashID:  remoteSiteID,
+				Endpoint: endpoint,
+			})
 		}
 	}
 
-	// not found, create new entry
-	stashIDs = append(stashIDs, models.StashID{
-		StashID:  *remoteSiteID,
-		Endpoint: endpoint,
-	})
-
 	if sliceutil.SliceSame(originalStashIDs, stashIDs) {
 		return nil, nil
 	}
@@ -227,12 +123,13 @@ func (g sceneRelationships) stashIDs(ctx context.Context) ([]models.StashID, err
 }
 
 func (g sceneRelationships) cover(ctx context.Context) ([]byte, error) {
-	scraped := g.result.result.Image
-
-	if scraped == nil {
+	provider := g.result.fieldProvider("cover_image")
+	if provider == nil {
 		return nil, nil
 	}
 
+	scraped := provider.result.Image
+
 	// always overwrite if present
 	existingCover, err := g.sceneReader.GetCover(ctx, g.scene.ID)
 	if err != nil {
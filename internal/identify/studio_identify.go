@@ -0,0 +1,187 @@
+package identify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/plugin"
+	"github.com/stashapp/stash/pkg/txn"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+type StudioReaderUpdater interface {
+	UpdatePartial(ctx context.Context, id int, updatedStudio models.StudioPartial) (*models.Studio, error)
+	GetImage(ctx context.Context, studioID int) ([]byte, error)
+	UpdateImage(ctx context.Context, studioID int, image []byte) error
+	GetAliases(ctx context.Context, studioID int) ([]string, error)
+	UpdateAliases(ctx context.Context, studioID int, aliases []string) error
+	GetStashIDs(ctx context.Context, studioID int) ([]models.StashID, error)
+	UpdateStashIDs(ctx context.Context, studioID int, stashIDs []models.StashID) error
+}
+
+// StudioIdentifier identifies studios using the first source which finds a
+// match. The studio name is never changed.
+type StudioIdentifier struct {
+	StudioReaderUpdater StudioReaderUpdater
+	StudioCreator       StudioCreator
+
+	DefaultOptions   *MetadataOptions
+	Sources          []ScraperSource
+	PostHookExecutor PostHookExecutor
+}
+
+func (t *StudioIdentifier) Identify(ctx context.Context, txnManager txn.Manager, s *models.Studio) error {
+	scraped, source := t.scrapeStudio(ctx, s)
+	if scraped == nil {
+		logger.Debugf("Unable to identify studio %s", s.Name)
+		return nil
+	}
+
+	updated := updatedFields{}
+	if err := txn.WithTxn(ctx, txnManager, func(ctx context.Context) error {
+		options := getSourceOptions(source, t.DefaultOptions)
+		fieldOptions := getFieldOptions(options)
+
+		partial, err := t.getStudioPartial(ctx, s, scraped, source, fieldOptions, updated)
+		if err != nil {
+			return err
+		}
+
+		originalAliases, err := t.StudioReaderUpdater.GetAliases(ctx, s.ID)
+		if err != nil {
+			return fmt.Errorf("error getting studio aliases: %w", err)
+		}
+		aliases := getAliases(s.Name, originalAliases, scraped.Aliases, fieldOptions["aliases"])
+		if aliases != nil {
+			updated.add("aliases", true, aliases)
+		}
+
+		originalStashIDs, err := t.StudioReaderUpdater.GetStashIDs(ctx, s.ID)
+		if err != nil {
+			return fmt.Errorf("error getting studio stash ids: %w", err)
+		}
+		stashIDs := getStashIDs(originalStashIDs, source.RemoteSite, scraped.RemoteSiteID, fieldOptions["stash_ids"])
+		if stashIDs != nil {
+			updated.add("stash_ids", true, stashIDs)
+		}
+
+		var image []byte
+		if getSetCoverImage(options) {
+			image, err = t.image(ctx, s, scraped)
+			if err != nil {
+				return err
+			}
+			if image != nil {
+				updated.add("image", true, *scraped.Image)
+			}
+		}
+
+		// don't update anything if nothing was set
+		if len(updated) == 0 {
+			logger.Debugf("Nothing to set for studio %s", s.Name)
+			return nil
+		}
+
+		if _, err := t.StudioReaderUpdater.UpdatePartial(ctx, s.ID, partial); err != nil {
+			return fmt.Errorf("error updating studio: %w", err)
+		}
+
+		if aliases != nil {
+			if err := t.StudioReaderUpdater.UpdateAliases(ctx, s.ID, aliases); err != nil {
+				return fmt.Errorf("error updating studio aliases: %w", err)
+			}
+		}
+
+		if stashIDs != nil {
+			if err := t.StudioReaderUpdater.UpdateStashIDs(ctx, s.ID, stashIDs); err != nil {
+				return fmt.Errorf("error updating studio stash ids: %w", err)
+			}
+		}
+
+		if image != nil {
+			if err := t.StudioReaderUpdater.UpdateImage(ctx, s.ID, image); err != nil {
+				return fmt.Errorf("error updating studio image: %w", err)
+			}
+		}
+
+		logger.Infof("Successfully identified studio %s using %s", s.Name, source.Name)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("error modifying studio: %w", err)
+	}
+
+	if len(updated) > 0 && t.PostHookExecutor != nil {
+		t.PostHookExecutor.ExecutePostHooks(ctx, s.ID, plugin.StudioUpdatePost, updated.hookInput(s.ID), updated.fields())
+	}
+
+	return nil
+}
+
+func (t *StudioIdentifier) scrapeStudio(ctx context.Context, s *models.Studio) (*models.ScrapedStudio, ScraperSource) {
+	for _, source := range t.Sources {
+		// skip sources which cannot identify studios
+		if source.StudioScraper == nil {
+			continue
+		}
+
+		scraped, err := source.StudioScraper.ScrapeStudio(ctx, s)
+		if err != nil {
+			logger.Errorf("error scraping studio from %s: %v", source.Name, err)
+			continue
+		}
+
+		if scraped != nil {
+			return scraped, source
+		}
+	}
+
+	return nil, ScraperSource{}
+}
+
+func (t *StudioIdentifier) getStudioPartial(ctx context.Context, s *models.Studio, scraped *models.ScrapedStudio, source ScraperSource, fieldOptions map[string]*FieldOptions, updated updatedFields) (models.StudioPartial, error) {
+	partial := models.NewStudioPartial()
+
+	partial.URL = getOptionalString(fieldOptions, "url", s.URL, scraped.URL)
+	updated.add("url", partial.URL.Set, partial.URL.Value)
+	partial.Details = getOptionalString(fieldOptions, "details", s.Details, scraped.Details)
+	updated.add("details", partial.Details.Set, partial.Details.Value)
+
+	parentID, err := getStudioID(ctx, t.StudioCreator, source.RemoteSite, s.ParentID, scraped.Parent, fieldOptions["parent_studio"])
+	if err != nil {
+		return partial, fmt.Errorf("error getting parent studio: %w", err)
+	}
+	// a studio cannot be its own parent
+	if parentID != nil && *parentID != s.ID {
+		partial.ParentID = models.NewOptionalInt(*parentID)
+		updated.add("parent_id", true, strconv.Itoa(*parentID))
+	}
+
+	return partial, nil
+}
+
+func (t *StudioIdentifier) image(ctx context.Context, s *models.Studio, scraped *models.ScrapedStudio) ([]byte, error) {
+	if scraped.Image == nil {
+		return nil, nil
+	}
+
+	existing, err := t.StudioReaderUpdater.GetImage(ctx, s.ID)
+	if err != nil {
+		logger.Errorf("Error getting studio image: %v", err)
+	}
+
+	data, err := utils.ProcessImageInput(ctx, *scraped.Image)
+	if err != nil {
+		return nil, fmt.Errorf("error processing image input: %w", err)
+	}
+
+	// only return if different
+	if !bytes.Equal(existing, data) {
+		return data, nil
+	}
+
+	return nil, nil
+}
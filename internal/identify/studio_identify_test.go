@@ -0,0 +1,94 @@
+package identify
+
+import (
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStudioIdentifier_getStudioPartial(t *testing.T) {
+	const (
+		studioID = 1
+		parentID = 2
+	)
+
+	var (
+		url      = "scraped url"
+		details  = "scraped details"
+		selfID   = "1"
+		parentSt = "2"
+	)
+
+	s := &models.Studio{
+		ID:      studioID,
+		Name:    "name",
+		Details: "existing details",
+	}
+
+	tests := []struct {
+		name       string
+		parent     *models.ScrapedStudio
+		wantParent models.OptionalInt
+		wantFields []string
+	}{
+		{
+			"set parent",
+			&models.ScrapedStudio{StoredID: &parentSt},
+			models.NewOptionalInt(parentID),
+			[]string{"parent_id", "url"},
+		},
+		{
+			"parent is self",
+			&models.ScrapedStudio{StoredID: &selfID},
+			models.OptionalInt{},
+			[]string{"url"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scraped := &models.ScrapedStudio{
+				URL:     &url,
+				Details: &details,
+				Parent:  tt.parent,
+			}
+
+			identifier := StudioIdentifier{}
+			updated := updatedFields{}
+			partial, err := identifier.getStudioPartial(testCtx, s, scraped, ScraperSource{}, nil, updated)
+			if err != nil {
+				t.Fatalf("getStudioPartial() error = %v", err)
+			}
+
+			assert.Equal(t, models.NewOptionalString(url), partial.URL)
+			// details are already set and strategy defaults to merge
+			assert.False(t, partial.Details.Set)
+			assert.Equal(t, tt.wantParent, partial.ParentID)
+			assert.Equal(t, tt.wantFields, updated.fields())
+		})
+	}
+}
+
+func Test_getAliases(t *testing.T) {
+	scraped := "Alias 1, Name, alias 2"
+	overwrite := &FieldOptions{Strategy: FieldStrategyOverwrite}
+	ignore := &FieldOptions{Strategy: FieldStrategyIgnore}
+
+	tests := []struct {
+		name          string
+		original      []string
+		fieldStrategy *FieldOptions
+		want          []string
+	}{
+		{"merge", []string{"alias 2"}, nil, []string{"alias 2", "Alias 1"}},
+		{"overwrite", []string{"other"}, overwrite, []string{"Alias 1", "alias 2"}},
+		{"ignore", []string{"other"}, ignore, nil},
+		{"unchanged", []string{"Alias 1", "alias 2"}, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getAliases("name", tt.original, &scraped, tt.fieldStrategy)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
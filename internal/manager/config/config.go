@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -121,15 +122,25 @@ const (
 	SessionStoreKey = "session_store_key"
 
 	// scraping options
-	ScrapersPath              = "scrapers_path"
-	ScraperUserAgent          = "scraper_user_agent"
-	ScraperCertCheck          = "scraper_cert_check"
-	ScraperCDPPath            = "scraper_cdp_path"
-	ScraperExcludeTagPatterns = "scraper_exclude_tag_patterns"
+	ScrapersPath                 = "scrapers_path"
+	ScraperUserAgent             = "scraper_user_agent"
+	ScraperCertCheck             = "scraper_cert_check"
+	ScraperCDPPath               = "scraper_cdp_path"
+	ScraperCDPPoolMin            = "scraper_cdp_pool_min"
+	ScraperCDPPoolMax            = "scraper_cdp_pool_max"
+	scraperCDPPoolMaxDefault     = 2
+	ScraperCDPIdleTimeout        = "scraper_cdp_idle_timeout"
+	scraperCDPIdleTimeoutDefault = 300
+	ScraperCDPBlockResources     = "scraper_cdp_block_resources"
+	ScraperExcludeTagPatterns    = "scraper_exclude_tag_patterns"
+	ScraperCredentials           = "scraper_credentials"
 
 	// stash-box options
 	StashBoxes = "stash_boxes"
 
+	// remote stash instances to sync scene metadata with
+	StashServers = "stash_servers"
+
 	PythonPath = "python_path"
 
 	// plugin options
@@ -213,6 +224,8 @@ const (
 	DLNADefaultIPWhitelist = "dlna.default_whitelist"
 	DLNAInterfaces         = "dlna.interfaces"
 
+	DLNAActivityExcludedIPs = "dlna.activity_excluded_ips"
+
 	DLNAVideoSortOrder        = "dlna.video_sort_order"
 	dlnaVideoSortOrderDefault = "title"
 
@@ -273,6 +286,15 @@ func (s *StashBoxError) Error() string {
 	return "Stash-box: " + s.msg
 }
 
+// StashServerError represents configuration errors of remote stash servers
+type StashServerError struct {
+	msg string
+}
+
+func (s *StashServerError) Error() string {
+	return "Stash server: " + s.msg
+}
+
 func IsOfficialBuild() bool {
 	return officialBuild == "true"
 }
@@ -695,6 +717,48 @@ func (i *Instance) GetScraperCDPPath() string {
 	return i.getString(ScraperCDPPath)
 }
 
+// GetScraperCDPPoolMin returns the number of browsers kept running for CDP
+// scrapers, even when idle.
+func (i *Instance) GetScraperCDPPoolMin() int {
+	return i.getInt(ScraperCDPPoolMin)
+}
+
+// GetScraperCDPPoolMax returns the maximum number of browsers run at the same
+// time for CDP scrapers.
+func (i *Instance) GetScraperCDPPoolMax() int {
+	i.RLock()
+	defer i.RUnlock()
+
+	ret := scraperCDPPoolMaxDefault
+	v := i.viper(ScraperCDPPoolMax)
+	if v.IsSet(ScraperCDPPoolMax) {
+		ret = v.GetInt(ScraperCDPPoolMax)
+	}
+
+	return ret
+}
+
+// GetScraperCDPIdleTimeout returns the time in seconds after which idle
+// browsers used for CDP scrapers are stopped.
+func (i *Instance) GetScraperCDPIdleTimeout() int {
+	i.RLock()
+	defer i.RUnlock()
+
+	ret := scraperCDPIdleTimeoutDefault
+	v := i.viper(ScraperCDPIdleTimeout)
+	if v.IsSet(ScraperCDPIdleTimeout) {
+		ret = v.GetInt(ScraperCDPIdleTimeout)
+	}
+
+	return ret
+}
+
+// GetScraperCDPBlockResources returns true if images, media and fonts
+// should not be loaded by CDP scrapers.
+func (i *Instance) GetScraperCDPBlockResources() bool {
+	return i.getBoolDefault(ScraperCDPBlockResources, true)
+}
+
 // GetScraperCertCheck returns true if the scraper should check for insecure
 // certificates when fetching an image or a page.
 func (i *Instance) GetScraperCertCheck() bool {
@@ -705,6 +769,29 @@ func (i *Instance) GetScraperExcludeTagPatterns() []string {
 	return i.getStringSlice(ScraperExcludeTagPatterns)
 }
 
+// GetAllScraperCredentials returns the site credentials stored for all
+// scrapers.
+func (i *Instance) GetAllScraperCredentials() []*models.ScraperCredentials {
+	var ret []*models.ScraperCredentials
+	if err := i.unmarshalKey(ScraperCredentials, &ret); err != nil {
+		logger.Warnf("error in unmarshalkey: %v", err)
+	}
+
+	return ret
+}
+
+// GetScraperCredentials returns the site credentials stored for the scraper,
+// or nil if there are none.
+func (i *Instance) GetScraperCredentials(scraperID string) *models.ScraperCredentials {
+	for _, c := range i.GetAllScraperCredentials() {
+		if c.ScraperID == scraperID {
+			return c
+		}
+	}
+
+	return nil
+}
+
 func (i *Instance) GetStashBoxes() []*models.StashBox {
 	var boxes []*models.StashBox
 	if err := i.unmarshalKey(StashBoxes, &boxes); err != nil {
@@ -714,6 +801,21 @@ func (i *Instance) GetStashBoxes() []*models.StashBox {
 	return boxes
 }
 
+func (i *Instance) GetStashServers() []*models.StashServer {
+	var servers []*models.StashServer
+	if err := i.unmarshalKey(StashServers, &servers); err != nil {
+		logger.Warnf("error in unmarshalkey: %v", err)
+	}
+
+	for _, s := range servers {
+		if !s.SyncDirection.IsValid() {
+			s.SyncDirection = models.StashSyncDirectionPull
+		}
+	}
+
+	return servers
+}
+
 func (i *Instance) GetDefaultPluginsPath() string {
 	// default to the same directory as the config file
 	fn := filepath.Join(i.GetConfigPath(), "plugins")
@@ -951,6 +1053,37 @@ func (i *Instance) ValidateStashBoxes(boxes []*StashBoxInput) error {
 	return nil
 }
 
+type StashServerInput struct {
+	Name          string                    `json:"name"`
+	URL           string                    `json:"url"`
+	APIKey        string                    `json:"api_key"`
+	SyncInterval  int                       `json:"sync_interval"`
+	SyncDirection models.StashSyncDirection `json:"sync_direction"`
+}
+
+func (i *Instance) ValidateStashServers(servers []*StashServerInput) error {
+	for _, server := range servers {
+		if server.Name == "" {
+			return &StashServerError{msg: "name cannot be blank"}
+		}
+
+		u, err := url.Parse(server.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return &StashServerError{msg: "url is invalid"}
+		}
+
+		if server.SyncInterval < 0 {
+			return &StashServerError{msg: "sync interval cannot be negative"}
+		}
+
+		if server.SyncInterval > 0 && !server.SyncDirection.IsValid() {
+			return &StashServerError{msg: "sync direction is invalid"}
+		}
+	}
+
+	return nil
+}
+
 // GetMaxSessionAge gets the maximum age for session cookies, in seconds.
 // Session cookie expiry times are refreshed every request.
 func (i *Instance) GetMaxSessionAge() int {
@@ -1374,6 +1507,12 @@ func (i *Instance) GetDLNADefaultIPWhitelist() []string {
 	return i.getStringSlice(DLNADefaultIPWhitelist)
 }
 
+// GetDLNAActivityExcludedIPs returns a list of IP addresses/wildcards for
+// which scene play counts, play durations and resume times are not recorded.
+func (i *Instance) GetDLNAActivityExcludedIPs() []string {
+	return i.getStringSlice(DLNAActivityExcludedIPs)
+}
+
 // GetDLNAInterfaces returns a list of interface names to expose DLNA on. If
 // empty, runs on all interfaces.
 func (i *Instance) GetDLNAInterfaces() []string {
@@ -25,6 +25,9 @@ type SpriteGenerator struct {
 	Columns         int
 	SlowSeek        bool // use alternate seek function, very slow!
 
+	// Cuts, if set, are used to avoid blank and near-uniform frames
+	Cuts *generate.SceneCuts
+
 	Overwrite bool
 
 	g *generate.Generator
@@ -107,11 +110,23 @@ func (g *SpriteGenerator) generateSpriteImage() error {
 
 		for i := 0; i < g.Info.ChunkCount; i++ {
 			time := float64(i) * stepSize
+			if g.Cuts != nil {
+				time = g.Cuts.FrameTime(time, stepSize)
+			}
 
 			img, err := g.g.SpriteScreenshot(context.TODO(), g.Info.VideoFile.Path, time)
 			if err != nil {
 				return err
 			}
+
+			// try the middle of the interval if the frame is near-uniform
+			if g.Cuts != nil && generate.IsUniformImage(img) {
+				alt := g.Cuts.FrameTime(float64(i)*stepSize+stepSize/2, stepSize/2)
+				if altImg, err := g.g.SpriteScreenshot(context.TODO(), g.Info.VideoFile.Path, alt); err == nil && !generate.IsUniformImage(altImg) {
+					img = altImg
+				}
+			}
+
 			images = append(images, img)
 		}
 	} else {
@@ -0,0 +1,110 @@
+package manager
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os/exec"
+
+	"github.com/stashapp/stash/internal/static"
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/fsutil"
+	"github.com/stashapp/stash/pkg/image"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// ImageServer serves image files and thumbnails. The primary file of the
+// image must be loaded.
+type ImageServer struct{}
+
+func (s *ImageServer) ServeThumbnail(img *models.Image, w http.ResponseWriter, r *http.Request) {
+	filepath := GetInstance().Paths.Generated.GetThumbnailPath(img.Checksum, models.DefaultGthumbWidth)
+
+	// if the thumbnail doesn't exist, encode on the fly
+	exists, _ := fsutil.FileExists(filepath)
+	if exists {
+		utils.ServeStaticFile(w, r, filepath)
+	} else {
+		const useDefault = true
+
+		f := img.Files.Primary()
+		if f == nil {
+			s.serveImage(w, r, img, useDefault)
+			return
+		}
+
+		clipPreviewOptions := image.ClipPreviewOptions{
+			InputArgs:  GetInstance().Config.GetTranscodeInputArgs(),
+			OutputArgs: GetInstance().Config.GetTranscodeOutputArgs(),
+			Preset:     GetInstance().Config.GetPreviewPreset().String(),
+		}
+
+		encoder := image.NewThumbnailEncoder(GetInstance().FFMPEG, GetInstance().FFProbe, clipPreviewOptions)
+		data, err := encoder.GetThumbnail(f, models.DefaultGthumbWidth)
+		if err != nil {
+			// don't log for unsupported image format
+			// don't log for file not found - can optionally be logged in serveImage
+			if !errors.Is(err, image.ErrNotSupportedForThumbnail) && !errors.Is(err, fs.ErrNotExist) {
+				logger.Errorf("error generating thumbnail for %s: %v", f.Base().Path, err)
+
+				var exitErr *exec.ExitError
+				if errors.As(err, &exitErr) {
+					logger.Errorf("stderr: %s", string(exitErr.Stderr))
+				}
+			}
+
+			// backwards compatibility - fallback to original image instead
+			s.serveImage(w, r, img, useDefault)
+			return
+		}
+
+		// write the generated thumbnail to disk if enabled
+		if GetInstance().Config.IsWriteImageThumbnails() {
+			logger.Debugf("writing thumbnail to disk: %s", img.Path)
+			if err := fsutil.WriteFile(filepath, data); err == nil {
+				utils.ServeStaticFile(w, r, filepath)
+				return
+			}
+			logger.Errorf("error writing thumbnail for image %s: %v", img.Path, err)
+		}
+		utils.ServeStaticContent(w, r, data)
+	}
+}
+
+func (s *ImageServer) ServeImage(img *models.Image, w http.ResponseWriter, r *http.Request) {
+	const useDefault = false
+	s.serveImage(w, r, img, useDefault)
+}
+
+func (s *ImageServer) serveImage(w http.ResponseWriter, r *http.Request, i *models.Image, useDefault bool) {
+	const defaultImageImage = "image/image.svg"
+
+	if i.Files.Primary() != nil {
+		err := i.Files.Primary().Base().Serve(&file.OsFS{}, w, r)
+		if err == nil {
+			return
+		}
+
+		if !useDefault {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// only log in debug since it can get noisy
+		logger.Debugf("Error serving %s: %v", i.DisplayName(), err)
+	}
+
+	if !useDefault {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	// fall back to static image
+	f, _ := static.Image.Open(defaultImageImage)
+	defer f.Close()
+	image, _ := io.ReadAll(f)
+	utils.ServeImage(w, r, image)
+}
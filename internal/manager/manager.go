@@ -40,11 +40,12 @@ import (
 )
 
 type SystemStatus struct {
-	DatabaseSchema *int             `json:"databaseSchema"`
-	DatabasePath   *string          `json:"databasePath"`
-	ConfigPath     *string          `json:"configPath"`
-	AppSchema      int              `json:"appSchema"`
-	Status         SystemStatusEnum `json:"status"`
+	DatabaseSchema *int                   `json:"databaseSchema"`
+	DatabasePath   *string                `json:"databasePath"`
+	ConfigPath     *string                `json:"configPath"`
+	AppSchema      int                    `json:"appSchema"`
+	Status         SystemStatusEnum       `json:"status"`
+	ScraperCDPPool *scraper.CDPPoolStatus `json:"scraperCDPPool"`
 }
 
 type SystemStatusEnum string
@@ -219,13 +220,18 @@ func initialize() error {
 	}
 
 	instance.DLNAService = dlna.NewService(instance.Repository, dlna.Repository{
-		SceneFinder:     instance.Repository.Scene,
-		FileFinder:      instance.Repository.File,
-		StudioFinder:    instance.Repository.Studio,
-		TagFinder:       instance.Repository.Tag,
-		PerformerFinder: instance.Repository.Performer,
-		MovieFinder:     instance.Repository.Movie,
-	}, instance.Config, &sceneServer)
+		SceneFinder:       instance.Repository.Scene,
+		SceneWriter:       instance.Repository.Scene,
+		FileFinder:        instance.Repository.File,
+		CaptionFinder:     instance.Repository.File,
+		ImageFinder:       instance.Repository.Image,
+		GalleryFinder:     instance.Repository.Gallery,
+		StudioFinder:      instance.Repository.Studio,
+		TagFinder:         instance.Repository.Tag,
+		PerformerFinder:   instance.Repository.Performer,
+		MovieFinder:       instance.Repository.Movie,
+		SavedFilterFinder: instance.Repository.SavedFilter,
+	}, instance.Config, &sceneServer, &ImageServer{})
 
 	if !cfg.IsNewSystem() {
 		logger.Infof("using config file: %s", cfg.GetConfigFile())
@@ -275,6 +281,8 @@ func initialize() error {
 		}
 	}
 
+	go instance.runStashSyncScheduler(ctx)
+
 	return nil
 }
 
@@ -523,6 +531,7 @@ func (s *Manager) initScraperCache() *scraper.Cache {
 	ret, err := scraper.NewCache(config.GetInstance(), s.Repository, scraper.Repository{
 		SceneFinder:     s.Repository.Scene,
 		GalleryFinder:   s.Repository.Gallery,
+		ImageFinder:     s.Repository.Image,
 		TagFinder:       s.Repository.Tag,
 		PerformerFinder: s.Repository.Performer,
 		MovieFinder:     s.Repository.Movie,
@@ -773,6 +782,7 @@ func (s *Manager) GetSystemStatus() *SystemStatus {
 		AppSchema:      appSchema,
 		Status:         status,
 		ConfigPath:     &configFile,
+		ScraperCDPPool: scraper.GetCDPPoolStatus(),
 	}
 }
 
@@ -786,6 +796,9 @@ func (s *Manager) Shutdown(code int) {
 		s.StreamManager = nil
 	}
 
+	scraper.CloseCDPPool()
+	scraper.SaveSessions()
+
 	// TODO: Each part of the manager needs to gracefully stop at some point
 	// for now, we just close the database.
 	err := s.Database.Close()
@@ -13,6 +13,8 @@ import (
 	"github.com/stashapp/stash/pkg/job"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scene/generate"
+	"github.com/stashapp/stash/pkg/sliceutil/stringslice"
 )
 
 func useAsVideo(pathname string) bool {
@@ -174,6 +176,85 @@ func (s *Manager) Generate(ctx context.Context, input GenerateMetadataInput) (in
 	return s.JobManager.Add(ctx, "Generating...", j), nil
 }
 
+func (s *Manager) DetectIntros(ctx context.Context, input DetectIntrosInput) (int, error) {
+	if err := s.validateFFMPEG(); err != nil {
+		return 0, err
+	}
+
+	j := &DetectIntrosJob{
+		txnManager: s.Repository,
+		input:      input,
+		generator: &generate.Generator{
+			Encoder:      instance.FFMPEG,
+			FFMpegConfig: instance.Config,
+			LockManager:  instance.ReadLockManager,
+			ScenePaths:   instance.Paths.Scene,
+		},
+	}
+
+	return s.JobManager.Add(ctx, "Detecting intros...", j), nil
+}
+
+func (s *Manager) Reencode(ctx context.Context, input ReencodeInput) (int, error) {
+	if err := s.validateFFMPEG(); err != nil {
+		return 0, err
+	}
+
+	if !input.VideoCodec.IsValid() {
+		return 0, fmt.Errorf("invalid video codec: %s", input.VideoCodec)
+	}
+
+	j := &ReencodeJob{
+		repository: s.Repository,
+		scanner:    s.Scanner,
+		input:      input,
+	}
+
+	return s.JobManager.Add(ctx, "Re-encoding...", j), nil
+}
+
+func (s *Manager) Remux(ctx context.Context, input RemuxInput) (int, error) {
+	if err := s.validateFFMPEG(); err != nil {
+		return 0, err
+	}
+
+	j := &RemuxJob{
+		repository: s.Repository,
+		scanner:    s.Scanner,
+		input:      input,
+	}
+
+	return s.JobManager.Add(ctx, "Remuxing...", j), nil
+}
+
+func (s *Manager) SplitScene(ctx context.Context, input SceneSplitInput) (int, error) {
+	if err := s.validateFFMPEG(); err != nil {
+		return 0, err
+	}
+
+	j := &SplitSceneJob{
+		repository: s.Repository,
+		scanner:    s.Scanner,
+		input:      input,
+	}
+
+	return s.JobManager.Add(ctx, "Splitting scene...", j), nil
+}
+
+func (s *Manager) TrimScene(ctx context.Context, input SceneTrimInput) (int, error) {
+	if err := s.validateFFMPEG(); err != nil {
+		return 0, err
+	}
+
+	j := &TrimSceneJob{
+		repository: s.Repository,
+		scanner:    s.Scanner,
+		input:      input,
+	}
+
+	return s.JobManager.Add(ctx, "Trimming scene...", j), nil
+}
+
 func (s *Manager) GenerateDefaultScreenshot(ctx context.Context, sceneId string) int {
 	return s.generateScreenshot(ctx, sceneId, nil)
 }
@@ -212,10 +293,11 @@ func (s *Manager) generateScreenshot(ctx context.Context, sceneId string, at *fl
 		}
 
 		task := GenerateCoverTask{
-			txnManager:   s.Repository,
-			Scene:        *scene,
-			ScreenshotAt: at,
-			Overwrite:    true,
+			txnManager:          s.Repository,
+			Scene:               *scene,
+			ScreenshotAt:        at,
+			Overwrite:           true,
+			fileNamingAlgorithm: config.GetInstance().GetVideoFileNamingAlgorithm(),
 		}
 
 		task.Start(ctx)
@@ -443,3 +525,116 @@ func (s *Manager) StashBoxBatchPerformerTag(ctx context.Context, input StashBoxB
 
 	return s.JobManager.Add(ctx, "Batch stash-box performer tag...", j)
 }
+
+// If neither studio_ids nor studio_names are set, tag all studios
+type StashBoxBatchStudioTagInput struct {
+	// Stash endpoint to use for the studio tagging
+	Endpoint int `json:"endpoint"`
+	// Fields to exclude when executing the studio tagging
+	ExcludeFields []string `json:"exclude_fields"`
+	// Refresh studios already tagged by StashBox if true. Only tag studios with no StashBox tagging if false
+	Refresh bool `json:"refresh"`
+	// If set, only tag these studio ids
+	StudioIds []string `json:"studio_ids"`
+	// If set, only tag these studio names
+	StudioNames []string `json:"studio_names"`
+}
+
+func (s *Manager) StashBoxBatchStudioTag(ctx context.Context, input StashBoxBatchStudioTagInput) int {
+	j := job.MakeJobExec(func(ctx context.Context, progress *job.Progress) {
+		logger.Infof("Initiating stash-box batch studio tag")
+
+		boxes := config.GetInstance().GetStashBoxes()
+		if input.Endpoint < 0 || input.Endpoint >= len(boxes) {
+			logger.Error(fmt.Errorf("invalid stash_box_index %d", input.Endpoint))
+			return
+		}
+		box := boxes[input.Endpoint]
+
+		newTask := func(studio *models.Studio, name *string) StashBoxStudioTagTask {
+			return StashBoxStudioTagTask{
+				txnManager:      s.Repository,
+				studio:          studio,
+				name:            name,
+				refresh:         input.Refresh,
+				box:             box,
+				excluded_fields: input.ExcludeFields,
+			}
+		}
+
+		var tasks []StashBoxStudioTagTask
+
+		switch {
+		case len(input.StudioIds) > 0:
+			if err := s.Repository.WithTxn(ctx, func(ctx context.Context) error {
+				studioQuery := s.Repository.Studio
+
+				ids, err := stringslice.StringSliceToIntSlice(input.StudioIds)
+				if err != nil {
+					return fmt.Errorf("invalid studio ids: %w", err)
+				}
+
+				studios, err := studioQuery.FindMany(ctx, ids)
+				if err != nil {
+					return fmt.Errorf("error finding studios: %w", err)
+				}
+
+				for _, studio := range studios {
+					tasks = append(tasks, newTask(studio, nil))
+				}
+				return nil
+			}); err != nil {
+				logger.Error(err.Error())
+			}
+		case len(input.StudioNames) > 0:
+			for i := range input.StudioNames {
+				if len(input.StudioNames[i]) > 0 {
+					tasks = append(tasks, newTask(nil, &input.StudioNames[i]))
+				}
+			}
+		default:
+			if err := s.Repository.WithTxn(ctx, func(ctx context.Context) error {
+				modifier := models.CriterionModifierIsNull
+				if input.Refresh {
+					modifier = models.CriterionModifierNotNull
+				}
+
+				studios, _, err := s.Repository.Studio.Query(ctx, &models.StudioFilterType{
+					StashIDEndpoint: &models.StashIDCriterionInput{
+						Endpoint: &box.Endpoint,
+						Modifier: modifier,
+					},
+				}, allObjects("name"))
+				if err != nil {
+					return fmt.Errorf("error querying studios: %v", err)
+				}
+
+				for _, studio := range studios {
+					tasks = append(tasks, newTask(studio, nil))
+				}
+				return nil
+			}); err != nil {
+				logger.Error(err.Error())
+				return
+			}
+		}
+
+		if len(tasks) == 0 {
+			return
+		}
+
+		progress.SetTotal(len(tasks))
+
+		logger.Infof("Starting stash-box batch operation for %d studios", len(tasks))
+
+		for _, task := range tasks {
+			progress.ExecuteTask(task.Description(), func() {
+				task.Start(ctx)
+			})
+
+			progress.Increment()
+		}
+	})
+
+	return s.JobManager.Add(ctx, "Batch stash-box studio tag...", j)
+}
@@ -36,7 +36,10 @@ type FileReaderWriter interface {
 	file.Store
 	Query(ctx context.Context, options models.FileQueryOptions) (*models.FileQueryResult, error)
 	GetCaptions(ctx context.Context, fileID file.ID) ([]*models.VideoCaption, error)
+	GetManyCaptions(ctx context.Context, fileIDs []file.ID) ([][]*models.VideoCaption, error)
 	IsPrimary(ctx context.Context, fileID file.ID) (bool, error)
+	AddFingerprintHistory(ctx context.Context, fileID file.ID, fp []file.Fingerprint) error
+	GetFingerprintHistory(ctx context.Context, fileID file.ID) ([]file.Fingerprint, error)
 }
 
 type FolderReaderWriter interface {
@@ -59,6 +62,8 @@ type Repository struct {
 	Studio         models.StudioReaderWriter
 	Tag            models.TagReaderWriter
 	SavedFilter    models.SavedFilterReaderWriter
+	IdentifyReview models.IdentifyReviewReaderWriter
+	DeletedStashID models.DeletedStashIDReaderWriter
 }
 
 func (r *Repository) WithTxn(ctx context.Context, fn txn.TxnFunc) error {
@@ -91,6 +96,8 @@ func sqliteRepository(d *sqlite.Database) Repository {
 		Studio:         txnRepo.Studio,
 		Tag:            txnRepo.Tag,
 		SavedFilter:    txnRepo.SavedFilter,
+		IdentifyReview: txnRepo.IdentifyReview,
+		DeletedStashID: txnRepo.DeletedStashID,
 	}
 }
 
@@ -57,6 +57,25 @@ func (s *SceneServer) StreamSceneDirect(scene *models.Scene, w http.ResponseWrit
 	http.ServeFile(w, r, filepath)
 }
 
+// StreamSceneTranscode live transcodes the primary file of the scene. The
+// video file of the options is set from the scene.
+func (s *SceneServer) StreamSceneTranscode(scene *models.Scene, w http.ResponseWriter, r *http.Request, options ffmpeg.TranscodeOptions) {
+	f := scene.Files.Primary()
+	if f == nil {
+		http.Error(w, http.StatusText(404), 404)
+		return
+	}
+
+	streamManager := GetInstance().StreamManager
+	if streamManager == nil {
+		http.Error(w, "Live transcoding disabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	options.VideoFile = f
+	streamManager.ServeTranscode(w, r, options)
+}
+
 func (s *SceneServer) ServeScreenshot(scene *models.Scene, w http.ResponseWriter, r *http.Request) {
 	const defaultSceneImage = "scene/scene.svg"
 
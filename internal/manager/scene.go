@@ -156,12 +156,15 @@ func GetSceneStreamPaths(scene *models.Scene, directStreamURL *url.URL, maxStrea
 	// don't care if we can't get the container
 	container, _ := GetVideoFileContainer(pf)
 
-	if HasTranscode(scene, config.GetInstance().GetVideoFileNamingAlgorithm()) || ffmpeg.IsValidAudioForContainer(audioCodec, container) {
+	// the direct stream serves the generated transcode or remux if present
+	hasTranscode := HasTranscode(scene, config.GetInstance().GetVideoFileNamingAlgorithm())
+	if hasTranscode || ffmpeg.IsValidAudioForContainer(audioCodec, container) {
 		endpoints = append(endpoints, makeStreamEndpoint(directEndpointType, ""))
 	}
 
 	// only add mkv stream endpoint if the scene container is an mkv already
-	if container == ffmpeg.Matroska {
+	// and there is no transcode to play directly instead
+	if container == ffmpeg.Matroska && !hasTranscode {
 		endpoints = append(endpoints, makeStreamEndpoint(mkvEndpointType, ""))
 	}
 
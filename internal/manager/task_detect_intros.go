@@ -0,0 +1,284 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/stashapp/stash/pkg/job"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scene"
+	"github.com/stashapp/stash/pkg/scene/generate"
+	"github.com/stashapp/stash/pkg/scene/intro"
+	"github.com/stashapp/stash/pkg/sliceutil/stringslice"
+)
+
+const (
+	// introWindow is the duration in seconds at the start and end of each
+	// file that is searched for intros and outros.
+	introWindow = 180.0
+	// introFrameInterval is the time in seconds between hashed frames.
+	introFrameInterval = 1.0
+	// introMaxComparisons is the maximum number of other scenes that each
+	// scene is compared against.
+	introMaxComparisons = 8
+)
+
+var introOptions = intro.Options{
+	MaxDistance: 8,
+	MinDuration: 3,
+	MinMatches:  2,
+}
+
+type DetectIntrosInput struct {
+	// Studios to detect intros for. If empty, all studios are used.
+	StudioIds []string `json:"studio_ids"`
+	// Overwrite existing intro and outro ranges
+	Overwrite bool `json:"overwrite"`
+}
+
+// DetectIntrosJob finds intro and outro segments that are repeated across
+// the scenes of each studio.
+type DetectIntrosJob struct {
+	txnManager Repository
+	input      DetectIntrosInput
+	generator  *generate.Generator
+
+	progress *job.Progress
+}
+
+type introSequences struct {
+	intro intro.Sequence
+	outro intro.Sequence
+}
+
+func (j *DetectIntrosJob) Execute(ctx context.Context, progress *job.Progress) {
+	j.progress = progress
+
+	studioIDs, err := j.getStudioIDs(ctx)
+	if err != nil {
+		logger.Errorf("Error getting studios: %v", err)
+		return
+	}
+
+	var total int
+	studioScenes := make(map[int][]*models.Scene)
+	if err := j.txnManager.WithReadTxn(ctx, func(ctx context.Context) error {
+		for _, id := range studioIDs {
+			scenes, err := j.getStudioScenes(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			// need at least two scenes to find repeated segments
+			if len(scenes) < 2 {
+				continue
+			}
+
+			studioScenes[id] = scenes
+			total += len(scenes)
+		}
+
+		return nil
+	}); err != nil {
+		logger.Errorf("Error getting scenes: %v", err)
+		return
+	}
+
+	progress.SetTotal(total)
+
+	for _, id := range studioIDs {
+		if job.IsCancelled(ctx) {
+			logger.Info("Stopping due to user request")
+			return
+		}
+
+		if scenes := studioScenes[id]; len(scenes) > 0 {
+			j.detectStudio(ctx, scenes)
+		}
+	}
+
+	logger.Info("Finished detecting intros")
+}
+
+func (j *DetectIntrosJob) getStudioIDs(ctx context.Context) ([]int, error) {
+	if len(j.input.StudioIds) > 0 {
+		return stringslice.StringSliceToIntSlice(j.input.StudioIds)
+	}
+
+	var ret []int
+	if err := j.txnManager.WithReadTxn(ctx, func(ctx context.Context) error {
+		studios, err := j.txnManager.Studio.All(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, s := range studios {
+			ret = append(ret, s.ID)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (j *DetectIntrosJob) getStudioScenes(ctx context.Context, studioID int) ([]*models.Scene, error) {
+	depth := 0
+	sceneFilter := &models.SceneFilterType{
+		Studios: &models.HierarchicalMultiCriterionInput{
+			Value:    []string{strconv.Itoa(studioID)},
+			Modifier: models.CriterionModifierIncludes,
+			Depth:    &depth,
+		},
+	}
+
+	sort := "path"
+	findFilter := &models.FindFilterType{
+		Sort: &sort,
+	}
+
+	var ret []*models.Scene
+	if err := scene.BatchProcess(ctx, j.txnManager.Scene, sceneFilter, findFilter, func(s *models.Scene) error {
+		if err := s.LoadPrimaryFile(ctx, j.txnManager.File); err != nil {
+			return err
+		}
+
+		if s.Files.Primary() != nil {
+			ret = append(ret, s)
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("querying scenes for studio %d: %w", studioID, err)
+	}
+
+	return ret, nil
+}
+
+func (j *DetectIntrosJob) required(s *models.Scene) bool {
+	if j.input.Overwrite {
+		return true
+	}
+
+	return s.IntroStart == nil && s.OutroStart == nil
+}
+
+func (j *DetectIntrosJob) detectStudio(ctx context.Context, scenes []*models.Scene) {
+	// frame hashes are calculated once per scene and cached
+	cache := make(map[int]*introSequences)
+	getSequences := func(s *models.Scene) *introSequences {
+		if ret, found := cache[s.ID]; found {
+			return ret
+		}
+
+		ret, err := j.sequences(ctx, s)
+		if err != nil {
+			logger.Warnf("Error hashing frames of %s: %v", s.Path, err)
+		}
+
+		cache[s.ID] = ret
+		return ret
+	}
+
+	for i, s := range scenes {
+		if job.IsCancelled(ctx) {
+			return
+		}
+
+		if !j.required(s) {
+			j.progress.Increment()
+			continue
+		}
+
+		j.progress.ExecuteTask("Detecting intro for "+s.Path, func() {
+			target := getSequences(s)
+			if target == nil {
+				return
+			}
+
+			// compare against the following scenes, wrapping around
+			var intros, outros []intro.Sequence
+			for k := 1; k < len(scenes) && k <= introMaxComparisons; k++ {
+				other := getSequences(scenes[(i+k)%len(scenes)])
+				if other == nil {
+					continue
+				}
+				intros = append(intros, other.intro)
+				outros = append(outros, other.outro)
+			}
+
+			introSegment := intro.Detect(target.intro, intros, introOptions)
+			outroSegment := intro.Detect(target.outro, outros, introOptions)
+
+			if err := j.updateScene(ctx, s, introSegment, outroSegment); err != nil {
+				logger.Errorf("Error updating intro for %s: %v", s.Path, err)
+			}
+		})
+
+		j.progress.Increment()
+	}
+}
+
+func (j *DetectIntrosJob) sequences(ctx context.Context, s *models.Scene) (*introSequences, error) {
+	f := s.Files.Primary()
+	window := math.Min(introWindow, f.Duration/2)
+	if window < introOptions.MinDuration {
+		return nil, nil
+	}
+
+	introHashes, err := j.generator.FrameHashes(ctx, f.Path, 0, window, introFrameInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	outroStart := f.Duration - window
+	outroHashes, err := j.generator.FrameHashes(ctx, f.Path, outroStart, window, introFrameInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	return &introSequences{
+		intro: intro.Sequence{
+			Interval: introFrameInterval,
+			Hashes:   introHashes,
+		},
+		outro: intro.Sequence{
+			Offset:   outroStart,
+			Interval: introFrameInterval,
+			Hashes:   outroHashes,
+		},
+	}, nil
+}
+
+func segmentPartial(seg *models.SceneSegment) (start models.OptionalFloat64, end models.OptionalFloat64) {
+	if seg == nil {
+		return models.NewOptionalFloat64Ptr(nil), models.NewOptionalFloat64Ptr(nil)
+	}
+
+	return models.NewOptionalFloat64(seg.Start), models.NewOptionalFloat64(seg.End)
+}
+
+func (j *DetectIntrosJob) updateScene(ctx context.Context, s *models.Scene, introSegment, outroSegment *models.SceneSegment) error {
+	if introSegment == nil && outroSegment == nil && !j.input.Overwrite {
+		return nil
+	}
+
+	partial := models.NewScenePartial()
+	partial.IntroStart, partial.IntroEnd = segmentPartial(introSegment)
+	partial.OutroStart, partial.OutroEnd = segmentPartial(outroSegment)
+
+	if introSegment != nil {
+		logger.Infof("Detected intro %.0fs-%.0fs for %s", introSegment.Start, introSegment.End, s.Path)
+	}
+	if outroSegment != nil {
+		logger.Infof("Detected outro %.0fs-%.0fs for %s", outroSegment.Start, outroSegment.End, s.Path)
+	}
+
+	return j.txnManager.WithTxn(ctx, func(ctx context.Context) error {
+		_, err := j.txnManager.Scene.UpdatePartial(ctx, s.ID, partial)
+		return err
+	})
+}
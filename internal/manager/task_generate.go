@@ -27,10 +27,15 @@ type GenerateMetadataInput struct {
 	MarkerScreenshots   bool                         `json:"markerScreenshots"`
 	Transcodes          bool                         `json:"transcodes"`
 	// Generate transcodes even if not required
-	ForceTranscodes           bool `json:"forceTranscodes"`
+	ForceTranscodes bool `json:"forceTranscodes"`
+	// Generate MP4 remuxes of files with browser supported codecs in an
+	// unsupported container
+	Remuxes                   bool `json:"remuxes"`
 	Phashes                   bool `json:"phashes"`
 	InteractiveHeatmapsSpeeds bool `json:"interactiveHeatmapsSpeeds"`
 	ClipPreviews              bool `json:"clipPreviews"`
+	// Detect scene changes, used when generating covers, sprites and previews
+	SceneCuts bool `json:"sceneCuts"`
 	// scene ids to generate for
 	SceneIDs []string `json:"sceneIDs"`
 	// marker ids to generate for
@@ -72,6 +77,7 @@ type totalsGenerate struct {
 	phashes                  int64
 	interactiveHeatmapSpeeds int64
 	clipPreviews             int64
+	sceneCuts                int64
 
 	tasks int
 }
@@ -161,7 +167,7 @@ func (j *GenerateJob) Execute(ctx context.Context, progress *job.Progress) {
 		if j.input.Markers {
 			logMsg += fmt.Sprintf(" %d markers", totals.markers)
 		}
-		if j.input.Transcodes {
+		if j.input.Transcodes || j.input.Remuxes {
 			logMsg += fmt.Sprintf(" %d transcodes", totals.transcodes)
 		}
 		if j.input.Phashes {
@@ -173,6 +179,9 @@ func (j *GenerateJob) Execute(ctx context.Context, progress *job.Progress) {
 		if j.input.ClipPreviews {
 			logMsg += fmt.Sprintf(" %d Image Clip Previews", totals.clipPreviews)
 		}
+		if j.input.SceneCuts {
+			logMsg += fmt.Sprintf(" %d scene cuts", totals.sceneCuts)
+		}
 		if logMsg == "Generating" {
 			logMsg = "Nothing selected to generate"
 		}
@@ -331,11 +340,32 @@ func getGeneratePreviewOptions(optionsInput GeneratePreviewOptionsInput) generat
 }
 
 func (j *GenerateJob) queueSceneJobs(ctx context.Context, g *generate.Generator, scene *models.Scene, queue chan<- Task, totals *totalsGenerate) {
+	// scene cuts are queued first, since the cover, sprite and preview tasks
+	// use them if present
+	var sceneCutsTask *GenerateSceneCutsTask
+	if j.input.SceneCuts {
+		task := &GenerateSceneCutsTask{
+			Scene:               *scene,
+			Overwrite:           j.overwrite,
+			fileNamingAlgorithm: j.fileNamingAlgo,
+			generator:           g,
+		}
+
+		if task.required() {
+			sceneCutsTask = task
+			totals.sceneCuts++
+			totals.tasks++
+			queue <- task
+		}
+	}
+
 	if j.input.Covers {
 		task := &GenerateCoverTask{
-			txnManager: j.txnManager,
-			Scene:      *scene,
-			Overwrite:  j.overwrite,
+			txnManager:          j.txnManager,
+			Scene:               *scene,
+			Overwrite:           j.overwrite,
+			fileNamingAlgorithm: j.fileNamingAlgo,
+			sceneCuts:           sceneCutsTask,
 		}
 
 		if task.required(ctx) {
@@ -350,6 +380,7 @@ func (j *GenerateJob) queueSceneJobs(ctx context.Context, g *generate.Generator,
 			Scene:               *scene,
 			Overwrite:           j.overwrite,
 			fileNamingAlgorithm: j.fileNamingAlgo,
+			sceneCuts:           sceneCutsTask,
 		}
 
 		if task.required() {
@@ -373,6 +404,7 @@ func (j *GenerateJob) queueSceneJobs(ctx context.Context, g *generate.Generator,
 			Overwrite:           j.overwrite,
 			fileNamingAlgorithm: j.fileNamingAlgo,
 			generator:           g,
+			sceneCuts:           sceneCutsTask,
 		}
 
 		if task.required() {
@@ -409,12 +441,14 @@ func (j *GenerateJob) queueSceneJobs(ctx context.Context, g *generate.Generator,
 		}
 	}
 
-	if j.input.Transcodes {
+	if j.input.Transcodes || j.input.Remuxes {
 		forceTranscode := j.input.ForceTranscodes
 		task := &GenerateTranscodeTask{
 			Scene:               *scene,
 			Overwrite:           j.overwrite,
 			Force:               forceTranscode,
+			Transcode:           j.input.Transcodes,
+			Remux:               j.input.Remuxes,
 			fileNamingAlgorithm: j.fileNamingAlgo,
 			g:                   g,
 		}
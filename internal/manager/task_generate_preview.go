@@ -3,6 +3,7 @@ package manager
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/stashapp/stash/pkg/fsutil"
 	"github.com/stashapp/stash/pkg/logger"
@@ -20,6 +21,7 @@ type GeneratePreviewTask struct {
 	fileNamingAlgorithm models.HashAlgorithm
 
 	generator *generate.Generator
+	sceneCuts *GenerateSceneCutsTask
 
 	videoPreviewExists *bool
 	imagePreviewExists *bool
@@ -40,6 +42,9 @@ func (t *GeneratePreviewTask) Start(ctx context.Context) {
 			return
 		}
 
+		t.Options.Cuts = getSceneCuts(ctx, videoChecksum, t.sceneCuts)
+		t.excludeIntroOutro(videoFile.VideoStreamDuration)
+
 		if err := t.generateVideo(videoChecksum, videoFile.VideoStreamDuration, videoFile.FrameRate); err != nil {
 			logger.Errorf("error generating preview: %v", err)
 			logErrorOutput(err)
@@ -74,6 +79,18 @@ func (t *GeneratePreviewTask) generateVideo(videoChecksum string, videoDuration
 	return nil
 }
 
+// excludeIntroOutro replaces the configured exclusions with the detected
+// intro and outro of the scene, where present.
+func (t *GeneratePreviewTask) excludeIntroOutro(videoDuration float64) {
+	if t.Scene.IntroEnd != nil {
+		t.Options.ExcludeStart = strconv.FormatFloat(*t.Scene.IntroEnd, 'f', -1, 64)
+	}
+
+	if t.Scene.OutroStart != nil && *t.Scene.OutroStart < videoDuration {
+		t.Options.ExcludeEnd = strconv.FormatFloat(videoDuration-*t.Scene.OutroStart, 'f', -1, 64)
+	}
+}
+
 func (t *GeneratePreviewTask) generateWebp(videoChecksum string) error {
 	videoFilename := t.Scene.Path
 	return t.generator.PreviewWebp(context.TODO(), videoFilename, videoChecksum)
@@ -0,0 +1,85 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/stashapp/stash/pkg/fsutil"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scene/generate"
+)
+
+// GenerateSceneCutsTask detects the scene changes and blank intervals of a
+// scene's primary file. Other generate tasks for the same scene may call
+// Start to ensure the scene cuts exist before using them; detection is only
+// run once.
+type GenerateSceneCutsTask struct {
+	Scene               models.Scene
+	Overwrite           bool
+	fileNamingAlgorithm models.HashAlgorithm
+
+	generator *generate.Generator
+
+	once sync.Once
+}
+
+func (t *GenerateSceneCutsTask) GetDescription() string {
+	return fmt.Sprintf("Detecting scene changes for %s", t.Scene.Path)
+}
+
+func (t *GenerateSceneCutsTask) Start(ctx context.Context) {
+	t.once.Do(func() {
+		if !t.required() {
+			return
+		}
+
+		videoFile := t.Scene.Files.Primary()
+		sceneHash := t.Scene.GetHash(t.fileNamingAlgorithm)
+
+		if err := t.generator.SceneCuts(ctx, videoFile.Path, videoFile.Duration, sceneHash); err != nil {
+			logger.Errorf("error detecting scene changes: %v", err)
+			logErrorOutput(err)
+		}
+	})
+}
+
+func (t *GenerateSceneCutsTask) required() bool {
+	if t.Scene.Path == "" || t.Scene.Files.Primary() == nil {
+		return false
+	}
+
+	if t.Overwrite {
+		return true
+	}
+
+	sceneHash := t.Scene.GetHash(t.fileNamingAlgorithm)
+	if sceneHash == "" {
+		return false
+	}
+
+	exists, _ := fsutil.FileExists(instance.Paths.Scene.GetSceneCutsPath(sceneHash))
+	return !exists
+}
+
+// getSceneCuts returns the scene cuts for the scene with the given hash.
+// If task is not nil, it is run first to ensure that the scene cuts exist.
+// Returns nil if the scene cuts have not been generated.
+func getSceneCuts(ctx context.Context, sceneHash string, task *GenerateSceneCutsTask) *generate.SceneCuts {
+	if sceneHash == "" {
+		return nil
+	}
+
+	if task != nil {
+		task.Start(ctx)
+	}
+
+	ret, err := generate.LoadSceneCuts(instance.Paths.Scene.GetSceneCutsPath(sceneHash))
+	if err != nil {
+		logger.Warnf("error reading scene cuts: %v", err)
+		return nil
+	}
+
+	return ret
+}
@@ -14,6 +14,9 @@ type GenerateCoverTask struct {
 	ScreenshotAt *float64
 	txnManager   Repository
 	Overwrite    bool
+
+	fileNamingAlgorithm models.HashAlgorithm
+	sceneCuts           *GenerateSceneCutsTask
 }
 
 func (t *GenerateCoverTask) GetDescription() string {
@@ -41,11 +44,9 @@ func (t *GenerateCoverTask) Start(ctx context.Context) {
 		return
 	}
 
-	var at float64
+	var cuts *generate.SceneCuts
 	if t.ScreenshotAt == nil {
-		at = float64(videoFile.Duration) * 0.2
-	} else {
-		at = *t.ScreenshotAt
+		cuts = getSceneCuts(ctx, t.Scene.GetHash(t.fileNamingAlgorithm), t.sceneCuts)
 	}
 
 	// we'll generate the screenshot, grab the generated data and set it
@@ -62,7 +63,8 @@ func (t *GenerateCoverTask) Start(ctx context.Context) {
 	}
 
 	coverImageData, err := g.Screenshot(context.TODO(), videoFile.Path, videoFile.Width, videoFile.Duration, generate.ScreenshotOptions{
-		At: &at,
+		At:   t.ScreenshotAt,
+		Cuts: cuts,
 	})
 	if err != nil {
 		logger.Errorf("Error generating screenshot: %v", err)
@@ -13,6 +13,8 @@ type GenerateSpriteTask struct {
 	Scene               models.Scene
 	Overwrite           bool
 	fileNamingAlgorithm models.HashAlgorithm
+
+	sceneCuts *GenerateSceneCutsTask
 }
 
 func (t *GenerateSpriteTask) GetDescription() string {
@@ -41,6 +43,7 @@ func (t *GenerateSpriteTask) Start(ctx context.Context) {
 		return
 	}
 	generator.Overwrite = t.Overwrite
+	generator.Cuts = getSceneCuts(ctx, sceneHash, t.sceneCuts)
 
 	if err := generator.Generate(); err != nil {
 		logger.Errorf("error generating sprite: %s", err.Error())
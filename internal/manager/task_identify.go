@@ -19,12 +19,18 @@ import (
 
 var ErrInput = errors.New("invalid request input")
 
+type identifyPostHookExecutor interface {
+	identify.SceneUpdatePostHookExecutor
+	identify.PostHookExecutor
+}
+
 type IdentifyJob struct {
-	postHookExecutor identify.SceneUpdatePostHookExecutor
+	postHookExecutor identifyPostHookExecutor
 	input            identify.Options
 
-	stashBoxes []*models.StashBox
-	progress   *job.Progress
+	stashBoxes          []*models.StashBox
+	fieldSourcePriority map[string][]int
+	progress            *job.Progress
 }
 
 func CreateIdentifyJob(input identify.Options) *IdentifyJob {
@@ -49,45 +55,94 @@ func (j *IdentifyJob) Execute(ctx context.Context, progress *job.Progress) {
 		return
 	}
 
-	// if scene ids provided, use those
-	// otherwise, batch query for all scenes - ordering by path
-	// don't use a transaction to query scenes
-	if err := txn.WithDatabase(ctx, instance.Repository, func(ctx context.Context) error {
-		if len(j.input.SceneIDs) == 0 {
-			return j.identifyAllScenes(ctx, sources)
-		}
+	j.fieldSourcePriority, err = j.getFieldSourcePriority(sources)
+	if err != nil {
+		logger.Error(err)
+		return
+	}
 
-		sceneIDs, err := stringslice.StringSliceToIntSlice(j.input.SceneIDs)
-		if err != nil {
-			return fmt.Errorf("invalid scene IDs: %w", err)
-		}
+	// totals are added as each object type is queried
+	progress.Definite()
 
-		progress.SetTotal(len(sceneIDs))
-		for _, id := range sceneIDs {
-			if job.IsCancelled(ctx) {
-				break
+	// don't use a transaction to query objects
+	if err := txn.WithDatabase(ctx, instance.Repository, func(ctx context.Context) error {
+		if j.identifyScenesRequested() {
+			if err := j.identifyScenes(ctx, sources); err != nil {
+				logger.Errorf("Error encountered while identifying scenes: %v", err)
 			}
-
-			// find the scene
-			var err error
-			scene, err := instance.Repository.Scene.Find(ctx, id)
-			if err != nil {
-				return fmt.Errorf("finding scene id %d: %w", id, err)
+		}
+		if len(j.input.GalleryIDs) > 0 || j.input.GalleryFilter != nil {
+			if err := j.identifyGalleries(ctx, sources); err != nil {
+				logger.Errorf("Error encountered while identifying galleries: %v", err)
 			}
-
-			if scene == nil {
-				return fmt.Errorf("scene with id %d not found", id)
+		}
+		if len(j.input.PerformerIDs) > 0 || j.input.PerformerFilter != nil {
+			if err := j.identifyPerformers(ctx, sources); err != nil {
+				logger.Errorf("Error encountered while identifying performers: %v", err)
+			}
+		}
+		if len(j.input.MovieIDs) > 0 || j.input.MovieFilter != nil {
+			if err := j.identifyMovies(ctx, sources); err != nil {
+				logger.Errorf("Error encountered while identifying movies: %v", err)
 			}
-
-			j.identifyScene(ctx, scene, sources)
 		}
 
 		return nil
 	}); err != nil {
-		logger.Errorf("Error encountered while identifying scenes: %v", err)
+		logger.Errorf("Error encountered while identifying: %v", err)
 	}
 }
 
+// identifyScenesRequested returns true if scenes should be identified. Scenes
+// are identified if scene ids or paths are provided, or if no other object
+// types are requested.
+func (j *IdentifyJob) identifyScenesRequested() bool {
+	if len(j.input.SceneIDs) > 0 || len(j.input.Paths) > 0 {
+		return true
+	}
+
+	i := j.input
+	otherTypes := len(i.GalleryIDs) > 0 || i.GalleryFilter != nil ||
+		len(i.PerformerIDs) > 0 || i.PerformerFilter != nil ||
+		len(i.MovieIDs) > 0 || i.MovieFilter != nil
+
+	return !otherTypes
+}
+
+func (j *IdentifyJob) identifyScenes(ctx context.Context, sources []identify.ScraperSource) error {
+	// if scene ids provided, use those
+	// otherwise, batch query for all scenes - ordering by path
+	if len(j.input.SceneIDs) == 0 {
+		return j.identifyAllScenes(ctx, sources)
+	}
+
+	sceneIDs, err := stringslice.StringSliceToIntSlice(j.input.SceneIDs)
+	if err != nil {
+		return fmt.Errorf("invalid scene IDs: %w", err)
+	}
+
+	j.progress.AddTotal(len(sceneIDs))
+	for _, id := range sceneIDs {
+		if job.IsCancelled(ctx) {
+			break
+		}
+
+		// find the scene
+		scene, err := instance.Repository.Scene.Find(ctx, id)
+		if err != nil {
+			return fmt.Errorf("finding scene id %d: %w", id, err)
+		}
+
+		if scene == nil {
+			return fmt.Errorf("scene with id %d not found", id)
+		}
+
+		j.identifyScene(ctx, scene, sources)
+	}
+
+	return nil
+}
+
 func (j *IdentifyJob) identifyAllScenes(ctx context.Context, sources []identify.ScraperSource) error {
 	// exclude organised
 	organised := false
@@ -113,7 +168,7 @@ func (j *IdentifyJob) identifyAllScenes(ctx context.Context, sources []identify.
 		return fmt.Errorf("error getting scene count: %w", err)
 	}
 
-	j.progress.SetTotal(countResult.Count)
+	j.progress.AddTotal(countResult.Count)
 
 	return scene.BatchProcess(ctx, instance.Repository.Scene, sceneFilter, findFilter, func(scene *models.Scene) error {
 		if job.IsCancelled(ctx) {
@@ -141,6 +196,15 @@ func (j *IdentifyJob) identifyScene(ctx context.Context, s *models.Scene, source
 			DefaultOptions:              j.input.Options,
 			Sources:                     sources,
 			SceneUpdatePostHookExecutor: j.postHookExecutor,
+
+			MergeSources:        j.input.MergeSources != nil && *j.input.MergeSources,
+			FieldSourcePriority: j.fieldSourcePriority,
+
+			ReviewCreator: instance.Repository.IdentifyReview,
+		}
+
+		if j.input.ConfidenceThreshold != nil {
+			task.ConfidenceThreshold = *j.input.ConfidenceThreshold
 		}
 
 		taskError = task.Identify(ctx, instance.Repository, s)
@@ -164,31 +228,46 @@ func (j *IdentifyJob) getSources() ([]identify.ScraperSource, error) {
 
 		var src identify.ScraperSource
 		if stashBox != nil {
+			s := stashboxSource{
+				stashbox.NewClient(*stashBox, instance.Repository, stashbox.Repository{
+					Scene:     instance.Repository.Scene,
+					Performer: instance.Repository.Performer,
+					Tag:       instance.Repository.Tag,
+					Studio:    instance.Repository.Studio,
+					File:      instance.Repository.File,
+				}),
+				stashBox.Endpoint,
+			}
 			src = identify.ScraperSource{
-				Name: "stash-box: " + stashBox.Endpoint,
-				Scraper: stashboxSource{
-					stashbox.NewClient(*stashBox, instance.Repository, stashbox.Repository{
-						Scene:     instance.Repository.Scene,
-						Performer: instance.Repository.Performer,
-						Tag:       instance.Repository.Tag,
-						Studio:    instance.Repository.Studio,
-					}),
-					stashBox.Endpoint,
-				},
-				RemoteSite: stashBox.Endpoint,
+				Name:             "stash-box: " + stashBox.Endpoint,
+				Scraper:          s,
+				PerformerScraper: s,
+				RemoteSite:       stashBox.Endpoint,
 			}
 		} else {
 			scraperID := *source.Source.ScraperID
-			s := instance.ScraperCache.GetScraper(scraperID)
-			if s == nil {
+			spec := instance.ScraperCache.GetScraper(scraperID)
+			if spec == nil {
 				return nil, fmt.Errorf("%w: scraper with id %q", models.ErrNotFound, scraperID)
 			}
+			s := scraperSource{
+				cache:     instance.ScraperCache,
+				scraperID: scraperID,
+			}
 			src = identify.ScraperSource{
-				Name: s.Name,
-				Scraper: scraperSource{
-					cache:     instance.ScraperCache,
-					scraperID: scraperID,
-				},
+				Name:    spec.Name,
+				Scraper: s,
+			}
+
+			// only use the scraper for the object types it supports
+			if spec.Gallery != nil {
+				src.GalleryScraper = s
+			}
+			if spec.Performer != nil {
+				src.PerformerScraper = s
+			}
+			if spec.Movie != nil {
+				src.MovieScraper = s
 			}
 		}
 
@@ -199,6 +278,56 @@ func (j *IdentifyJob) getSources() ([]identify.ScraperSource, error) {
 	return ret, nil
 }
 
+// getFieldSourcePriority resolves the sources of each field to indexes into
+// the identify sources.
+func (j *IdentifyJob) getFieldSourcePriority(sources []identify.ScraperSource) (map[string][]int, error) {
+	if len(j.input.FieldSourcePriority) == 0 {
+		return nil, nil
+	}
+
+	ret := make(map[string][]int)
+	for _, f := range j.input.FieldSourcePriority {
+		var indexes []int
+		for _, src := range f.Sources {
+			index, err := j.findSourceIndex(src, sources)
+			if err != nil {
+				return nil, fmt.Errorf("source priority of field %s: %w", f.Field, err)
+			}
+
+			indexes = append(indexes, index)
+		}
+
+		ret[f.Field] = indexes
+	}
+
+	return ret, nil
+}
+
+func (j *IdentifyJob) findSourceIndex(src *scraper.Source, sources []identify.ScraperSource) (int, error) {
+	if src.ScraperID != nil {
+		for i, s := range j.input.Sources {
+			if s.Source.ScraperID != nil && *s.Source.ScraperID == *src.ScraperID {
+				return i, nil
+			}
+		}
+
+		return -1, fmt.Errorf("%w: scraper %q is not an identify source", ErrInput, *src.ScraperID)
+	}
+
+	stashBox, err := j.getStashBox(src)
+	if err != nil {
+		return -1, err
+	}
+
+	for i, s := range sources {
+		if s.RemoteSite != "" && strings.EqualFold(s.RemoteSite, stashBox.Endpoint) {
+			return i, nil
+		}
+	}
+
+	return -1, fmt.Errorf("%w: stash-box %q is not an identify source", ErrInput, stashBox.Endpoint)
+}
+
 func (j *IdentifyJob) getStashBox(src *scraper.Source) (*models.StashBox, error) {
 	if src.ScraperID != nil {
 		return nil, nil
@@ -0,0 +1,354 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/stashapp/stash/internal/identify"
+	"github.com/stashapp/stash/pkg/job"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scraper"
+	"github.com/stashapp/stash/pkg/sliceutil/stringslice"
+)
+
+// allObjects returns a find filter which returns all objects, sorted by the
+// given field.
+func allObjects(sort string) *models.FindFilterType {
+	perPage := -1
+	return &models.FindFilterType{
+		Sort:    &sort,
+		PerPage: &perPage,
+	}
+}
+
+func (j *IdentifyJob) identifyGalleries(ctx context.Context, sources []identify.ScraperSource) error {
+	var galleries []*models.Gallery
+	if len(j.input.GalleryIDs) > 0 {
+		ids, err := stringslice.StringSliceToIntSlice(j.input.GalleryIDs)
+		if err != nil {
+			return fmt.Errorf("invalid gallery IDs: %w", err)
+		}
+
+		galleries, err = instance.Repository.Gallery.FindMany(ctx, ids)
+		if err != nil {
+			return fmt.Errorf("finding galleries: %w", err)
+		}
+	} else {
+		var err error
+		galleries, _, err = instance.Repository.Gallery.Query(ctx, j.input.GalleryFilter, allObjects("path"))
+		if err != nil {
+			return fmt.Errorf("querying galleries: %w", err)
+		}
+	}
+
+	j.progress.AddTotal(len(galleries))
+
+	task := identify.GalleryIdentifier{
+		GalleryReaderUpdater: instance.Repository.Gallery,
+		StudioCreator:        instance.Repository.Studio,
+		PerformerCreator:     instance.Repository.Performer,
+		TagCreator:           instance.Repository.Tag,
+
+		DefaultOptions:   j.input.Options,
+		Sources:          sources,
+		PostHookExecutor: j.postHookExecutor,
+	}
+
+	for _, g := range galleries {
+		if job.IsCancelled(ctx) {
+			return nil
+		}
+
+		j.progress.ExecuteTask("Identifying gallery "+g.DisplayName(), func() {
+			if err := task.Identify(ctx, instance.Repository, g); err != nil {
+				logger.Errorf("Error encountered identifying gallery %s: %v", g.DisplayName(), err)
+			}
+		})
+
+		j.progress.Increment()
+	}
+
+	return nil
+}
+
+func (j *IdentifyJob) identifyPerformers(ctx context.Context, sources []identify.ScraperSource) error {
+	var performers []*models.Performer
+	if len(j.input.PerformerIDs) > 0 {
+		ids, err := stringslice.StringSliceToIntSlice(j.input.PerformerIDs)
+		if err != nil {
+			return fmt.Errorf("invalid performer IDs: %w", err)
+		}
+
+		performers, err = instance.Repository.Performer.FindMany(ctx, ids)
+		if err != nil {
+			return fmt.Errorf("finding performers: %w", err)
+		}
+	} else {
+		var err error
+		performers, _, err = instance.Repository.Performer.Query(ctx, j.input.PerformerFilter, allObjects("name"))
+		if err != nil {
+			return fmt.Errorf("querying performers: %w", err)
+		}
+	}
+
+	j.progress.AddTotal(len(performers))
+
+	task := identify.PerformerIdentifier{
+		PerformerReaderUpdater: instance.Repository.Performer,
+		TagCreator:             instance.Repository.Tag,
+
+		DefaultOptions:   j.input.Options,
+		Sources:          sources,
+		PostHookExecutor: j.postHookExecutor,
+	}
+
+	for _, p := range performers {
+		if job.IsCancelled(ctx) {
+			return nil
+		}
+
+		// stash ids are used to find the performer on stash-box
+		if err := p.LoadStashIDs(ctx, instance.Repository.Performer); err != nil {
+			return fmt.Errorf("loading stash ids for performer %d: %w", p.ID, err)
+		}
+
+		j.progress.ExecuteTask("Identifying performer "+p.Name, func() {
+			if err := task.Identify(ctx, instance.Repository, p); err != nil {
+				logger.Errorf("Error encountered identifying performer %s: %v", p.Name, err)
+			}
+		})
+
+		j.progress.Increment()
+	}
+
+	return nil
+}
+
+func (j *IdentifyJob) identifyMovies(ctx context.Context, sources []identify.ScraperSource) error {
+	var movies []*models.Movie
+	if len(j.input.MovieIDs) > 0 {
+		ids, err := stringslice.StringSliceToIntSlice(j.input.MovieIDs)
+		if err != nil {
+			return fmt.Errorf("invalid movie IDs: %w", err)
+		}
+
+		movies, err = instance.Repository.Movie.FindMany(ctx, ids)
+		if err != nil {
+			return fmt.Errorf("finding movies: %w", err)
+		}
+	} else {
+		var err error
+		movies, _, err = instance.Repository.Movie.Query(ctx, j.input.MovieFilter, allObjects("name"))
+		if err != nil {
+			return fmt.Errorf("querying movies: %w", err)
+		}
+	}
+
+	j.progress.AddTotal(len(movies))
+
+	task := identify.MovieIdentifier{
+		MovieReaderUpdater: instance.Repository.Movie,
+		StudioCreator:      instance.Repository.Studio,
+
+		DefaultOptions:   j.input.Options,
+		Sources:          sources,
+		PostHookExecutor: j.postHookExecutor,
+	}
+
+	for _, m := range movies {
+		if job.IsCancelled(ctx) {
+			return nil
+		}
+
+		j.progress.ExecuteTask("Identifying movie "+m.Name, func() {
+			if err := task.Identify(ctx, instance.Repository, m); err != nil {
+				logger.Errorf("Error encountered identifying movie %s: %v", m.Name, err)
+			}
+		})
+
+		j.progress.Increment()
+	}
+
+	return nil
+}
+
+// ScrapePerformer finds the performer using its stash id for the endpoint if
+// set, otherwise by exact name.
+func (s stashboxSource) ScrapePerformer(ctx context.Context, p *models.Performer) (*models.ScrapedPerformer, error) {
+	for _, stashID := range p.StashIDs.List() {
+		if stashID.Endpoint == s.endpoint {
+			ret, err := s.FindStashBoxPerformerByID(ctx, stashID.StashID)
+			if err != nil {
+				return nil, fmt.Errorf("error querying stash-box using stash id %s: %w", stashID.StashID, err)
+			}
+
+			return ret, nil
+		}
+	}
+
+	ret, err := s.FindStashBoxPerformerByName(ctx, p.Name)
+	if err != nil {
+		return nil, fmt.Errorf("error querying stash-box using name %s: %w", p.Name, err)
+	}
+
+	return ret, nil
+}
+
+// ScrapeGallery scrapes the gallery by fragment, falling back to the gallery
+// url if the scraper does not support gallery fragments.
+func (s scraperSource) ScrapeGallery(ctx context.Context, g *models.Gallery) (*scraper.ScrapedGallery, error) {
+	content, err := s.cache.ScrapeID(ctx, s.scraperID, g.ID, scraper.ScrapeContentTypeGallery)
+	if err != nil && !errors.Is(err, scraper.ErrNotSupported) {
+		return nil, err
+	}
+
+	if content == nil && g.URL != "" {
+		content, err = s.cache.ScrapeURLWithScraper(ctx, s.scraperID, g.URL, scraper.ScrapeContentTypeGallery)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// don't try to convert nil return value
+	if content == nil {
+		return nil, nil
+	}
+
+	switch v := content.(type) {
+	case scraper.ScrapedGallery:
+		return &v, nil
+	case *scraper.ScrapedGallery:
+		return v, nil
+	}
+
+	return nil, errors.New("could not convert content to gallery")
+}
+
+// ScrapePerformer scrapes the performer using its url if supported.
+// Otherwise, the performer is searched for by name, and the result matching
+// the name exactly is scraped in full.
+func (s scraperSource) ScrapePerformer(ctx context.Context, p *models.Performer) (*models.ScrapedPerformer, error) {
+	if p.URL != "" {
+		content, err := s.cache.ScrapeURLWithScraper(ctx, s.scraperID, p.URL, scraper.ScrapeContentTypePerformer)
+		if err != nil {
+			return nil, err
+		}
+
+		if content != nil {
+			return toScrapedPerformer(content)
+		}
+	}
+
+	results, err := s.cache.ScrapeName(ctx, s.scraperID, p.Name, scraper.ScrapeContentTypePerformer)
+	if errors.Is(err, scraper.ErrNotSupported) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var found *models.ScrapedPerformer
+	for _, r := range results {
+		sp, err := toScrapedPerformer(r)
+		if err != nil {
+			return nil, err
+		}
+
+		if sp.Name != nil && strings.EqualFold(*sp.Name, p.Name) {
+			found = sp
+			break
+		}
+	}
+
+	if found == nil {
+		return nil, nil
+	}
+
+	// search results are usually incomplete, so scrape the full performer
+	content, err := s.cache.ScrapeFragment(ctx, s.scraperID, scraper.Input{
+		Performer: scrapedToPerformerInput(found),
+	})
+	if errors.Is(err, scraper.ErrNotSupported) {
+		// use the search result as is
+		return found, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if content == nil {
+		return nil, nil
+	}
+
+	return toScrapedPerformer(content)
+}
+
+// ScrapeMovie scrapes the movie using its url.
+func (s scraperSource) ScrapeMovie(ctx context.Context, m *models.Movie) (*models.ScrapedMovie, error) {
+	if m.URL == "" {
+		return nil, nil
+	}
+
+	content, err := s.cache.ScrapeURLWithScraper(ctx, s.scraperID, m.URL, scraper.ScrapeContentTypeMovie)
+	if err != nil {
+		return nil, err
+	}
+
+	// don't try to convert nil return value
+	if content == nil {
+		return nil, nil
+	}
+
+	switch v := content.(type) {
+	case models.ScrapedMovie:
+		return &v, nil
+	case *models.ScrapedMovie:
+		return v, nil
+	}
+
+	return nil, errors.New("could not convert content to movie")
+}
+
+func toScrapedPerformer(content scraper.ScrapedContent) (*models.ScrapedPerformer, error) {
+	switch v := content.(type) {
+	case models.ScrapedPerformer:
+		return &v, nil
+	case *models.ScrapedPerformer:
+		return v, nil
+	}
+
+	return nil, errors.New("could not convert content to performer")
+}
+
+func scrapedToPerformerInput(p *models.ScrapedPerformer) *scraper.ScrapedPerformerInput {
+	return &scraper.ScrapedPerformerInput{
+		StoredID:       p.StoredID,
+		Name:           p.Name,
+		Disambiguation: p.Disambiguation,
+		Gender:         p.Gender,
+		URL:            p.URL,
+		Twitter:        p.Twitter,
+		Instagram:      p.Instagram,
+		Birthdate:      p.Birthdate,
+		Ethnicity:      p.Ethnicity,
+		Country:        p.Country,
+		EyeColor:       p.EyeColor,
+		Height:         p.Height,
+		Measurements:   p.Measurements,
+		FakeTits:       p.FakeTits,
+		PenisLength:    p.PenisLength,
+		Circumcised:    p.Circumcised,
+		CareerLength:   p.CareerLength,
+		Tattoos:        p.Tattoos,
+		Piercings:      p.Piercings,
+		Aliases:        p.Aliases,
+		Details:        p.Details,
+		DeathDate:      p.DeathDate,
+		HairColor:      p.HairColor,
+		Weight:         p.Weight,
+		RemoteSiteID:   p.RemoteSiteID,
+	}
+}
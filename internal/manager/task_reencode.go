@@ -0,0 +1,442 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/ffmpeg/transcoder"
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/job"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scene"
+	"github.com/stashapp/stash/pkg/sliceutil/stringslice"
+	"github.com/stashapp/stash/pkg/txn"
+)
+
+type ReencodeVideoCodec string
+
+const (
+	ReencodeVideoCodecH264 ReencodeVideoCodec = "H264"
+	ReencodeVideoCodecHevc ReencodeVideoCodec = "HEVC"
+	ReencodeVideoCodecAv1  ReencodeVideoCodec = "AV1"
+)
+
+var AllReencodeVideoCodec = []ReencodeVideoCodec{
+	ReencodeVideoCodecH264,
+	ReencodeVideoCodecHevc,
+	ReencodeVideoCodecAv1,
+}
+
+func (e ReencodeVideoCodec) IsValid() bool {
+	switch e {
+	case ReencodeVideoCodecH264, ReencodeVideoCodecHevc, ReencodeVideoCodecAv1:
+		return true
+	}
+	return false
+}
+
+func (e ReencodeVideoCodec) String() string {
+	return string(e)
+}
+
+func (e *ReencodeVideoCodec) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ReencodeVideoCodec(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ReencodeVideoCodec", str)
+	}
+	return nil
+}
+
+func (e ReencodeVideoCodec) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ReencodeAudioCodec string
+
+const (
+	ReencodeAudioCodecCopy ReencodeAudioCodec = "COPY"
+	ReencodeAudioCodecAac  ReencodeAudioCodec = "AAC"
+	ReencodeAudioCodecOpus ReencodeAudioCodec = "OPUS"
+)
+
+var AllReencodeAudioCodec = []ReencodeAudioCodec{
+	ReencodeAudioCodecCopy,
+	ReencodeAudioCodecAac,
+	ReencodeAudioCodecOpus,
+}
+
+func (e ReencodeAudioCodec) IsValid() bool {
+	switch e {
+	case ReencodeAudioCodecCopy, ReencodeAudioCodecAac, ReencodeAudioCodecOpus:
+		return true
+	}
+	return false
+}
+
+func (e ReencodeAudioCodec) String() string {
+	return string(e)
+}
+
+func (e *ReencodeAudioCodec) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ReencodeAudioCodec(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ReencodeAudioCodec", str)
+	}
+	return nil
+}
+
+func (e ReencodeAudioCodec) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ReencodeInput struct {
+	// Scenes to re-encode. If empty, scene_filter is used
+	SceneIDs []string `json:"scene_ids"`
+	// Filter of scenes to re-encode. If empty, all scenes are re-encoded
+	SceneFilter *models.SceneFilterType `json:"scene_filter"`
+	VideoCodec  ReencodeVideoCodec      `json:"video_codec"`
+	// Constant rate factor. Defaults to a value suitable for the codec
+	Crf        *int                `json:"crf"`
+	AudioCodec *ReencodeAudioCodec `json:"audio_codec"`
+	// Re-encode files already using the target codec, and keep re-encoded
+	// files that are larger than the original
+	Force bool `json:"force"`
+}
+
+// ReencodeJob converts the primary files of scenes to an MP4 file with the
+// given codecs, replacing the original file.
+type ReencodeJob struct {
+	repository Repository
+	scanner    scanner
+	input      ReencodeInput
+
+	// saved is the total number of bytes saved
+	saved int64
+}
+
+// reencodeTmpSuffix is appended to the path of the original file while
+// re-encoding. It is not a video extension so it is not picked up by scans.
+const reencodeTmpSuffix = ".reencode.tmp"
+
+func (j *ReencodeJob) Execute(ctx context.Context, progress *job.Progress) {
+	scenes, err := findScenesWithPrimaryFile(ctx, j.repository, j.input.SceneIDs, j.input.SceneFilter)
+	if err != nil {
+		logger.Errorf("Error getting scenes: %v", err)
+		return
+	}
+
+	progress.SetTotal(len(scenes))
+
+	var count int
+	for _, s := range scenes {
+		if job.IsCancelled(ctx) {
+			logger.Info("Stopping due to user request")
+			break
+		}
+
+		f := s.Files.Primary()
+		if j.required(f) {
+			progress.ExecuteTask(fmt.Sprintf("Re-encoding %s", f.Path), func() {
+				if err := j.reencodeScene(ctx, s, progress); err != nil {
+					logger.Errorf("Error re-encoding %s: %v", f.Path, err)
+					logErrorOutput(err)
+					return
+				}
+				count++
+			})
+		}
+
+		progress.Increment()
+	}
+
+	logger.Infof("Re-encoded %d files, saving %s", count, formatSize(j.saved))
+}
+
+// findScenesWithPrimaryFile returns the scenes with the given IDs, or the
+// scenes matching the filter if no IDs are given. Scenes without a primary
+// file are excluded.
+func findScenesWithPrimaryFile(ctx context.Context, r Repository, sceneIDs []string, sceneFilter *models.SceneFilterType) ([]*models.Scene, error) {
+	var ret []*models.Scene
+
+	addScene := func(ctx context.Context, s *models.Scene) error {
+		if err := s.LoadPrimaryFile(ctx, r.File); err != nil {
+			return err
+		}
+
+		if s.Files.Primary() != nil {
+			ret = append(ret, s)
+		}
+		return nil
+	}
+
+	if err := r.WithReadTxn(ctx, func(ctx context.Context) error {
+		if len(sceneIDs) > 0 {
+			ids, err := stringslice.StringSliceToIntSlice(sceneIDs)
+			if err != nil {
+				return err
+			}
+
+			scenes, err := r.Scene.FindMany(ctx, ids)
+			if err != nil {
+				return err
+			}
+
+			for _, s := range scenes {
+				if err := addScene(ctx, s); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}
+
+		sort := "path"
+		findFilter := &models.FindFilterType{
+			Sort: &sort,
+		}
+
+		return scene.BatchProcess(ctx, r.Scene, sceneFilter, findFilter, func(s *models.Scene) error {
+			return addScene(ctx, s)
+		})
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (j *ReencodeJob) targetCodec() string {
+	switch j.input.VideoCodec {
+	case ReencodeVideoCodecHevc:
+		return ffmpeg.Hevc
+	case ReencodeVideoCodecAv1:
+		return ffmpeg.Av1
+	default:
+		return ffmpeg.H264
+	}
+}
+
+func (j *ReencodeJob) required(f *file.VideoFile) bool {
+	// files in zip files cannot be replaced
+	if f.ZipFileID != nil {
+		return false
+	}
+
+	if j.input.Force {
+		return true
+	}
+
+	return f.VideoCodec != j.targetCodec() || !strings.EqualFold(filepath.Ext(f.Path), ".mp4")
+}
+
+func (j *ReencodeJob) transcodeOptions(output string) transcoder.TranscodeOptions {
+	ret := transcoder.TranscodeOptions{
+		OutputPath: output,
+		Format:     ffmpeg.FormatMP4,
+		AudioCodec: ffmpeg.AudioCodecAAC,
+		ExtraOutputArgs: []string{
+			"-map_metadata", "0",
+			"-movflags", "+faststart",
+		},
+	}
+
+	var crf int
+	switch j.input.VideoCodec {
+	case ReencodeVideoCodecHevc:
+		crf = 26
+		ret.VideoCodec = ffmpeg.VideoCodecLibX265
+		// hvc1 tag is required for playback on Apple devices
+		ret.VideoArgs = ffmpeg.Args{"-preset", "medium", "-tag:v", "hvc1"}
+	case ReencodeVideoCodecAv1:
+		crf = 32
+		ret.VideoCodec = ffmpeg.VideoCodecLibSVTAV1
+		ret.VideoArgs = ffmpeg.Args{"-preset", "8"}
+	default:
+		crf = 22
+		ret.VideoCodec = ffmpeg.VideoCodecLibX264
+		ret.VideoArgs = ffmpeg.Args{"-preset", "medium", "-pix_fmt", "yuv420p"}
+	}
+
+	if j.input.Crf != nil {
+		crf = *j.input.Crf
+	}
+	ret.VideoArgs = append(ret.VideoArgs, "-crf", strconv.Itoa(crf))
+
+	if j.input.AudioCodec != nil {
+		switch *j.input.AudioCodec {
+		case ReencodeAudioCodecCopy:
+			ret.AudioCodec = ffmpeg.AudioCodecCopy
+		case ReencodeAudioCodecOpus:
+			ret.AudioCodec = ffmpeg.AudioCodecLibOpus
+		}
+	}
+
+	return ret
+}
+
+func (j *ReencodeJob) reencodeScene(ctx context.Context, s *models.Scene, progress *job.Progress) error {
+	f := s.Files.Primary()
+	tmpPath := f.Path + reencodeTmpSuffix
+
+	lockCtx := instance.ReadLockManager.ReadLock(ctx, f.Path)
+	err := instance.FFMPEG.Generate(lockCtx, transcoder.Transcode(f.Path, j.transcodeOptions(tmpPath)))
+	lockCtx.Cancel()
+
+	if err == nil {
+		err = verifyVideoFile(ctx, f, tmpPath, j.targetCodec())
+	}
+
+	var size int64
+	if err == nil {
+		var info os.FileInfo
+		info, err = os.Stat(tmpPath)
+		if err == nil {
+			size = info.Size()
+			if size >= f.Size && !j.input.Force {
+				err = fmt.Errorf("re-encoded file is not smaller than the original (%s)", formatSize(size))
+			}
+		}
+	}
+
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	newPath, err := replaceVideoFile(ctx, j.repository, f, tmpPath)
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	logger.Infof("Replaced %s with %s (%s, oshash %s): saved %s",
+		f.Path, newPath, formatSize(size), f.Fingerprints.GetString(file.FingerprintTypeOshash), formatSize(f.Size-size))
+	j.saved += f.Size - size
+
+	// rescan the file to update its fingerprints and metadata. The previous
+	// fingerprints are kept in the fingerprint history.
+	scanVideoFiles(ctx, j.scanner, []string{newPath}, progress)
+
+	return nil
+}
+
+// verifyVideoFile checks that the converted file at path has the expected
+// video codec and the same duration as the original, and can be decoded
+// without errors.
+func verifyVideoFile(ctx context.Context, original *file.VideoFile, path string, videoCodec string) error {
+	probe, err := instance.FFProbe.NewVideoFile(path)
+	if err != nil {
+		return fmt.Errorf("reading converted file: %w", err)
+	}
+
+	if probe.VideoCodec != videoCodec {
+		return fmt.Errorf("converted file has video codec %q", probe.VideoCodec)
+	}
+
+	tolerance := math.Max(1, original.Duration*0.01)
+	if math.Abs(probe.FileDuration-original.Duration) > tolerance {
+		return fmt.Errorf("converted file duration %.2f does not match original duration %.2f", probe.FileDuration, original.Duration)
+	}
+
+	var args ffmpeg.Args
+	args = args.LogLevel(ffmpeg.LogLevelError).XError()
+	args = args.Input(path)
+	args = args.Format(ffmpeg.FormatNull)
+	args = args.NullOutput()
+
+	if err := instance.FFMPEG.Generate(ctx, args); err != nil {
+		return fmt.Errorf("decoding converted file: %w", err)
+	}
+
+	return nil
+}
+
+// replaceVideoFile replaces the original file with the converted file at
+// tmpPath, renaming the file to use the mp4 extension. The file entry is
+// kept so that the scene is retained, and the fingerprints of the original
+// file are kept in the fingerprint history of the file, so that the scene
+// can still be matched by them. Returns the new path of the file.
+func replaceVideoFile(ctx context.Context, r Repository, f *file.VideoFile, tmpPath string) (string, error) {
+	ext := filepath.Ext(f.Basename)
+	basename := strings.TrimSuffix(f.Basename, ext) + ".mp4"
+	newPath := filepath.Join(filepath.Dir(f.Path), basename)
+	backupPath := f.Path + ".bak.tmp"
+
+	if err := r.WithTxn(ctx, func(ctx context.Context) error {
+		folder, err := r.Folder.Find(ctx, f.ParentFolderID)
+		if err != nil {
+			return err
+		}
+		if folder == nil {
+			return fmt.Errorf("folder %d not found", f.ParentFolderID)
+		}
+
+		// restore the original file before the mover moves it back
+		var replaced bool
+		txn.AddPostRollbackHook(ctx, func(ctx context.Context) {
+			if replaced {
+				if err := os.Rename(backupPath, newPath); err != nil {
+					logger.Errorf("Error restoring %s: %v", backupPath, err)
+				}
+			}
+		})
+		txn.AddPostCommitHook(ctx, func(ctx context.Context) {
+			if err := os.Remove(backupPath); err != nil {
+				logger.Warnf("Error removing %s: %v", backupPath, err)
+			}
+		})
+
+		// the rescan replaces the fingerprints of the file
+		if err := r.File.AddFingerprintHistory(ctx, f.ID, f.Fingerprints); err != nil {
+			return fmt.Errorf("saving fingerprint history: %w", err)
+		}
+
+		mover := file.NewMover(r.File, r.Folder)
+		mover.RegisterHooks(ctx, r)
+
+		if basename != f.Basename {
+			if err := mover.Move(ctx, f, folder, basename); err != nil {
+				return err
+			}
+		}
+
+		if err := os.Rename(newPath, backupPath); err != nil {
+			return err
+		}
+		if err := os.Rename(tmpPath, newPath); err != nil {
+			if err := os.Rename(backupPath, newPath); err != nil {
+				logger.Errorf("Error restoring %s: %v", backupPath, err)
+			}
+			return err
+		}
+		replaced = true
+
+		return nil
+	}); err != nil {
+		return "", err
+	}
+
+	return newPath, nil
+}
+
+func formatSize(bytes int64) string {
+	return fmt.Sprintf("%.1f MB", float64(bytes)/(1024*1024))
+}
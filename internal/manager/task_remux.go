@@ -0,0 +1,128 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/ffmpeg/transcoder"
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/job"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+type RemuxInput struct {
+	// Scenes to remux. If empty, scene_filter is used
+	SceneIDs []string `json:"scene_ids"`
+	// Filter of scenes to remux. If empty, all scenes are considered
+	SceneFilter *models.SceneFilterType `json:"scene_filter"`
+}
+
+// RemuxJob copies the streams of scene files with browser supported codecs in
+// an unsupported container into an MP4 file, replacing the original file.
+type RemuxJob struct {
+	repository Repository
+	scanner    scanner
+	input      RemuxInput
+}
+
+// remuxTmpSuffix is appended to the path of the original file while
+// remuxing. It is not a video extension so it is not picked up by scans.
+const remuxTmpSuffix = ".remux.tmp"
+
+func (j *RemuxJob) Execute(ctx context.Context, progress *job.Progress) {
+	scenes, err := findScenesWithPrimaryFile(ctx, j.repository, j.input.SceneIDs, j.input.SceneFilter)
+	if err != nil {
+		logger.Errorf("Error getting scenes: %v", err)
+		return
+	}
+
+	progress.SetTotal(len(scenes))
+
+	var count int
+	for _, s := range scenes {
+		if job.IsCancelled(ctx) {
+			logger.Info("Stopping due to user request")
+			break
+		}
+
+		f := s.Files.Primary()
+		if j.required(f) {
+			progress.ExecuteTask(fmt.Sprintf("Remuxing %s", f.Path), func() {
+				if err := j.remuxScene(ctx, f, progress); err != nil {
+					logger.Errorf("Error remuxing %s: %v", f.Path, err)
+					logErrorOutput(err)
+					return
+				}
+				count++
+			})
+		}
+
+		progress.Increment()
+	}
+
+	logger.Infof("Remuxed %d files", count)
+}
+
+func (j *RemuxJob) required(f *file.VideoFile) bool {
+	// files in zip files cannot be replaced
+	if f.ZipFileID != nil {
+		return false
+	}
+
+	container, err := GetVideoFileContainer(f)
+	if err != nil {
+		logger.Warnf("Error getting container of %s: %v", f.Path, err)
+		return false
+	}
+
+	audioCodec := ffmpeg.MissingUnsupported
+	if f.AudioCodec != "" {
+		audioCodec = ffmpeg.ProbeAudioCodec(f.AudioCodec)
+	}
+
+	return ffmpeg.IsRemuxable(f.VideoCodec, audioCodec, container)
+}
+
+func (j *RemuxJob) remuxScene(ctx context.Context, f *file.VideoFile, progress *job.Progress) error {
+	tmpPath := f.Path + remuxTmpSuffix
+
+	args := transcoder.Transcode(f.Path, transcoder.TranscodeOptions{
+		OutputPath: tmpPath,
+		Format:     ffmpeg.FormatMP4,
+		VideoCodec: ffmpeg.VideoCodecCopy,
+		AudioCodec: ffmpeg.AudioCodecCopy,
+		ExtraOutputArgs: []string{
+			"-map_metadata", "0",
+			"-movflags", "+faststart",
+		},
+	})
+
+	lockCtx := instance.ReadLockManager.ReadLock(ctx, f.Path)
+	err := instance.FFMPEG.Generate(lockCtx, args)
+	lockCtx.Cancel()
+
+	if err == nil {
+		err = verifyVideoFile(ctx, f, tmpPath, f.VideoCodec)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	newPath, err := replaceVideoFile(ctx, j.repository, f, tmpPath)
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	logger.Infof("Replaced %s with %s", f.Path, newPath)
+
+	// rescan the file to update its fingerprints and metadata
+	scanVideoFiles(ctx, j.scanner, []string{newPath}, progress)
+
+	return nil
+}
@@ -553,9 +553,10 @@ func (g *sceneGenerators) Generate(ctx context.Context, s *models.Scene, f *file
 		progress.AddTotal(1)
 		g.taskQueue.Add(fmt.Sprintf("Generating cover for %s", path), func(ctx context.Context) {
 			taskCover := GenerateCoverTask{
-				Scene:      *s,
-				txnManager: instance.Repository,
-				Overwrite:  overwrite,
+				Scene:               *s,
+				txnManager:          instance.Repository,
+				Overwrite:           overwrite,
+				fileNamingAlgorithm: fileNamingAlgorithm,
 			}
 			taskCover.Start(ctx)
 			progress.Increment()
@@ -0,0 +1,541 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/ffmpeg/transcoder"
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/fsutil"
+	"github.com/stashapp/stash/pkg/job"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scene"
+)
+
+type SceneSplitInput struct {
+	ID string `json:"id"`
+	// Times in seconds to split the file at
+	Times []float64 `json:"times"`
+	// Split the file at the times of the scene's markers
+	Markers bool `json:"markers"`
+	// Re-encode the file so that the cuts are frame accurate
+	Reencode bool `json:"reencode"`
+}
+
+type SceneTrimInput struct {
+	ID string `json:"id"`
+	// Time in seconds of the new start of the file
+	Start *float64 `json:"start"`
+	// Time in seconds of the new end of the file
+	End *float64 `json:"end"`
+	// Re-encode the file so that the cuts are frame accurate
+	Reencode bool `json:"reencode"`
+}
+
+// sceneMarkerTags is a scene marker with its loaded tag IDs.
+type sceneMarkerTags struct {
+	marker *models.SceneMarker
+	tagIDs []int
+}
+
+// loadSplitScene returns the scene with the given ID with its relationships,
+// primary file and markers loaded.
+func loadSplitScene(ctx context.Context, r Repository, id string) (*models.Scene, []sceneMarkerTags, error) {
+	sceneID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var s *models.Scene
+	var markers []sceneMarkerTags
+	if err := r.WithReadTxn(ctx, func(ctx context.Context) error {
+		s, err = r.Scene.Find(ctx, sceneID)
+		if err != nil {
+			return err
+		}
+		if s == nil {
+			return fmt.Errorf("scene with id %d not found", sceneID)
+		}
+
+		if err := s.LoadRelationships(ctx, r.Scene); err != nil {
+			return err
+		}
+		if err := s.LoadPrimaryFile(ctx, r.File); err != nil {
+			return err
+		}
+		if s.Files.Primary() == nil {
+			return fmt.Errorf("scene with id %d has no files", sceneID)
+		}
+
+		sm, err := r.SceneMarker.FindBySceneID(ctx, sceneID)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range sm {
+			tagIDs, err := r.SceneMarker.GetTagIDs(ctx, m.ID)
+			if err != nil {
+				return err
+			}
+			markers = append(markers, sceneMarkerTags{marker: m, tagIDs: tagIDs})
+		}
+
+		return nil
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	return s, markers, nil
+}
+
+// cutVideo extracts the segment of the input file into output. Streams are
+// copied unless reencode is true. Returns the time in the input file at
+// which the output starts, which is before the start of the segment if the
+// cut was moved to the preceding keyframe.
+func cutVideo(ctx context.Context, input string, output string, seg models.SceneSegment, reencode bool) (float64, error) {
+	options := transcoder.CutOptions{
+		OutputPath: output,
+		StartTime:  seg.Start,
+		Duration:   seg.End - seg.Start,
+	}
+
+	if reencode {
+		if strings.EqualFold(filepath.Ext(output), ".webm") {
+			options.VideoCodec = ffmpeg.VideoCodecVP9
+			options.VideoArgs = ffmpeg.Args{"-crf", "30", "-b:v", "0"}
+			options.AudioCodec = ffmpeg.AudioCodecLibOpus
+		} else {
+			options.VideoCodec = ffmpeg.VideoCodecLibX264
+			options.VideoArgs = ffmpeg.Args{"-pix_fmt", "yuv420p", "-preset", "fast", "-crf", "18"}
+			options.AudioCodec = ffmpeg.AudioCodecAAC
+		}
+	}
+
+	lockCtx := instance.ReadLockManager.ReadLock(ctx, input)
+	defer lockCtx.Cancel()
+
+	if err := instance.FFMPEG.Generate(lockCtx, transcoder.Cut(input, options)); err != nil {
+		// remove the partial output
+		_ = os.Remove(output)
+		return 0, err
+	}
+
+	if reencode || seg.Start == 0 {
+		return seg.Start, nil
+	}
+
+	start, err := cutStartTime(output, seg)
+	if err != nil {
+		_ = os.Remove(output)
+		return 0, err
+	}
+
+	return start, nil
+}
+
+// cutStartTime returns the time in the input file at which the stream copied
+// output of the segment starts. Copied streams start at the keyframe before
+// the start of the segment, and run until its end.
+func cutStartTime(output string, seg models.SceneSegment) (float64, error) {
+	probe, err := instance.FFProbe.NewVideoFile(output)
+	if err != nil {
+		return 0, fmt.Errorf("reading cut file: %w", err)
+	}
+
+	start := seg.End - probe.FileDuration
+	if start < 0 {
+		start = 0
+	}
+	if start > seg.Start {
+		start = seg.Start
+	}
+
+	return start, nil
+}
+
+// scanVideoFiles scans the given files into the database, creating or
+// updating their scenes.
+func scanVideoFiles(ctx context.Context, s scanner, paths []string, progress *job.Progress) {
+	c := instance.Config
+	taskQueue := job.NewTaskQueue(ctx, progress, len(paths), 1)
+
+	s.Scan(ctx, getScanHandlers(ScanMetadataInput{}, taskQueue, progress), file.ScanOptions{
+		Paths:             paths,
+		ScanFilters:       []file.PathFilter{newScanFilter(c, time.Time{})},
+		ZipFileExtensions: c.GetGalleryExtensions(),
+		ParallelTasks:     1,
+		HandlerRequiredFilters: []file.Filter{
+			newHandlerRequiredFilter(c),
+		},
+	}, progress)
+
+	taskQueue.Close()
+}
+
+// SplitSceneJob splits the primary file of a scene into new files, and
+// creates a scene for each with the metadata of the original scene.
+type SplitSceneJob struct {
+	repository Repository
+	scanner    scanner
+	input      SceneSplitInput
+}
+
+func (j *SplitSceneJob) Execute(ctx context.Context, progress *job.Progress) {
+	s, markers, err := loadSplitScene(ctx, j.repository, j.input.ID)
+	if err != nil {
+		logger.Errorf("Error loading scene: %v", err)
+		return
+	}
+
+	f := s.Files.Primary()
+
+	times := j.input.Times
+	if j.input.Markers {
+		for _, m := range markers {
+			times = append(times, m.marker.Seconds)
+		}
+	}
+
+	segments := scene.SplitSegments(f.Duration, times)
+	if len(segments) < 2 {
+		logger.Warnf("No split points within %s", f.Path)
+		return
+	}
+
+	progress.SetTotal(len(segments))
+
+	var outputs []string
+	var starts []float64
+	for i, seg := range segments {
+		if job.IsCancelled(ctx) {
+			logger.Info("Stopping due to user request")
+			removeSplitOutputs(outputs)
+			return
+		}
+
+		output := splitOutputPath(f.Path, i+1)
+		if exists, _ := fsutil.FileExists(output); exists {
+			logger.Errorf("Error splitting %s: %s already exists", f.Path, output)
+			removeSplitOutputs(outputs)
+			return
+		}
+
+		var start float64
+		progress.ExecuteTask(fmt.Sprintf("Cutting %s", output), func() {
+			start, err = cutVideo(ctx, f.Path, output, seg, j.input.Reencode)
+		})
+		if err != nil {
+			logger.Errorf("Error splitting %s: %v", f.Path, err)
+			logErrorOutput(err)
+			removeSplitOutputs(outputs)
+			return
+		}
+
+		outputs = append(outputs, output)
+		starts = append(starts, start)
+		progress.Increment()
+	}
+
+	scanVideoFiles(ctx, j.scanner, outputs, progress)
+
+	for i, output := range outputs {
+		if err := j.updateSplitScene(ctx, s, markers, output, segments[i], starts[i], i+1); err != nil {
+			logger.Errorf("Error updating scene for %s: %v", output, err)
+		}
+	}
+
+	logger.Infof("Split %s into %d files", f.Path, len(outputs))
+}
+
+// splitOutputPath returns the path of part n of the file.
+func splitOutputPath(path string, n int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s.part%d%s", strings.TrimSuffix(path, ext), n, ext)
+}
+
+func removeSplitOutputs(outputs []string) {
+	for _, o := range outputs {
+		if err := os.Remove(o); err != nil {
+			logger.Warnf("Error removing %s: %v", o, err)
+		}
+	}
+}
+
+// updateSplitScene copies the metadata and markers of src to the scene
+// created for the split file, which starts at start in the original file.
+func (j *SplitSceneJob) updateSplitScene(ctx context.Context, src *models.Scene, markers []sceneMarkerTags, path string, seg models.SceneSegment, start float64, n int) error {
+	r := j.repository
+	return r.WithTxn(ctx, func(ctx context.Context) error {
+		f, err := r.File.FindByPath(ctx, path)
+		if err != nil {
+			return err
+		}
+		if f == nil {
+			return errors.New("file was not scanned")
+		}
+
+		scenes, err := r.Scene.FindByFileID(ctx, f.Base().ID)
+		if err != nil {
+			return err
+		}
+		if len(scenes) == 0 {
+			return errors.New("scene was not created")
+		}
+
+		dest := scenes[0]
+
+		partial := scene.SplitPartial(src, n)
+		partial.IntroStart, partial.IntroEnd = segmentPartial(scene.RebaseSegment(src.Intro(), seg, start))
+		partial.OutroStart, partial.OutroEnd = segmentPartial(scene.RebaseSegment(src.Outro(), seg, start))
+
+		if _, err := r.Scene.UpdatePartial(ctx, dest.ID, partial); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, m := range markers {
+			newMarker := scene.RebaseMarker(m.marker, seg, start)
+			if newMarker == nil {
+				continue
+			}
+
+			newMarker.ID = 0
+			newMarker.SceneID = dest.ID
+			newMarker.CreatedAt = now
+			newMarker.UpdatedAt = now
+
+			if err := r.SceneMarker.Create(ctx, newMarker); err != nil {
+				return fmt.Errorf("creating scene marker: %w", err)
+			}
+			if err := r.SceneMarker.UpdateTags(ctx, newMarker.ID, m.tagIDs); err != nil {
+				return fmt.Errorf("updating scene marker tags: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// trimBackupSuffix is appended to the path of the original file when it is
+// replaced by the trimmed file. It is not a video extension so the backup is
+// not picked up by scans.
+const trimBackupSuffix = ".untrimmed"
+
+// TrimSceneJob removes the start and/or end of the primary file of a scene,
+// replacing the file. The original file is kept as a backup.
+type TrimSceneJob struct {
+	repository Repository
+	scanner    scanner
+	input      SceneTrimInput
+}
+
+func (j *TrimSceneJob) Execute(ctx context.Context, progress *job.Progress) {
+	s, markers, err := loadSplitScene(ctx, j.repository, j.input.ID)
+	if err != nil {
+		logger.Errorf("Error loading scene: %v", err)
+		return
+	}
+
+	f := s.Files.Primary()
+
+	seg := models.SceneSegment{Start: 0, End: f.Duration}
+	if j.input.Start != nil {
+		seg.Start = *j.input.Start
+	}
+	if j.input.End != nil {
+		seg.End = *j.input.End
+	}
+
+	if seg.Start < 0 || seg.End > f.Duration || seg.End <= seg.Start {
+		logger.Errorf("Invalid trim range %.2f-%.2f for %s", seg.Start, seg.End, f.Path)
+		return
+	}
+	if seg.Start == 0 && seg.End == f.Duration {
+		logger.Warnf("Nothing to trim from %s", f.Path)
+		return
+	}
+
+	backup := f.Path + trimBackupSuffix
+	if exists, _ := fsutil.FileExists(backup); exists {
+		logger.Errorf("Error trimming %s: backup %s already exists", f.Path, backup)
+		return
+	}
+
+	progress.SetTotal(1)
+
+	ext := filepath.Ext(f.Path)
+	output := strings.TrimSuffix(f.Path, ext) + ".trim" + ext
+
+	var start float64
+	progress.ExecuteTask(fmt.Sprintf("Trimming %s", f.Path), func() {
+		start, err = cutVideo(ctx, f.Path, output, seg, j.input.Reencode)
+	})
+	if err != nil {
+		logger.Errorf("Error trimming %s: %v", f.Path, err)
+		logErrorOutput(err)
+		return
+	}
+
+	if job.IsCancelled(ctx) {
+		logger.Info("Stopping due to user request")
+		removeSplitOutputs([]string{output})
+		return
+	}
+
+	// the generated files are named by the hash of the untrimmed file, so
+	// they are regenerated for the new hash
+	regenerate := generatedFilesInput(s)
+
+	if err := replaceWithBackup(f.Path, output, backup); err != nil {
+		logger.Errorf("Error replacing %s: %v", f.Path, err)
+		removeSplitOutputs([]string{output})
+		return
+	}
+
+	logger.Infof("Kept the untrimmed file as %s", backup)
+
+	progress.Increment()
+
+	// rescan the file to update its fingerprints and duration
+	scanVideoFiles(ctx, j.scanner, []string{f.Path}, progress)
+
+	if err := j.updateTrimmedScene(ctx, s, markers, seg, start); err != nil {
+		logger.Errorf("Error updating scene for %s: %v", f.Path, err)
+		return
+	}
+
+	if regenerate.hasGenerated() {
+		if _, err := instance.Generate(ctx, regenerate.GenerateMetadataInput); err != nil {
+			logger.Warnf("Error regenerating files for %s: %v", f.Path, err)
+		}
+	}
+
+	logger.Infof("Trimmed %s to %.2f-%.2f", f.Path, start, seg.End)
+}
+
+// replaceWithBackup renames the file at path to backup, and replaces it with
+// the file at replacement. The original file is restored on failure.
+func replaceWithBackup(path string, replacement string, backup string) error {
+	if err := os.Rename(path, backup); err != nil {
+		return err
+	}
+
+	if err := os.Rename(replacement, path); err != nil {
+		if restoreErr := os.Rename(backup, path); restoreErr != nil {
+			logger.Errorf("Error restoring %s: %v", backup, restoreErr)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// regenerateInput is the input to regenerate the generated files of a scene
+// which existed for its previous hash.
+type regenerateInput struct {
+	GenerateMetadataInput
+}
+
+func (i regenerateInput) hasGenerated() bool {
+	return i.Previews || i.ImagePreviews || i.Sprites || i.Transcodes ||
+		i.Markers || i.MarkerImagePreviews || i.MarkerScreenshots ||
+		i.InteractiveHeatmapsSpeeds || i.SceneCuts
+}
+
+// generatedFilesInput returns the input to regenerate the generated files
+// which exist for the current hash of the scene.
+func generatedFilesInput(s *models.Scene) regenerateInput {
+	hash := s.GetHash(instance.Config.GetVideoFileNamingAlgorithm())
+	ret := regenerateInput{GenerateMetadataInput{
+		SceneIDs:  []string{strconv.Itoa(s.ID)},
+		Overwrite: true,
+	}}
+
+	if hash == "" {
+		return ret
+	}
+
+	exists := func(path string) bool {
+		ret, _ := fsutil.FileExists(path)
+		return ret
+	}
+
+	paths := instance.Paths.Scene
+	ret.Previews = exists(paths.GetVideoPreviewPath(hash))
+	ret.ImagePreviews = exists(paths.GetWebpPreviewPath(hash))
+	ret.Sprites = exists(paths.GetSpriteImageFilePath(hash))
+	ret.Transcodes = exists(paths.GetTranscodePath(hash))
+	ret.InteractiveHeatmapsSpeeds = exists(paths.GetInteractiveHeatmapPath(hash))
+	ret.SceneCuts = exists(paths.GetSceneCutsPath(hash))
+
+	markersDir := filepath.Join(instance.Paths.Generated.Markers, hash)
+	globExists := func(pattern string) bool {
+		matches, _ := filepath.Glob(filepath.Join(markersDir, pattern))
+		return len(matches) > 0
+	}
+
+	ret.Markers = globExists("*.mp4")
+	ret.MarkerImagePreviews = globExists("*.webp")
+	ret.MarkerScreenshots = globExists("*.jpg")
+
+	return ret
+}
+
+// updateTrimmedScene re-bases the markers and intro and outro of s to the
+// trimmed file, which starts at start in the original file, removing any
+// markers outside of the trimmed range. The generated files of the untrimmed
+// file are deleted.
+func (j *TrimSceneJob) updateTrimmedScene(ctx context.Context, s *models.Scene, markers []sceneMarkerTags, seg models.SceneSegment, start float64) error {
+	r := j.repository
+
+	// s still has the hash of the untrimmed file, which is used to name the
+	// generated files
+	fileDeleter := &scene.FileDeleter{
+		Deleter:        file.NewDeleter(),
+		FileNamingAlgo: instance.Config.GetVideoFileNamingAlgorithm(),
+		Paths:          instance.Paths,
+	}
+
+	if err := r.WithTxn(ctx, func(ctx context.Context) error {
+		if err := fileDeleter.MarkGeneratedFiles(s); err != nil {
+			return err
+		}
+
+		for _, m := range markers {
+			newMarker := scene.RebaseMarker(m.marker, seg, start)
+			if newMarker == nil {
+				if err := r.SceneMarker.Destroy(ctx, m.marker.ID); err != nil {
+					return fmt.Errorf("destroying scene marker %d: %w", m.marker.ID, err)
+				}
+				continue
+			}
+
+			newMarker.UpdatedAt = time.Now()
+			if err := r.SceneMarker.Update(ctx, newMarker); err != nil {
+				return fmt.Errorf("updating scene marker %d: %w", m.marker.ID, err)
+			}
+		}
+
+		partial := models.NewScenePartial()
+		partial.IntroStart, partial.IntroEnd = segmentPartial(scene.RebaseSegment(s.Intro(), seg, start))
+		partial.OutroStart, partial.OutroEnd = segmentPartial(scene.RebaseSegment(s.Outro(), seg, start))
+		partial.ResumeTime = models.NewOptionalFloat64(0)
+
+		_, err := r.Scene.UpdatePartial(ctx, s.ID, partial)
+		return err
+	}); err != nil {
+		fileDeleter.Rollback()
+		return err
+	}
+
+	fileDeleter.Commit()
+	return nil
+}
@@ -0,0 +1,720 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/stashapp/stash/internal/identify"
+	"github.com/stashapp/stash/pkg/job"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/plugin"
+	"github.com/stashapp/stash/pkg/scene"
+	"github.com/stashapp/stash/pkg/scraper"
+	"github.com/stashapp/stash/pkg/scraper/stashbox"
+	"github.com/stashapp/stash/pkg/txn"
+)
+
+type StashBoxSyncInput struct {
+	// Index of the stash-box instance to sync with
+	Endpoint int `json:"endpoint"`
+	// Object types to sync. If none are set, all types are synced
+	Scenes     bool `json:"scenes"`
+	Performers bool `json:"performers"`
+	Studios    bool `json:"studios"`
+	// Strategies used to apply the changes
+	Options *identify.MetadataOptions `json:"options"`
+	// Do a dry run. Log the changes without applying them
+	DryRun bool `json:"dryRun"`
+}
+
+func (i StashBoxSyncInput) syncAll() bool {
+	return !i.Scenes && !i.Performers && !i.Studios
+}
+
+// StashBoxSyncJob refreshes the scenes, performers and studios linked to a
+// stash-box instance with the current data of their stash-box entries.
+type StashBoxSyncJob struct {
+	input            StashBoxSyncInput
+	box              *models.StashBox
+	client           *stashbox.Client
+	postHookExecutor identifyPostHookExecutor
+	progress         *job.Progress
+}
+
+func CreateStashBoxSyncJob(input StashBoxSyncInput) (*StashBoxSyncJob, error) {
+	boxes := instance.Config.GetStashBoxes()
+	if input.Endpoint < 0 || input.Endpoint >= len(boxes) {
+		return nil, fmt.Errorf("invalid stash_box_index %d", input.Endpoint)
+	}
+	box := boxes[input.Endpoint]
+
+	var postHookExecutor identifyPostHookExecutor = instance.PluginCache
+	if input.DryRun {
+		postHookExecutor = dryRunPostHookExecutor{}
+
+		// cover images are stored outside of the database transaction, so
+		// they cannot be rolled back
+		options := identify.MetadataOptions{}
+		if input.Options != nil {
+			options = *input.Options
+		}
+		setCoverImage := false
+		options.SetCoverImage = &setCoverImage
+		input.Options = &options
+	}
+
+	return &StashBoxSyncJob{
+		input: input,
+		box:   box,
+		client: stashbox.NewClient(*box, instance.Repository, stashbox.Repository{
+			Scene:     instance.Repository.Scene,
+			Performer: instance.Repository.Performer,
+			Tag:       instance.Repository.Tag,
+			Studio:    instance.Repository.Studio,
+		}),
+		postHookExecutor: postHookExecutor,
+	}, nil
+}
+
+func (j *StashBoxSyncJob) Execute(ctx context.Context, progress *job.Progress) {
+	j.progress = progress
+	progress.Definite()
+
+	logger.Infof("Syncing with stash-box %s", j.box.Endpoint)
+	if j.input.DryRun {
+		logger.Infof("Running in Dry Mode")
+	}
+
+	if j.input.syncAll() || j.input.Scenes {
+		if err := j.syncScenes(ctx); err != nil {
+			logger.Errorf("Error syncing scenes: %v", err)
+			return
+		}
+	}
+
+	if j.input.syncAll() || j.input.Performers {
+		if err := j.syncPerformers(ctx); err != nil {
+			logger.Errorf("Error syncing performers: %v", err)
+			return
+		}
+	}
+
+	if j.input.syncAll() || j.input.Studios {
+		if err := j.syncStudios(ctx); err != nil {
+			logger.Errorf("Error syncing studios: %v", err)
+			return
+		}
+	}
+
+	if job.IsCancelled(ctx) {
+		logger.Info("Stopping due to user request")
+		return
+	}
+
+	logger.Infof("Finished syncing with stash-box %s", j.box.Endpoint)
+}
+
+// linkedFilter returns a stash id criterion matching objects linked to the
+// stash-box instance.
+func (j *StashBoxSyncJob) linkedFilter() *models.StashIDCriterionInput {
+	return &models.StashIDCriterionInput{
+		Endpoint: &j.box.Endpoint,
+		Modifier: models.CriterionModifierNotNull,
+	}
+}
+
+func (j *StashBoxSyncJob) source() identify.ScraperSource {
+	return identify.ScraperSource{
+		Name:       "stash-box: " + j.box.Endpoint,
+		RemoteSite: j.box.Endpoint,
+	}
+}
+
+func (j *StashBoxSyncJob) syncScenes(ctx context.Context) error {
+	var scenes []*models.Scene
+	if err := txn.WithReadTxn(ctx, instance.Repository, func(ctx context.Context) error {
+		var err error
+		scenes, err = scene.Query(ctx, instance.Repository.Scene, &models.SceneFilterType{
+			StashIDEndpoint: j.linkedFilter(),
+		}, allObjects("path"))
+		return err
+	}); err != nil {
+		return fmt.Errorf("querying scenes: %w", err)
+	}
+
+	j.progress.AddTotal(len(scenes))
+
+	task := identify.SceneIdentifier{
+		SceneReaderUpdater: instance.Repository.Scene,
+		StudioCreator:      instance.Repository.Studio,
+		PerformerCreator:   instance.Repository.Performer,
+		TagCreator:         instance.Repository.Tag,
+
+		DefaultOptions:              j.input.Options,
+		SceneUpdatePostHookExecutor: j.postHookExecutor,
+	}
+
+	for _, s := range scenes {
+		if job.IsCancelled(ctx) {
+			return nil
+		}
+
+		j.progress.ExecuteTask("Syncing scene "+s.DisplayName(), func() {
+			if err := j.syncScene(ctx, &task, s); err != nil {
+				logger.Errorf("Error syncing scene %s: %v", s.DisplayName(), err)
+			}
+		})
+
+		j.progress.Increment()
+	}
+
+	return nil
+}
+
+func (j *StashBoxSyncJob) syncScene(ctx context.Context, task *identify.SceneIdentifier, s *models.Scene) error {
+	r := instance.Repository
+	if err := txn.WithReadTxn(ctx, r, func(ctx context.Context) error {
+		return loadSceneSyncRelationships(ctx, s)
+	}); err != nil {
+		return err
+	}
+
+	stashID := stashIDForEndpoint(s.StashIDs.List(), j.box.Endpoint)
+	if stashID == nil {
+		return nil
+	}
+
+	scraped, err := j.client.FindStashBoxSceneByID(ctx, stashID.StashID)
+	if err != nil {
+		return fmt.Errorf("querying stash-box using stash id %s: %w", stashID.StashID, err)
+	}
+
+	if scraped == nil {
+		return j.flagDeleted(ctx, "scene", s.DisplayName(), models.DeletedStashID{
+			SceneID:  &s.ID,
+			Endpoint: stashID.Endpoint,
+			StashID:  stashID.StashID,
+		})
+	}
+
+	if err := txn.WithTxn(ctx, j.txnManager(nil), func(ctx context.Context) error {
+		if err := r.DeletedStashID.DestroyByStashID(ctx, *stashID); err != nil {
+			return err
+		}
+
+		newStashIDs := j.followRedirect(s.StashIDs.List(), *stashID, scraped.RemoteSiteID, "scene", s.DisplayName())
+		if newStashIDs == nil {
+			return nil
+		}
+
+		_, err := r.Scene.UpdatePartial(ctx, s.ID, models.ScenePartial{
+			StashIDs: &models.UpdateStashIDs{
+				StashIDs: newStashIDs,
+				Mode:     models.RelationshipUpdateModeSet,
+			},
+			UpdatedAt: models.NewOptionalTime(time.Now()),
+		})
+		s.StashIDs = models.NewRelatedStashIDs(newStashIDs)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	source := j.source()
+	source.Scraper = fetchedSource{scene: scraped}
+	task.Sources = []identify.ScraperSource{source}
+
+	before := *s
+	var after *models.Scene
+	if err := j.identify(ctx, func(ctx context.Context, m txn.Manager) error {
+		return task.Identify(ctx, m, s)
+	}, func(ctx context.Context) error {
+		var err error
+		after, err = r.Scene.Find(ctx, s.ID)
+		if err != nil || after == nil {
+			return err
+		}
+
+		return loadSceneSyncRelationships(ctx, after)
+	}); err != nil {
+		return err
+	}
+
+	if after != nil {
+		j.logChanges("scene", s.DisplayName(), &before, after)
+	}
+
+	return nil
+}
+
+func loadSceneSyncRelationships(ctx context.Context, s *models.Scene) error {
+	r := instance.Repository
+	if err := s.LoadPerformerIDs(ctx, r.Scene); err != nil {
+		return err
+	}
+	if err := s.LoadTagIDs(ctx, r.Scene); err != nil {
+		return err
+	}
+	return s.LoadStashIDs(ctx, r.Scene)
+}
+
+func (j *StashBoxSyncJob) syncPerformers(ctx context.Context) error {
+	var performers []*models.Performer
+	if err := txn.WithReadTxn(ctx, instance.Repository, func(ctx context.Context) error {
+		var err error
+		performers, _, err = instance.Repository.Performer.Query(ctx, &models.PerformerFilterType{
+			StashIDEndpoint: j.linkedFilter(),
+		}, allObjects("name"))
+		return err
+	}); err != nil {
+		return fmt.Errorf("querying performers: %w", err)
+	}
+
+	j.progress.AddTotal(len(performers))
+
+	task := identify.PerformerIdentifier{
+		PerformerReaderUpdater: instance.Repository.Performer,
+		TagCreator:             instance.Repository.Tag,
+
+		DefaultOptions:   j.input.Options,
+		PostHookExecutor: j.postHookExecutor,
+	}
+
+	for _, p := range performers {
+		if job.IsCancelled(ctx) {
+			return nil
+		}
+
+		j.progress.ExecuteTask("Syncing performer "+p.Name, func() {
+			if err := j.syncPerformer(ctx, &task, p); err != nil {
+				logger.Errorf("Error syncing performer %s: %v", p.Name, err)
+			}
+		})
+
+		j.progress.Increment()
+	}
+
+	return nil
+}
+
+func (j *StashBoxSyncJob) syncPerformer(ctx context.Context, task *identify.PerformerIdentifier, p *models.Performer) error {
+	r := instance.Repository
+	if err := txn.WithReadTxn(ctx, r, func(ctx context.Context) error {
+		return loadPerformerSyncRelationships(ctx, p)
+	}); err != nil {
+		return err
+	}
+
+	stashID := stashIDForEndpoint(p.StashIDs.List(), j.box.Endpoint)
+	if stashID == nil {
+		return nil
+	}
+
+	scraped, err := j.client.FindStashBoxPerformerByID(ctx, stashID.StashID)
+	if err != nil {
+		return fmt.Errorf("querying stash-box using stash id %s: %w", stashID.StashID, err)
+	}
+
+	if scraped == nil {
+		return j.flagDeleted(ctx, "performer", p.Name, models.DeletedStashID{
+			PerformerID: &p.ID,
+			Endpoint:    stashID.Endpoint,
+			StashID:     stashID.StashID,
+		})
+	}
+
+	if err := txn.WithTxn(ctx, j.txnManager(nil), func(ctx context.Context) error {
+		if err := r.DeletedStashID.DestroyByStashID(ctx, *stashID); err != nil {
+			return err
+		}
+
+		newStashIDs := j.followRedirect(p.StashIDs.List(), *stashID, scraped.RemoteSiteID, "performer", p.Name)
+		if newStashIDs == nil {
+			return nil
+		}
+
+		partial := models.NewPerformerPartial()
+		partial.StashIDs = &models.UpdateStashIDs{
+			StashIDs: newStashIDs,
+			Mode:     models.RelationshipUpdateModeSet,
+		}
+		_, err := r.Performer.UpdatePartial(ctx, p.ID, partial)
+		p.StashIDs = models.NewRelatedStashIDs(newStashIDs)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	source := j.source()
+	source.PerformerScraper = fetchedSource{performer: scraped}
+	task.Sources = []identify.ScraperSource{source}
+
+	before := *p
+	var after *models.Performer
+	if err := j.identify(ctx, func(ctx context.Context, m txn.Manager) error {
+		return task.Identify(ctx, m, p)
+	}, func(ctx context.Context) error {
+		var err error
+		after, err = r.Performer.Find(ctx, p.ID)
+		if err != nil || after == nil {
+			return err
+		}
+
+		return loadPerformerSyncRelationships(ctx, after)
+	}); err != nil {
+		return err
+	}
+
+	if after != nil {
+		j.logChanges("performer", p.Name, &before, after)
+	}
+
+	return nil
+}
+
+func loadPerformerSyncRelationships(ctx context.Context, p *models.Performer) error {
+	r := instance.Repository
+	if err := p.LoadAliases(ctx, r.Performer); err != nil {
+		return err
+	}
+	if err := p.LoadTagIDs(ctx, r.Performer); err != nil {
+		return err
+	}
+	return p.LoadStashIDs(ctx, r.Performer)
+}
+
+func (j *StashBoxSyncJob) syncStudios(ctx context.Context) error {
+	var studios []*models.Studio
+	if err := txn.WithReadTxn(ctx, instance.Repository, func(ctx context.Context) error {
+		var err error
+		studios, _, err = instance.Repository.Studio.Query(ctx, &models.StudioFilterType{
+			StashIDEndpoint: j.linkedFilter(),
+		}, allObjects("name"))
+		return err
+	}); err != nil {
+		return fmt.Errorf("querying studios: %w", err)
+	}
+
+	j.progress.AddTotal(len(studios))
+
+	task := identify.StudioIdentifier{
+		StudioReaderUpdater: instance.Repository.Studio,
+		StudioCreator:       instance.Repository.Studio,
+
+		DefaultOptions:   j.input.Options,
+		PostHookExecutor: j.postHookExecutor,
+	}
+
+	for _, s := range studios {
+		if job.IsCancelled(ctx) {
+			return nil
+		}
+
+		j.progress.ExecuteTask("Syncing studio "+s.Name, func() {
+			if err := j.syncStudio(ctx, &task, s); err != nil {
+				logger.Errorf("Error syncing studio %s: %v", s.Name, err)
+			}
+		})
+
+		j.progress.Increment()
+	}
+
+	return nil
+}
+
+func (j *StashBoxSyncJob) syncStudio(ctx context.Context, task *identify.StudioIdentifier, s *models.Studio) error {
+	r := instance.Repository
+
+	var stashIDs []models.StashID
+	if err := txn.WithReadTxn(ctx, r, func(ctx context.Context) error {
+		var err error
+		stashIDs, err = r.Studio.GetStashIDs(ctx, s.ID)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	stashID := stashIDForEndpoint(stashIDs, j.box.Endpoint)
+	if stashID == nil {
+		return nil
+	}
+
+	scraped, err := j.client.FindStashBoxStudio(ctx, stashID.StashID)
+	if err != nil {
+		return fmt.Errorf("querying stash-box using stash id %s: %w", stashID.StashID, err)
+	}
+
+	if scraped == nil {
+		return j.flagDeleted(ctx, "studio", s.Name, models.DeletedStashID{
+			StudioID: &s.ID,
+			Endpoint: stashID.Endpoint,
+			StashID:  stashID.StashID,
+		})
+	}
+
+	if err := txn.WithTxn(ctx, j.txnManager(nil), func(ctx context.Context) error {
+		if err := r.DeletedStashID.DestroyByStashID(ctx, *stashID); err != nil {
+			return err
+		}
+
+		newStashIDs := j.followRedirect(stashIDs, *stashID, scraped.RemoteSiteID, "studio", s.Name)
+		if newStashIDs == nil {
+			return nil
+		}
+
+		return r.Studio.UpdateStashIDs(ctx, s.ID, newStashIDs)
+	}); err != nil {
+		return err
+	}
+
+	source := j.source()
+	source.StudioScraper = fetchedSource{studio: scraped}
+	task.Sources = []identify.ScraperSource{source}
+
+	before := *s
+	var after *models.Studio
+	if err := j.identify(ctx, func(ctx context.Context, m txn.Manager) error {
+		return task.Identify(ctx, m, s)
+	}, func(ctx context.Context) error {
+		var err error
+		after, err = r.Studio.Find(ctx, s.ID)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	if after != nil {
+		j.logChanges("studio", s.Name, &before, after)
+	}
+
+	return nil
+}
+
+// txnManager returns the transaction manager used to write changes. In a dry
+// run, transactions are rolled back after calling inspect.
+func (j *StashBoxSyncJob) txnManager(inspect txn.TxnFunc) txn.Manager {
+	if !j.input.DryRun {
+		return instance.Repository
+	}
+
+	return &dryRunTxnManager{
+		Manager: instance.Repository,
+		inspect: inspect,
+	}
+}
+
+// identify applies the changes of an object using fn, then reads the changed
+// object using load. In a dry run, load is called before the changes are
+// rolled back.
+func (j *StashBoxSyncJob) identify(ctx context.Context, fn func(ctx context.Context, m txn.Manager) error, load txn.TxnFunc) error {
+	m := j.txnManager(load)
+	if err := fn(ctx, m); err != nil {
+		return err
+	}
+
+	if dryRun, ok := m.(*dryRunTxnManager); ok {
+		return dryRun.err
+	}
+
+	return txn.WithReadTxn(ctx, instance.Repository, load)
+}
+
+// flagDeleted records that the stash-box entry of an object was deleted,
+// replacing any existing record of the stash id.
+func (j *StashBoxSyncJob) flagDeleted(ctx context.Context, objectType string, name string, deleted models.DeletedStashID) error {
+	logger.Warnf("The stash-box entry %s of %s %s was deleted", deleted.StashID, objectType, name)
+
+	deleted.CreatedAt = time.Now()
+	stashID := models.StashID{
+		Endpoint: deleted.Endpoint,
+		StashID:  deleted.StashID,
+	}
+
+	r := instance.Repository
+	return txn.WithTxn(ctx, j.txnManager(nil), func(ctx context.Context) error {
+		if err := r.DeletedStashID.DestroyByStashID(ctx, stashID); err != nil {
+			return err
+		}
+
+		return r.DeletedStashID.Create(ctx, &deleted)
+	})
+}
+
+// followRedirect returns the stash ids with the stash id replaced by the id
+// of the returned stash-box entry, if the entry was merged into another.
+// Returns nil if the entry was not merged.
+func (j *StashBoxSyncJob) followRedirect(stashIDs []models.StashID, stashID models.StashID, remoteSiteID *string, objectType string, name string) []models.StashID {
+	if remoteSiteID == nil || *remoteSiteID == stashID.StashID {
+		return nil
+	}
+
+	action := "updating"
+	if j.input.DryRun {
+		action = "would update"
+	}
+	logger.Infof("The stash-box entry %s of %s %s was merged into %s, %s stash id", stashID.StashID, objectType, name, *remoteSiteID, action)
+
+	var ret []models.StashID
+	for _, id := range stashIDs {
+		if id == stashID {
+			id.StashID = *remoteSiteID
+		}
+		ret = append(ret, id)
+	}
+
+	return ret
+}
+
+func stashIDForEndpoint(stashIDs []models.StashID, endpoint string) *models.StashID {
+	for _, id := range stashIDs {
+		if id.Endpoint == endpoint {
+			return &id
+		}
+	}
+
+	return nil
+}
+
+// fields which are never changed by syncing
+var ignoredChangeFields = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+	"files":      true,
+}
+
+// logChanges logs the fields which differ between two copies of an object.
+func (j *StashBoxSyncJob) logChanges(objectType string, name string, before interface{}, after interface{}) {
+	changes := objectChanges(before, after)
+	if len(changes) == 0 {
+		logger.Debugf("No changes to %s %s", objectType, name)
+		return
+	}
+
+	action := "Updated"
+	if j.input.DryRun {
+		action = "Would update"
+	}
+
+	logger.Infof("%s %s %s:\n  %s", action, objectType, name, strings.Join(changes, "\n  "))
+}
+
+// objectChanges returns a description of each field which differs between
+// two objects of the same struct type. Relationships are only compared if
+// loaded in both objects.
+func objectChanges(before interface{}, after interface{}) []string {
+	bv := reflect.Indirect(reflect.ValueOf(before))
+	av := reflect.Indirect(reflect.ValueOf(after))
+	t := bv.Type()
+
+	var ret []string
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" || ignoredChangeFields[name] {
+			continue
+		}
+
+		b, bok := changeValue(bv.Field(i))
+		a, aok := changeValue(av.Field(i))
+		if !bok || !aok || reflect.DeepEqual(b, a) {
+			continue
+		}
+
+		ret = append(ret, fmt.Sprintf("%s: %v -> %v", name, formatChangeValue(b), formatChangeValue(a)))
+	}
+
+	return ret
+}
+
+type loadedRelationship interface {
+	Loaded() bool
+}
+
+// changeValue returns the comparable value of a field. Returns false if the
+// field is a relationship which is not loaded.
+func changeValue(v reflect.Value) (interface{}, bool) {
+	if v.CanAddr() {
+		if rel, ok := v.Addr().Interface().(loadedRelationship); ok {
+			list := v.Addr().MethodByName("List")
+			if !rel.Loaded() || !list.IsValid() {
+				return nil, false
+			}
+
+			return list.Call(nil)[0].Interface(), true
+		}
+	}
+
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, true
+		}
+		return v.Elem().Interface(), true
+	}
+
+	return v.Interface(), true
+}
+
+func formatChangeValue(v interface{}) string {
+	if v == nil {
+		return "(none)"
+	}
+
+	if s, ok := v.(string); ok {
+		if s == "" {
+			return "(none)"
+		}
+		return fmt.Sprintf("%q", s)
+	}
+
+	return fmt.Sprintf("%v", v)
+}
+
+// fetchedSource is an identify source returning objects already fetched
+// from stash-box.
+type fetchedSource struct {
+	scene     *scraper.ScrapedScene
+	performer *models.ScrapedPerformer
+	studio    *models.ScrapedStudio
+}
+
+func (s fetchedSource) ScrapeScene(ctx context.Context, sceneID int) (*scraper.ScrapedScene, error) {
+	return s.scene, nil
+}
+
+func (s fetchedSource) ScrapePerformer(ctx context.Context, p *models.Performer) (*models.ScrapedPerformer, error) {
+	return s.performer, nil
+}
+
+func (s fetchedSource) ScrapeStudio(ctx context.Context, studio *models.Studio) (*models.ScrapedStudio, error) {
+	return s.studio, nil
+}
+
+// dryRunTxnManager rolls back transactions instead of committing them. The
+// inspect function is called with the transaction context before rolling
+// back, so that the changes can be read.
+type dryRunTxnManager struct {
+	txn.Manager
+	inspect txn.TxnFunc
+	err     error
+}
+
+func (m *dryRunTxnManager) Commit(ctx context.Context) error {
+	if m.inspect != nil {
+		m.err = m.inspect(ctx)
+	}
+
+	return m.Manager.Rollback(ctx)
+}
+
+// dryRunPostHookExecutor does not execute plugin hooks for changes which are
+// not applied.
+type dryRunPostHookExecutor struct{}
+
+func (dryRunPostHookExecutor) ExecuteSceneUpdatePostHooks(ctx context.Context, input models.SceneUpdateInput, inputFields []string) {
+}
+
+func (dryRunPostHookExecutor) ExecutePostHooks(ctx context.Context, id int, hookType plugin.HookTriggerEnum, input interface{}, inputFields []string) {
+}
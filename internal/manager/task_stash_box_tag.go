@@ -10,6 +10,7 @@ import (
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/scraper/stashbox"
 	"github.com/stashapp/stash/pkg/sliceutil/stringslice"
+	"github.com/stashapp/stash/pkg/studio"
 	"github.com/stashapp/stash/pkg/txn"
 	"github.com/stashapp/stash/pkg/utils"
 )
@@ -307,3 +308,360 @@ func getIntPtr(val *string) *int {
 		return &v
 	}
 }
+
+// stashBoxStudioFinder finds studios on a stash-box instance.
+type stashBoxStudioFinder interface {
+	FindStashBoxStudio(ctx context.Context, id string) (*models.ScrapedStudio, error)
+	FindStashBoxStudioByName(ctx context.Context, name string) (*models.ScrapedStudio, error)
+	FindStashBoxStudioByURL(ctx context.Context, url string) (*models.ScrapedStudio, error)
+}
+
+type StashBoxStudioTagTask struct {
+	txnManager      Repository
+	box             *models.StashBox
+	name            *string
+	studio          *models.Studio
+	refresh         bool
+	excluded_fields []string
+}
+
+func (t *StashBoxStudioTagTask) Start(ctx context.Context) {
+	t.stashBoxStudioTag(ctx)
+}
+
+func (t *StashBoxStudioTagTask) Description() string {
+	return fmt.Sprintf("Tagging studio %s from stash-box", t.studioName())
+}
+
+func (t *StashBoxStudioTagTask) studioName() string {
+	if t.name != nil {
+		return *t.name
+	} else if t.studio != nil {
+		return t.studio.Name
+	}
+
+	return ""
+}
+
+func (t *StashBoxStudioTagTask) stashBoxStudioTag(ctx context.Context) {
+	r := t.txnManager
+	client := stashbox.NewClient(*t.box, r, stashbox.Repository{
+		Scene:     r.Scene,
+		Performer: r.Performer,
+		Tag:       r.Tag,
+		Studio:    r.Studio,
+	})
+
+	t.tagStudio(ctx, client)
+}
+
+func (t *StashBoxStudioTagTask) tagStudio(ctx context.Context, client stashBoxStudioFinder) {
+	r := t.txnManager
+
+	scraped, err := t.findStudio(ctx, client)
+	if err != nil {
+		logger.Errorf("Error fetching studio data from stash-box: %s", err.Error())
+		return
+	}
+
+	if scraped == nil {
+		logger.Infof("No match found for %s", t.studioName())
+		return
+	}
+
+	excluded := map[string]bool{}
+	for _, field := range t.excluded_fields {
+		excluded[field] = true
+	}
+
+	// fetch the parent before starting the transaction, in case it needs
+	// to be created
+	var parent *models.ScrapedStudio
+	if scraped.Parent != nil && !excluded["parent_studio"] {
+		parent, err = t.getParent(ctx, client, scraped.Parent)
+		if err != nil {
+			logger.Warnf("Failed to fetch parent studio %s: %v", scraped.Parent.Name, err)
+		}
+	}
+
+	var image []byte
+	if scraped.Image != nil && !excluded["image"] {
+		image, err = utils.ProcessImageInput(ctx, *scraped.Image)
+		if err != nil {
+			logger.Warnf("Failed to read studio image: %v", err)
+		}
+	}
+
+	existing := t.studio
+	if existing == nil && scraped.StoredID != nil {
+		// the name matched an existing studio, which is updated instead
+		existing, err = t.findStoredStudio(ctx, *scraped.StoredID)
+		if err != nil {
+			logger.Errorf("Failed to find studio %s: %v", *scraped.StoredID, err)
+			return
+		}
+	}
+
+	if existing != nil {
+		if err := txn.WithTxn(ctx, r, func(ctx context.Context) error {
+			return t.updateStudio(ctx, existing, scraped, parent, image, excluded)
+		}); err != nil {
+			logger.Warnf("failure to execute partial update of studio: %v", err)
+			return
+		}
+
+		logger.Infof("Updated studio %s", existing.Name)
+	} else if t.name != nil {
+		var id *int
+		if err := txn.WithTxn(ctx, r, func(ctx context.Context) error {
+			var err error
+			id, err = t.createStudio(ctx, scraped, parent, image)
+			return err
+		}); err != nil {
+			logger.Errorf("Failed to save studio %s: %s", *t.name, err.Error())
+			return
+		}
+
+		if id != nil {
+			logger.Infof("Saved studio %s", *t.name)
+		}
+	}
+}
+
+// findStudio finds the studio by its stash id if refreshing. Otherwise the
+// studio is matched by name, then by its aliases, then by its URL.
+func (t *StashBoxStudioTagTask) findStudio(ctx context.Context, client stashBoxStudioFinder) (*models.ScrapedStudio, error) {
+	r := t.txnManager
+
+	if t.refresh && t.studio != nil {
+		var stashIDs []models.StashID
+		if err := txn.WithReadTxn(ctx, r, func(ctx context.Context) error {
+			var err error
+			stashIDs, err = r.Studio.GetStashIDs(ctx, t.studio.ID)
+			return err
+		}); err != nil {
+			return nil, err
+		}
+
+		for _, id := range stashIDs {
+			if id.Endpoint == t.box.Endpoint {
+				return client.FindStashBoxStudio(ctx, id.StashID)
+			}
+		}
+
+		return nil, nil
+	}
+
+	ret, err := client.FindStashBoxStudioByName(ctx, t.studioName())
+	if ret != nil || err != nil || t.studio == nil {
+		return ret, err
+	}
+
+	var aliases []string
+	if err := txn.WithReadTxn(ctx, r, func(ctx context.Context) error {
+		var err error
+		aliases, err = r.Studio.GetAliases(ctx, t.studio.ID)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	for _, alias := range aliases {
+		ret, err = client.FindStashBoxStudioByName(ctx, alias)
+		if ret != nil || err != nil {
+			return ret, err
+		}
+	}
+
+	if t.studio.URL != "" {
+		return client.FindStashBoxStudioByURL(ctx, t.studio.URL)
+	}
+
+	return nil, nil
+}
+
+// findStoredStudio returns the existing studio matched by the stash-box
+// studio.
+func (t *StashBoxStudioTagTask) findStoredStudio(ctx context.Context, storedID string) (*models.Studio, error) {
+	r := t.txnManager
+
+	id, err := strconv.Atoi(storedID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid studio id %s: %w", storedID, err)
+	}
+
+	var ret *models.Studio
+	if err := txn.WithReadTxn(ctx, r, func(ctx context.Context) error {
+		var err error
+		ret, err = r.Studio.Find(ctx, id)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	if ret == nil {
+		return nil, fmt.Errorf("studio with id %d not found", id)
+	}
+
+	return ret, nil
+}
+
+// getParent returns the parent studio. If the parent does not exist locally,
+// the full parent studio is fetched so that it can be created.
+func (t *StashBoxStudioTagTask) getParent(ctx context.Context, client stashBoxStudioFinder, parent *models.ScrapedStudio) (*models.ScrapedStudio, error) {
+	if parent.StoredID != nil || parent.RemoteSiteID == nil {
+		return parent, nil
+	}
+
+	ret, err := client.FindStashBoxStudio(ctx, *parent.RemoteSiteID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ret == nil {
+		// the parent was deleted - don't recreate it
+		return nil, nil
+	}
+
+	return ret, nil
+}
+
+// parentID returns the id of the parent studio, creating it if needed.
+// Returns nil if the parent could not be created because its name is used
+// by another studio.
+func (t *StashBoxStudioTagTask) parentID(ctx context.Context, parent *models.ScrapedStudio) (*int, error) {
+	if parent.StoredID != nil {
+		id, err := strconv.Atoi(*parent.StoredID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parent studio id %s: %w", *parent.StoredID, err)
+		}
+		return &id, nil
+	}
+
+	var image []byte
+	if parent.Image != nil {
+		var err error
+		image, err = utils.ProcessImageInput(ctx, *parent.Image)
+		if err != nil {
+			logger.Warnf("Failed to read parent studio image: %v", err)
+		}
+	}
+
+	id, err := t.createStudio(ctx, parent, nil, image)
+	if err != nil {
+		return nil, fmt.Errorf("creating parent studio %s: %w", parent.Name, err)
+	}
+
+	if id != nil {
+		logger.Infof("Created parent studio %s", parent.Name)
+	}
+	return id, nil
+}
+
+func (t *StashBoxStudioTagTask) updateStudio(ctx context.Context, s *models.Studio, scraped *models.ScrapedStudio, parent *models.ScrapedStudio, image []byte, excluded map[string]bool) error {
+	r := t.txnManager
+
+	partial := models.NewStudioPartial()
+
+	if scraped.URL != nil && !excluded["url"] {
+		partial.URL = models.NewOptionalString(*scraped.URL)
+	}
+	if scraped.Details != nil && !excluded["details"] {
+		partial.Details = models.NewOptionalString(*scraped.Details)
+	}
+	if !excluded["name"] && scraped.Name != s.Name {
+		if err := studio.EnsureStudioNameUnique(ctx, s.ID, scraped.Name, r.Studio); err != nil {
+			logger.Warnf("Not renaming studio %s to %s: %v", s.Name, scraped.Name, err)
+		} else {
+			partial.Name = models.NewOptionalString(scraped.Name)
+		}
+	}
+	if parent != nil {
+		parentID, err := t.parentID(ctx, parent)
+		if err != nil {
+			return err
+		}
+
+		// a studio cannot be its own parent
+		if parentID != nil && *parentID != s.ID {
+			partial.ParentID = models.NewOptionalInt(*parentID)
+		}
+	}
+
+	if _, err := r.Studio.UpdatePartial(ctx, s.ID, partial); err != nil {
+		return err
+	}
+
+	// overwrite the stash id for the endpoint, but preserve existing stash
+	// ids for other endpoints
+	existing, err := r.Studio.GetStashIDs(ctx, s.ID)
+	if err != nil {
+		return err
+	}
+
+	stashIDs := models.UpdateStashIDs{
+		StashIDs: existing,
+	}
+	stashIDs.Set(models.StashID{
+		Endpoint: t.box.Endpoint,
+		StashID:  *scraped.RemoteSiteID,
+	})
+
+	if err := r.Studio.UpdateStashIDs(ctx, s.ID, stashIDs.StashIDs); err != nil {
+		return err
+	}
+
+	if len(image) > 0 {
+		if err := r.Studio.UpdateImage(ctx, s.ID, image); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createStudio creates the studio, returning its id. Returns nil if the
+// name of the studio is used by another studio.
+func (t *StashBoxStudioTagTask) createStudio(ctx context.Context, scraped *models.ScrapedStudio, parent *models.ScrapedStudio, image []byte) (*int, error) {
+	r := t.txnManager
+
+	if err := studio.EnsureStudioNameUnique(ctx, 0, scraped.Name, r.Studio); err != nil {
+		logger.Warnf("Not creating studio %s: %v", scraped.Name, err)
+		return nil, nil
+	}
+
+	newStudio := models.NewStudio(scraped.Name)
+	newStudio.URL = getString(scraped.URL)
+	newStudio.Details = getString(scraped.Details)
+
+	if parent != nil {
+		parentID, err := t.parentID(ctx, parent)
+		if err != nil {
+			return nil, err
+		}
+		newStudio.ParentID = parentID
+	}
+
+	if err := r.Studio.Create(ctx, newStudio); err != nil {
+		return nil, err
+	}
+
+	if scraped.RemoteSiteID != nil {
+		if err := r.Studio.UpdateStashIDs(ctx, newStudio.ID, []models.StashID{
+			{
+				Endpoint: t.box.Endpoint,
+				StashID:  *scraped.RemoteSiteID,
+			},
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(image) > 0 {
+		if err := r.Studio.UpdateImage(ctx, newStudio.ID, image); err != nil {
+			return nil, err
+		}
+	}
+
+	return &newStudio.ID, nil
+}
@@ -0,0 +1,327 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/job"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scraper/stashbox"
+	"github.com/stashapp/stash/pkg/sliceutil/intslice"
+	"github.com/stashapp/stash/pkg/sliceutil/stringslice"
+	"github.com/stashapp/stash/pkg/tag"
+	"github.com/stashapp/stash/pkg/txn"
+)
+
+type StashBoxTagSyncInput struct {
+	// Index of the stash-box instance to sync with
+	Endpoint int `json:"endpoint"`
+	// If set, only sync these tag ids
+	TagIds []string `json:"tag_ids"`
+	// Refresh tags already linked to the stash-box instance if true. Only
+	// link tags which are not linked if false
+	Refresh bool `json:"refresh"`
+	// Set the stash-box category of each tag as its parent tag
+	MapCategories bool `json:"map_categories"`
+	// Fields to exclude when syncing
+	ExcludeFields []string `json:"exclude_fields"`
+}
+
+// StashBoxTagSyncJob links local tags to the tags of a stash-box instance,
+// and imports their aliases and descriptions.
+type StashBoxTagSyncJob struct {
+	input    StashBoxTagSyncInput
+	box      *models.StashBox
+	client   *stashbox.Client
+	excluded map[string]bool
+}
+
+func CreateStashBoxTagSyncJob(input StashBoxTagSyncInput) (*StashBoxTagSyncJob, error) {
+	boxes := instance.Config.GetStashBoxes()
+	if input.Endpoint < 0 || input.Endpoint >= len(boxes) {
+		return nil, fmt.Errorf("invalid stash_box_index %d", input.Endpoint)
+	}
+	box := boxes[input.Endpoint]
+
+	excluded := make(map[string]bool)
+	for _, field := range input.ExcludeFields {
+		excluded[field] = true
+	}
+
+	return &StashBoxTagSyncJob{
+		input: input,
+		box:   box,
+		client: stashbox.NewClient(*box, instance.Repository, stashbox.Repository{
+			Scene:     instance.Repository.Scene,
+			Performer: instance.Repository.Performer,
+			Tag:       instance.Repository.Tag,
+			Studio:    instance.Repository.Studio,
+		}),
+		excluded: excluded,
+	}, nil
+}
+
+func (j *StashBoxTagSyncJob) Execute(ctx context.Context, progress *job.Progress) {
+	logger.Infof("Syncing tags with stash-box %s", j.box.Endpoint)
+
+	tags, err := j.getTags(ctx)
+	if err != nil {
+		logger.Errorf("Error getting tags: %v", err)
+		return
+	}
+
+	progress.SetTotal(len(tags))
+
+	for _, t := range tags {
+		if job.IsCancelled(ctx) {
+			logger.Info("Stopping due to user request")
+			return
+		}
+
+		progress.ExecuteTask("Syncing tag "+t.Name, func() {
+			if err := j.syncTag(ctx, t); err != nil {
+				logger.Errorf("Error syncing tag %s: %v", t.Name, err)
+			}
+		})
+
+		progress.Increment()
+	}
+
+	logger.Infof("Finished syncing tags with stash-box %s", j.box.Endpoint)
+}
+
+func (j *StashBoxTagSyncJob) getTags(ctx context.Context) ([]*models.Tag, error) {
+	var ret []*models.Tag
+	if err := txn.WithReadTxn(ctx, instance.Repository, func(ctx context.Context) error {
+		qb := instance.Repository.Tag
+
+		if len(j.input.TagIds) == 0 {
+			var err error
+			ret, err = qb.All(ctx)
+			return err
+		}
+
+		ids, err := stringslice.StringSliceToIntSlice(j.input.TagIds)
+		if err != nil {
+			return fmt.Errorf("invalid tag ids: %w", err)
+		}
+
+		ret, err = qb.FindMany(ctx, ids)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (j *StashBoxTagSyncJob) syncTag(ctx context.Context, t *models.Tag) error {
+	r := instance.Repository
+
+	var stashIDs []models.StashID
+	var aliases []string
+	if err := txn.WithReadTxn(ctx, r, func(ctx context.Context) error {
+		var err error
+		stashIDs, err = r.Tag.GetStashIDs(ctx, t.ID)
+		if err != nil {
+			return err
+		}
+
+		aliases, err = r.Tag.GetAliases(ctx, t.ID)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	stashID := stashIDForEndpoint(stashIDs, j.box.Endpoint)
+	if stashID != nil && !j.input.Refresh {
+		return nil
+	}
+
+	var scraped *models.ScrapedTag
+	var err error
+	if stashID != nil {
+		scraped, err = j.client.FindStashBoxTag(ctx, stashID.StashID)
+		if err != nil {
+			return fmt.Errorf("querying stash-box using stash id %s: %w", stashID.StashID, err)
+		}
+
+		if scraped == nil {
+			logger.Warnf("Tag %s is linked to stash-box tag %s, which no longer exists", t.Name, stashID.StashID)
+			return nil
+		}
+	} else {
+		scraped, err = j.findTag(ctx, t, aliases)
+		if err != nil {
+			return fmt.Errorf("querying stash-box: %w", err)
+		}
+
+		if scraped == nil {
+			logger.Debugf("No stash-box tag found for %s", t.Name)
+			return nil
+		}
+	}
+
+	return txn.WithTxn(ctx, r, func(ctx context.Context) error {
+		if stashID == nil {
+			linked, err := j.link(ctx, t, stashIDs, *scraped.RemoteSiteID)
+			if err != nil || !linked {
+				return err
+			}
+		}
+
+		if !j.excluded["aliases"] {
+			if err := j.updateAliases(ctx, t, aliases, scraped); err != nil {
+				return err
+			}
+		}
+
+		if !j.excluded["description"] && t.Description == "" && scraped.Description != nil && *scraped.Description != "" {
+			partial := models.NewTagPartial()
+			partial.Description = models.NewOptionalString(*scraped.Description)
+			if _, err := r.Tag.UpdatePartial(ctx, t.ID, partial); err != nil {
+				return err
+			}
+		}
+
+		if j.input.MapCategories && len(scraped.Parents) > 0 {
+			if err := j.setCategory(ctx, t, scraped.Parents[0]); err != nil {
+				return err
+			}
+		}
+
+		logger.Infof("Synced tag %s with stash-box tag %s", t.Name, scraped.Name)
+		return nil
+	})
+}
+
+// findTag finds the stash-box tag by the tag name, then by its aliases.
+func (j *StashBoxTagSyncJob) findTag(ctx context.Context, t *models.Tag, aliases []string) (*models.ScrapedTag, error) {
+	for _, name := range append([]string{t.Name}, aliases...) {
+		ret, err := j.client.FindStashBoxTagByName(ctx, name)
+		if ret != nil || err != nil {
+			return ret, err
+		}
+	}
+
+	return nil, nil
+}
+
+// link sets the stash id of the tag. Returns false if another tag is already
+// linked to the stash-box tag.
+func (j *StashBoxTagSyncJob) link(ctx context.Context, t *models.Tag, stashIDs []models.StashID, remoteID string) (bool, error) {
+	qb := instance.Repository.Tag
+
+	stashID := models.StashID{
+		Endpoint: j.box.Endpoint,
+		StashID:  remoteID,
+	}
+
+	existing, err := qb.FindByStashID(ctx, stashID)
+	if err != nil {
+		return false, err
+	}
+
+	if len(existing) > 0 && existing[0].ID != t.ID {
+		logger.Warnf("Not linking tag %s: the stash-box tag is already linked to tag %s. These tags may need to be merged.", t.Name, existing[0].Name)
+		return false, nil
+	}
+
+	if err := qb.UpdateStashIDs(ctx, t.ID, append(stashIDs, stashID)); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// updateAliases adds the name and aliases of the stash-box tag to the tag
+// aliases. Names which are used by another tag are skipped.
+func (j *StashBoxTagSyncJob) updateAliases(ctx context.Context, t *models.Tag, aliases []string, scraped *models.ScrapedTag) error {
+	qb := instance.Repository.Tag
+
+	candidates := []string{scraped.Name}
+	if scraped.Aliases != nil {
+		candidates = append(candidates, stringslice.FromString(*scraped.Aliases, ",")...)
+	}
+
+	var added []string
+	for _, a := range candidates {
+		existing := stringslice.StrMap(append(aliases, added...), strings.ToLower)
+		if a == "" || strings.EqualFold(a, t.Name) || stringslice.StrInclude(existing, strings.ToLower(a)) {
+			continue
+		}
+
+		if err := tag.EnsureTagNameUnique(ctx, t.ID, a, qb); err != nil {
+			logger.Warnf("Not adding alias %q to tag %s: %v", a, t.Name, err)
+			continue
+		}
+
+		added = append(added, a)
+	}
+
+	if len(added) == 0 {
+		return nil
+	}
+
+	return qb.UpdateAliases(ctx, t.ID, append(aliases, added...))
+}
+
+// setCategory sets the tag named after the stash-box category as a parent of
+// the tag, creating it if needed.
+func (j *StashBoxTagSyncJob) setCategory(ctx context.Context, t *models.Tag, category *models.ScrapedTag) error {
+	qb := instance.Repository.Tag
+
+	parent, err := tag.ByName(ctx, qb, category.Name)
+	if err != nil {
+		return err
+	}
+
+	if parent == nil {
+		parent, err = tag.ByAlias(ctx, qb, category.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	if parent == nil {
+		parent = models.NewTag(category.Name)
+		if category.Description != nil {
+			parent.Description = *category.Description
+		}
+
+		if err := qb.Create(ctx, parent); err != nil {
+			return fmt.Errorf("creating category tag %s: %w", category.Name, err)
+		}
+
+		logger.Infof("Created category tag %s", category.Name)
+	}
+
+	if parent.ID == t.ID {
+		return nil
+	}
+
+	parents, err := qb.FindByChildTagID(ctx, t.ID)
+	if err != nil {
+		return err
+	}
+
+	var parentIDs []int
+	for _, p := range parents {
+		parentIDs = append(parentIDs, p.ID)
+	}
+
+	if intslice.IntInclude(parentIDs, parent.ID) {
+		return nil
+	}
+
+	parentIDs = append(parentIDs, parent.ID)
+
+	if err := tag.ValidateHierarchy(ctx, t, parentIDs, nil, qb); err != nil {
+		logger.Warnf("Not setting category of tag %s: %v", t.Name, err)
+		return nil
+	}
+
+	return qb.UpdateParentTags(ctx, t.ID, parentIDs)
+}
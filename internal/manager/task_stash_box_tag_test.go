@@ -0,0 +1,289 @@
+package manager
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testStashBoxEndpoint = "https://stashbox.example.com/graphql"
+
+// testStudioFinder finds stash-box studios by stash-box id, name or URL.
+type testStudioFinder struct {
+	byID   map[string]*models.ScrapedStudio
+	byName map[string]*models.ScrapedStudio
+	byURL  map[string]*models.ScrapedStudio
+}
+
+func (f testStudioFinder) FindStashBoxStudio(ctx context.Context, id string) (*models.ScrapedStudio, error) {
+	return f.byID[id], nil
+}
+
+func (f testStudioFinder) FindStashBoxStudioByName(ctx context.Context, name string) (*models.ScrapedStudio, error) {
+	return f.byName[name], nil
+}
+
+func (f testStudioFinder) FindStashBoxStudioByURL(ctx context.Context, url string) (*models.ScrapedStudio, error) {
+	return f.byURL[url], nil
+}
+
+func newTestStudioTagTask(studio *models.Studio, name *string, excluded ...string) (*StashBoxStudioTagTask, *mocks.StudioReaderWriter) {
+	qb := &mocks.StudioReaderWriter{}
+
+	return &StashBoxStudioTagTask{
+		txnManager: Repository{
+			TxnManager: &mocks.TxnManager{},
+			Studio:     qb,
+		},
+		box:             &models.StashBox{Endpoint: testStashBoxEndpoint},
+		name:            name,
+		studio:          studio,
+		excluded_fields: excluded,
+	}, qb
+}
+
+// mockStudioNameUnused mocks the name and alias queries run when checking
+// that a studio name is unique. If usedBy is set, the name is used by that
+// studio.
+func mockStudioNameUnused(qb *mocks.StudioReaderWriter, name string, usedBy *models.Studio) {
+	var ret []*models.Studio
+	if usedBy != nil {
+		ret = []*models.Studio{usedBy}
+	}
+
+	qb.On("Query", mock.Anything, mock.MatchedBy(func(f *models.StudioFilterType) bool {
+		return f.Name != nil && f.Name.Value == name
+	}), mock.Anything).Return(ret, len(ret), nil)
+	qb.On("Query", mock.Anything, mock.MatchedBy(func(f *models.StudioFilterType) bool {
+		return f.Aliases != nil && f.Aliases.Value == name
+	}), mock.Anything).Return(nil, 0, nil)
+}
+
+func mockStudioUpdate(qb *mocks.StudioReaderWriter, id int) {
+	qb.On("UpdatePartial", mock.Anything, id, mock.Anything).Return(&models.Studio{ID: id}, nil)
+	qb.On("GetStashIDs", mock.Anything, id).Return(nil, nil)
+	qb.On("UpdateStashIDs", mock.Anything, id, mock.Anything).Return(nil)
+}
+
+func updatedPartial(qb *mocks.StudioReaderWriter) models.StudioPartial {
+	for _, c := range qb.Calls {
+		if c.Method == "UpdatePartial" {
+			return c.Arguments.Get(2).(models.StudioPartial)
+		}
+	}
+
+	return models.StudioPartial{}
+}
+
+func TestStashBoxStudioTagTask_findStudio(t *testing.T) {
+	const studioID = 1
+
+	remoteID := "remote"
+	scraped := &models.ScrapedStudio{
+		Name:         "Remote",
+		RemoteSiteID: &remoteID,
+	}
+
+	tests := []struct {
+		name   string
+		studio *models.Studio
+		finder testStudioFinder
+		want   *models.ScrapedStudio
+	}{
+		{
+			"name",
+			&models.Studio{ID: studioID, Name: "Studio"},
+			testStudioFinder{byName: map[string]*models.ScrapedStudio{"Studio": scraped}},
+			scraped,
+		},
+		{
+			"alias",
+			&models.Studio{ID: studioID, Name: "Studio"},
+			testStudioFinder{byName: map[string]*models.ScrapedStudio{"Alias": scraped}},
+			scraped,
+		},
+		{
+			"url",
+			&models.Studio{ID: studioID, Name: "Studio", URL: "https://example.com"},
+			testStudioFinder{byURL: map[string]*models.ScrapedStudio{"https://example.com": scraped}},
+			scraped,
+		},
+		{
+			"no match",
+			&models.Studio{ID: studioID, Name: "Studio", URL: "https://example.com"},
+			testStudioFinder{},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task, qb := newTestStudioTagTask(tt.studio, nil)
+			qb.On("GetAliases", mock.Anything, studioID).Return([]string{"Alias"}, nil)
+
+			got, err := task.findStudio(context.Background(), tt.finder)
+			if err != nil {
+				t.Fatalf("findStudio() error = %v", err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestStashBoxStudioTagTask_tagStudio_storedID(t *testing.T) {
+	const storedID = 2
+
+	name := "Studio"
+	stored := strconv.Itoa(storedID)
+	remoteID := "remote"
+	finder := testStudioFinder{byName: map[string]*models.ScrapedStudio{
+		name: {
+			Name:         name,
+			StoredID:     &stored,
+			RemoteSiteID: &remoteID,
+		},
+	}}
+
+	task, qb := newTestStudioTagTask(nil, &name)
+	qb.On("Find", mock.Anything, storedID).Return(&models.Studio{ID: storedID, Name: name}, nil)
+	mockStudioUpdate(qb, storedID)
+
+	task.tagStudio(context.Background(), finder)
+
+	qb.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	qb.AssertCalled(t, "UpdateStashIDs", mock.Anything, storedID, []models.StashID{
+		{Endpoint: testStashBoxEndpoint, StashID: remoteID},
+	})
+}
+
+func TestStashBoxStudioTagTask_tagStudio_create(t *testing.T) {
+	const createdID = 3
+
+	name := "Studio"
+	remoteID := "remote"
+	finder := testStudioFinder{byName: map[string]*models.ScrapedStudio{
+		name: {
+			Name:         name,
+			RemoteSiteID: &remoteID,
+		},
+	}}
+
+	t.Run("created", func(t *testing.T) {
+		task, qb := newTestStudioTagTask(nil, &name)
+		mockStudioNameUnused(qb, name, nil)
+		qb.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			args.Get(1).(*models.Studio).ID = createdID
+		}).Return(nil)
+		qb.On("UpdateStashIDs", mock.Anything, createdID, mock.Anything).Return(nil)
+
+		task.tagStudio(context.Background(), finder)
+
+		qb.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(s *models.Studio) bool {
+			return s.Name == name
+		}))
+	})
+
+	t.Run("name used", func(t *testing.T) {
+		task, qb := newTestStudioTagTask(nil, &name)
+		mockStudioNameUnused(qb, name, &models.Studio{ID: 4, Name: name})
+
+		task.tagStudio(context.Background(), finder)
+
+		qb.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestStashBoxStudioTagTask_tagStudio_parent(t *testing.T) {
+	const (
+		studioID = 1
+		parentID = 5
+	)
+
+	remoteID := "remote"
+	parentRemoteID := "parent"
+	finder := testStudioFinder{
+		byName: map[string]*models.ScrapedStudio{
+			"Studio": {
+				Name:         "Studio",
+				RemoteSiteID: &remoteID,
+				Parent: &models.ScrapedStudio{
+					Name:         "Parent",
+					RemoteSiteID: &parentRemoteID,
+				},
+			},
+		},
+		byID: map[string]*models.ScrapedStudio{
+			parentRemoteID: {
+				Name:         "Parent",
+				RemoteSiteID: &parentRemoteID,
+			},
+		},
+	}
+
+	task, qb := newTestStudioTagTask(&models.Studio{ID: studioID, Name: "Studio"}, nil)
+	mockStudioNameUnused(qb, "Parent", nil)
+	qb.On("Create", mock.Anything, mock.MatchedBy(func(s *models.Studio) bool {
+		return s.Name == "Parent"
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.Studio).ID = parentID
+	}).Return(nil)
+	qb.On("UpdateStashIDs", mock.Anything, parentID, []models.StashID{
+		{Endpoint: testStashBoxEndpoint, StashID: parentRemoteID},
+	}).Return(nil)
+	mockStudioUpdate(qb, studioID)
+
+	task.tagStudio(context.Background(), finder)
+
+	partial := updatedPartial(qb)
+	assert.True(t, partial.ParentID.Set)
+	assert.Equal(t, parentID, partial.ParentID.Value)
+}
+
+func TestStashBoxStudioTagTask_tagStudio_name(t *testing.T) {
+	const studioID = 1
+
+	remoteID := "remote"
+	finder := testStudioFinder{byURL: map[string]*models.ScrapedStudio{
+		"https://example.com": {
+			Name:         "New Name",
+			RemoteSiteID: &remoteID,
+		},
+	}}
+	newStudio := func() *models.Studio {
+		return &models.Studio{ID: studioID, Name: "Studio", URL: "https://example.com"}
+	}
+
+	tests := []struct {
+		name     string
+		excluded []string
+		usedBy   *models.Studio
+		wantSet  bool
+	}{
+		{"renamed", nil, nil, true},
+		{"excluded", []string{"name"}, nil, false},
+		{"name used", nil, &models.Studio{ID: 2, Name: "New Name"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task, qb := newTestStudioTagTask(newStudio(), nil, tt.excluded...)
+			qb.On("GetAliases", mock.Anything, studioID).Return(nil, nil)
+			mockStudioNameUnused(qb, "New Name", tt.usedBy)
+			mockStudioUpdate(qb, studioID)
+
+			task.tagStudio(context.Background(), finder)
+
+			partial := updatedPartial(qb)
+			assert.Equal(t, tt.wantSet, partial.Name.Set)
+			if tt.wantSet {
+				assert.Equal(t, "New Name", partial.Name.Value)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/stashapp/stash/internal/identify"
+	"github.com/stashapp/stash/internal/stashsync"
+	"github.com/stashapp/stash/pkg/job"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scene"
+	"github.com/stashapp/stash/pkg/sliceutil/stringslice"
+	"github.com/stashapp/stash/pkg/txn"
+)
+
+type StashSyncInput struct {
+	// Index of the stash server to sync with
+	ServerIndex int                       `json:"server_index"`
+	Direction   models.StashSyncDirection `json:"direction"`
+	// If set, only sync these scene ids. All scenes are synced otherwise
+	SceneIDs []string `json:"scene_ids"`
+	// Strategies used to apply the changes. Defaults to the options of the
+	// default identify settings
+	Options *identify.MetadataOptions `json:"options"`
+}
+
+// StashSyncJob copies scene metadata between this instance and a remote
+// stash instance, matching scenes by their file fingerprints.
+type StashSyncJob struct {
+	input  StashSyncInput
+	server *models.StashServer
+	syncer *stashsync.Syncer
+}
+
+func CreateStashSyncJob(input StashSyncInput) (*StashSyncJob, error) {
+	servers := instance.Config.GetStashServers()
+	if input.ServerIndex < 0 || input.ServerIndex >= len(servers) {
+		return nil, fmt.Errorf("invalid server_index %d", input.ServerIndex)
+	}
+	server := servers[input.ServerIndex]
+
+	if !input.Direction.IsValid() {
+		return nil, fmt.Errorf("invalid direction %s", input.Direction)
+	}
+
+	var options identify.MetadataOptions
+	if input.Options != nil {
+		options = *input.Options
+	} else if defaults := instance.Config.GetDefaultIdentifySettings(); defaults != nil && defaults.Options != nil {
+		options = *defaults.Options
+	}
+
+	r := instance.Repository
+
+	return &StashSyncJob{
+		input:  input,
+		server: server,
+		syncer: &stashsync.Syncer{
+			Client: stashsync.NewClient(*server),
+			Repository: stashsync.Repository{
+				TxnManager:  r,
+				Scene:       r.Scene,
+				Performer:   r.Performer,
+				Studio:      r.Studio,
+				Tag:         r.Tag,
+				SceneMarker: r.SceneMarker,
+			},
+			Options: options,
+			Identifier: identify.SceneIdentifier{
+				SceneReaderUpdater:          r.Scene,
+				StudioCreator:               r.Studio,
+				PerformerCreator:            r.Performer,
+				TagCreator:                  r.Tag,
+				SceneUpdatePostHookExecutor: instance.PluginCache,
+			},
+		},
+	}, nil
+}
+
+func (j *StashSyncJob) Execute(ctx context.Context, progress *job.Progress) {
+	logger.Infof("Syncing scenes with stash server %s (%s)", j.server.Name, j.input.Direction)
+
+	scenes, err := j.getScenes(ctx)
+	if err != nil {
+		logger.Errorf("Error getting scenes: %v", err)
+		return
+	}
+
+	progress.SetTotal(len(scenes))
+
+	matched := 0
+	for _, s := range scenes {
+		if job.IsCancelled(ctx) {
+			logger.Info("Stopping due to user request")
+			return
+		}
+
+		progress.ExecuteTask("Syncing "+s.Path, func() {
+			var found bool
+			var err error
+			if j.input.Direction == models.StashSyncDirectionPush {
+				found, err = j.syncer.Push(ctx, s)
+			} else {
+				found, err = j.syncer.Pull(ctx, s)
+			}
+
+			if err != nil {
+				logger.Errorf("Error syncing %s: %v", s.Path, err)
+			}
+
+			if found {
+				matched++
+			} else {
+				logger.Debugf("No scene matching %s found on stash server %s", s.Path, j.server.Name)
+			}
+		})
+
+		progress.Increment()
+	}
+
+	logger.Infof("Finished syncing with stash server %s. %d of %d scenes matched", j.server.Name, matched, len(scenes))
+}
+
+func (j *StashSyncJob) getScenes(ctx context.Context) ([]*models.Scene, error) {
+	var ret []*models.Scene
+	if err := txn.WithReadTxn(ctx, instance.Repository, func(ctx context.Context) error {
+		qb := instance.Repository.Scene
+
+		if len(j.input.SceneIDs) == 0 {
+			var err error
+			ret, err = scene.Query(ctx, qb, nil, allObjects("path"))
+			return err
+		}
+
+		ids, err := stringslice.StringSliceToIntSlice(j.input.SceneIDs)
+		if err != nil {
+			return fmt.Errorf("invalid scene ids: %w", err)
+		}
+
+		ret, err = qb.FindMany(ctx, ids)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// runStashSyncScheduler adds sync jobs for the stash servers with a sync
+// interval, until the context is cancelled. The configuration is re-read on
+// each tick, so changes to the servers take effect without a restart. A sync
+// is not added while the previous sync with the server is queued or running.
+func (s *Manager) runStashSyncScheduler(ctx context.Context) {
+	const tick = time.Minute
+
+	// keyed by server url
+	lastRun := make(map[string]time.Time)
+	lastJob := make(map[string]int)
+
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if s.GetSystemStatus().Status != SystemStatusEnumOk {
+				continue
+			}
+
+			for i, server := range s.Config.GetStashServers() {
+				if server.SyncInterval <= 0 {
+					continue
+				}
+
+				last, found := lastRun[server.URL]
+				if !found {
+					// wait a full interval after startup or adding the server
+					lastRun[server.URL] = now
+					continue
+				}
+
+				if now.Sub(last) < time.Duration(server.SyncInterval)*time.Minute {
+					continue
+				}
+
+				if id, found := lastJob[server.URL]; found && s.isJobPending(id) {
+					logger.Debugf("Skipping scheduled sync with stash server %s: previous sync has not finished", server.Name)
+					continue
+				}
+
+				lastRun[server.URL] = now
+
+				j, err := CreateStashSyncJob(StashSyncInput{
+					ServerIndex: i,
+					Direction:   server.SyncDirection,
+				})
+				if err != nil {
+					logger.Errorf("Error creating scheduled sync with stash server %s: %v", server.Name, err)
+					continue
+				}
+
+				lastJob[server.URL] = s.JobManager.Add(ctx, "Syncing with stash server "+server.Name+"...", j)
+			}
+		}
+	}
+}
+
+// isJobPending returns true if the job is queued or running.
+func (s *Manager) isJobPending(id int) bool {
+	j := s.JobManager.GetJob(id)
+	if j == nil {
+		return false
+	}
+
+	switch j.Status {
+	case job.StatusReady, job.StatusRunning, job.StatusStopping:
+		return true
+	}
+
+	return false
+}
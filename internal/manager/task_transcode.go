@@ -18,6 +18,11 @@ type GenerateTranscodeTask struct {
 
 	// is true, generate even if video is browser-supported
 	Force bool
+	// is true, transcode files which are not browser-supported
+	Transcode bool
+	// is true, copy the streams of files into an MP4 container without
+	// re-encoding where possible, instead of transcoding them
+	Remux bool
 
 	g *generate.Generator
 }
@@ -55,7 +60,19 @@ func (t *GenerateTranscodeTask) Start(ctc context.Context) {
 		audioCodec = ffmpeg.ProbeAudioCodec(f.AudioCodec)
 	}
 
-	if !t.Force && ffmpeg.IsStreamable(videoCodec, audioCodec, container) == nil {
+	action := t.action(videoCodec, audioCodec, container)
+	if action == transcodeActionNone {
+		return
+	}
+
+	sceneHash := t.Scene.GetHash(t.fileNamingAlgorithm)
+
+	// only the container is unsupported - copy the streams into an mp4.
+	// Otherwise the transcode below applies the maximum transcode size.
+	if action == transcodeActionRemux {
+		if err := t.g.Remux(ctc, f.Path, sceneHash); err != nil {
+			logger.Errorf("[transcode] error generating remux: %v", err)
+		}
 		return
 	}
 
@@ -67,7 +84,6 @@ func (t *GenerateTranscodeTask) Start(ctc context.Context) {
 		return
 	}
 
-	sceneHash := t.Scene.GetHash(t.fileNamingAlgorithm)
 	transcodeSize := config.GetInstance().GetMaxTranscodeSize()
 
 	w, h := videoFile.TranscodeScale(transcodeSize.GetMaxResolution())
@@ -112,10 +128,6 @@ func (t *GenerateTranscodeTask) required() bool {
 		return false
 	}
 
-	if t.Force {
-		return true
-	}
-
 	var videoCodec string
 	if f.VideoCodec != "" {
 		videoCodec = f.VideoCodec
@@ -130,9 +142,33 @@ func (t *GenerateTranscodeTask) required() bool {
 		container = f.Format
 	}
 
-	if ffmpeg.IsStreamable(videoCodec, audioCodec, ffmpeg.Container(container)) == nil {
-		return false
+	return t.action(videoCodec, audioCodec, ffmpeg.Container(container)) != transcodeActionNone
+}
+
+type transcodeAction int
+
+const (
+	transcodeActionNone transcodeAction = iota
+	transcodeActionRemux
+	transcodeActionTranscode
+)
+
+// action returns how the transcode of a file with the given codecs and
+// container is generated. Files which can be remuxed are remuxed if
+// remuxing is enabled, and other files are transcoded if transcoding is
+// enabled.
+func (t *GenerateTranscodeTask) action(videoCodec string, audioCodec ffmpeg.ProbeAudioCodec, container ffmpeg.Container) transcodeAction {
+	if t.Remux && ffmpeg.IsRemuxable(videoCodec, audioCodec, container) {
+		return transcodeActionRemux
+	}
+
+	if !t.Transcode {
+		return transcodeActionNone
+	}
+
+	if !t.Force && ffmpeg.IsStreamable(videoCodec, audioCodec, container) == nil {
+		return transcodeActionNone
 	}
 
-	return true
+	return transcodeActionTranscode
 }
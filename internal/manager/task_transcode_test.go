@@ -0,0 +1,47 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+)
+
+func TestGenerateTranscodeTask_action(t *testing.T) {
+	type file struct {
+		videoCodec string
+		audioCodec ffmpeg.ProbeAudioCodec
+		container  ffmpeg.Container
+	}
+
+	var (
+		streamable  = file{ffmpeg.H264, ffmpeg.Aac, ffmpeg.Mp4}
+		remuxable   = file{ffmpeg.H264, ffmpeg.Aac, ffmpeg.Matroska}
+		unsupported = file{"wmv3", ffmpeg.MissingUnsupported, ffmpeg.Wmv}
+		none        = transcodeActionNone
+		remux       = transcodeActionRemux
+		transcode   = transcodeActionTranscode
+		allFiles    = []file{streamable, remuxable, unsupported}
+	)
+
+	tests := []struct {
+		name string
+		task GenerateTranscodeTask
+		want []transcodeAction
+	}{
+		{"transcodes", GenerateTranscodeTask{Transcode: true}, []transcodeAction{none, transcode, transcode}},
+		{"remuxes", GenerateTranscodeTask{Remux: true}, []transcodeAction{none, remux, none}},
+		{"transcodes and remuxes", GenerateTranscodeTask{Transcode: true, Remux: true}, []transcodeAction{none, remux, transcode}},
+		{"forced transcodes", GenerateTranscodeTask{Transcode: true, Force: true}, []transcodeAction{transcode, transcode, transcode}},
+		{"forced transcodes and remuxes", GenerateTranscodeTask{Transcode: true, Remux: true, Force: true}, []transcodeAction{transcode, remux, transcode}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, f := range allFiles {
+				if got := tt.task.action(f.videoCodec, f.audioCodec, f.container); got != tt.want[i] {
+					t.Errorf("action(%s, %s, %s) = %v, want %v", f.videoCodec, f.audioCodec, f.container, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
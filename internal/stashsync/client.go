@@ -0,0 +1,343 @@
+// Package stashsync synchronises scene metadata with other stash instances,
+// matching scenes by their file fingerprints.
+package stashsync
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/shurcooL/graphql"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const requestTimeout = 60 * time.Second
+
+// Fingerprints are the fingerprints of a single file used to find a scene on
+// the remote instance. Empty values are ignored.
+type Fingerprints struct {
+	Checksum string
+	Oshash   string
+	Phash    string
+}
+
+// apiKeyTransport adds the API key of the remote instance to each request
+// sent to its host. Image urls returned by the remote instance may point to
+// other hosts, which must not receive the key.
+type apiKeyTransport struct {
+	apiKey string
+	host   string
+	next   http.RoundTripper
+}
+
+func (t apiKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.apiKey != "" && strings.EqualFold(req.URL.Host, t.host) {
+		req = req.Clone(req.Context())
+		req.Header.Set("ApiKey", t.apiKey)
+	}
+
+	return t.next.RoundTrip(req)
+}
+
+// Client queries and updates a remote stash instance.
+type Client struct {
+	client     *graphql.Client
+	httpClient *http.Client
+	server     models.StashServer
+}
+
+// NewClient returns a client for the given stash server.
+func NewClient(server models.StashServer) *Client {
+	var host string
+	if u, err := url.Parse(server.URL); err == nil {
+		host = u.Host
+	}
+
+	httpClient := &http.Client{
+		Timeout: requestTimeout,
+		Transport: apiKeyTransport{
+			apiKey: server.APIKey,
+			host:   host,
+			next:   http.DefaultTransport,
+		},
+	}
+
+	endpoint := strings.TrimSuffix(server.URL, "/") + "/graphql"
+
+	return &Client{
+		client:     graphql.NewClient(endpoint, httpClient),
+		httpClient: httpClient,
+		server:     server,
+	}
+}
+
+// FindScene returns the remote scene matching the fingerprints. The checksum
+// and oshash are tried first, then the phash. A phash must match exactly one
+// remote scene. Returns nil if no scene is found.
+func (c *Client) FindScene(ctx context.Context, fp Fingerprints) (*remoteScene, error) {
+	if fp.Checksum != "" || fp.Oshash != "" {
+		input := SceneHashInput{}
+		if fp.Checksum != "" {
+			input.Checksum = &fp.Checksum
+		}
+		if fp.Oshash != "" {
+			input.Oshash = &fp.Oshash
+		}
+
+		var q struct {
+			FindSceneByHash *remoteScene `graphql:"findSceneByHash(input: $input)"`
+		}
+
+		vars := map[string]interface{}{
+			"input": input,
+		}
+
+		if err := c.client.Query(ctx, &q, vars); err != nil {
+			return nil, fmt.Errorf("finding scene by hash: %w", err)
+		}
+
+		if q.FindSceneByHash != nil {
+			return q.FindSceneByHash, nil
+		}
+	}
+
+	if fp.Phash == "" {
+		return nil, nil
+	}
+
+	var q struct {
+		FindScenes struct {
+			Count  int            `graphql:"count"`
+			Scenes []*remoteScene `graphql:"scenes"`
+		} `graphql:"findScenes(scene_filter: $scene_filter, filter: $filter)"`
+	}
+
+	distance := 0
+	perPage := 2
+	vars := map[string]interface{}{
+		"scene_filter": SceneFilterType{
+			PhashDistance: &PhashDistanceCriterionInput{
+				Value:    fp.Phash,
+				Modifier: criterionModifierEquals,
+				Distance: &distance,
+			},
+		},
+		"filter": FindFilterType{
+			PerPage: &perPage,
+		},
+	}
+
+	if err := c.client.Query(ctx, &q, vars); err != nil {
+		return nil, fmt.Errorf("finding scene by phash: %w", err)
+	}
+
+	if q.FindScenes.Count != 1 {
+		return nil, nil
+	}
+
+	return q.FindScenes.Scenes[0], nil
+}
+
+func nameFilter(name string) *StringCriterionInput {
+	return &StringCriterionInput{
+		Value:    name,
+		Modifier: criterionModifierEquals,
+	}
+}
+
+func singleResult() FindFilterType {
+	perPage := 1
+	return FindFilterType{PerPage: &perPage}
+}
+
+// findStudio returns the id of the remote studio with the given name, or an
+// empty string if not found.
+func (c *Client) findStudio(ctx context.Context, name string) (string, error) {
+	var q struct {
+		FindStudios struct {
+			Studios []remoteStudio `graphql:"studios"`
+		} `graphql:"findStudios(studio_filter: $studio_filter, filter: $filter)"`
+	}
+
+	vars := map[string]interface{}{
+		"studio_filter": StudioFilterType{Name: nameFilter(name)},
+		"filter":        singleResult(),
+	}
+
+	if err := c.client.Query(ctx, &q, vars); err != nil {
+		return "", fmt.Errorf("finding studio %s: %w", name, err)
+	}
+
+	if len(q.FindStudios.Studios) == 0 {
+		return "", nil
+	}
+
+	return q.FindStudios.Studios[0].ID, nil
+}
+
+func (c *Client) createStudio(ctx context.Context, input StudioCreateInput) (string, error) {
+	var m struct {
+		StudioCreate *struct {
+			ID string `graphql:"id"`
+		} `graphql:"studioCreate(input: $input)"`
+	}
+
+	vars := map[string]interface{}{
+		"input": input,
+	}
+
+	if err := c.client.Mutate(ctx, &m, vars); err != nil {
+		return "", fmt.Errorf("creating studio %s: %w", input.Name, err)
+	}
+
+	return m.StudioCreate.ID, nil
+}
+
+// findPerformer returns the id of the remote performer with the given name,
+// or an empty string if not found.
+func (c *Client) findPerformer(ctx context.Context, name string) (string, error) {
+	var q struct {
+		FindPerformers struct {
+			Performers []struct {
+				ID string `graphql:"id"`
+			} `graphql:"performers"`
+		} `graphql:"findPerformers(performer_filter: $performer_filter, filter: $filter)"`
+	}
+
+	vars := map[string]interface{}{
+		"performer_filter": PerformerFilterType{Name: nameFilter(name)},
+		"filter":           singleResult(),
+	}
+
+	if err := c.client.Query(ctx, &q, vars); err != nil {
+		return "", fmt.Errorf("finding performer %s: %w", name, err)
+	}
+
+	if len(q.FindPerformers.Performers) == 0 {
+		return "", nil
+	}
+
+	return q.FindPerformers.Performers[0].ID, nil
+}
+
+func (c *Client) createPerformer(ctx context.Context, input PerformerCreateInput) (string, error) {
+	var m struct {
+		PerformerCreate *struct {
+			ID string `graphql:"id"`
+		} `graphql:"performerCreate(input: $input)"`
+	}
+
+	vars := map[string]interface{}{
+		"input": input,
+	}
+
+	if err := c.client.Mutate(ctx, &m, vars); err != nil {
+		return "", fmt.Errorf("creating performer %s: %w", input.Name, err)
+	}
+
+	return m.PerformerCreate.ID, nil
+}
+
+// findTag returns the id of the remote tag with the given name, or an empty
+// string if not found.
+func (c *Client) findTag(ctx context.Context, name string) (string, error) {
+	var q struct {
+		FindTags struct {
+			Tags []remoteTag `graphql:"tags"`
+		} `graphql:"findTags(tag_filter: $tag_filter, filter: $filter)"`
+	}
+
+	vars := map[string]interface{}{
+		"tag_filter": TagFilterType{Name: nameFilter(name)},
+		"filter":     singleResult(),
+	}
+
+	if err := c.client.Query(ctx, &q, vars); err != nil {
+		return "", fmt.Errorf("finding tag %s: %w", name, err)
+	}
+
+	if len(q.FindTags.Tags) == 0 {
+		return "", nil
+	}
+
+	return q.FindTags.Tags[0].ID, nil
+}
+
+func (c *Client) createTag(ctx context.Context, input TagCreateInput) (string, error) {
+	var m struct {
+		TagCreate *struct {
+			ID string `graphql:"id"`
+		} `graphql:"tagCreate(input: $input)"`
+	}
+
+	vars := map[string]interface{}{
+		"input": input,
+	}
+
+	if err := c.client.Mutate(ctx, &m, vars); err != nil {
+		return "", fmt.Errorf("creating tag %s: %w", input.Name, err)
+	}
+
+	return m.TagCreate.ID, nil
+}
+
+func (c *Client) updateScene(ctx context.Context, input SceneUpdateInput) error {
+	var m struct {
+		SceneUpdate *struct {
+			ID string `graphql:"id"`
+		} `graphql:"sceneUpdate(input: $input)"`
+	}
+
+	vars := map[string]interface{}{
+		"input": input,
+	}
+
+	if err := c.client.Mutate(ctx, &m, vars); err != nil {
+		return fmt.Errorf("updating scene %s: %w", input.ID, err)
+	}
+
+	return nil
+}
+
+func (c *Client) createMarker(ctx context.Context, input SceneMarkerCreateInput) error {
+	var m struct {
+		SceneMarkerCreate *struct {
+			ID string `graphql:"id"`
+		} `graphql:"sceneMarkerCreate(input: $input)"`
+	}
+
+	vars := map[string]interface{}{
+		"input": input,
+	}
+
+	if err := c.client.Mutate(ctx, &m, vars); err != nil {
+		return fmt.Errorf("creating marker %s: %w", input.Title, err)
+	}
+
+	return nil
+}
+
+// getImage downloads an image from the remote instance.
+func (c *Client) getImage(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("http error %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
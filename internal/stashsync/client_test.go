@@ -0,0 +1,155 @@
+package stashsync
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const testAPIKey = "api-key"
+
+type graphqlRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// newTestServer returns a server which responds to graphql requests using
+// the respond function, failing the test if the API key is not set.
+func newTestServer(t *testing.T, respond func(req graphqlRequest) string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, testAPIKey, r.Header.Get("ApiKey"))
+
+		var req graphqlRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(respond(req)))
+	}))
+}
+
+func TestClient_FindScene(t *testing.T) {
+	const (
+		checksum = "checksum"
+		phash    = "phash"
+	)
+
+	server := newTestServer(t, func(req graphqlRequest) string {
+		switch {
+		case strings.Contains(req.Query, "findSceneByHash"):
+			assert.Contains(t, req.Query, "$input:SceneHashInput!")
+			input := req.Variables["input"].(map[string]interface{})
+			if input["checksum"] == checksum {
+				return `{"data":{"findSceneByHash":{"id":"1","title":"by hash"}}}`
+			}
+			return `{"data":{"findSceneByHash":null}}`
+		case strings.Contains(req.Query, "findScenes"):
+			assert.Contains(t, req.Query, "$scene_filter:SceneFilterType!")
+			filter := req.Variables["scene_filter"].(map[string]interface{})["phash_distance"].(map[string]interface{})
+			assert.Equal(t, "EQUALS", filter["modifier"])
+			if filter["value"] == phash {
+				return `{"data":{"findScenes":{"count":1,"scenes":[{"id":"2","title":"by phash"}]}}}`
+			}
+			return `{"data":{"findScenes":{"count":2,"scenes":[{"id":"3"},{"id":"4"}]}}}`
+		}
+
+		t.Errorf("unexpected query: %s", req.Query)
+		return `{}`
+	})
+	defer server.Close()
+
+	c := NewClient(models.StashServer{
+		URL:    server.URL + "/",
+		APIKey: testAPIKey,
+	})
+
+	tests := []struct {
+		name   string
+		fp     Fingerprints
+		wantID string
+	}{
+		{"checksum", Fingerprints{Checksum: checksum, Phash: phash}, "1"},
+		{"phash", Fingerprints{Oshash: "unknown", Phash: phash}, "2"},
+		{"ambiguous phash", Fingerprints{Phash: "other"}, ""},
+		{"no fingerprints", Fingerprints{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.FindScene(context.Background(), tt.fp)
+			if err != nil {
+				t.Errorf("FindScene() error = %v", err)
+				return
+			}
+
+			gotID := ""
+			if got != nil {
+				gotID = got.ID
+			}
+
+			assert.Equal(t, tt.wantID, gotID)
+		})
+	}
+}
+
+func TestClient_createTag(t *testing.T) {
+	server := newTestServer(t, func(req graphqlRequest) string {
+		assert.Contains(t, req.Query, "mutation")
+		assert.Contains(t, req.Query, "$input:TagCreateInput!")
+		assert.Equal(t, "name", req.Variables["input"].(map[string]interface{})["name"])
+		return `{"data":{"tagCreate":{"id":"5"}}}`
+	})
+	defer server.Close()
+
+	c := NewClient(models.StashServer{
+		URL:    server.URL,
+		APIKey: testAPIKey,
+	})
+
+	got, err := c.createTag(context.Background(), TagCreateInput{Name: "name"})
+	if err != nil {
+		t.Errorf("createTag() error = %v", err)
+		return
+	}
+
+	assert.Equal(t, "5", got)
+}
+
+func TestClient_getImage(t *testing.T) {
+	handler := func(wantAPIKey string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, wantAPIKey, r.Header.Get("ApiKey"))
+			_, _ = w.Write([]byte("image"))
+		}
+	}
+
+	server := httptest.NewServer(handler(testAPIKey))
+	defer server.Close()
+
+	other := httptest.NewServer(handler(""))
+	defer other.Close()
+
+	c := NewClient(models.StashServer{
+		URL:    server.URL,
+		APIKey: testAPIKey,
+	})
+
+	for _, u := range []string{server.URL + "/scene/1/screenshot", other.URL + "/screenshot.jpg"} {
+		got, err := c.getImage(context.Background(), u)
+		if err != nil {
+			t.Errorf("getImage(%s) error = %v", u, err)
+			continue
+		}
+
+		assert.Equal(t, []byte("image"), got)
+	}
+}
@@ -0,0 +1,162 @@
+package stashsync
+
+// The graphql client derives the type of each query variable from the name of
+// its Go type. The names of these types must therefore match the input types
+// of the remote stash schema.
+
+type CriterionModifier string
+
+const criterionModifierEquals CriterionModifier = "EQUALS"
+
+type SceneHashInput struct {
+	Checksum *string `json:"checksum,omitempty"`
+	Oshash   *string `json:"oshash,omitempty"`
+}
+
+type FindFilterType struct {
+	PerPage *int `json:"per_page,omitempty"`
+}
+
+type StringCriterionInput struct {
+	Value    string            `json:"value"`
+	Modifier CriterionModifier `json:"modifier"`
+}
+
+type PhashDistanceCriterionInput struct {
+	Value    string            `json:"value"`
+	Modifier CriterionModifier `json:"modifier"`
+	Distance *int              `json:"distance,omitempty"`
+}
+
+type SceneFilterType struct {
+	PhashDistance *PhashDistanceCriterionInput `json:"phash_distance,omitempty"`
+}
+
+type StudioFilterType struct {
+	Name *StringCriterionInput `json:"name,omitempty"`
+}
+
+type PerformerFilterType struct {
+	Name *StringCriterionInput `json:"name,omitempty"`
+}
+
+type TagFilterType struct {
+	Name *StringCriterionInput `json:"name,omitempty"`
+}
+
+type StudioCreateInput struct {
+	Name string  `json:"name"`
+	URL  *string `json:"url,omitempty"`
+}
+
+type PerformerCreateInput struct {
+	Name           string   `json:"name"`
+	Disambiguation *string  `json:"disambiguation,omitempty"`
+	URL            *string  `json:"url,omitempty"`
+	Gender         *string  `json:"gender,omitempty"`
+	Birthdate      *string  `json:"birthdate,omitempty"`
+	Ethnicity      *string  `json:"ethnicity,omitempty"`
+	Country        *string  `json:"country,omitempty"`
+	EyeColor       *string  `json:"eye_color,omitempty"`
+	HeightCm       *int     `json:"height_cm,omitempty"`
+	Measurements   *string  `json:"measurements,omitempty"`
+	FakeTits       *string  `json:"fake_tits,omitempty"`
+	CareerLength   *string  `json:"career_length,omitempty"`
+	Tattoos        *string  `json:"tattoos,omitempty"`
+	Piercings      *string  `json:"piercings,omitempty"`
+	AliasList      []string `json:"alias_list,omitempty"`
+	Twitter        *string  `json:"twitter,omitempty"`
+	Instagram      *string  `json:"instagram,omitempty"`
+	Details        *string  `json:"details,omitempty"`
+	DeathDate      *string  `json:"death_date,omitempty"`
+	HairColor      *string  `json:"hair_color,omitempty"`
+	Weight         *int     `json:"weight,omitempty"`
+}
+
+type TagCreateInput struct {
+	Name string `json:"name"`
+}
+
+type SceneUpdateInput struct {
+	ID           string   `json:"id"`
+	Title        *string  `json:"title,omitempty"`
+	Code         *string  `json:"code,omitempty"`
+	Details      *string  `json:"details,omitempty"`
+	Director     *string  `json:"director,omitempty"`
+	URL          *string  `json:"url,omitempty"`
+	Date         *string  `json:"date,omitempty"`
+	StudioID     *string  `json:"studio_id,omitempty"`
+	PerformerIds []string `json:"performer_ids,omitempty"`
+	TagIds       []string `json:"tag_ids,omitempty"`
+	// This should be a base64 encoded data URL
+	CoverImage *string `json:"cover_image,omitempty"`
+}
+
+type SceneMarkerCreateInput struct {
+	Title        string   `json:"title"`
+	Seconds      float64  `json:"seconds"`
+	SceneID      string   `json:"scene_id"`
+	PrimaryTagID string   `json:"primary_tag_id"`
+	TagIds       []string `json:"tag_ids,omitempty"`
+}
+
+type remoteTag struct {
+	ID   string `graphql:"id"`
+	Name string `graphql:"name"`
+}
+
+type remoteStudio struct {
+	ID   string  `graphql:"id"`
+	Name string  `graphql:"name"`
+	URL  *string `graphql:"url"`
+}
+
+type remotePerformer struct {
+	ID             string   `graphql:"id"`
+	Name           string   `graphql:"name"`
+	Disambiguation *string  `graphql:"disambiguation"`
+	Gender         *string  `graphql:"gender"`
+	URL            *string  `graphql:"url"`
+	Twitter        *string  `graphql:"twitter"`
+	Instagram      *string  `graphql:"instagram"`
+	Birthdate      *string  `graphql:"birthdate"`
+	Ethnicity      *string  `graphql:"ethnicity"`
+	Country        *string  `graphql:"country"`
+	EyeColor       *string  `graphql:"eye_color"`
+	HeightCm       *int     `graphql:"height_cm"`
+	Measurements   *string  `graphql:"measurements"`
+	FakeTits       *string  `graphql:"fake_tits"`
+	CareerLength   *string  `graphql:"career_length"`
+	Tattoos        *string  `graphql:"tattoos"`
+	Piercings      *string  `graphql:"piercings"`
+	AliasList      []string `graphql:"alias_list"`
+	Details        *string  `graphql:"details"`
+	DeathDate      *string  `graphql:"death_date"`
+	HairColor      *string  `graphql:"hair_color"`
+	Weight         *int     `graphql:"weight"`
+}
+
+type remoteMarker struct {
+	ID         string      `graphql:"id"`
+	Title      string      `graphql:"title"`
+	Seconds    float64     `graphql:"seconds"`
+	PrimaryTag remoteTag   `graphql:"primary_tag"`
+	Tags       []remoteTag `graphql:"tags"`
+}
+
+type remoteScene struct {
+	ID           string             `graphql:"id"`
+	Title        *string            `graphql:"title"`
+	Code         *string            `graphql:"code"`
+	Details      *string            `graphql:"details"`
+	Director     *string            `graphql:"director"`
+	URL          *string            `graphql:"url"`
+	Date         *string            `graphql:"date"`
+	Studio       *remoteStudio      `graphql:"studio"`
+	Performers   []*remotePerformer `graphql:"performers"`
+	Tags         []remoteTag        `graphql:"tags"`
+	SceneMarkers []remoteMarker     `graphql:"scene_markers"`
+	Paths        struct {
+		Screenshot *string `graphql:"screenshot"`
+	} `graphql:"paths"`
+}
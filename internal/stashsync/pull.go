@@ -0,0 +1,341 @@
+package stashsync
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stashapp/stash/internal/identify"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/match"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scraper"
+	"github.com/stashapp/stash/pkg/sliceutil/intslice"
+	"github.com/stashapp/stash/pkg/txn"
+)
+
+// markerTolerance is the maximum difference in seconds between two markers
+// with the same primary tag for them to be considered the same marker.
+const markerTolerance = 0.5
+
+// pulledSource is an identify source returning a scene already fetched from
+// the remote instance.
+type pulledSource struct {
+	scene *scraper.ScrapedScene
+}
+
+func (s pulledSource) ScrapeScene(ctx context.Context, sceneID int) (*scraper.ScrapedScene, error) {
+	return s.scene, nil
+}
+
+// Pull copies the metadata of the matching remote scene to the local scene.
+// Returns false if no matching remote scene was found.
+func (s *Syncer) Pull(ctx context.Context, scene *models.Scene) (bool, error) {
+	remote, err := s.findRemoteScene(ctx, scene)
+	if err != nil || remote == nil {
+		return false, err
+	}
+
+	scraped := remote.toScrapedScene()
+
+	if err := txn.WithReadTxn(ctx, s.Repository.TxnManager, func(ctx context.Context) error {
+		return s.matchScrapedScene(ctx, scraped)
+	}); err != nil {
+		return true, fmt.Errorf("matching remote scene: %w", err)
+	}
+
+	if s.setCoverImage() && remote.Paths.Screenshot != nil {
+		image, err := s.Client.getImage(ctx, *remote.Paths.Screenshot)
+		if err != nil {
+			logger.Warnf("Could not get cover image of remote scene %s: %v", remote.ID, err)
+		} else {
+			cover := toDataURL(image)
+			scraped.Image = &cover
+		}
+	}
+
+	identifier := s.Identifier
+	identifier.DefaultOptions = &s.Options
+	identifier.Sources = []identify.ScraperSource{
+		{
+			Name:    "stash " + s.Client.server.Name,
+			Scraper: pulledSource{scene: scraped},
+		},
+	}
+
+	if err := identifier.Identify(ctx, s.Repository.TxnManager, scene); err != nil {
+		return true, err
+	}
+
+	if s.syncMarkers() && len(remote.SceneMarkers) > 0 {
+		if err := txn.WithTxn(ctx, s.Repository.TxnManager, func(ctx context.Context) error {
+			return s.pullMarkers(ctx, scene.ID, remote.SceneMarkers)
+		}); err != nil {
+			return true, fmt.Errorf("pulling markers: %w", err)
+		}
+	}
+
+	return true, nil
+}
+
+func (s remoteScene) toScrapedScene() *scraper.ScrapedScene {
+	ret := &scraper.ScrapedScene{
+		Title:    s.Title,
+		Code:     s.Code,
+		Details:  s.Details,
+		Director: s.Director,
+		URL:      s.URL,
+		Date:     s.Date,
+	}
+
+	if s.Studio != nil {
+		ret.Studio = &models.ScrapedStudio{
+			Name: s.Studio.Name,
+			URL:  s.Studio.URL,
+		}
+	}
+
+	for _, p := range s.Performers {
+		ret.Performers = append(ret.Performers, p.toScrapedPerformer())
+	}
+
+	for _, t := range s.Tags {
+		ret.Tags = append(ret.Tags, &models.ScrapedTag{
+			Name: t.Name,
+		})
+	}
+
+	return ret
+}
+
+func intToString(v *int) *string {
+	if v == nil {
+		return nil
+	}
+
+	ret := strconv.Itoa(*v)
+	return &ret
+}
+
+func (p remotePerformer) toScrapedPerformer() *models.ScrapedPerformer {
+	ret := &models.ScrapedPerformer{
+		Name:           &p.Name,
+		Disambiguation: p.Disambiguation,
+		Gender:         p.Gender,
+		URL:            p.URL,
+		Twitter:        p.Twitter,
+		Instagram:      p.Instagram,
+		Birthdate:      p.Birthdate,
+		Ethnicity:      p.Ethnicity,
+		Country:        p.Country,
+		EyeColor:       p.EyeColor,
+		Height:         intToString(p.HeightCm),
+		Measurements:   p.Measurements,
+		FakeTits:       p.FakeTits,
+		CareerLength:   p.CareerLength,
+		Tattoos:        p.Tattoos,
+		Piercings:      p.Piercings,
+		Details:        p.Details,
+		DeathDate:      p.DeathDate,
+		HairColor:      p.HairColor,
+		Weight:         intToString(p.Weight),
+	}
+
+	if len(p.AliasList) > 0 {
+		aliases := strings.Join(p.AliasList, ", ")
+		ret.Aliases = &aliases
+	}
+
+	return ret
+}
+
+// matchScrapedScene sets the stored ids of the studio, performers and tags
+// which exist locally.
+func (s *Syncer) matchScrapedScene(ctx context.Context, scraped *scraper.ScrapedScene) error {
+	r := s.Repository
+
+	if scraped.Studio != nil {
+		if err := match.ScrapedStudio(ctx, r.Studio, scraped.Studio, nil); err != nil {
+			return err
+		}
+	}
+
+	for _, p := range scraped.Performers {
+		if err := match.ScrapedPerformer(ctx, r.Performer, p, nil); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range scraped.Tags {
+		if err := match.ScrapedTag(ctx, r.Tag, t, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// markerKey identifies a marker by its time and primary tag name.
+type markerKey struct {
+	seconds    float64
+	primaryTag string
+}
+
+func (k markerKey) matches(o markerKey) bool {
+	return strings.EqualFold(k.primaryTag, o.primaryTag) && math.Abs(k.seconds-o.seconds) <= markerTolerance
+}
+
+// missingMarkers returns the indexes of the wanted markers which do not match
+// any of the existing markers.
+func missingMarkers(existing []markerKey, wanted []markerKey) []int {
+	var ret []int
+	for i, w := range wanted {
+		found := false
+		for _, e := range existing {
+			if e.matches(w) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			ret = append(ret, i)
+		}
+	}
+
+	return ret
+}
+
+func (s *Syncer) pullMarkers(ctx context.Context, sceneID int, remote []remoteMarker) error {
+	r := s.Repository
+
+	existing, err := r.SceneMarker.FindBySceneID(ctx, sceneID)
+	if err != nil {
+		return err
+	}
+
+	var primaryTagIDs []int
+	for _, m := range existing {
+		primaryTagIDs = intslice.IntAppendUnique(primaryTagIDs, m.PrimaryTagID)
+	}
+
+	tagNames, err := s.tagNames(ctx, primaryTagIDs)
+	if err != nil {
+		return err
+	}
+
+	var existingKeys []markerKey
+	for _, m := range existing {
+		existingKeys = append(existingKeys, markerKey{
+			seconds:    m.Seconds,
+			primaryTag: tagNames[m.PrimaryTagID],
+		})
+	}
+
+	var wantedKeys []markerKey
+	for _, m := range remote {
+		wantedKeys = append(wantedKeys, markerKey{
+			seconds:    m.Seconds,
+			primaryTag: m.PrimaryTag.Name,
+		})
+	}
+
+	createMissing := s.fieldOptions("tags").ShouldCreateMissing()
+
+	for _, i := range missingMarkers(existingKeys, wantedKeys) {
+		m := remote[i]
+
+		primaryTagID, err := s.localTagID(ctx, m.PrimaryTag.Name, createMissing)
+		if err != nil {
+			return err
+		}
+
+		if primaryTagID == nil {
+			logger.Debugf("Not pulling marker %s: tag %s does not exist", m.Title, m.PrimaryTag.Name)
+			continue
+		}
+
+		var tagIDs []int
+		for _, t := range m.Tags {
+			tagID, err := s.localTagID(ctx, t.Name, createMissing)
+			if err != nil {
+				return err
+			}
+
+			if tagID != nil {
+				tagIDs = intslice.IntAppendUnique(tagIDs, *tagID)
+			}
+		}
+
+		currentTime := time.Now()
+		newMarker := &models.SceneMarker{
+			Title:        m.Title,
+			Seconds:      m.Seconds,
+			PrimaryTagID: *primaryTagID,
+			SceneID:      sceneID,
+			CreatedAt:    currentTime,
+			UpdatedAt:    currentTime,
+		}
+
+		if err := r.SceneMarker.Create(ctx, newMarker); err != nil {
+			return err
+		}
+
+		if len(tagIDs) > 0 {
+			if err := r.SceneMarker.UpdateTags(ctx, newMarker.ID, tagIDs); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// tagNames returns the names of the tags with the given ids, keyed by id.
+func (s *Syncer) tagNames(ctx context.Context, ids []int) (map[int]string, error) {
+	tags, err := s.Repository.Tag.FindMany(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[int]string)
+	for _, t := range tags {
+		ret[t.ID] = t.Name
+	}
+
+	return ret, nil
+}
+
+// localTagID returns the id of the local tag with the given name or alias.
+// Creates the tag if it does not exist and create is true, otherwise returns
+// nil.
+func (s *Syncer) localTagID(ctx context.Context, name string, create bool) (*int, error) {
+	qb := s.Repository.Tag
+
+	t := &models.ScrapedTag{Name: name}
+	if err := match.ScrapedTag(ctx, qb, t, nil); err != nil {
+		return nil, err
+	}
+
+	if t.StoredID != nil {
+		id, err := strconv.Atoi(*t.StoredID)
+		if err != nil {
+			return nil, err
+		}
+		return &id, nil
+	}
+
+	if !create {
+		return nil, nil
+	}
+
+	newTag := models.NewTag(name)
+	if err := qb.Create(ctx, newTag); err != nil {
+		return nil, fmt.Errorf("creating tag %s: %w", name, err)
+	}
+
+	return &newTag.ID, nil
+}
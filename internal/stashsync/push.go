@@ -0,0 +1,459 @@
+package stashsync
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/stashapp/stash/internal/identify"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/sliceutil/stringslice"
+	"github.com/stashapp/stash/pkg/txn"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// localScene is a local scene and the related objects pushed to the remote
+// instance.
+type localScene struct {
+	scene      *models.Scene
+	studio     *models.Studio
+	performers []*models.Performer
+	tags       []*models.Tag
+	markers    []localMarker
+	cover      []byte
+}
+
+type localMarker struct {
+	title      string
+	seconds    float64
+	primaryTag string
+	tags       []string
+}
+
+// Push copies the metadata of the local scene to the matching remote scene.
+// Returns false if no matching remote scene was found.
+func (s *Syncer) Push(ctx context.Context, scene *models.Scene) (bool, error) {
+	remote, err := s.findRemoteScene(ctx, scene)
+	if err != nil || remote == nil {
+		return false, err
+	}
+
+	var local *localScene
+	if err := txn.WithReadTxn(ctx, s.Repository.TxnManager, func(ctx context.Context) error {
+		var err error
+		local, err = s.loadLocalScene(ctx, scene)
+		return err
+	}); err != nil {
+		return true, fmt.Errorf("loading scene: %w", err)
+	}
+
+	input := SceneUpdateInput{ID: remote.ID}
+	changed := s.setSceneFields(&input, scene, remote)
+
+	if local.studio != nil && (remote.Studio == nil || !strings.EqualFold(remote.Studio.Name, local.studio.Name)) && s.fieldOptions("studio").ShouldSetSingleValue(remote.Studio != nil) {
+		studioID, err := s.remoteStudioID(ctx, local.studio)
+		if err != nil {
+			return true, err
+		}
+
+		if studioID != "" {
+			input.StudioID = &studioID
+			changed = true
+		}
+	}
+
+	performerIDs, err := s.remotePerformerIDs(ctx, local.performers)
+	if err != nil {
+		return true, err
+	}
+
+	var existingPerformerIDs []string
+	for _, p := range remote.Performers {
+		existingPerformerIDs = append(existingPerformerIDs, p.ID)
+	}
+
+	if ids, set := mergeIDs(s.fieldOptions("performers").GetStrategy(), existingPerformerIDs, performerIDs); set {
+		input.PerformerIds = ids
+		changed = true
+	}
+
+	tagIDs, err := s.remoteTagIDs(ctx, local.tags)
+	if err != nil {
+		return true, err
+	}
+
+	var existingTagIDs []string
+	for _, t := range remote.Tags {
+		existingTagIDs = append(existingTagIDs, t.ID)
+	}
+
+	if ids, set := mergeIDs(s.fieldOptions("tags").GetStrategy(), existingTagIDs, tagIDs); set {
+		input.TagIds = ids
+		changed = true
+	}
+
+	if s.setCoverImage() && len(local.cover) > 0 && s.remoteCoverDiffers(ctx, remote, local.cover) {
+		cover := toDataURL(local.cover)
+		input.CoverImage = &cover
+		changed = true
+	}
+
+	if changed {
+		if err := s.Client.updateScene(ctx, input); err != nil {
+			return true, err
+		}
+	}
+
+	if s.syncMarkers() && len(local.markers) > 0 {
+		if err := s.pushMarkers(ctx, remote, local.markers); err != nil {
+			return true, fmt.Errorf("pushing markers: %w", err)
+		}
+	}
+
+	return true, nil
+}
+
+func (s *Syncer) loadLocalScene(ctx context.Context, scene *models.Scene) (*localScene, error) {
+	r := s.Repository
+	ret := &localScene{scene: scene}
+
+	if err := scene.LoadPerformerIDs(ctx, r.Scene); err != nil {
+		return nil, err
+	}
+
+	if err := scene.LoadTagIDs(ctx, r.Scene); err != nil {
+		return nil, err
+	}
+
+	if scene.StudioID != nil {
+		var err error
+		ret.studio, err = r.Studio.Find(ctx, *scene.StudioID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	ret.performers, err = r.Performer.FindMany(ctx, scene.PerformerIDs.List())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range ret.performers {
+		if err := p.LoadAliases(ctx, r.Performer); err != nil {
+			return nil, err
+		}
+	}
+
+	ret.tags, err = r.Tag.FindMany(ctx, scene.TagIDs.List())
+	if err != nil {
+		return nil, err
+	}
+
+	if s.syncMarkers() {
+		ret.markers, err = s.loadLocalMarkers(ctx, scene.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if s.setCoverImage() {
+		ret.cover, err = r.Scene.GetCover(ctx, scene.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ret, nil
+}
+
+func (s *Syncer) loadLocalMarkers(ctx context.Context, sceneID int) ([]localMarker, error) {
+	r := s.Repository
+
+	markers, err := r.SceneMarker.FindBySceneID(ctx, sceneID)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []localMarker
+	for _, m := range markers {
+		tagIDs, err := r.SceneMarker.GetTagIDs(ctx, m.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		names, err := s.tagNames(ctx, append([]int{m.PrimaryTagID}, tagIDs...))
+		if err != nil {
+			return nil, err
+		}
+
+		lm := localMarker{
+			title:      m.Title,
+			seconds:    m.Seconds,
+			primaryTag: names[m.PrimaryTagID],
+		}
+		for _, id := range tagIDs {
+			lm.tags = append(lm.tags, names[id])
+		}
+
+		ret = append(ret, lm)
+	}
+
+	return ret, nil
+}
+
+// setSceneFields sets the single-value fields of the input which should be
+// pushed. Returns true if any field was set.
+func (s *Syncer) setSceneFields(input *SceneUpdateInput, scene *models.Scene, remote *remoteScene) bool {
+	changed := false
+	get := func(field string, local string, existing *string) *string {
+		if local == "" || (existing != nil && *existing == local) {
+			return nil
+		}
+
+		if !s.fieldOptions(field).ShouldSetSingleValue(existing != nil && *existing != "") {
+			return nil
+		}
+
+		changed = true
+		return &local
+	}
+
+	date := ""
+	if scene.Date != nil {
+		date = scene.Date.String()
+	}
+
+	input.Title = get("title", scene.Title, remote.Title)
+	input.Code = get("code", scene.Code, remote.Code)
+	input.Details = get("details", scene.Details, remote.Details)
+	input.Director = get("director", scene.Director, remote.Director)
+	input.URL = get("url", scene.URL, remote.URL)
+	input.Date = get("date", date, remote.Date)
+
+	return changed
+}
+
+// mergeIDs returns the ids to set for a multi-value field using the field
+// strategy. Returns false if the field should not be changed.
+func mergeIDs(strategy identify.FieldStrategy, existing []string, local []string) ([]string, bool) {
+	if strategy == identify.FieldStrategyIgnore || len(local) == 0 {
+		return nil, false
+	}
+
+	ret := local
+	if strategy == identify.FieldStrategyMerge {
+		ret = stringslice.StrAppendUniques(existing, local)
+	}
+
+	if len(ret) == len(existing) && len(stringslice.StrAppendUniques(existing, ret)) == len(existing) {
+		return nil, false
+	}
+
+	return ret, true
+}
+
+// remoteStudioID returns the id of the remote studio with the same name as
+// the local studio, creating it if it does not exist and missing studios
+// should be created. Returns an empty string otherwise.
+func (s *Syncer) remoteStudioID(ctx context.Context, studio *models.Studio) (string, error) {
+	ret, err := s.Client.findStudio(ctx, studio.Name)
+	if err != nil || ret != "" {
+		return ret, err
+	}
+
+	if !s.fieldOptions("studio").ShouldCreateMissing() {
+		return "", nil
+	}
+
+	input := StudioCreateInput{Name: studio.Name}
+	if studio.URL != "" {
+		input.URL = &studio.URL
+	}
+
+	return s.Client.createStudio(ctx, input)
+}
+
+func (s *Syncer) remotePerformerIDs(ctx context.Context, performers []*models.Performer) ([]string, error) {
+	createMissing := s.fieldOptions("performers").ShouldCreateMissing()
+	includeMale := s.Options.IncludeMalePerformers == nil || *s.Options.IncludeMalePerformers
+
+	var ret []string
+	for _, p := range performers {
+		if !includeMale && p.Gender != nil && *p.Gender == models.GenderEnumMale {
+			continue
+		}
+
+		id, err := s.Client.findPerformer(ctx, p.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		if id == "" && createMissing {
+			id, err = s.Client.createPerformer(ctx, newPerformerCreateInput(p))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if id != "" {
+			ret = append(ret, id)
+		}
+	}
+
+	return ret, nil
+}
+
+func (s *Syncer) remoteTagIDs(ctx context.Context, tags []*models.Tag) ([]string, error) {
+	createMissing := s.fieldOptions("tags").ShouldCreateMissing()
+
+	var ret []string
+	for _, t := range tags {
+		id, err := s.remoteTagID(ctx, t.Name, createMissing)
+		if err != nil {
+			return nil, err
+		}
+
+		if id != "" {
+			ret = append(ret, id)
+		}
+	}
+
+	return ret, nil
+}
+
+// remoteTagID returns the id of the remote tag with the given name. Creates
+// the tag if it does not exist and create is true, otherwise returns an
+// empty string.
+func (s *Syncer) remoteTagID(ctx context.Context, name string, create bool) (string, error) {
+	ret, err := s.Client.findTag(ctx, name)
+	if err != nil || ret != "" || !create {
+		return ret, err
+	}
+
+	return s.Client.createTag(ctx, TagCreateInput{Name: name})
+}
+
+func optionalString(v string) *string {
+	if v == "" {
+		return nil
+	}
+
+	return &v
+}
+
+func newPerformerCreateInput(p *models.Performer) PerformerCreateInput {
+	ret := PerformerCreateInput{
+		Name:           p.Name,
+		Disambiguation: optionalString(p.Disambiguation),
+		URL:            optionalString(p.URL),
+		Ethnicity:      optionalString(p.Ethnicity),
+		Country:        optionalString(p.Country),
+		EyeColor:       optionalString(p.EyeColor),
+		HeightCm:       p.Height,
+		Measurements:   optionalString(p.Measurements),
+		FakeTits:       optionalString(p.FakeTits),
+		CareerLength:   optionalString(p.CareerLength),
+		Tattoos:        optionalString(p.Tattoos),
+		Piercings:      optionalString(p.Piercings),
+		AliasList:      p.Aliases.List(),
+		Twitter:        optionalString(p.Twitter),
+		Instagram:      optionalString(p.Instagram),
+		Details:        optionalString(p.Details),
+		HairColor:      optionalString(p.HairColor),
+		Weight:         p.Weight,
+	}
+
+	if p.Gender != nil {
+		ret.Gender = optionalString(p.Gender.String())
+	}
+	if p.Birthdate != nil {
+		ret.Birthdate = optionalString(p.Birthdate.String())
+	}
+	if p.DeathDate != nil {
+		ret.DeathDate = optionalString(p.DeathDate.String())
+	}
+
+	return ret
+}
+
+// remoteCoverDiffers returns true if the cover of the remote scene differs
+// from the given image, or could not be retrieved.
+func (s *Syncer) remoteCoverDiffers(ctx context.Context, remote *remoteScene, cover []byte) bool {
+	if remote.Paths.Screenshot == nil {
+		return true
+	}
+
+	existing, err := s.Client.getImage(ctx, *remote.Paths.Screenshot)
+	if err != nil {
+		logger.Debugf("Could not get cover image of remote scene %s: %v", remote.ID, err)
+		return true
+	}
+
+	return !bytes.Equal(existing, cover)
+}
+
+func toDataURL(data []byte) string {
+	return "data:" + http.DetectContentType(data) + ";base64," + utils.GetBase64StringFromData(data)
+}
+
+func (s *Syncer) pushMarkers(ctx context.Context, remote *remoteScene, markers []localMarker) error {
+	var existingKeys []markerKey
+	for _, m := range remote.SceneMarkers {
+		existingKeys = append(existingKeys, markerKey{
+			seconds:    m.Seconds,
+			primaryTag: m.PrimaryTag.Name,
+		})
+	}
+
+	var wantedKeys []markerKey
+	for _, m := range markers {
+		wantedKeys = append(wantedKeys, markerKey{
+			seconds:    m.seconds,
+			primaryTag: m.primaryTag,
+		})
+	}
+
+	createMissing := s.fieldOptions("tags").ShouldCreateMissing()
+
+	for _, i := range missingMarkers(existingKeys, wantedKeys) {
+		m := markers[i]
+
+		primaryTagID, err := s.remoteTagID(ctx, m.primaryTag, createMissing)
+		if err != nil {
+			return err
+		}
+
+		if primaryTagID == "" {
+			logger.Debugf("Not pushing marker %s: tag %s does not exist on the remote instance", m.title, m.primaryTag)
+			continue
+		}
+
+		input := SceneMarkerCreateInput{
+			Title:        m.title,
+			Seconds:      m.seconds,
+			SceneID:      remote.ID,
+			PrimaryTagID: primaryTagID,
+		}
+
+		for _, t := range m.tags {
+			tagID, err := s.remoteTagID(ctx, t, createMissing)
+			if err != nil {
+				return err
+			}
+
+			if tagID != "" {
+				input.TagIds = stringslice.StrAppendUnique(input.TagIds, tagID)
+			}
+		}
+
+		if err := s.Client.createMarker(ctx, input); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package stashsync
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stashapp/stash/internal/identify"
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/match"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/txn"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+type SceneReader interface {
+	models.VideoFileLoader
+	models.PerformerIDLoader
+	models.TagIDLoader
+	GetCover(ctx context.Context, sceneID int) ([]byte, error)
+}
+
+type PerformerReader interface {
+	match.PerformerFinder
+	FindMany(ctx context.Context, ids []int) ([]*models.Performer, error)
+	models.AliasLoader
+}
+
+type StudioReader interface {
+	match.StudioFinder
+	Find(ctx context.Context, id int) (*models.Studio, error)
+}
+
+type TagReaderWriter interface {
+	match.TagFinder
+	FindMany(ctx context.Context, ids []int) ([]*models.Tag, error)
+	Create(ctx context.Context, newTag *models.Tag) error
+}
+
+type SceneMarkerReaderWriter interface {
+	FindBySceneID(ctx context.Context, sceneID int) ([]*models.SceneMarker, error)
+	GetTagIDs(ctx context.Context, markerID int) ([]int, error)
+	Create(ctx context.Context, newSceneMarker *models.SceneMarker) error
+	UpdateTags(ctx context.Context, markerID int, tagIDs []int) error
+}
+
+type Repository struct {
+	TxnManager  txn.Manager
+	Scene       SceneReader
+	Performer   PerformerReader
+	Studio      StudioReader
+	Tag         TagReaderWriter
+	SceneMarker SceneMarkerReaderWriter
+}
+
+// Syncer copies scene metadata between this instance and a remote stash
+// instance.
+//
+// Field strategies and creation of missing objects are controlled by the
+// identify metadata options. The markers field option controls markers: they
+// are never synced if its strategy is IGNORE, and are otherwise only ever
+// added. Markers on the target scene are never changed or removed.
+type Syncer struct {
+	Client     *Client
+	Repository Repository
+	Options    identify.MetadataOptions
+	// Identifier applies pulled metadata to local scenes. Its sources and
+	// default options are set by the syncer.
+	Identifier identify.SceneIdentifier
+}
+
+// findRemoteScene returns the remote scene matching the fingerprints of any
+// file of the local scene, or nil if none is found.
+func (s *Syncer) findRemoteScene(ctx context.Context, scene *models.Scene) (*remoteScene, error) {
+	if err := txn.WithReadTxn(ctx, s.Repository.TxnManager, func(ctx context.Context) error {
+		return scene.LoadFiles(ctx, s.Repository.Scene)
+	}); err != nil {
+		return nil, fmt.Errorf("loading files: %w", err)
+	}
+
+	for _, f := range scene.Files.List() {
+		ret, err := s.Client.FindScene(ctx, fileFingerprints(f))
+		if ret != nil || err != nil {
+			return ret, err
+		}
+	}
+
+	return nil, nil
+}
+
+func fileFingerprints(f *file.VideoFile) Fingerprints {
+	ret := Fingerprints{
+		Checksum: f.Fingerprints.GetString(file.FingerprintTypeMD5),
+		Oshash:   f.Fingerprints.GetString(file.FingerprintTypeOshash),
+	}
+
+	if f.Fingerprints.Get(file.FingerprintTypePhash) != nil {
+		ret.Phash = utils.PhashToString(f.Fingerprints.GetInt64(file.FingerprintTypePhash))
+	}
+
+	return ret
+}
+
+func (s *Syncer) fieldOptions(field string) *identify.FieldOptions {
+	return s.Options.GetFieldOptions(field)
+}
+
+func (s *Syncer) setCoverImage() bool {
+	return s.Options.SetCoverImage != nil && *s.Options.SetCoverImage
+}
+
+func (s *Syncer) syncMarkers() bool {
+	return s.fieldOptions("markers").GetStrategy() != identify.FieldStrategyIgnore
+}
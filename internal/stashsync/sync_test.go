@@ -0,0 +1,130 @@
+package stashsync
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stashapp/stash/internal/identify"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func Test_mergeIDs(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy identify.FieldStrategy
+		existing []string
+		local    []string
+		want     []string
+		wantSet  bool
+	}{
+		{"ignore", identify.FieldStrategyIgnore, []string{"1"}, []string{"2"}, nil, false},
+		{"merge", identify.FieldStrategyMerge, []string{"1"}, []string{"2"}, []string{"1", "2"}, true},
+		{"merge unchanged", identify.FieldStrategyMerge, []string{"1", "2"}, []string{"2"}, nil, false},
+		{"overwrite", identify.FieldStrategyOverwrite, []string{"1"}, []string{"2"}, []string{"2"}, true},
+		{"overwrite unchanged", identify.FieldStrategyOverwrite, []string{"1", "2"}, []string{"2", "1"}, nil, false},
+		{"no local", identify.FieldStrategyOverwrite, []string{"1"}, nil, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotSet := mergeIDs(tt.strategy, tt.existing, tt.local)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantSet, gotSet)
+		})
+	}
+}
+
+func Test_missingMarkers(t *testing.T) {
+	existing := []markerKey{
+		{seconds: 10, primaryTag: "Kiss"},
+		{seconds: 20, primaryTag: "Dance"},
+	}
+
+	wanted := []markerKey{
+		{seconds: 10.2, primaryTag: "kiss"},
+		{seconds: 20, primaryTag: "Kiss"},
+		{seconds: 30, primaryTag: "Dance"},
+	}
+
+	assert.Equal(t, []int{1, 2}, missingMarkers(existing, wanted))
+}
+
+func TestSyncer_setSceneFields(t *testing.T) {
+	strPtr := func(s string) *string {
+		return &s
+	}
+
+	scene := &models.Scene{
+		Title:    "title",
+		Code:     "code",
+		Details:  "details",
+		Director: "director",
+	}
+
+	remote := &remoteScene{
+		Title:   strPtr("remote title"),
+		Code:    strPtr("code"),
+		Details: strPtr(""),
+	}
+
+	tests := []struct {
+		name    string
+		options identify.MetadataOptions
+		want    SceneUpdateInput
+	}{
+		{
+			"merge",
+			identify.MetadataOptions{},
+			SceneUpdateInput{
+				Details:  strPtr("details"),
+				Director: strPtr("director"),
+			},
+		},
+		{
+			"overwrite title",
+			identify.MetadataOptions{
+				FieldOptions: []*identify.FieldOptions{
+					{Field: "title", Strategy: identify.FieldStrategyOverwrite},
+					{Field: "director", Strategy: identify.FieldStrategyIgnore},
+				},
+			},
+			SceneUpdateInput{
+				Title:   strPtr("title"),
+				Details: strPtr("details"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Syncer{Options: tt.options}
+
+			var got SceneUpdateInput
+			changed := s.setSceneFields(&got, scene, remote)
+			assert.True(t, changed)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_remoteScene_toScrapedScene(t *testing.T) {
+	height := 170
+	remote := remoteScene{
+		Studio: &remoteStudio{Name: "studio"},
+		Performers: []*remotePerformer{
+			{Name: "performer", HeightCm: &height, AliasList: []string{"a", "b"}},
+		},
+		Tags: []remoteTag{{ID: "1", Name: "tag"}},
+	}
+
+	got := remote.toScrapedScene()
+
+	assert.Equal(t, "studio", got.Studio.Name)
+	assert.Len(t, got.Performers, 1)
+	assert.Equal(t, "performer", *got.Performers[0].Name)
+	assert.Equal(t, "170", *got.Performers[0].Height)
+	assert.Equal(t, "a, b", *got.Performers[0].Aliases)
+	assert.Len(t, got.Tags, 1)
+	assert.Equal(t, "tag", got.Tags[0].Name)
+}
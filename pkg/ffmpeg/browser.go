@@ -53,6 +53,13 @@ func IsStreamable(videoCodec string, audioCodec ProbeAudioCodec, container Conta
 	return nil
 }
 
+// IsRemuxable returns true if a file that is not streamable in its container
+// would be streamable if its streams were copied into an MP4 container,
+// without re-encoding.
+func IsRemuxable(videoCodec string, audioCodec ProbeAudioCodec, container Container) bool {
+	return IsStreamable(videoCodec, audioCodec, container) != nil && IsStreamable(videoCodec, audioCodec, Mp4) == nil
+}
+
 func isValidCodec(codecName string, supportedCodecs []string) bool {
 	for _, c := range supportedCodecs {
 		if c == codecName {
@@ -12,14 +12,15 @@ func (c VideoCodec) Args() []string {
 
 var (
 	// Software codec's
-	VideoCodecLibX264 VideoCodec = "libx264"
-	VideoCodecLibWebP VideoCodec = "libwebp"
-	VideoCodecBMP     VideoCodec = "bmp"
-	VideoCodecMJpeg   VideoCodec = "mjpeg"
-	VideoCodecVP9     VideoCodec = "libvpx-vp9"
-	VideoCodecVPX     VideoCodec = "libvpx"
-	VideoCodecLibX265 VideoCodec = "libx265"
-	VideoCodecCopy    VideoCodec = "copy"
+	VideoCodecLibX264   VideoCodec = "libx264"
+	VideoCodecLibWebP   VideoCodec = "libwebp"
+	VideoCodecBMP       VideoCodec = "bmp"
+	VideoCodecMJpeg     VideoCodec = "mjpeg"
+	VideoCodecVP9       VideoCodec = "libvpx-vp9"
+	VideoCodecVPX       VideoCodec = "libvpx"
+	VideoCodecLibX265   VideoCodec = "libx265"
+	VideoCodecLibSVTAV1 VideoCodec = "libsvtav1"
+	VideoCodecCopy      VideoCodec = "copy"
 )
 
 type AudioCodec string
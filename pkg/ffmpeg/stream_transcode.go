@@ -112,6 +112,23 @@ var (
 			return
 		},
 	}
+	// StreamTypeMPEGTS is used for DLNA renderers, most of which support
+	// H264 and AAC in an MPEG-TS container.
+	StreamTypeMPEGTS = StreamFormat{
+		MimeType: MimeMpegVideo,
+		Args: func(codec VideoCodec, videoFilter VideoFilter, videoOnly bool) (args Args) {
+			args = CodecInit(codec)
+			args = args.VideoFilter(videoFilter)
+			if videoOnly {
+				args = args.SkipAudio()
+			} else {
+				args = args.AudioCodec(AudioCodecAAC)
+				args = append(args, "-ac", "2")
+			}
+			args = args.Format(FormatMpegTS)
+			return
+		},
+	}
 	StreamTypeMKV = StreamFormat{
 		MimeType: MimeMkvVideo,
 		Args: func(codec VideoCodec, videoFilter VideoFilter, videoOnly bool) (args Args) {
@@ -141,7 +158,7 @@ type TranscodeOptions struct {
 
 func FileGetCodec(sm *StreamManager, mimetype string) (codec VideoCodec) {
 	switch mimetype {
-	case MimeMp4Video:
+	case MimeMp4Video, MimeMpegVideo:
 		codec = VideoCodecLibX264
 		if hwcodec := sm.encoder.hwCodecMP4Compatible(); hwcodec != nil && sm.config.GetTranscodeHardwareAcceleration() {
 			codec = *hwcodec
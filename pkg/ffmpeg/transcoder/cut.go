@@ -0,0 +1,75 @@
+package transcoder
+
+import "github.com/stashapp/stash/pkg/ffmpeg"
+
+type CutOptions struct {
+	OutputPath string
+	Format     ffmpeg.Format
+
+	// StartTime and Duration are the range of the input to extract, in seconds.
+	// If Duration is 0, then the range extends to the end of the input.
+	StartTime float64
+	Duration  float64
+
+	// If VideoCodec is not provided, then the streams are copied without
+	// re-encoding. The cut points will be moved to the preceding keyframe.
+	VideoCodec ffmpeg.VideoCodec
+	VideoArgs  ffmpeg.Args
+
+	AudioCodec ffmpeg.AudioCodec
+	AudioArgs  ffmpeg.Args
+
+	// Verbosity is the logging verbosity. Defaults to LogLevelError if not set.
+	Verbosity ffmpeg.LogLevel
+}
+
+func (o *CutOptions) setDefaults() {
+	if o.Verbosity == "" {
+		o.Verbosity = ffmpeg.LogLevelError
+	}
+}
+
+// Cut returns the arguments to extract a range of the input file into a
+// new file.
+func Cut(input string, options CutOptions) ffmpeg.Args {
+	options.setDefaults()
+
+	var args ffmpeg.Args
+	args = args.LogLevel(options.Verbosity).Overwrite()
+
+	if options.StartTime > 0 {
+		args = args.Seek(options.StartTime)
+	}
+
+	args = args.Input(input)
+
+	if options.Duration > 0 {
+		args = args.Duration(options.Duration)
+	}
+
+	// https://trac.ffmpeg.org/ticket/6375
+	args = args.MaxMuxingQueueSize(1024)
+
+	if options.VideoCodec == "" {
+		options.VideoCodec = ffmpeg.VideoCodecCopy
+	}
+
+	args = args.VideoCodec(options.VideoCodec)
+	args = args.AppendArgs(options.VideoArgs)
+
+	if options.AudioCodec == "" {
+		options.AudioCodec = ffmpeg.AudioCodecCopy
+	}
+
+	args = args.AudioCodec(options.AudioCodec)
+	args = args.AppendArgs(options.AudioArgs)
+
+	// copied streams may start before the seek point; shift the timestamps
+	// so that the output starts at zero
+	args = append(args, "-avoid_negative_ts", "make_zero")
+
+	args = args.Format(options.Format)
+	args = args.Output(options.OutputPath)
+
+	return args
+}
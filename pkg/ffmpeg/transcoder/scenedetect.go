@@ -0,0 +1,58 @@
+package transcoder
+
+import (
+	"fmt"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+)
+
+type SceneDetectOptions struct {
+	// Threshold is the scene change score (0-1) above which a frame is considered a cut.
+	Threshold float64
+
+	// BlackDuration is the minimum duration in seconds of a black interval.
+	BlackDuration float64
+	// BlackPixelThreshold is the luminance (0-1) below which a pixel is considered black.
+	BlackPixelThreshold float64
+
+	// Width is the width frames are scaled to before analysis. Defaults to no scaling.
+	Width int
+
+	// Verbosity is the logging verbosity. Defaults to LogLevelInfo if not set,
+	// since the filter output is written to the log.
+	Verbosity ffmpeg.LogLevel
+}
+
+func (o *SceneDetectOptions) setDefaults() {
+	if o.Verbosity == "" {
+		o.Verbosity = ffmpeg.LogLevelInfo
+	}
+}
+
+// SceneDetect returns the arguments to decode the input, logging black
+// intervals using the blackdetect filter and scene changes using the select
+// and showinfo filters. The results are written to stderr and no output
+// file is produced.
+func SceneDetect(input string, options SceneDetectOptions) ffmpeg.Args {
+	options.setDefaults()
+
+	var args ffmpeg.Args
+	args = args.LogLevel(options.Verbosity)
+	args = append(args, "-nostats")
+	args = args.Input(input)
+	args = args.SkipAudio()
+
+	var vf ffmpeg.VideoFilter
+	if options.Width > 0 {
+		vf = vf.ScaleWidth(options.Width)
+	}
+	vf = vf.Append(fmt.Sprintf("blackdetect=d=%v:pix_th=%v", options.BlackDuration, options.BlackPixelThreshold))
+	vf = vf.Append(fmt.Sprintf("select=gt(scene\\,%v)", options.Threshold))
+	vf = vf.Append("showinfo")
+	args = args.VideoFilter(vf)
+
+	args = args.Format(ffmpeg.FormatNull)
+	args = args.NullOutput()
+
+	return args
+}
@@ -132,13 +132,34 @@ func ScrapedMovie(ctx context.Context, qb MovieNamesFinder, m *models.ScrapedMov
 	return nil
 }
 
+type TagFinder interface {
+	tag.Queryer
+	FindByStashID(ctx context.Context, stashID models.StashID) ([]*models.Tag, error)
+}
+
 // ScrapedTag matches the provided tag with the tags
 // in the database and sets the ID field if one is found.
-func ScrapedTag(ctx context.Context, qb tag.Queryer, s *models.ScrapedTag) error {
+func ScrapedTag(ctx context.Context, qb TagFinder, s *models.ScrapedTag, stashBoxEndpoint *string) error {
 	if s.StoredID != nil {
 		return nil
 	}
 
+	// Check if a tag with the StashID already exists
+	if stashBoxEndpoint != nil && s.RemoteSiteID != nil {
+		tags, err := qb.FindByStashID(ctx, models.StashID{
+			StashID:  *s.RemoteSiteID,
+			Endpoint: *stashBoxEndpoint,
+		})
+		if err != nil {
+			return err
+		}
+		if len(tags) > 0 {
+			id := strconv.Itoa(tags[0].ID)
+			s.StoredID = &id
+			return nil
+		}
+	}
+
 	t, err := tag.ByName(ctx, qb, s.Name)
 
 	if err != nil {
@@ -0,0 +1,96 @@
+package match
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var testCtx = context.Background()
+
+func TestScrapedTag(t *testing.T) {
+	const (
+		linkedID   = 1
+		namedID    = 2
+		endpoint   = "endpoint"
+		linkedName = "Big Tits"
+		remoteID   = "remote-id"
+		unknownID  = "unknown-id"
+	)
+
+	byStashID := func(id string) interface{} {
+		return models.StashID{
+			StashID:  id,
+			Endpoint: endpoint,
+		}
+	}
+
+	mockTagReader := &mocks.TagReaderWriter{}
+	mockTagReader.On("FindByStashID", testCtx, byStashID(remoteID)).Return([]*models.Tag{{ID: linkedID}}, nil)
+	mockTagReader.On("FindByStashID", testCtx, byStashID(unknownID)).Return(nil, nil)
+	mockTagReader.On("Query", testCtx, mock.Anything, mock.Anything).Return([]*models.Tag{{ID: namedID}}, 1, nil)
+
+	strPtr := func(s string) *string {
+		return &s
+	}
+
+	tests := []struct {
+		name             string
+		tag              *models.ScrapedTag
+		stashBoxEndpoint *string
+		want             *string
+	}{
+		{
+			"already matched",
+			&models.ScrapedTag{
+				StoredID: strPtr("3"),
+				Name:     linkedName,
+			},
+			nil,
+			strPtr("3"),
+		},
+		{
+			"stash id",
+			&models.ScrapedTag{
+				Name:         "big boobs",
+				RemoteSiteID: strPtr(remoteID),
+			},
+			strPtr(endpoint),
+			strPtr(strconv.Itoa(linkedID)),
+		},
+		{
+			"unknown stash id",
+			&models.ScrapedTag{
+				Name:         linkedName,
+				RemoteSiteID: strPtr(unknownID),
+			},
+			strPtr(endpoint),
+			strPtr(strconv.Itoa(namedID)),
+		},
+		{
+			"no endpoint",
+			&models.ScrapedTag{
+				Name:         linkedName,
+				RemoteSiteID: strPtr(remoteID),
+			},
+			nil,
+			strPtr(strconv.Itoa(namedID)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ScrapedTag(testCtx, mockTagReader, tt.tag, tt.stashBoxEndpoint); err != nil {
+				t.Errorf("ScrapedTag() error = %v", err)
+				return
+			}
+
+			assert.Equal(t, tt.want, tt.tag.StoredID)
+		})
+	}
+}
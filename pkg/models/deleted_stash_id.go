@@ -0,0 +1,21 @@
+package models
+
+import "context"
+
+type DeletedStashIDReader interface {
+	Find(ctx context.Context, id int) (*DeletedStashID, error)
+	FindByEndpoint(ctx context.Context, endpoint string) ([]*DeletedStashID, error)
+	All(ctx context.Context) ([]*DeletedStashID, error)
+}
+
+type DeletedStashIDWriter interface {
+	Create(ctx context.Context, newObject *DeletedStashID) error
+	Destroy(ctx context.Context, id int) error
+	// DestroyByStashID removes all entries with the given stash id
+	DestroyByStashID(ctx context.Context, stashID StashID) error
+}
+
+type DeletedStashIDReaderWriter interface {
+	DeletedStashIDReader
+	DeletedStashIDWriter
+}
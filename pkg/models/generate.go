@@ -16,9 +16,11 @@ type GenerateMetadataOptions struct {
 	MarkerImagePreviews       bool                    `json:"markerImagePreviews"`
 	MarkerScreenshots         bool                    `json:"markerScreenshots"`
 	Transcodes                bool                    `json:"transcodes"`
+	Remuxes                   bool                    `json:"remuxes"`
 	Phashes                   bool                    `json:"phashes"`
 	InteractiveHeatmapsSpeeds bool                    `json:"interactiveHeatmapsSpeeds"`
 	ClipPreviews              bool                    `json:"clipPreviews"`
+	SceneCuts                 bool                    `json:"sceneCuts"`
 }
 
 type GeneratePreviewOptions struct {
@@ -0,0 +1,20 @@
+package models
+
+import "context"
+
+type IdentifyReviewReader interface {
+	Find(ctx context.Context, id int) (*IdentifyReview, error)
+	FindBySceneID(ctx context.Context, sceneID int) ([]*IdentifyReview, error)
+	All(ctx context.Context) ([]*IdentifyReview, error)
+}
+
+type IdentifyReviewWriter interface {
+	Create(ctx context.Context, newReview *IdentifyReview) error
+	Destroy(ctx context.Context, id int) error
+	DestroyBySceneID(ctx context.Context, sceneID int) error
+}
+
+type IdentifyReviewReaderWriter interface {
+	IdentifyReviewReader
+	IdentifyReviewWriter
+}
@@ -52,6 +52,22 @@ type ImageFilterType struct {
 	UpdatedAt *TimestampCriterionInput `json:"updated_at"`
 }
 
+type ImageUpdateInput struct {
+	ClientMutationID *string  `json:"clientMutationId"`
+	ID               string   `json:"id"`
+	Title            *string  `json:"title"`
+	Rating           *int     `json:"rating"`
+	Rating100        *int     `json:"rating100"`
+	Organized        *bool    `json:"organized"`
+	URL              *string  `json:"url"`
+	Date             *string  `json:"date"`
+	StudioID         *string  `json:"studio_id"`
+	PerformerIds     []string `json:"performer_ids"`
+	TagIds           []string `json:"tag_ids"`
+	GalleryIds       []string `json:"gallery_ids"`
+	PrimaryFileID    *string  `json:"primary_file_id"`
+}
+
 type ImageDestroyInput struct {
 	ID              string `json:"id"`
 	DeleteFile      *bool  `json:"delete_file"`
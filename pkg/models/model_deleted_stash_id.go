@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// DeletedStashID is a stash id of a scene, performer or studio whose
+// stash-box entry was found to be deleted. Only one of the object ids is set.
+type DeletedStashID struct {
+	ID          int       `json:"id"`
+	SceneID     *int      `json:"scene_id"`
+	PerformerID *int      `json:"performer_id"`
+	StudioID    *int      `json:"studio_id"`
+	Endpoint    string    `json:"endpoint"`
+	StashID     string    `json:"stash_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
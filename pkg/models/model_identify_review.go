@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// IdentifyReview is an identify result which was not applied because its
+// confidence was below the threshold, pending review.
+type IdentifyReview struct {
+	ID      int `json:"id"`
+	SceneID int `json:"scene_id"`
+	// Name of the source or sources of the result
+	Source string `json:"source"`
+	// Confidence expressed in 0-1 scale
+	Confidence float64 `json:"confidence"`
+	// JSON encoded result, as stored by the identify task
+	Data      string    `json:"data"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -43,6 +43,12 @@ type Scene struct {
 	PlayDuration float64    `json:"play_duration"`
 	PlayCount    int        `json:"play_count"`
 
+	// detected intro and outro ranges, in seconds
+	IntroStart *float64 `json:"intro_start"`
+	IntroEnd   *float64 `json:"intro_end"`
+	OutroStart *float64 `json:"outro_start"`
+	OutroEnd   *float64 `json:"outro_end"`
+
 	GalleryIDs   RelatedIDs      `json:"gallery_ids"`
 	TagIDs       RelatedIDs      `json:"tag_ids"`
 	PerformerIDs RelatedIDs      `json:"performer_ids"`
@@ -157,6 +163,10 @@ type ScenePartial struct {
 	PlayDuration OptionalFloat64
 	PlayCount    OptionalInt
 	LastPlayedAt OptionalTime
+	IntroStart   OptionalFloat64
+	IntroEnd     OptionalFloat64
+	OutroStart   OptionalFloat64
+	OutroEnd     OptionalFloat64
 
 	GalleryIDs    *UpdateIDs
 	TagIDs        *UpdateIDs
@@ -248,6 +258,16 @@ func (s ScenePartial) UpdateInput(id int) SceneUpdateInput {
 	return ret
 }
 
+// Intro returns the detected intro of the scene, or nil if not set.
+func (s Scene) Intro() *SceneSegment {
+	return newSceneSegment(s.IntroStart, s.IntroEnd)
+}
+
+// Outro returns the detected outro of the scene, or nil if not set.
+func (s Scene) Outro() *SceneSegment {
+	return newSceneSegment(s.OutroStart, s.OutroEnd)
+}
+
 // GetTitle returns the title of the scene. If the Title field is empty,
 // then the base filename is returned.
 func (s Scene) GetTitle() string {
@@ -312,3 +332,20 @@ type VideoCaption struct {
 func (c VideoCaption) Path(filePath string) string {
 	return filepath.Join(filepath.Dir(filePath), c.Filename)
 }
+
+// SceneSegment is a range of time within a scene, in seconds.
+type SceneSegment struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+func newSceneSegment(start, end *float64) *SceneSegment {
+	if start == nil || end == nil {
+		return nil
+	}
+
+	return &SceneSegment{
+		Start: *start,
+		End:   *end,
+	}
+}
@@ -7,10 +7,14 @@ import (
 
 type ScrapedStudio struct {
 	// Set if studio matched
-	StoredID     *string `json:"stored_id"`
-	Name         string  `json:"name"`
-	URL          *string `json:"url"`
+	StoredID *string        `json:"stored_id"`
+	Name     string         `json:"name"`
+	URL      *string        `json:"url"`
+	Parent   *ScrapedStudio `json:"parent"`
+	// This should be a base64 encoded data URL
 	Image        *string `json:"image"`
+	Details      *string `json:"details"`
+	Aliases      *string `json:"aliases"`
 	RemoteSiteID *string `json:"remote_site_id"`
 }
 
@@ -54,8 +58,14 @@ func (ScrapedPerformer) IsScrapedContent() {}
 
 type ScrapedTag struct {
 	// Set if tag matched
-	StoredID *string `json:"stored_id"`
-	Name     string  `json:"name"`
+	StoredID    *string       `json:"stored_id"`
+	Name        string        `json:"name"`
+	Description *string       `json:"description"`
+	Aliases     *string       `json:"aliases"`
+	Parents     []*ScrapedTag `json:"parents"`
+	// This should be a base64 encoded data URL
+	Image        *string `json:"image"`
+	RemoteSiteID *string `json:"remote_site_id"`
 }
 
 func (ScrapedTag) IsScrapedContent() {}
@@ -53,3 +53,7 @@ func (sp *scenePaths) GetSpriteVttFilePath(checksum string) string {
 func (sp *scenePaths) GetInteractiveHeatmapPath(checksum string) string {
 	return filepath.Join(sp.InteractiveHeatmap, checksum+".png")
 }
+
+func (sp *scenePaths) GetSceneCutsPath(checksum string) string {
+	return filepath.Join(sp.Vtt, checksum+"_cuts.json")
+}
@@ -27,4 +27,6 @@ type Repository struct {
 	Studio         StudioReaderWriter
 	Tag            TagReaderWriter
 	SavedFilter    SavedFilterReaderWriter
+	IdentifyReview IdentifyReviewReaderWriter
+	DeletedStashID DeletedStashIDReaderWriter
 }
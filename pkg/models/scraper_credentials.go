@@ -0,0 +1,9 @@
+package models
+
+// ScraperCredentials are the site credentials of a scraper. They are stored
+// in the stash configuration rather than the scraper configuration.
+type ScraperCredentials struct {
+	ScraperID string `json:"scraper_id"`
+	Username  string `json:"username"`
+	Password  string `json:"password"`
+}
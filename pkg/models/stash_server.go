@@ -0,0 +1,61 @@
+package models
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type StashSyncDirection string
+
+const (
+	// Copy metadata from the remote stash instance to this one
+	StashSyncDirectionPull StashSyncDirection = "PULL"
+	// Copy metadata from this stash instance to the remote one
+	StashSyncDirectionPush StashSyncDirection = "PUSH"
+)
+
+var AllStashSyncDirection = []StashSyncDirection{
+	StashSyncDirectionPull,
+	StashSyncDirectionPush,
+}
+
+func (e StashSyncDirection) IsValid() bool {
+	switch e {
+	case StashSyncDirectionPull, StashSyncDirectionPush:
+		return true
+	}
+	return false
+}
+
+func (e StashSyncDirection) String() string {
+	return string(e)
+}
+
+func (e *StashSyncDirection) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = StashSyncDirection(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid StashSyncDirection", str)
+	}
+	return nil
+}
+
+func (e StashSyncDirection) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+// StashServer is a remote stash instance to sync scene metadata with.
+type StashServer struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	APIKey string `json:"api_key"`
+	// Minutes between scheduled syncs. Scheduled syncs are disabled if zero.
+	SyncInterval int `json:"sync_interval"`
+	// Direction of scheduled syncs
+	SyncDirection StashSyncDirection `json:"sync_direction"`
+}
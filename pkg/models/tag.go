@@ -54,6 +54,7 @@ type TagReader interface {
 	FindByGalleryID(ctx context.Context, galleryID int) ([]*Tag, error)
 	FindByName(ctx context.Context, name string, nocase bool) (*Tag, error)
 	FindByNames(ctx context.Context, names []string, nocase bool) ([]*Tag, error)
+	FindByStashID(ctx context.Context, stashID StashID) ([]*Tag, error)
 	FindByParentTagID(ctx context.Context, parentID int) ([]*Tag, error)
 	FindByChildTagID(ctx context.Context, childID int) ([]*Tag, error)
 	Count(ctx context.Context) (int, error)
@@ -65,6 +66,7 @@ type TagReader interface {
 	GetImage(ctx context.Context, tagID int) ([]byte, error)
 	HasImage(ctx context.Context, tagID int) (bool, error)
 	GetAliases(ctx context.Context, tagID int) ([]string, error)
+	StashIDLoader
 	FindAllAncestors(ctx context.Context, tagID int, excludeIDs []int) ([]*TagPath, error)
 	FindAllDescendants(ctx context.Context, tagID int, excludeIDs []int) ([]*TagPath, error)
 }
@@ -76,6 +78,7 @@ type TagWriter interface {
 	Destroy(ctx context.Context, id int) error
 	UpdateImage(ctx context.Context, tagID int, image []byte) error
 	UpdateAliases(ctx context.Context, tagID int, aliases []string) error
+	UpdateStashIDs(ctx context.Context, tagID int, stashIDs []StashID) error
 	Merge(ctx context.Context, source []int, destination int) error
 	UpdateParentTags(ctx context.Context, tagID int, parentIDs []int) error
 	UpdateChildTags(ctx context.Context, tagID int, parentIDs []int) error
@@ -25,29 +25,12 @@ func argToString(call otto.FunctionCall) string {
 	return arg.String()
 }
 
-func logTrace(call otto.FunctionCall) otto.Value {
-	logger.Trace(pluginPrefix + argToString(call))
-	return otto.UndefinedValue()
-}
-
-func logDebug(call otto.FunctionCall) otto.Value {
-	logger.Debug(pluginPrefix + argToString(call))
-	return otto.UndefinedValue()
-}
-
-func logInfo(call otto.FunctionCall) otto.Value {
-	logger.Info(pluginPrefix + argToString(call))
-	return otto.UndefinedValue()
-}
-
-func logWarn(call otto.FunctionCall) otto.Value {
-	logger.Warn(pluginPrefix + argToString(call))
-	return otto.UndefinedValue()
-}
-
-func logError(call otto.FunctionCall) otto.Value {
-	logger.Error(pluginPrefix + argToString(call))
-	return otto.UndefinedValue()
+// logFunc returns a function logging its argument with the prefix.
+func logFunc(log func(args ...interface{}), prefix string) func(call otto.FunctionCall) otto.Value {
+	return func(call otto.FunctionCall) otto.Value {
+		log(prefix + argToString(call))
+		return otto.UndefinedValue()
+	}
 }
 
 // Progress logs the current progress value. The progress value should be
@@ -69,24 +52,32 @@ func logProgressFunc(c chan float64) func(call otto.FunctionCall) otto.Value {
 }
 
 func AddLogAPI(vm *otto.Otto, progress chan float64) error {
+	return AddPrefixedLogAPI(vm, pluginPrefix, progress)
+}
+
+// AddPrefixedLogAPI adds the log API, prefixing each message with prefix.
+// log.Progress is only added if progress is not nil.
+func AddPrefixedLogAPI(vm *otto.Otto, prefix string, progress chan float64) error {
 	log, _ := vm.Object("({})")
-	if err := log.Set("Trace", logTrace); err != nil {
+	if err := log.Set("Trace", logFunc(logger.Trace, prefix)); err != nil {
 		return fmt.Errorf("error setting Trace: %w", err)
 	}
-	if err := log.Set("Debug", logDebug); err != nil {
+	if err := log.Set("Debug", logFunc(logger.Debug, prefix)); err != nil {
 		return fmt.Errorf("error setting Debug: %w", err)
 	}
-	if err := log.Set("Info", logInfo); err != nil {
+	if err := log.Set("Info", logFunc(logger.Info, prefix)); err != nil {
 		return fmt.Errorf("error setting Info: %w", err)
 	}
-	if err := log.Set("Warn", logWarn); err != nil {
+	if err := log.Set("Warn", logFunc(logger.Warn, prefix)); err != nil {
 		return fmt.Errorf("error setting Warn: %w", err)
 	}
-	if err := log.Set("Error", logError); err != nil {
+	if err := log.Set("Error", logFunc(logger.Error, prefix)); err != nil {
 		return fmt.Errorf("error setting Error: %w", err)
 	}
-	if err := log.Set("Progress", logProgressFunc(progress)); err != nil {
-		return fmt.Errorf("error setting Progress: %v", err)
+	if progress != nil {
+		if err := log.Set("Progress", logProgressFunc(progress)); err != nil {
+			return fmt.Errorf("error setting Progress: %v", err)
+		}
 	}
 	if err := vm.Set("log", log); err != nil {
 		return fmt.Errorf("unable to set log: %w", err)
@@ -74,6 +74,12 @@ func (d *FileDeleter) MarkGeneratedFiles(scene *models.Scene) error {
 		files = append(files, heatmapPath)
 	}
 
+	sceneCutsPath := d.Paths.Scene.GetSceneCutsPath(sceneHash)
+	exists, _ = fsutil.FileExists(sceneCutsPath)
+	if exists {
+		files = append(files, sceneCutsPath)
+	}
+
 	return d.Files(files)
 }
 
@@ -0,0 +1,71 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+)
+
+const (
+	// frames are scaled to frameHashWidth x frameHashHeight greyscale pixels
+	// to calculate a 64-bit difference hash
+	frameHashWidth  = 9
+	frameHashHeight = 8
+	frameHashSize   = frameHashWidth * frameHashHeight
+)
+
+// FrameHashes returns difference hashes of frames sampled every interval
+// seconds from the range of the input file starting at start and lasting
+// duration seconds.
+func (g Generator) FrameHashes(ctx context.Context, input string, start float64, duration float64, interval float64) ([]uint64, error) {
+	lockCtx := g.LockManager.ReadLock(ctx, input)
+	defer lockCtx.Cancel()
+
+	var args ffmpeg.Args
+	args = args.LogLevel(ffmpeg.LogLevelError)
+	if start > 0 {
+		args = args.Seek(start)
+	}
+	args = args.Input(input)
+	args = args.Duration(duration)
+	args = args.SkipAudio()
+
+	var vf ffmpeg.VideoFilter
+	vf = vf.Append(fmt.Sprintf("fps=1/%v", interval))
+	vf = vf.ScaleDimensions(frameHashWidth, frameHashHeight)
+	vf = vf.Append("format=gray")
+	args = args.VideoFilter(vf)
+
+	args = args.Format(ffmpeg.FormatRawVideo)
+	args = args.Output("-")
+
+	out, err := g.generateOutput(lockCtx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []uint64
+	for i := 0; i+frameHashSize <= len(out); i += frameHashSize {
+		ret = append(ret, differenceHash(out[i:i+frameHashSize]))
+	}
+
+	return ret, nil
+}
+
+// differenceHash calculates the difference hash of a frameHashWidth x
+// frameHashHeight greyscale image. Each bit is set if a pixel is brighter
+// than the pixel to its right.
+func differenceHash(pix []byte) uint64 {
+	var ret uint64
+	for y := 0; y < frameHashHeight; y++ {
+		row := pix[y*frameHashWidth : (y+1)*frameHashWidth]
+		for x := 0; x < frameHashWidth-1; x++ {
+			ret <<= 1
+			if row[x] > row[x+1] {
+				ret |= 1
+			}
+		}
+	}
+	return ret
+}
@@ -42,6 +42,8 @@ type ScenePaths interface {
 	GetSpriteVttFilePath(checksum string) string
 
 	GetTranscodePath(checksum string) string
+
+	GetSceneCutsPath(checksum string) string
 }
 
 type FFMpegConfig interface {
@@ -181,3 +183,29 @@ func (g Generator) generateOutput(lockCtx *fsutil.LockContext, args []string) ([
 
 	return stdout.Bytes(), nil
 }
+
+// generateLog runs ffmpeg with the given args and returns its standard error output.
+// Used for filters that report their results through the log.
+func (g Generator) generateLog(lockCtx *fsutil.LockContext, args []string) ([]byte, error) {
+	cmd := g.Encoder.Command(lockCtx, args)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("error starting command: %w", err)
+	}
+
+	lockCtx.AttachCommand(cmd)
+
+	if err := cmd.Wait(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			exitErr.Stderr = stderr.Bytes()
+			err = exitErr
+		}
+		return nil, fmt.Errorf("error running ffmpeg command <%s>: %w", strings.Join(args, " "), err)
+	}
+
+	return stderr.Bytes(), nil
+}
@@ -33,6 +33,10 @@ type PreviewOptions struct {
 	Preset string
 
 	Audio bool
+
+	// Cuts, if set, are used to start segments at scene changes and to
+	// avoid blank frames.
+	Cuts *SceneCuts
 }
 
 func getExcludeValue(videoDuration float64, v string) float64 {
@@ -113,6 +117,11 @@ func (g *Generator) previewVideo(input string, videoDuration float64, options Pr
 			logger.Warnf("[generator] Segment duration (%f) too short. Using %f instead.", options.SegmentDuration, minSegmentDuration)
 		}
 
+		var segmentTimes []float64
+		if options.Cuts != nil {
+			segmentTimes = options.Cuts.segmentTimes(offset, stepSize, options.Segments, segmentDuration)
+		}
+
 		for i := 0; i < options.Segments; i++ {
 			chunkFile, err := g.tempFile(g.ScenePaths, mp4Pattern)
 			if err != nil {
@@ -122,6 +131,9 @@ func (g *Generator) previewVideo(input string, videoDuration float64, options Pr
 			tmpFiles = append(tmpFiles, chunkFile.Name())
 
 			time := offset + (float64(i) * stepSize)
+			if segmentTimes != nil {
+				time = segmentTimes[i]
+			}
 
 			chunkOptions := previewChunkOptions{
 				StartTime:  time,
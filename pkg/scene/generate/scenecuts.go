@@ -0,0 +1,295 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/stashapp/stash/pkg/ffmpeg/transcoder"
+	"github.com/stashapp/stash/pkg/fsutil"
+	"github.com/stashapp/stash/pkg/logger"
+)
+
+const (
+	jsonPattern = "*.json"
+
+	sceneCutThreshold           = 0.4
+	sceneCutBlackDuration       = 0.1
+	sceneCutBlackPixelThreshold = 0.1
+	sceneCutAnalysisWidth       = 320
+
+	// shotOffset is the time in seconds after a cut or blank interval that
+	// frames are taken from, to avoid transitions.
+	shotOffset = 0.5
+
+	// maxCoverCandidates is the maximum number of screenshots taken when
+	// looking for a cover that is not a near-uniform frame.
+	maxCoverCandidates = 5
+
+	// uniformStdDevThreshold is the luminance standard deviation (0-255)
+	// below which an image is considered near-uniform.
+	uniformStdDevThreshold = 8.0
+)
+
+var (
+	showInfoRE    = regexp.MustCompile(`Parsed_showinfo.*pts_time:\s*([0-9.]+)`)
+	blackDetectRE = regexp.MustCompile(`black_start:\s*([0-9.]+)\s+black_end:\s*([0-9.]+)`)
+)
+
+// TimeRange is a range of time in seconds within a video file.
+type TimeRange struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+func (r TimeRange) contains(t float64) bool {
+	return t >= r.Start && t < r.End
+}
+
+// SceneCuts is the result of scene change detection on a video file.
+type SceneCuts struct {
+	Duration float64 `json:"duration"`
+	// Cuts are the times in seconds of detected scene changes, in ascending order.
+	Cuts []float64 `json:"cuts"`
+	// Blank are the intervals consisting of black frames, in ascending order.
+	Blank []TimeRange `json:"blank"`
+}
+
+// LoadSceneCuts reads the scene cuts from the given file.
+// Returns nil if the file does not exist.
+func LoadSceneCuts(fn string) (*SceneCuts, error) {
+	data, err := os.ReadFile(fn)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var ret SceneCuts
+	if err := json.Unmarshal(data, &ret); err != nil {
+		return nil, fmt.Errorf("decoding scene cuts %s: %w", fn, err)
+	}
+
+	return &ret, nil
+}
+
+func parseSceneCuts(out []byte, videoDuration float64) *SceneCuts {
+	ret := &SceneCuts{
+		Duration: videoDuration,
+		Cuts:     []float64{},
+		Blank:    []TimeRange{},
+	}
+
+	for _, m := range showInfoRE.FindAllSubmatch(out, -1) {
+		t, err := strconv.ParseFloat(string(m[1]), 64)
+		if err != nil || t <= 0 {
+			continue
+		}
+		ret.Cuts = append(ret.Cuts, t)
+	}
+
+	for _, m := range blackDetectRE.FindAllSubmatch(out, -1) {
+		start, err := strconv.ParseFloat(string(m[1]), 64)
+		if err != nil {
+			continue
+		}
+		end, err := strconv.ParseFloat(string(m[2]), 64)
+		if err != nil || end <= start {
+			continue
+		}
+		ret.Blank = append(ret.Blank, TimeRange{Start: start, End: end})
+	}
+
+	sort.Float64s(ret.Cuts)
+	sort.Slice(ret.Blank, func(i, j int) bool {
+		return ret.Blank[i].Start < ret.Blank[j].Start
+	})
+
+	return ret
+}
+
+// IsBlank returns true if t falls within a blank interval.
+func (c *SceneCuts) IsBlank(t float64) bool {
+	for _, r := range c.Blank {
+		if r.contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// skipBlank returns t if it is not within a blank interval. Otherwise,
+// it returns the first time after the blank interval.
+func (c *SceneCuts) skipBlank(t float64) float64 {
+	for _, r := range c.Blank {
+		if r.contains(t) {
+			t = r.End + shotOffset
+		}
+	}
+	return t
+}
+
+// FrameTime returns a time in the range [t, t+window) that is not within a
+// blank interval. Returns t if there is no such time.
+func (c *SceneCuts) FrameTime(t float64, window float64) float64 {
+	ret := c.skipBlank(t)
+	if ret >= t+window || (c.Duration > 0 && ret >= c.Duration) {
+		return t
+	}
+	return ret
+}
+
+// segmentTimes returns the start times for preview segments. Each segment
+// starts at the first shot in its step that is not blank and is long enough
+// to fit the segment. If there is no such shot, then the evenly spaced time
+// is used, skipping any blank frames if possible.
+func (c *SceneCuts) segmentTimes(offset float64, stepSize float64, segments int, segmentDuration float64) []float64 {
+	ret := make([]float64, segments)
+
+	for i := 0; i < segments; i++ {
+		target := offset + float64(i)*stepSize
+		last := target + stepSize - segmentDuration
+
+		ret[i] = c.FrameTime(target, math.Max(last-target, 0))
+
+		for _, cut := range c.Cuts {
+			if cut < target {
+				continue
+			}
+			start := c.skipBlank(cut + shotOffset)
+			if start > last {
+				break
+			}
+			if c.shotLength(start) >= segmentDuration {
+				ret[i] = start
+				break
+			}
+		}
+	}
+
+	return ret
+}
+
+// shotLength returns the time from t until the next cut or blank interval.
+func (c *SceneCuts) shotLength(t float64) float64 {
+	end := c.Duration
+	for _, cut := range c.Cuts {
+		if cut > t {
+			end = cut
+			break
+		}
+	}
+	for _, r := range c.Blank {
+		if r.Start > t && r.Start < end {
+			end = r.Start
+			break
+		}
+	}
+	return end - t
+}
+
+// coverCandidates returns times to take a cover screenshot from, starting at
+// the shot containing at and followed by the start of subsequent shots.
+func (c *SceneCuts) coverCandidates(at float64) []float64 {
+	ret := []float64{c.FrameTime(at, c.Duration)}
+
+	for _, cut := range c.Cuts {
+		if len(ret) >= maxCoverCandidates {
+			break
+		}
+		if cut <= at {
+			continue
+		}
+		t := c.skipBlank(cut + shotOffset)
+		if c.Duration > 0 && t >= c.Duration {
+			break
+		}
+		ret = append(ret, t)
+	}
+
+	return ret
+}
+
+// IsUniformImage returns true if the luminance of img is near-uniform,
+// such as a blank frame or a plain title card.
+func IsUniformImage(img image.Image) bool {
+	b := img.Bounds()
+	if b.Empty() {
+		return true
+	}
+
+	// sample at most 64x64 points
+	stepX := b.Dx()/64 + 1
+	stepY := b.Dy()/64 + 1
+
+	var sum, sumSq, n float64
+	for y := b.Min.Y; y < b.Max.Y; y += stepY {
+		for x := b.Min.X; x < b.Max.X; x += stepX {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			// ITU-R 601 luma, scaled to 0-255
+			l := (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(bl)) / 257
+			sum += l
+			sumSq += l * l
+			n++
+		}
+	}
+
+	mean := sum / n
+	variance := sumSq/n - mean*mean
+	return math.Sqrt(math.Max(variance, 0)) < uniformStdDevThreshold
+}
+
+// SceneCuts runs scene change and black frame detection on the input file
+// and writes the results to the scene cuts file for hash.
+func (g Generator) SceneCuts(ctx context.Context, input string, videoDuration float64, hash string) error {
+	lockCtx := g.LockManager.ReadLock(ctx, input)
+	defer lockCtx.Cancel()
+
+	output := g.ScenePaths.GetSceneCutsPath(hash)
+	if !g.Overwrite {
+		if exists, _ := fsutil.FileExists(output); exists {
+			return nil
+		}
+	}
+
+	logger.Infof("[generator] detecting scene changes for %s", input)
+
+	if err := g.generateFile(lockCtx, g.ScenePaths, jsonPattern, output, g.sceneCuts(input, videoDuration)); err != nil {
+		return err
+	}
+
+	logger.Debug("created scene cuts: ", output)
+
+	return nil
+}
+
+func (g Generator) sceneCuts(input string, videoDuration float64) generateFn {
+	return func(lockCtx *fsutil.LockContext, tmpFn string) error {
+		args := transcoder.SceneDetect(input, transcoder.SceneDetectOptions{
+			Threshold:           sceneCutThreshold,
+			BlackDuration:       sceneCutBlackDuration,
+			BlackPixelThreshold: sceneCutBlackPixelThreshold,
+			Width:               sceneCutAnalysisWidth,
+		})
+
+		out, err := g.generateLog(lockCtx, args)
+		if err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(parseSceneCuts(out, videoDuration))
+		if err != nil {
+			return err
+		}
+
+		return os.WriteFile(tmpFn, data, 0644)
+	}
+}
@@ -0,0 +1,97 @@
+package generate
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+const sceneDetectOutput = `Input #0, mov,mp4,m4a,3gp,3g2,mj2, from 'test.mp4':
+[blackdetect @ 0x55d5c8e0] black_start:0 black_end:2.5 black_duration:2.5
+[Parsed_showinfo_3 @ 0x55d5c940] n:   0 pts:  76800 pts_time:5       duration:    512 duration_time:0.04
+[Parsed_showinfo_3 @ 0x55d5c940] n:   1 pts: 256000 pts_time:16.6667 duration:    512 duration_time:0.04
+[blackdetect @ 0x55d5c8e0] black_start:40 black_end:42 black_duration:2
+[Parsed_showinfo_3 @ 0x55d5c940] n:   2 pts: 640000 pts_time:41.6667 duration:    512 duration_time:0.04
+`
+
+func TestParseSceneCuts(t *testing.T) {
+	got := parseSceneCuts([]byte(sceneDetectOutput), 60)
+	want := &SceneCuts{
+		Duration: 60,
+		Cuts:     []float64{5, 16.6667, 41.6667},
+		Blank: []TimeRange{
+			{Start: 0, End: 2.5},
+			{Start: 40, End: 42},
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseSceneCuts() = %v; want %v", got, want)
+	}
+}
+
+func TestSceneCuts_FrameTime(t *testing.T) {
+	c := parseSceneCuts([]byte(sceneDetectOutput), 60)
+
+	tests := []struct {
+		name   string
+		t      float64
+		window float64
+		want   float64
+	}{
+		{"not blank", 10, 5, 10},
+		{"blank start", 0, 5, 3},
+		{"blank outside window", 0, 2, 0},
+		{"blank middle", 41, 5, 42.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.FrameTime(tt.t, tt.window); got != tt.want {
+				t.Errorf("FrameTime(%v, %v) = %v; want %v", tt.t, tt.window, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSceneCuts_segmentTimes(t *testing.T) {
+	c := parseSceneCuts([]byte(sceneDetectOutput), 60)
+
+	got := c.segmentTimes(0, 15, 4, 1)
+	want := []float64{5.5, 17.1667, 42.1667, 45}
+
+	if len(got) != len(want) {
+		t.Fatalf("segmentTimes() = %v; want %v", got, want)
+	}
+
+	for i := range want {
+		if diff := got[i] - want[i]; diff > 0.0001 || diff < -0.0001 {
+			t.Errorf("segmentTimes()[%d] = %v; want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestIsUniformImage(t *testing.T) {
+	uniform := image.NewGray(image.Rect(0, 0, 32, 32))
+	for i := range uniform.Pix {
+		uniform.Pix[i] = 250
+	}
+
+	if !IsUniformImage(uniform) {
+		t.Errorf("IsUniformImage(uniform) = false; want true")
+	}
+
+	checked := image.NewGray(image.Rect(0, 0, 32, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 32; x++ {
+			if (x/4+y/4)%2 == 0 {
+				checked.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+
+	if IsUniformImage(checked) {
+		t.Errorf("IsUniformImage(checked) = true; want false")
+	}
+}
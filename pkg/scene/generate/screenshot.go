@@ -1,7 +1,10 @@
 package generate
 
 import (
+	"bytes"
 	"context"
+	"image"
+	_ "image/jpeg"
 
 	"github.com/stashapp/stash/pkg/ffmpeg/transcoder"
 	"github.com/stashapp/stash/pkg/fsutil"
@@ -19,6 +22,10 @@ const (
 
 type ScreenshotOptions struct {
 	At *float64
+
+	// Cuts, if set and At is nil, are used to avoid taking the screenshot
+	// from a blank or near-uniform frame.
+	Cuts *SceneCuts
 }
 
 func (g Generator) Screenshot(ctx context.Context, input string, videoWidth int, videoDuration float64, options ScreenshotOptions) ([]byte, error) {
@@ -32,13 +39,33 @@ func (g Generator) Screenshot(ctx context.Context, input string, videoWidth int,
 		at = *options.At
 	}
 
-	ret, err := g.generateBytes(lockCtx, g.ScenePaths, jpgPattern, g.screenshot(input, screenshotOptions{
-		Time:    at,
-		Quality: screenshotQuality,
-		// default Width is video width
-	}))
-	if err != nil {
-		return nil, err
+	candidates := []float64{at}
+	if options.At == nil && options.Cuts != nil {
+		candidates = options.Cuts.coverCandidates(at)
+	}
+
+	var ret []byte
+	for _, t := range candidates {
+		var err error
+		ret, err = g.generateBytes(lockCtx, g.ScenePaths, jpgPattern, g.screenshot(input, screenshotOptions{
+			Time:    t,
+			Quality: screenshotQuality,
+			// default Width is video width
+		}))
+		if err != nil {
+			return nil, err
+		}
+
+		if len(candidates) == 1 {
+			break
+		}
+
+		img, _, err := image.Decode(bytes.NewReader(ret))
+		if err != nil || !IsUniformImage(img) {
+			break
+		}
+
+		logger.Debugf("screenshot for %s at %f is near-uniform, trying next scene", input, t)
 	}
 
 	return ret, nil
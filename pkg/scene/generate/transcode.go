@@ -47,6 +47,15 @@ func (g Generator) TranscodeCopyVideo(ctx context.Context, input string, hash st
 	return g.makeTranscode(lockCtx, hash, g.transcodeCopyVideo(input, options))
 }
 
+// Remux copies the video and audio streams into an MP4 container without
+// re-encoding.
+func (g Generator) Remux(ctx context.Context, input string, hash string) error {
+	lockCtx := g.LockManager.ReadLock(ctx, input)
+	defer lockCtx.Cancel()
+
+	return g.makeTranscode(lockCtx, hash, g.remux(input))
+}
+
 func (g Generator) makeTranscode(lockCtx *fsutil.LockContext, hash string, generateFn generateFn) error {
 	output := g.ScenePaths.GetTranscodePath(hash)
 	if !g.Overwrite {
@@ -171,3 +180,17 @@ func (g Generator) transcodeCopyVideo(input string, options TranscodeOptions) ge
 		return g.generate(lockCtx, args)
 	}
 }
+
+func (g Generator) remux(input string) generateFn {
+	return func(lockCtx *fsutil.LockContext, tmpFn string) error {
+		args := transcoder.Transcode(input, transcoder.TranscodeOptions{
+			OutputPath: tmpFn,
+			VideoCodec: ffmpeg.VideoCodecCopy,
+			AudioCodec: ffmpeg.AudioCodecCopy,
+			// allow playback to start before the file is fully downloaded
+			ExtraOutputArgs: []string{"-movflags", "+faststart"},
+		})
+
+		return g.generate(lockCtx, args)
+	}
+}
@@ -0,0 +1,120 @@
+// Package intro provides detection of intro and outro segments that are
+// repeated across scenes.
+package intro
+
+import (
+	"math/bits"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// minHashBits and maxHashBits bound the number of set bits in a frame hash
+// for the frame to be considered. Frames outside of this range, such as
+// blank frames, are not distinctive enough to be matched.
+const (
+	minHashBits = 4
+	maxHashBits = 60
+)
+
+// Sequence is a sequence of frame hashes sampled at a fixed interval.
+type Sequence struct {
+	// Offset is the time in seconds of the first frame.
+	Offset float64
+	// Interval is the time in seconds between frames.
+	Interval float64
+	Hashes   []uint64
+}
+
+type Options struct {
+	// MaxDistance is the maximum hamming distance between two frame hashes
+	// for them to be considered the same frame.
+	MaxDistance int
+	// MinDuration is the minimum duration in seconds of a repeated segment.
+	MinDuration float64
+	// MinMatches is the minimum number of other sequences a segment must
+	// be found in.
+	MinMatches int
+}
+
+func distinctive(h uint64) bool {
+	n := bits.OnesCount64(h)
+	return n >= minHashBits && n <= maxHashBits
+}
+
+func matches(a, b uint64, maxDistance int) bool {
+	return distinctive(a) && distinctive(b) && bits.OnesCount64(a^b) <= maxDistance
+}
+
+// commonRun returns the start index in a and the length of the longest run
+// of matching frames that appears in both a and b.
+func commonRun(a, b []uint64, maxDistance int) (start int, length int) {
+	// prev[j] is the length of the run ending at a[i-1] and b[j-1]
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			if matches(a[i-1], b[j-1], maxDistance) {
+				cur[j] = prev[j-1] + 1
+				if cur[j] > length {
+					length = cur[j]
+					start = i - length
+				}
+			} else {
+				cur[j] = 0
+			}
+		}
+		prev, cur = cur, prev
+	}
+
+	return start, length
+}
+
+func overlaps(a, b models.SceneSegment) bool {
+	return a.Start < b.End && b.Start < a.End
+}
+
+// Detect returns the segment of target that is repeated in at least
+// MinMatches of others. Where more than one segment is repeated, the segment
+// found in the most sequences is returned. Returns nil if no repeated segment
+// was found.
+func Detect(target Sequence, others []Sequence, options Options) *models.SceneSegment {
+	var candidates []models.SceneSegment
+	for _, o := range others {
+		start, length := commonRun(target.Hashes, o.Hashes, options.MaxDistance)
+		if length == 0 || float64(length)*target.Interval < options.MinDuration {
+			continue
+		}
+
+		candidates = append(candidates, models.SceneSegment{
+			Start: target.Offset + float64(start)*target.Interval,
+			End:   target.Offset + float64(start+length)*target.Interval,
+		})
+	}
+
+	var ret *models.SceneSegment
+	bestCount := 0
+	for _, c := range candidates {
+		count := 0
+		merged := c
+		for _, o := range candidates {
+			if overlaps(c, o) {
+				count++
+				if o.Start < merged.Start {
+					merged.Start = o.Start
+				}
+				if o.End > merged.End {
+					merged.End = o.End
+				}
+			}
+		}
+
+		if count >= options.MinMatches && count > bestCount {
+			bestCount = count
+			v := merged
+			ret = &v
+		}
+	}
+
+	return ret
+}
@@ -0,0 +1,67 @@
+package intro
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const (
+	bumper1 uint64 = 0x0f0f0f0f0f0f0f0f
+	bumper2 uint64 = 0x00ff00ff00ff00ff
+	bumper3 uint64 = 0x3333333333333333
+	bumper4 uint64 = 0x5555555555555555
+)
+
+func TestDetect(t *testing.T) {
+	bumper := []uint64{bumper1, bumper2, bumper3, bumper4}
+
+	sequence := func(prefix []uint64, suffix ...uint64) Sequence {
+		hashes := append(append(append([]uint64{}, prefix...), bumper...), suffix...)
+		return Sequence{
+			Interval: 1,
+			Hashes:   hashes,
+		}
+	}
+
+	target := sequence([]uint64{0, 0}, 0x123456789abcdef0)
+	others := []Sequence{
+		sequence(nil, 0xfedcba9876543210),
+		// bumper with a single frame off by one bit
+		sequence([]uint64{0}),
+		// blank frames should not match
+		{Interval: 1, Hashes: []uint64{0, 0, 0, 0, 0, 0}},
+	}
+	others[1].Hashes[2] ^= 1
+
+	tests := []struct {
+		name    string
+		options Options
+		want    *models.SceneSegment
+	}{
+		{
+			"found",
+			Options{MaxDistance: 2, MinDuration: 3, MinMatches: 2},
+			&models.SceneSegment{Start: 2, End: 6},
+		},
+		{
+			"too short",
+			Options{MaxDistance: 2, MinDuration: 5, MinMatches: 1},
+			nil,
+		},
+		{
+			"not enough matches",
+			Options{MaxDistance: 2, MinDuration: 3, MinMatches: 3},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect(target, others, tt.options); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Detect() = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -40,6 +40,10 @@ func MigrateHash(p *paths.Paths, oldHash string, newHash string) {
 	oldPath = scenePaths.GetInteractiveHeatmapPath(oldHash)
 	newPath = scenePaths.GetInteractiveHeatmapPath(newHash)
 	migrateSceneFiles(oldPath, newPath)
+
+	oldPath = scenePaths.GetSceneCutsPath(oldHash)
+	newPath = scenePaths.GetSceneCutsPath(newHash)
+	migrateSceneFiles(oldPath, newPath)
 }
 
 func migrateSceneFiles(oldName, newName string) {
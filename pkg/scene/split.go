@@ -0,0 +1,101 @@
+package scene
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// minSplitDuration is the minimum duration in seconds of a segment produced
+// by splitting a file. Split points closer than this to each other or to
+// the start or end of the file are ignored.
+const minSplitDuration = 1.0
+
+// SplitSegments returns the segments produced by splitting a file of the
+// given duration at the given times. Times outside of the file are ignored.
+func SplitSegments(duration float64, times []float64) []models.SceneSegment {
+	sorted := make([]float64, len(times))
+	copy(sorted, times)
+	sort.Float64s(sorted)
+
+	var ret []models.SceneSegment
+	start := 0.0
+	for _, t := range sorted {
+		if t-start < minSplitDuration || duration-t < minSplitDuration {
+			continue
+		}
+
+		ret = append(ret, models.SceneSegment{Start: start, End: t})
+		start = t
+	}
+
+	return append(ret, models.SceneSegment{Start: start, End: duration})
+}
+
+// RebaseMarker returns a copy of the marker with its time relative to
+// start, the time in the original file at which the cut file starts. This is
+// before the start of the segment if the cut was moved to a keyframe.
+// Returns nil if the marker is outside of the segment.
+func RebaseMarker(m *models.SceneMarker, seg models.SceneSegment, start float64) *models.SceneMarker {
+	if m.Seconds < seg.Start || m.Seconds >= seg.End {
+		return nil
+	}
+
+	ret := *m
+	ret.Seconds -= start
+	return &ret
+}
+
+// RebaseSegment returns the range r relative to start, the time in the
+// original file at which the cut file starts. Returns nil if r is nil or is
+// not entirely within seg.
+func RebaseSegment(r *models.SceneSegment, seg models.SceneSegment, start float64) *models.SceneSegment {
+	if r == nil || r.Start < seg.Start || r.End > seg.End {
+		return nil
+	}
+
+	return &models.SceneSegment{
+		Start: r.Start - start,
+		End:   r.End - start,
+	}
+}
+
+// SplitPartial returns a ScenePartial that copies the metadata of src to
+// the scene created from part n of its file. Stash IDs are not copied since
+// they identify the unsplit scene.
+func SplitPartial(src *models.Scene, n int) models.ScenePartial {
+	ret := models.NewScenePartial()
+
+	if src.Title != "" {
+		ret.Title = models.NewOptionalString(fmt.Sprintf("%s - Part %d", src.Title, n))
+	}
+
+	ret.Code = models.NewOptionalString(src.Code)
+	ret.Details = models.NewOptionalString(src.Details)
+	ret.Director = models.NewOptionalString(src.Director)
+	ret.URL = models.NewOptionalString(src.URL)
+	ret.Date = models.NewOptionalDatePtr(src.Date)
+	ret.Rating = models.NewOptionalIntPtr(src.Rating)
+	ret.Organized = models.NewOptionalBool(src.Organized)
+	ret.StudioID = models.NewOptionalIntPtr(src.StudioID)
+
+	ret.GalleryIDs = &models.UpdateIDs{
+		IDs:  src.GalleryIDs.List(),
+		Mode: models.RelationshipUpdateModeSet,
+	}
+	ret.TagIDs = &models.UpdateIDs{
+		IDs:  src.TagIDs.List(),
+		Mode: models.RelationshipUpdateModeSet,
+	}
+	ret.PerformerIDs = &models.UpdateIDs{
+		IDs:  src.PerformerIDs.List(),
+		Mode: models.RelationshipUpdateModeSet,
+	}
+	ret.MovieIDs = &models.UpdateMovieIDs{
+		Movies: src.Movies.List(),
+		Mode:   models.RelationshipUpdateModeSet,
+	}
+
+	return ret
+}
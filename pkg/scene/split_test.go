@@ -0,0 +1,96 @@
+package scene
+
+import (
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitSegments(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration float64
+		times    []float64
+		want     []models.SceneSegment
+	}{
+		{
+			"none",
+			60,
+			nil,
+			[]models.SceneSegment{{Start: 0, End: 60}},
+		},
+		{
+			"unsorted",
+			60,
+			[]float64{40, 20},
+			[]models.SceneSegment{{Start: 0, End: 20}, {Start: 20, End: 40}, {Start: 40, End: 60}},
+		},
+		{
+			"outside file",
+			60,
+			[]float64{-5, 0, 30, 60, 90},
+			[]models.SceneSegment{{Start: 0, End: 30}, {Start: 30, End: 60}},
+		},
+		{
+			"too close",
+			60,
+			[]float64{30, 30.5, 59.5},
+			[]models.SceneSegment{{Start: 0, End: 30}, {Start: 30, End: 60}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, SplitSegments(tt.duration, tt.times))
+		})
+	}
+}
+
+func TestRebaseMarker(t *testing.T) {
+	seg := models.SceneSegment{Start: 20, End: 40}
+
+	tests := []struct {
+		name    string
+		seconds float64
+		start   float64
+		want    *float64
+	}{
+		{"before", 10, 20, nil},
+		{"start", 20, 20, floatPtr(0)},
+		{"within", 25.5, 20, floatPtr(5.5)},
+		{"end", 40, 20, nil},
+		{"keyframe before start", 25.5, 18, floatPtr(7.5)},
+		{"before start after keyframe", 19, 18, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &models.SceneMarker{ID: 1, Seconds: tt.seconds}
+			got := RebaseMarker(m, seg, tt.start)
+
+			if tt.want == nil {
+				assert.Nil(t, got)
+				return
+			}
+
+			if assert.NotNil(t, got) {
+				assert.Equal(t, *tt.want, got.Seconds)
+				assert.Equal(t, tt.seconds, m.Seconds)
+			}
+		})
+	}
+}
+
+func TestRebaseSegment(t *testing.T) {
+	seg := models.SceneSegment{Start: 20, End: 40}
+
+	assert.Nil(t, RebaseSegment(nil, seg, 20))
+	assert.Nil(t, RebaseSegment(&models.SceneSegment{Start: 10, End: 25}, seg, 20))
+	assert.Equal(t, &models.SceneSegment{Start: 0, End: 10}, RebaseSegment(&models.SceneSegment{Start: 20, End: 30}, seg, 20))
+	assert.Equal(t, &models.SceneSegment{Start: 2, End: 12}, RebaseSegment(&models.SceneSegment{Start: 20, End: 30}, seg, 18))
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
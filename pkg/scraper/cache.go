@@ -16,7 +16,6 @@ import (
 	"github.com/stashapp/stash/pkg/match"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/scene"
-	"github.com/stashapp/stash/pkg/tag"
 	"github.com/stashapp/stash/pkg/txn"
 )
 
@@ -39,9 +38,15 @@ type GlobalConfig interface {
 	GetScraperUserAgent() string
 	GetScrapersPath() string
 	GetScraperCDPPath() string
+	GetScraperCDPPoolMin() int
+	GetScraperCDPPoolMax() int
+	GetScraperCDPIdleTimeout() int
+	GetScraperCDPBlockResources() bool
 	GetScraperCertCheck() bool
 	GetPythonPath() string
 	GetProxy() string
+	GetCachePath() string
+	GetScraperCredentials(scraperID string) *models.ScraperCredentials
 }
 
 func isCDPPathHTTP(c GlobalConfig) bool {
@@ -64,7 +69,7 @@ type StudioFinder interface {
 
 type TagFinder interface {
 	match.TagAutoTagQueryer
-	tag.Queryer
+	match.TagFinder
 }
 
 type GalleryFinder interface {
@@ -72,9 +77,15 @@ type GalleryFinder interface {
 	models.FileLoader
 }
 
+type ImageFinder interface {
+	Find(ctx context.Context, id int) (*models.Image, error)
+	models.FileLoader
+}
+
 type Repository struct {
 	SceneFinder     scene.IDFinder
 	GalleryFinder   GalleryFinder
+	ImageFinder     ImageFinder
 	TagFinder       TagFinder
 	PerformerFinder PerformerFinder
 	MovieFinder     match.MovieNamesFinder
@@ -139,6 +150,13 @@ func NewCache(globalConfig GlobalConfig, txnManager txn.Manager, repo Repository
 }
 
 func (c *Cache) loadScrapers() (map[string]scraper, error) {
+	// sessions hold a copy of the scraper configuration, so load them again
+	// from disk when next used
+	resetSessions()
+	// rate limits only ever tighten, so start again from the new
+	// configurations
+	resetRateLimiters()
+
 	path := c.globalConfig.GetScrapersPath()
 	scrapers := make(map[string]scraper)
 
@@ -184,6 +202,12 @@ func (c *Cache) ReloadScrapers() error {
 	return nil
 }
 
+// ClearSession logs the scraper out of its site, discarding the stored
+// cookies. The scraper logs in again when it is next used.
+func (c Cache) ClearSession(scraperID string) error {
+	return clearSession(c.globalConfig, scraperID)
+}
+
 // ListScrapers lists scrapers matching one of the given types.
 // Returns a list of scrapers, sorted by their name.
 func (c Cache) ListScrapers(tys []ScrapeContentType) []*Scraper {
@@ -289,6 +313,36 @@ func (c Cache) ScrapeURL(ctx context.Context, url string, ty ScrapeContentType)
 	return nil, nil
 }
 
+// ScrapeURLWithScraper scrapes a given url for the given content using the
+// scraper with the given id. Returns nil if the scraper does not support the
+// url.
+func (c Cache) ScrapeURLWithScraper(ctx context.Context, scraperID string, url string, ty ScrapeContentType) (ScrapedContent, error) {
+	s := c.findScraper(scraperID)
+	if s == nil {
+		return nil, fmt.Errorf("%w: id %s", ErrNotFound, scraperID)
+	}
+
+	if !s.supportsURL(url, ty) {
+		return nil, nil
+	}
+
+	ul, ok := s.(urlScraper)
+	if !ok {
+		return nil, fmt.Errorf("%w: cannot use scraper %s as an url scraper", ErrNotSupported, scraperID)
+	}
+
+	ret, err := ul.viaURL(ctx, c.client, url, ty)
+	if err != nil {
+		return nil, fmt.Errorf("scraper %s: %w", scraperID, err)
+	}
+
+	if ret == nil {
+		return ret, nil
+	}
+
+	return c.postScrape(ctx, ret)
+}
+
 func (c Cache) ScrapeID(ctx context.Context, scraperID string, id int, ty ScrapeContentType) (ScrapedContent, error) {
 	s := c.findScraper(scraperID)
 	if s == nil {
@@ -340,6 +394,27 @@ func (c Cache) ScrapeID(ctx context.Context, scraperID string, id int, ty Scrape
 			return nil, fmt.Errorf("scraper %s: %w", scraperID, err)
 		}
 
+		if scraped != nil {
+			ret = scraped
+		}
+	case ScrapeContentTypeImage:
+		is, ok := s.(imageScraper)
+		if !ok {
+			return nil, fmt.Errorf("%w: cannot use scraper %s as an image scraper", ErrNotSupported, scraperID)
+		}
+
+		image, err := c.getImage(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("scraper %s: unable to load image id %v: %w", scraperID, id, err)
+		}
+
+		// don't assign nil concrete pointer to ret interface, otherwise nil
+		// detection is harder
+		scraped, err := is.viaImage(ctx, c.client, image)
+		if err != nil {
+			return nil, fmt.Errorf("scraper %s: %w", scraperID, err)
+		}
+
 		if scraped != nil {
 			ret = scraped
 		}
@@ -387,3 +462,23 @@ func (c Cache) getGallery(ctx context.Context, galleryID int) (*models.Gallery,
 	}
 	return ret, nil
 }
+
+func (c Cache) getImage(ctx context.Context, imageID int) (*models.Image, error) {
+	var ret *models.Image
+	if err := txn.WithReadTxn(ctx, c.txnManager, func(ctx context.Context) error {
+		var err error
+		ret, err = c.repository.ImageFinder.Find(ctx, imageID)
+		if err != nil {
+			return err
+		}
+
+		if ret == nil {
+			return fmt.Errorf("image with id %d not found", imageID)
+		}
+
+		return ret.LoadFiles(ctx, c.repository.ImageFinder)
+	}); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,881 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/target"
+	"github.com/chromedp/chromedp"
+
+	"github.com/stashapp/stash/pkg/logger"
+)
+
+const (
+	// cdpTabsPerBrowser is the maximum number of tabs open in each browser
+	// of the pool.
+	cdpTabsPerBrowser = 4
+
+	defaultCDPPoolMax     = 2
+	defaultCDPIdleTimeout = 5 * time.Minute
+
+	// cdpPoolTick is the longest time between checks for idle browsers.
+	cdpPoolTick = 30 * time.Second
+
+	// cdpCloseTimeout is the timeout for disposing the browser context of
+	// a closed tab.
+	cdpCloseTimeout = 5 * time.Second
+)
+
+// cdpBlockedResources are the resource types which are not loaded when
+// resource blocking is enabled. They are not needed to scrape the page.
+var cdpBlockedResources = []network.ResourceType{
+	network.ResourceTypeImage,
+	network.ResourceTypeMedia,
+	network.ResourceTypeFont,
+}
+
+var (
+	errCDPPoolClosed = errors.New("CDP browser pool closed")
+	errCDPPoolFull   = errors.New("no CDP browser available")
+)
+
+// CDPPoolStatus describes the browsers used by CDP scrapers.
+type CDPPoolStatus struct {
+	// Number of browsers running
+	Browsers int `json:"browsers"`
+	// Maximum number of browsers run at the same time
+	MaxBrowsers int `json:"maxBrowsers"`
+	// Number of tabs in use by scrapers
+	ActiveTabs int `json:"activeTabs"`
+	// Number of tabs waiting to be used
+	IdleTabs int `json:"idleTabs"`
+	// Number of browsers which crashed or lost their connection
+	Restarts int `json:"restarts"`
+}
+
+type cdpPoolOptions struct {
+	path string
+	// remote is true if path is the address of a running browser
+	remote         bool
+	proxy          string
+	minBrowsers    int
+	maxBrowsers    int
+	idleTimeout    time.Duration
+	blockResources bool
+}
+
+func cdpPoolOptionsFromConfig(globalConfig GlobalConfig) cdpPoolOptions {
+	ret := cdpPoolOptions{
+		path:           globalConfig.GetScraperCDPPath(),
+		remote:         isCDPPathHTTP(globalConfig) || isCDPPathWS(globalConfig),
+		proxy:          globalConfig.GetProxy(),
+		minBrowsers:    globalConfig.GetScraperCDPPoolMin(),
+		maxBrowsers:    globalConfig.GetScraperCDPPoolMax(),
+		idleTimeout:    time.Duration(globalConfig.GetScraperCDPIdleTimeout()) * time.Second,
+		blockResources: globalConfig.GetScraperCDPBlockResources(),
+	}
+
+	if ret.maxBrowsers <= 0 {
+		ret.maxBrowsers = defaultCDPPoolMax
+	}
+
+	// connections to a remote address all use the same browser
+	if ret.remote {
+		ret.maxBrowsers = 1
+	}
+
+	if ret.minBrowsers < 0 {
+		ret.minBrowsers = 0
+	}
+	if ret.minBrowsers > ret.maxBrowsers {
+		ret.minBrowsers = ret.maxBrowsers
+	}
+
+	if ret.idleTimeout <= 0 {
+		ret.idleTimeout = defaultCDPIdleTimeout
+	}
+
+	return ret
+}
+
+// cdpBrowser is a browser process, or a connection to a remote browser.
+type cdpBrowser struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	browser *chromedp.Browser
+
+	idle     []*cdpTab
+	active   int
+	lastUsed time.Time
+}
+
+// alive returns false if the browser has crashed or its connection was
+// lost.
+func (b *cdpBrowser) alive() bool {
+	select {
+	case <-b.browser.LostConnection:
+		return false
+	case <-b.ctx.Done():
+		return false
+	default:
+		return true
+	}
+}
+
+func (b *cdpBrowser) tabs() int {
+	return b.active + len(b.idle)
+}
+
+// close stops the browser, or disconnects from the remote browser.
+func (b *cdpBrowser) close() {
+	for _, t := range b.idle {
+		t.cancel()
+	}
+	b.idle = nil
+	b.cancel()
+}
+
+// cdpTab is a tab in its own browser context, so that cookies, storage and
+// cache are not shared with the other tabs of the browser. Each tab is used
+// for a single scrape.
+type cdpTab struct {
+	browser          *cdpBrowser
+	browserContextID cdp.BrowserContextID
+	ctx              context.Context
+	cancel           context.CancelFunc
+}
+
+// cdpPool runs the browsers used by CDP scrapers. Browsers are started when
+// needed, up to the maximum, and stopped when idle. Tabs are opened ahead of
+// the next scrape, each in a fresh browser context.
+type cdpPool struct {
+	options cdpPoolOptions
+
+	// slots limits the number of tabs in use
+	slots chan struct{}
+	stop  chan struct{}
+	// draining is closed when the pool stops returning tabs before closing
+	draining chan struct{}
+
+	// overridden in tests
+	launch func(ctx context.Context) (*cdpBrowser, error)
+	open   func(b *cdpBrowser) (*cdpTab, error)
+
+	mu        sync.Mutex
+	browsers  []*cdpBrowser
+	launching int
+	// launched is closed and replaced when a launch finishes
+	launched chan struct{}
+	restarts int
+	closed   bool
+}
+
+func newCDPPool(options cdpPoolOptions) *cdpPool {
+	p := &cdpPool{
+		options:  options,
+		slots:    make(chan struct{}, options.maxBrowsers*cdpTabsPerBrowser),
+		stop:     make(chan struct{}),
+		draining: make(chan struct{}),
+		launched: make(chan struct{}),
+	}
+	p.launch = p.launchBrowser
+	p.open = p.openTab
+
+	go p.maintain()
+
+	return p
+}
+
+var cdpPools = struct {
+	sync.Mutex
+	pool *cdpPool
+	// replaced pools waiting for their tabs to be released
+	draining map[*cdpPool]struct{}
+}{
+	draining: make(map[*cdpPool]struct{}),
+}
+
+// getCDPPool returns the browser pool for the global configuration. The
+// pool is replaced if the configuration has changed since it was started.
+// The replaced pool is closed once the tabs in use have been released.
+func getCDPPool(globalConfig GlobalConfig) *cdpPool {
+	options := cdpPoolOptionsFromConfig(globalConfig)
+
+	cdpPools.Lock()
+	defer cdpPools.Unlock()
+
+	if p := cdpPools.pool; p != nil {
+		if p.options == options {
+			return p
+		}
+
+		logger.Debugf("[scraper] CDP configuration changed, restarting browsers")
+		cdpPools.draining[p] = struct{}{}
+		go func() {
+			p.drain()
+
+			cdpPools.Lock()
+			delete(cdpPools.draining, p)
+			cdpPools.Unlock()
+		}()
+	}
+
+	cdpPools.pool = newCDPPool(options)
+	return cdpPools.pool
+}
+
+// GetCDPPoolStatus returns the status of the browsers used by CDP scrapers,
+// or nil if no CDP scraper has been run.
+func GetCDPPoolStatus() *CDPPoolStatus {
+	cdpPools.Lock()
+	p := cdpPools.pool
+	cdpPools.Unlock()
+
+	if p == nil {
+		return nil
+	}
+
+	return p.status()
+}
+
+// CloseCDPPool stops the browsers used by CDP scrapers, including those of
+// replaced pools which are still in use.
+func CloseCDPPool() {
+	cdpPools.Lock()
+	pools := make([]*cdpPool, 0, len(cdpPools.draining)+1)
+	if cdpPools.pool != nil {
+		pools = append(pools, cdpPools.pool)
+	}
+	for p := range cdpPools.draining {
+		pools = append(pools, p)
+	}
+	cdpPools.pool = nil
+	cdpPools.Unlock()
+
+	for _, p := range pools {
+		p.close()
+	}
+}
+
+func (p *cdpPool) status() *CDPPoolStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ret := &CDPPoolStatus{
+		MaxBrowsers: p.options.maxBrowsers,
+		Restarts:    p.restarts,
+	}
+
+	for _, b := range p.browsers {
+		if !b.alive() {
+			continue
+		}
+
+		ret.Browsers++
+		ret.ActiveTabs += b.active
+		ret.IdleTabs += len(b.idle)
+	}
+
+	return ret
+}
+
+// run runs f with a tab from the pool. The context passed to f is cancelled
+// when ctx is done. If the browser crashes while running f, it is restarted
+// and f is run again.
+func (p *cdpPool) run(ctx context.Context, f func(ctx context.Context) error) error {
+	err := p.runOnce(ctx, f)
+
+	var crashed *cdpCrashError
+	if errors.As(err, &crashed) && ctx.Err() == nil {
+		logger.Warnf("[scraper] CDP browser crashed, retrying: %v", crashed.err)
+		err = p.runOnce(ctx, f)
+	}
+
+	var stillCrashed *cdpCrashError
+	if errors.As(err, &stillCrashed) {
+		return stillCrashed.err
+	}
+
+	return err
+}
+
+// cdpCrashError is returned by runOnce if the browser was lost while
+// running.
+type cdpCrashError struct {
+	err error
+}
+
+func (e *cdpCrashError) Error() string {
+	return e.err.Error()
+}
+
+func (p *cdpPool) runOnce(ctx context.Context, f func(ctx context.Context) error) error {
+	tab, err := p.acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	runCtx, cancel := context.WithCancel(tab.ctx)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			cancel()
+		case <-done:
+		}
+	}()
+
+	err = f(runCtx)
+	close(done)
+	cancel()
+
+	if err != nil && !tab.browser.alive() {
+		p.release(tab, false)
+		return &cdpCrashError{err: err}
+	}
+
+	// tabs in an unknown state are not replaced
+	p.release(tab, err == nil)
+	return err
+}
+
+// acquire returns an unused tab, starting a new browser if needed.
+func (p *cdpPool) acquire(ctx context.Context) (*cdpTab, error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-p.stop:
+		return nil, errCDPPoolClosed
+	case <-p.draining:
+		return nil, errCDPPoolClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	tab, err := p.acquireSlot(ctx)
+	if err != nil {
+		<-p.slots
+		return nil, err
+	}
+
+	return tab, nil
+}
+
+// acquireSlot returns a tab once a slot is held. If no browser has room for
+// another tab, a browser is started, or if the maximum number of browsers
+// are running or starting, a tab is opened once a browser has started.
+func (p *cdpPool) acquireSlot(ctx context.Context) (*cdpTab, error) {
+	for {
+		tab, launched, err := p.tryAcquireSlot(ctx)
+		if launched == nil {
+			return tab, err
+		}
+
+		select {
+		case <-launched:
+		case <-p.stop:
+			return nil, errCDPPoolClosed
+		case <-p.draining:
+			return nil, errCDPPoolClosed
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// tryAcquireSlot returns a tab, or a channel closed when a browser launch
+// finishes if the maximum number of browsers are running or starting.
+func (p *cdpPool) tryAcquireSlot(ctx context.Context) (*cdpTab, <-chan struct{}, error) {
+	p.mu.Lock()
+
+	if p.closed || p.isDraining() {
+		p.mu.Unlock()
+		return nil, nil, errCDPPoolClosed
+	}
+
+	lost := p.removeLost()
+
+	var browser *cdpBrowser
+	for _, b := range p.browsers {
+		if len(b.idle) > 0 {
+			tab := b.idle[len(b.idle)-1]
+			b.idle = b.idle[:len(b.idle)-1]
+			b.active++
+			p.mu.Unlock()
+			closeBrowsers(lost)
+			return tab, nil, nil
+		}
+
+		if browser == nil && b.tabs() < cdpTabsPerBrowser {
+			browser = b
+		}
+	}
+
+	if browser != nil {
+		browser.active++
+		p.mu.Unlock()
+		closeBrowsers(lost)
+		tab, err := p.newTab(browser)
+		return tab, nil, err
+	}
+
+	// the slots guarantee that a browser is starting if none has room for
+	// another tab and the maximum number of browsers is reached
+	if len(p.browsers)+p.launching >= p.options.maxBrowsers {
+		launched := p.launched
+		starting := p.launching > 0
+		p.mu.Unlock()
+		closeBrowsers(lost)
+
+		if !starting {
+			return nil, nil, errCDPPoolFull
+		}
+		return nil, launched, nil
+	}
+
+	p.launching++
+	p.mu.Unlock()
+	closeBrowsers(lost)
+
+	browser, err := p.launch(ctx)
+
+	p.mu.Lock()
+	p.launchFinished()
+	if err != nil {
+		p.mu.Unlock()
+		return nil, nil, err
+	}
+	if p.closed {
+		p.mu.Unlock()
+		browser.close()
+		return nil, nil, errCDPPoolClosed
+	}
+	browser.active++
+	p.browsers = append(p.browsers, browser)
+	p.mu.Unlock()
+
+	tab, err := p.newTab(browser)
+	return tab, nil, err
+}
+
+// launchFinished wakes the callers waiting for a browser to start. Must be
+// called with the lock held.
+func (p *cdpPool) launchFinished() {
+	p.launching--
+	close(p.launched)
+	p.launched = make(chan struct{})
+}
+
+// release returns the tab's slot to the pool and closes the tab. If reuse
+// is true, a new tab is opened in a fresh browser context in its place, so
+// that no cookies, storage or cache carry over to the next scrape.
+func (p *cdpPool) release(tab *cdpTab, reuse bool) {
+	b := tab.browser
+	tab.close()
+
+	var fresh *cdpTab
+	if reuse && b.alive() {
+		var err error
+		if fresh, err = p.open(b); err != nil {
+			logger.Debugf("[scraper] error opening CDP tab: %v", err)
+		}
+	}
+
+	p.mu.Lock()
+	b.active--
+	b.lastUsed = time.Now()
+	reuse = fresh != nil && !p.closed && b.alive()
+	if reuse {
+		b.idle = append(b.idle, fresh)
+	}
+	p.mu.Unlock()
+
+	if fresh != nil && !reuse {
+		fresh.close()
+	}
+
+	<-p.slots
+}
+
+// removeLost removes the browsers which crashed or lost their connection
+// from the pool, returning them. Must be called with the lock held.
+func (p *cdpPool) removeLost() []*cdpBrowser {
+	var lost []*cdpBrowser
+	kept := p.browsers[:0]
+	for _, b := range p.browsers {
+		if b.alive() {
+			kept = append(kept, b)
+			continue
+		}
+
+		lost = append(lost, b)
+	}
+	p.browsers = kept
+	p.restarts += len(lost)
+
+	return lost
+}
+
+// removeIdle removes the browsers which have not been used within the idle
+// timeout from the pool, keeping the minimum number of browsers, and returns
+// them. Must be called with the lock held.
+func (p *cdpPool) removeIdle(now time.Time) []*cdpBrowser {
+	var idle []*cdpBrowser
+	kept := p.browsers[:0]
+	for _, b := range p.browsers {
+		expired := b.active == 0 && now.Sub(b.lastUsed) >= p.options.idleTimeout
+		if expired && len(p.browsers)-len(idle) > p.options.minBrowsers {
+			idle = append(idle, b)
+			continue
+		}
+
+		kept = append(kept, b)
+	}
+	p.browsers = kept
+
+	return idle
+}
+
+func closeBrowsers(browsers []*cdpBrowser) {
+	for _, b := range browsers {
+		b.close()
+	}
+}
+
+// maintain stops idle browsers and starts browsers up to the minimum, until
+// the pool is closed.
+func (p *cdpPool) maintain() {
+	tick := p.options.idleTimeout
+	if tick > cdpPoolTick {
+		tick = cdpPoolTick
+	}
+
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	p.ensureMinBrowsers()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case now := <-ticker.C:
+			p.mu.Lock()
+			lost := p.removeLost()
+			idle := p.removeIdle(now)
+			p.mu.Unlock()
+
+			closeBrowsers(lost)
+			if len(idle) > 0 {
+				logger.Debugf("[scraper] stopping %d idle CDP browsers", len(idle))
+				closeBrowsers(idle)
+			}
+
+			p.ensureMinBrowsers()
+		}
+	}
+}
+
+func (p *cdpPool) ensureMinBrowsers() {
+	for {
+		p.mu.Lock()
+		if p.closed || len(p.browsers)+p.launching >= p.options.minBrowsers {
+			p.mu.Unlock()
+			return
+		}
+		p.launching++
+		p.mu.Unlock()
+
+		browser, err := p.launch(context.Background())
+
+		p.mu.Lock()
+		p.launchFinished()
+		if err != nil {
+			p.mu.Unlock()
+			logger.Warnf("[scraper] error starting CDP browser: %v", err)
+			return
+		}
+		if p.closed {
+			p.mu.Unlock()
+			browser.close()
+			return
+		}
+		p.browsers = append(p.browsers, browser)
+		p.mu.Unlock()
+	}
+}
+
+// drain stops the pool from returning tabs, and closes it once the tabs in
+// use have been released.
+func (p *cdpPool) drain() {
+	p.mu.Lock()
+	if p.closed || p.isDraining() {
+		p.mu.Unlock()
+		return
+	}
+	close(p.draining)
+	p.mu.Unlock()
+
+	// all slots are free once the tabs in use are released
+	for i := 0; i < cap(p.slots); i++ {
+		select {
+		case p.slots <- struct{}{}:
+		case <-p.stop:
+			return
+		}
+	}
+
+	p.close()
+}
+
+func (p *cdpPool) isDraining() bool {
+	select {
+	case <-p.draining:
+		return true
+	default:
+		return false
+	}
+}
+
+// close stops all browsers of the pool. Tabs in use are closed when they
+// are released.
+func (p *cdpPool) close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.stop)
+	browsers := p.browsers
+	p.browsers = nil
+	p.mu.Unlock()
+
+	closeBrowsers(browsers)
+}
+
+// launchBrowser starts a browser, or connects to the remote browser.
+func (p *cdpPool) launchBrowser(ctx context.Context) (*cdpBrowser, error) {
+	var allocCtx context.Context
+	var allocCancel context.CancelFunc
+
+	if p.options.remote {
+		remote, err := remoteCDPAddress(ctx, p.options.path)
+		if err != nil {
+			return nil, err
+		}
+
+		allocCtx, allocCancel = chromedp.NewRemoteAllocator(context.Background(), remote)
+	} else {
+		// chromedp uses a temporary user directory, removed when the
+		// browser stops
+		opts := chromedp.DefaultExecAllocatorOptions[:]
+		if p.options.path != "" {
+			opts = append(opts, chromedp.ExecPath(p.options.path))
+		}
+		if p.options.proxy != "" {
+			url, _, _ := splitProxyAuth(p.options.proxy)
+			opts = append(opts, chromedp.ProxyServer(url))
+		}
+
+		allocCtx, allocCancel = chromedp.NewExecAllocator(context.Background(), opts...)
+	}
+
+	ctx, cancel := chromedp.NewContext(allocCtx)
+
+	// the first run starts the browser, whose lifetime is bound to the
+	// context it is run with
+	if err := chromedp.Run(ctx); err != nil {
+		cancel()
+		allocCancel()
+		return nil, fmt.Errorf("starting CDP browser: %w", err)
+	}
+
+	logger.Debugf("[scraper] started CDP browser")
+
+	return &cdpBrowser{
+		ctx: ctx,
+		cancel: func() {
+			cancel()
+			allocCancel()
+		},
+		browser:  chromedp.FromContext(ctx).Browser,
+		lastUsed: time.Now(),
+	}, nil
+}
+
+// newTab opens a tab in its own browser context. The browser must already
+// count the tab as active.
+func (p *cdpPool) newTab(b *cdpBrowser) (*cdpTab, error) {
+	tab, err := p.open(b)
+	if err != nil {
+		p.mu.Lock()
+		b.active--
+		p.mu.Unlock()
+		return nil, fmt.Errorf("opening CDP tab: %w", err)
+	}
+
+	return tab, nil
+}
+
+func (p *cdpPool) openTab(b *cdpBrowser) (*cdpTab, error) {
+	browserCtx := cdp.WithExecutor(b.ctx, b.browser)
+
+	browserContextID, err := target.CreateBrowserContext().WithDisposeOnDetach(true).Do(browserCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	targetID, err := target.CreateTarget("about:blank").WithBrowserContextID(browserContextID).Do(browserCtx)
+	if err != nil {
+		_ = target.DisposeBrowserContext(browserContextID).Do(browserCtx)
+		return nil, err
+	}
+
+	ctx, cancel := chromedp.NewContext(b.ctx, chromedp.WithTargetID(targetID))
+	tab := &cdpTab{
+		browser:          b,
+		browserContextID: browserContextID,
+		ctx:              ctx,
+		cancel:           cancel,
+	}
+
+	tasks := chromedp.Tasks{network.Enable()}
+	if interceptTasks := p.interceptRequests(ctx); interceptTasks != nil {
+		tasks = append(tasks, interceptTasks)
+	}
+
+	// the first run attaches to the tab, whose lifetime is bound to the
+	// context it is run with
+	if err := chromedp.Run(ctx, tasks); err != nil {
+		tab.close()
+		return nil, err
+	}
+
+	return tab, nil
+}
+
+// interceptRequests listens for the requests of the tab, blocking the
+// resources which are not needed and authenticating with the proxy. It
+// returns the action to enable interception, or nil if not needed.
+func (p *cdpPool) interceptRequests(ctx context.Context) chromedp.Action {
+	proxyAuth := proxyUsesAuth(p.options.proxy)
+	if !proxyAuth && !p.options.blockResources {
+		return nil
+	}
+
+	_, user, pass := splitProxyAuth(p.options.proxy)
+
+	// Based on https://github.com/chromedp/examples/blob/master/proxy/main.go
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		switch ev := ev.(type) {
+		case *fetch.EventRequestPaused:
+			go func() {
+				if p.options.blockResources && isCDPBlockedResource(ev.ResourceType) {
+					_ = chromedp.Run(ctx, fetch.FailRequest(ev.RequestID, network.ErrorReasonBlockedByClient))
+					return
+				}
+
+				_ = chromedp.Run(ctx, fetch.ContinueRequest(ev.RequestID))
+			}()
+		case *fetch.EventAuthRequired:
+			response := &fetch.AuthChallengeResponse{
+				Response: fetch.AuthChallengeResponseResponseDefault,
+			}
+			if ev.AuthChallenge.Source == fetch.AuthChallengeSourceProxy {
+				response = &fetch.AuthChallengeResponse{
+					Response: fetch.AuthChallengeResponseResponseProvideCredentials,
+					Username: user,
+					Password: pass,
+				}
+			}
+
+			go func() {
+				_ = chromedp.Run(ctx, fetch.ContinueWithAuth(ev.RequestID, response))
+			}()
+		}
+	})
+
+	// authentication requires all requests to be intercepted
+	var patterns []*fetch.RequestPattern
+	if !proxyAuth {
+		for _, t := range cdpBlockedResources {
+			patterns = append(patterns, &fetch.RequestPattern{
+				URLPattern:   "*",
+				ResourceType: t,
+				RequestStage: fetch.RequestStageRequest,
+			})
+		}
+	}
+
+	return fetch.Enable().WithPatterns(patterns).WithHandleAuthRequests(proxyAuth)
+}
+
+func isCDPBlockedResource(t network.ResourceType) bool {
+	for _, blocked := range cdpBlockedResources {
+		if t == blocked {
+			return true
+		}
+	}
+	return false
+}
+
+// close closes the tab and its browser context.
+func (t *cdpTab) close() {
+	t.cancel()
+
+	if !t.browser.alive() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(t.browser.ctx, cdpCloseTimeout)
+	defer cancel()
+
+	_ = target.DisposeBrowserContext(t.browserContextID).Do(cdp.WithExecutor(ctx, t.browser.browser))
+}
+
+// remoteCDPAddress returns the websocket address of the browser at the
+// remote CDP path.
+func remoteCDPAddress(ctx context.Context, remote string) (string, error) {
+	// -------------------------------------------------------------------
+	// #1023
+	// when chromium is listening over RDP it only accepts requests
+	// with host headers that are either IPs or `localhost`
+	cdpURL, err := url.Parse(remote)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse CDP Path: %v", err)
+	}
+	hostname := cdpURL.Hostname()
+	if hostname != "localhost" {
+		if net.ParseIP(hostname) == nil { // not an IP
+			addr, err := net.LookupIP(hostname)
+			if err != nil || len(addr) == 0 { // can not resolve to IP
+				return "", fmt.Errorf("CDP: hostname <%s> can not be resolved", hostname)
+			}
+			if len(addr[0]) == 0 { // nil IP
+				return "", fmt.Errorf("CDP: hostname <%s> resolved to nil", hostname)
+			}
+			// addr is a valid IP
+			// replace the host part of the cdpURL with the IP
+			cdpURL.Host = strings.Replace(cdpURL.Host, hostname, addr[0].String(), 1)
+			// use that for remote
+			remote = cdpURL.String()
+		}
+	}
+	// --------------------------------------------------------------------
+
+	// if CDPPath is http(s) then we need to get the websocket URL
+	if cdpURL.Scheme == "http" || cdpURL.Scheme == "https" {
+		return getRemoteCDPWSAddress(ctx, remote)
+	}
+
+	return remote, nil
+}
@@ -0,0 +1,284 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/stretchr/testify/assert"
+)
+
+type cdpGlobalConfig struct {
+	mockGlobalConfig
+	cdpPath     string
+	minBrowsers int
+	maxBrowsers int
+	idleTimeout int
+}
+
+func (c cdpGlobalConfig) GetScraperCDPPath() string {
+	return c.cdpPath
+}
+
+func (c cdpGlobalConfig) GetScraperCDPPoolMin() int {
+	return c.minBrowsers
+}
+
+func (c cdpGlobalConfig) GetScraperCDPPoolMax() int {
+	return c.maxBrowsers
+}
+
+func (c cdpGlobalConfig) GetScraperCDPIdleTimeout() int {
+	return c.idleTimeout
+}
+
+func Test_cdpPoolOptionsFromConfig(t *testing.T) {
+	tests := []struct {
+		name            string
+		config          cdpGlobalConfig
+		wantMin         int
+		wantMax         int
+		wantIdleTimeout time.Duration
+	}{
+		{
+			"defaults",
+			cdpGlobalConfig{},
+			0,
+			defaultCDPPoolMax,
+			defaultCDPIdleTimeout,
+		},
+		{
+			"configured",
+			cdpGlobalConfig{minBrowsers: 1, maxBrowsers: 3, idleTimeout: 60},
+			1,
+			3,
+			time.Minute,
+		},
+		{
+			"min above max",
+			cdpGlobalConfig{minBrowsers: 5, maxBrowsers: 3},
+			3,
+			3,
+			defaultCDPIdleTimeout,
+		},
+		{
+			"remote",
+			cdpGlobalConfig{cdpPath: "ws://localhost:9222", minBrowsers: 2, maxBrowsers: 4},
+			1,
+			1,
+			defaultCDPIdleTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cdpPoolOptionsFromConfig(tt.config)
+			assert.Equal(t, tt.wantMin, got.minBrowsers)
+			assert.Equal(t, tt.wantMax, got.maxBrowsers)
+			assert.Equal(t, tt.wantIdleTimeout, got.idleTimeout)
+		})
+	}
+}
+
+// newTestCDPBrowser returns a browser which is not running, for testing the
+// pool bookkeeping.
+func newTestCDPBrowser(active int, idleTabs int, lastUsed time.Time) *cdpBrowser {
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &cdpBrowser{
+		ctx:      ctx,
+		cancel:   cancel,
+		browser:  &chromedp.Browser{LostConnection: make(chan struct{})},
+		active:   active,
+		lastUsed: lastUsed,
+	}
+
+	for i := 0; i < idleTabs; i++ {
+		b.idle = append(b.idle, &cdpTab{browser: b, ctx: ctx, cancel: func() {}})
+	}
+
+	return b
+}
+
+func Test_cdpPool_removeIdle(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-time.Hour)
+
+	active := newTestCDPBrowser(1, 0, old)
+	idle := newTestCDPBrowser(0, 2, old)
+	recent := newTestCDPBrowser(0, 1, now)
+	idle2 := newTestCDPBrowser(0, 0, old)
+
+	tests := []struct {
+		name        string
+		minBrowsers int
+		want        []*cdpBrowser
+		wantKept    []*cdpBrowser
+	}{
+		{"no minimum", 0, []*cdpBrowser{idle, idle2}, []*cdpBrowser{active, recent}},
+		{"minimum", 3, []*cdpBrowser{idle}, []*cdpBrowser{active, recent, idle2}},
+		{"minimum reached", 4, nil, []*cdpBrowser{active, idle, recent, idle2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &cdpPool{
+				options: cdpPoolOptions{
+					minBrowsers: tt.minBrowsers,
+					idleTimeout: time.Minute,
+				},
+				browsers: []*cdpBrowser{active, idle, recent, idle2},
+			}
+
+			got := p.removeIdle(now)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantKept, p.browsers)
+		})
+	}
+}
+
+func Test_cdpPool_removeLost(t *testing.T) {
+	running := newTestCDPBrowser(1, 1, time.Now())
+	crashed := newTestCDPBrowser(0, 1, time.Now())
+	close(crashed.browser.LostConnection)
+
+	p := &cdpPool{
+		options:  cdpPoolOptions{maxBrowsers: 2},
+		browsers: []*cdpBrowser{crashed, running},
+	}
+
+	assert.Equal(t, &CDPPoolStatus{
+		Browsers:    1,
+		MaxBrowsers: 2,
+		ActiveTabs:  1,
+		IdleTabs:    1,
+	}, p.status())
+
+	lost := p.removeLost()
+	assert.Equal(t, []*cdpBrowser{crashed}, lost)
+	assert.Equal(t, []*cdpBrowser{running}, p.browsers)
+	assert.Equal(t, 1, p.status().Restarts)
+}
+
+// newTestCDPPool returns a pool which starts browsers which are not
+// running, counting the launches.
+func newTestCDPPool(maxBrowsers int, launches *int32) *cdpPool {
+	p := newCDPPool(cdpPoolOptions{
+		maxBrowsers: maxBrowsers,
+		idleTimeout: time.Minute,
+	})
+	p.launch = func(ctx context.Context) (*cdpBrowser, error) {
+		atomic.AddInt32(launches, 1)
+		time.Sleep(10 * time.Millisecond)
+		return newTestCDPBrowser(0, 0, time.Now()), nil
+	}
+	p.open = func(b *cdpBrowser) (*cdpTab, error) {
+		return &cdpTab{browser: b, ctx: b.ctx, cancel: func() {}}, nil
+	}
+
+	return p
+}
+
+func Test_cdpPool_acquireMaxBrowsers(t *testing.T) {
+	const maxBrowsers = 2
+
+	var launches int32
+	p := newTestCDPPool(maxBrowsers, &launches)
+	defer p.close()
+
+	// use every slot at once, so that all tabs are requested while the
+	// browsers are starting
+	n := maxBrowsers * cdpTabsPerBrowser
+	tabs := make([]*cdpTab, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			tab, err := p.acquire(context.Background())
+			if err != nil {
+				t.Errorf("acquire() error = %v", err)
+				return
+			}
+			tabs[i] = tab
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(maxBrowsers), atomic.LoadInt32(&launches))
+
+	status := p.status()
+	assert.Equal(t, maxBrowsers, status.Browsers)
+	assert.Equal(t, n, status.ActiveTabs)
+
+	for _, b := range p.browsers {
+		assert.Equal(t, cdpTabsPerBrowser, b.active)
+	}
+}
+
+func Test_cdpPool_drain(t *testing.T) {
+	var launches int32
+	p := newTestCDPPool(1, &launches)
+
+	tab, err := p.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		p.drain()
+		close(drained)
+	}()
+
+	// the pool is closed to new tabs while draining
+	assert.Eventually(t, func() bool {
+		_, err := p.acquire(context.Background())
+		return errors.Is(err, errCDPPoolClosed)
+	}, time.Second, 10*time.Millisecond)
+
+	select {
+	case <-drained:
+		t.Fatal("pool closed with a tab in use")
+	default:
+	}
+
+	// stop the browser, so that the released tab is not disposed
+	tab.browser.cancel()
+	p.release(tab, false)
+
+	select {
+	case <-drained:
+	case <-time.After(time.Second):
+		t.Fatal("pool not closed after the tab was released")
+	}
+
+	assert.True(t, p.closed)
+}
+
+func TestGetCDPPool(t *testing.T) {
+	defer CloseCDPPool()
+
+	assert.Nil(t, GetCDPPoolStatus())
+
+	config := cdpGlobalConfig{maxBrowsers: 3}
+	p := getCDPPool(config)
+	assert.Same(t, p, getCDPPool(config))
+
+	assert.Equal(t, &CDPPoolStatus{MaxBrowsers: 3}, GetCDPPoolStatus())
+
+	// changing the configuration replaces the pool
+	config.maxBrowsers = 1
+	replaced := getCDPPool(config)
+	assert.NotSame(t, p, replaced)
+
+	CloseCDPPool()
+	assert.Nil(t, GetCDPPoolStatus())
+
+	_, err := replaced.acquire(context.Background())
+	assert.ErrorIs(t, err, errCDPPoolClosed)
+}
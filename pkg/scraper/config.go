@@ -33,6 +33,9 @@ type config struct {
 	// Configuration for querying gallery by a Gallery fragment
 	GalleryByFragment *scraperTypeConfig `yaml:"galleryByFragment"`
 
+	// Configuration for querying image by an Image fragment
+	ImageByFragment *scraperTypeConfig `yaml:"imageByFragment"`
+
 	// Configuration for querying scenes by name
 	SceneByName *scraperTypeConfig `yaml:"sceneByName"`
 
@@ -45,9 +48,21 @@ type config struct {
 	// Configuration for querying a gallery by a URL
 	GalleryByURL []*scrapeByURLConfig `yaml:"galleryByURL"`
 
+	// Configuration for querying an image by a URL
+	ImageByURL []*scrapeByURLConfig `yaml:"imageByURL"`
+
 	// Configuration for querying a movie by a URL
 	MovieByURL []*scrapeByURLConfig `yaml:"movieByURL"`
 
+	// Configuration for querying studios by name
+	StudioByName *scraperTypeConfig `yaml:"studioByName"`
+
+	// Configuration for querying a studio by a URL
+	StudioByURL []*scrapeByURLConfig `yaml:"studioByURL"`
+
+	// Configuration for querying tags by name
+	TagByName *scraperTypeConfig `yaml:"tagByName"`
+
 	// Scraper debugging options
 	DebugOptions *scraperDebugOptions `yaml:"debug"`
 
@@ -99,12 +114,48 @@ func (c config) validate() error {
 		}
 	}
 
+	if c.ImageByFragment != nil {
+		if err := c.ImageByFragment.validate(); err != nil {
+			return err
+		}
+	}
+
+	for _, s := range c.ImageByURL {
+		if err := s.validate(); err != nil {
+			return err
+		}
+	}
+
 	for _, s := range c.MovieByURL {
 		if err := s.validate(); err != nil {
 			return err
 		}
 	}
 
+	if c.StudioByName != nil {
+		if err := c.StudioByName.validate(); err != nil {
+			return err
+		}
+	}
+
+	for _, s := range c.StudioByURL {
+		if err := s.validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.TagByName != nil {
+		if err := c.TagByName.validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.DriverOptions != nil && c.DriverOptions.Login != nil {
+		if err := c.DriverOptions.Login.validate(c.DriverOptions.UseCDP); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -131,6 +182,10 @@ func (c scraperTypeConfig) validate() error {
 		return errors.New("script is mandatory for script scraper action")
 	}
 
+	if c.Action == scraperActionJS && len(c.Script) == 0 {
+		return errors.New("script is mandatory for scrapeJS scraper action")
+	}
+
 	return nil
 }
 
@@ -185,11 +240,14 @@ type header struct {
 }
 
 type scraperDriverOptions struct {
-	UseCDP  bool             `yaml:"useCDP"`
-	Sleep   int              `yaml:"sleep"`
-	Clicks  []*clickOptions  `yaml:"clicks"`
-	Cookies []*cookieOptions `yaml:"cookies"`
-	Headers []*header        `yaml:"headers"`
+	UseCDP    bool              `yaml:"useCDP"`
+	Sleep     int               `yaml:"sleep"`
+	Clicks    []*clickOptions   `yaml:"clicks"`
+	Cookies   []*cookieOptions  `yaml:"cookies"`
+	Headers   []*header         `yaml:"headers"`
+	Cache     *cacheOptions     `yaml:"cache"`
+	RateLimit *rateLimitOptions `yaml:"rateLimit"`
+	Login     *loginOptions     `yaml:"login"`
 }
 
 func loadConfigFromYAML(id string, reader io.Reader) (*config, error) {
@@ -234,8 +292,9 @@ func loadConfigFromYAMLFile(path string) (*config, error) {
 
 func (c config) spec() Scraper {
 	ret := Scraper{
-		ID:   c.ID,
-		Name: c.Name,
+		ID:            c.ID,
+		Name:          c.Name,
+		RequiresLogin: c.DriverOptions != nil && c.DriverOptions.Login != nil,
 	}
 
 	performer := ScraperSpec{}
@@ -289,6 +348,21 @@ func (c config) spec() Scraper {
 		ret.Gallery = &gallery
 	}
 
+	image := ScraperSpec{}
+	if c.ImageByFragment != nil {
+		image.SupportedScrapes = append(image.SupportedScrapes, ScrapeTypeFragment)
+	}
+	if len(c.ImageByURL) > 0 {
+		image.SupportedScrapes = append(image.SupportedScrapes, ScrapeTypeURL)
+		for _, v := range c.ImageByURL {
+			image.Urls = append(image.Urls, v.URL...)
+		}
+	}
+
+	if len(image.SupportedScrapes) > 0 {
+		ret.Image = &image
+	}
+
 	movie := ScraperSpec{}
 	if len(c.MovieByURL) > 0 {
 		movie.SupportedScrapes = append(movie.SupportedScrapes, ScrapeTypeURL)
@@ -301,6 +375,27 @@ func (c config) spec() Scraper {
 		ret.Movie = &movie
 	}
 
+	studio := ScraperSpec{}
+	if c.StudioByName != nil {
+		studio.SupportedScrapes = append(studio.SupportedScrapes, ScrapeTypeName)
+	}
+	if len(c.StudioByURL) > 0 {
+		studio.SupportedScrapes = append(studio.SupportedScrapes, ScrapeTypeURL)
+		for _, v := range c.StudioByURL {
+			studio.Urls = append(studio.Urls, v.URL...)
+		}
+	}
+
+	if len(studio.SupportedScrapes) > 0 {
+		ret.Studio = &studio
+	}
+
+	if c.TagByName != nil {
+		ret.Tag = &ScraperSpec{
+			SupportedScrapes: []ScrapeType{ScrapeTypeName},
+		}
+	}
+
 	return ret
 }
 
@@ -312,8 +407,14 @@ func (c config) supports(ty ScrapeContentType) bool {
 		return (c.SceneByName != nil && c.SceneByQueryFragment != nil) || c.SceneByFragment != nil || len(c.SceneByURL) > 0
 	case ScrapeContentTypeGallery:
 		return c.GalleryByFragment != nil || len(c.GalleryByURL) > 0
+	case ScrapeContentTypeImage:
+		return c.ImageByFragment != nil || len(c.ImageByURL) > 0
 	case ScrapeContentTypeMovie:
 		return len(c.MovieByURL) > 0
+	case ScrapeContentTypeStudio:
+		return c.StudioByName != nil || len(c.StudioByURL) > 0
+	case ScrapeContentTypeTag:
+		return c.TagByName != nil
 	}
 
 	panic("Unhandled ScrapeContentType")
@@ -339,12 +440,24 @@ func (c config) matchesURL(url string, ty ScrapeContentType) bool {
 				return true
 			}
 		}
+	case ScrapeContentTypeImage:
+		for _, scraper := range c.ImageByURL {
+			if scraper.matchesURL(url) {
+				return true
+			}
+		}
 	case ScrapeContentTypeMovie:
 		for _, scraper := range c.MovieByURL {
 			if scraper.matchesURL(url) {
 				return true
 			}
 		}
+	case ScrapeContentTypeStudio:
+		for _, scraper := range c.StudioByURL {
+			if scraper.matchesURL(url) {
+				return true
+			}
+		}
 	}
 
 	return false
@@ -31,6 +31,12 @@ func (c config) jar() (*cookiejar.Jar, error) {
 		return jar, nil
 	}
 
+	setConfigCookies(jar, opts)
+	return jar, nil
+}
+
+// setConfigCookies sets the cookies listed in the driver options in the jar
+func setConfigCookies(jar http.CookieJar, opts *scraperDriverOptions) {
 	for i, ckURL := range opts.Cookies {
 		url, err := url.Parse(ckURL.CookieURL) // CookieURL must be valid, include schema
 		if err != nil {
@@ -54,8 +60,6 @@ func (c config) jar() (*cookiejar.Jar, error) {
 			logger.Warnf("setting jar cookies for %s failed", url.String())
 		}
 	}
-
-	return jar, nil
 }
 
 func getCookieValue(cookie *scraperCookies) string {
@@ -0,0 +1,112 @@
+package scraper
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+// maxFixtureNameLength is the maximum length of the URL-derived part of a
+// fixture filename.
+const maxFixtureNameLength = 100
+
+var fixtureNameRE = regexp.MustCompile(`[^A-Za-z0-9.\-]+`)
+
+// fixtureName returns the filename of the fixture for the given URL. The
+// name is derived from the URL so that fixtures can be written by hand.
+func fixtureName(u string) string {
+	ret := fixtureNameRE.ReplaceAllString(u, "_")
+
+	if len(ret) > maxFixtureNameLength {
+		// keep long URLs unique
+		sum := sha256.Sum256([]byte(u))
+		ret = ret[:maxFixtureNameLength] + "_" + hex.EncodeToString(sum[:4])
+	}
+
+	return ret
+}
+
+// fixtureTransport serves responses from fixture files instead of the
+// network. In record mode, requests are sent to the network and the
+// responses are written to the fixture files.
+type fixtureTransport struct {
+	dir    string
+	record bool
+	next   http.RoundTripper
+
+	mutex    sync.Mutex
+	used     []string
+	recorded []string
+}
+
+func (t *fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	u := req.URL.String()
+	fn := filepath.Join(t.dir, fixtureName(u))
+
+	if t.record {
+		return t.recordResponse(req, fn)
+	}
+
+	body, err := os.ReadFile(fn)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("no fixture for %s: expected %s", u, fn)
+		}
+		return nil, err
+	}
+
+	t.mutex.Lock()
+	t.used = append(t.used, fn)
+	t.mutex.Unlock()
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{http.DetectContentType(body)}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func (t *fixtureTransport) recordResponse(req *http.Request, fn string) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// only successful responses are useful as fixtures
+	if resp.StatusCode == http.StatusOK {
+		if err := os.MkdirAll(t.dir, 0755); err != nil {
+			return nil, err
+		}
+
+		if err := os.WriteFile(fn, body, 0644); err != nil {
+			return nil, fmt.Errorf("writing fixture %s: %w", fn, err)
+		}
+
+		t.mutex.Lock()
+		t.recorded = append(t.recorded, fn)
+		t.mutex.Unlock()
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
@@ -33,6 +33,8 @@ func (g group) fragmentScraper(input Input) *scraperTypeConfig {
 	case input.Gallery != nil:
 		// TODO - this should be galleryByQueryFragment
 		return g.config.GalleryByFragment
+	case input.Image != nil:
+		return g.config.ImageByFragment
 	case input.Scene != nil:
 		return g.config.SceneByQueryFragment
 	}
@@ -75,6 +77,15 @@ func (g group) viaGallery(ctx context.Context, client *http.Client, gallery *mod
 	return s.scrapeGalleryByGallery(ctx, gallery)
 }
 
+func (g group) viaImage(ctx context.Context, client *http.Client, image *models.Image) (*ScrapedImage, error) {
+	if g.config.ImageByFragment == nil {
+		return nil, ErrNotSupported
+	}
+
+	s := g.config.getScraper(*g.config.ImageByFragment, client, g.globalConf)
+	return s.scrapeImageByImage(ctx, image)
+}
+
 func loadUrlCandidates(c config, ty ScrapeContentType) []*scrapeByURLConfig {
 	switch ty {
 	case ScrapeContentTypePerformer:
@@ -85,6 +96,10 @@ func loadUrlCandidates(c config, ty ScrapeContentType) []*scrapeByURLConfig {
 		return c.MovieByURL
 	case ScrapeContentTypeGallery:
 		return c.GalleryByURL
+	case ScrapeContentTypeImage:
+		return c.ImageByURL
+	case ScrapeContentTypeStudio:
+		return c.StudioByURL
 	}
 
 	panic("loadUrlCandidates: unreachable")
@@ -125,6 +140,20 @@ func (g group) viaName(ctx context.Context, client *http.Client, name string, ty
 
 		s := g.config.getScraper(*g.config.SceneByName, client, g.globalConf)
 		return s.scrapeByName(ctx, name, ty)
+	case ScrapeContentTypeStudio:
+		if g.config.StudioByName == nil {
+			break
+		}
+
+		s := g.config.getScraper(*g.config.StudioByName, client, g.globalConf)
+		return s.scrapeByName(ctx, name, ty)
+	case ScrapeContentTypeTag:
+		if g.config.TagByName == nil {
+			break
+		}
+
+		s := g.config.getScraper(*g.config.TagByName, client, g.globalConf)
+		return s.scrapeByName(ctx, name, ty)
 	}
 
 	return nil, fmt.Errorf("%w: cannot load %v by name", ErrNotSupported, ty)
@@ -0,0 +1,316 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const fixturesDir = "fixtures"
+
+// ScraperTestInput describes a run of a scraper against fixture files.
+type ScraperTestInput struct {
+	ScraperID   string            `json:"scraper_id"`
+	ContentType ScrapeContentType `json:"content_type"`
+	// URL to scrape. Exactly one of URL and Query must be set.
+	URL *string `json:"url"`
+	// Name to query. Exactly one of URL and Query must be set.
+	Query *string `json:"query"`
+	// Directory of the fixture files, relative to the scrapers directory.
+	// Defaults to fixtures/<scraper id> next to the scraper configuration.
+	FixturesPath *string `json:"fixtures_path"`
+	// If true, live responses are fetched and written to the fixture files.
+	Record *bool `json:"record"`
+	// Expected values of the first result, keyed by field path.
+	Expected map[string]interface{} `json:"expected"`
+}
+
+type ScraperTestMismatch struct {
+	Field    string  `json:"field"`
+	Expected string  `json:"expected"`
+	Actual   *string `json:"actual"`
+}
+
+type ScraperTestResult struct {
+	Results    []ScrapedContent       `json:"results"`
+	Fields     []*FieldTrace          `json:"fields"`
+	Mismatches []*ScraperTestMismatch `json:"mismatches"`
+	Passed     bool                   `json:"passed"`
+	// Fixture files read, or written when recording
+	Fixtures []string `json:"fixtures"`
+}
+
+func (c Cache) fixturesPath(conf config, input ScraperTestInput) (string, error) {
+	scrapersPath, err := filepath.Abs(c.globalConfig.GetScrapersPath())
+	if err != nil {
+		return "", err
+	}
+
+	var ret string
+	switch {
+	case input.FixturesPath != nil:
+		ret = *input.FixturesPath
+		if !filepath.IsAbs(ret) {
+			ret = filepath.Join(scrapersPath, ret)
+		}
+	case conf.path != "":
+		ret = filepath.Join(filepath.Dir(conf.path), fixturesDir, conf.ID)
+	default:
+		return "", errors.New("fixtures_path must be set")
+	}
+
+	ret, err = filepath.Abs(ret)
+	if err != nil {
+		return "", err
+	}
+
+	// fixtures may be written, so keep them inside the scrapers directory
+	rel, err := filepath.Rel(scrapersPath, ret)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("fixtures path %s is not inside the scrapers directory", ret)
+	}
+
+	return ret, nil
+}
+
+// checkTestable returns an error if the scrape of the input uses an action
+// whose requests cannot be read from the fixture files. Scripts run in a
+// separate process, and stash scrapers use their own client.
+func (c config) checkTestable(input ScraperTestInput) error {
+	var used []scraperTypeConfig
+	if input.URL != nil {
+		for _, u := range loadUrlCandidates(c, input.ContentType) {
+			if u.matchesURL(*input.URL) {
+				used = append(used, u.scraperTypeConfig)
+			}
+		}
+	} else {
+		var byName *scraperTypeConfig
+		switch input.ContentType {
+		case ScrapeContentTypePerformer:
+			byName = c.PerformerByName
+		case ScrapeContentTypeScene:
+			byName = c.SceneByName
+		case ScrapeContentTypeStudio:
+			byName = c.StudioByName
+		case ScrapeContentTypeTag:
+			byName = c.TagByName
+		}
+
+		if byName != nil {
+			used = append(used, *byName)
+		}
+	}
+
+	for _, u := range used {
+		if u.Action == scraperActionScript || u.Action == scraperActionStash {
+			return fmt.Errorf("%w: %s scrapers cannot be tested with fixtures", ErrNotSupported, u.Action)
+		}
+	}
+
+	return nil
+}
+
+// TestScraper runs a scraper against fixture files instead of the live site,
+// recording how each mapped field was extracted and comparing the first
+// result against the expected values.
+func (c Cache) TestScraper(ctx context.Context, input ScraperTestInput) (*ScraperTestResult, error) {
+	if (input.URL == nil) == (input.Query == nil) {
+		return nil, errors.New("exactly one of url and query must be set")
+	}
+
+	s := c.findScraper(input.ScraperID)
+	if s == nil {
+		return nil, fmt.Errorf("%w: id %s", ErrNotFound, input.ScraperID)
+	}
+
+	g, ok := s.(group)
+	if !ok {
+		return nil, fmt.Errorf("%w: only configured scrapers can be tested", ErrNotSupported)
+	}
+
+	record := input.Record != nil && *input.Record
+	conf := g.config
+
+	if err := conf.checkTestable(input); err != nil {
+		return nil, err
+	}
+
+	if conf.DriverOptions != nil {
+		if conf.DriverOptions.UseCDP && record {
+			return nil, fmt.Errorf("%w: cannot record responses of a CDP scraper, save the rendered page as a fixture instead", ErrNotSupported)
+		}
+
+		// fixtures stand in for the network, so caching, rate limiting,
+		// logging in and the browser are not used
+		driverOptions := *conf.DriverOptions
+		driverOptions.UseCDP = false
+		driverOptions.Cache = nil
+		driverOptions.RateLimit = nil
+		driverOptions.Login = nil
+		conf.DriverOptions = &driverOptions
+	}
+
+	dir, err := c.fixturesPath(conf, input)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := &fixtureTransport{
+		dir:    dir,
+		record: record,
+		next:   c.client.Transport,
+	}
+
+	client := *c.client
+	client.Transport = transport
+
+	testGroup := group{
+		config:     conf,
+		globalConf: c.globalConfig,
+	}
+
+	trace := &scrapeTrace{}
+	ctx = withScrapeTrace(ctx, trace)
+
+	ret := &ScraperTestResult{
+		Results:    []ScrapedContent{},
+		Mismatches: []*ScraperTestMismatch{},
+		Fixtures:   []string{},
+	}
+	if input.URL != nil {
+		if !conf.matchesURL(*input.URL, input.ContentType) {
+			return nil, fmt.Errorf("%w: no %s URL configuration matches %s", ErrNotSupported, strings.ToLower(input.ContentType.String()), *input.URL)
+		}
+
+		var content ScrapedContent
+		content, err = testGroup.viaURL(ctx, &client, *input.URL, input.ContentType)
+		if content != nil {
+			ret.Results = []ScrapedContent{content}
+		}
+	} else {
+		var content []ScrapedContent
+		content, err = testGroup.viaName(ctx, &client, *input.Query, input.ContentType)
+		ret.Results = append(ret.Results, content...)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// mapped fields are processed in map order
+	ret.Fields = append([]*FieldTrace{}, trace.fields...)
+	sort.SliceStable(ret.Fields, func(i, j int) bool {
+		if ret.Fields[i].Field != ret.Fields[j].Field {
+			return ret.Fields[i].Field < ret.Fields[j].Field
+		}
+		return ret.Fields[i].Selector < ret.Fields[j].Selector
+	})
+	if record {
+		ret.Fixtures = append(ret.Fixtures, transport.recorded...)
+	} else {
+		ret.Fixtures = append(ret.Fixtures, transport.used...)
+	}
+
+	var first ScrapedContent
+	if len(ret.Results) > 0 {
+		first = ret.Results[0]
+	}
+
+	mismatches, err := compareExpected(first, input.Expected)
+	if err != nil {
+		return nil, err
+	}
+	ret.Mismatches = append(ret.Mismatches, mismatches...)
+	ret.Passed = len(ret.Mismatches) == 0
+
+	return ret, nil
+}
+
+// flattenContent returns the fields of the scraped content keyed by their
+// path, such as "title", "studio.name" or "tags.0.name".
+func flattenContent(content ScrapedContent) (map[string]string, error) {
+	ret := make(map[string]string)
+	if content == nil {
+		return ret, nil
+	}
+
+	data, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+
+	var flatten func(prefix string, v interface{})
+	flatten = func(prefix string, v interface{}) {
+		join := func(key string) string {
+			if prefix == "" {
+				return key
+			}
+			return prefix + "." + key
+		}
+
+		switch vv := v.(type) {
+		case nil:
+		case map[string]interface{}:
+			for k, e := range vv {
+				flatten(join(k), e)
+			}
+		case []interface{}:
+			for i, e := range vv {
+				flatten(join(strconv.Itoa(i)), e)
+			}
+		default:
+			ret[prefix] = fmt.Sprint(vv)
+		}
+	}
+
+	flatten("", v)
+	return ret, nil
+}
+
+func compareExpected(content ScrapedContent, expected map[string]interface{}) ([]*ScraperTestMismatch, error) {
+	if len(expected) == 0 {
+		return nil, nil
+	}
+
+	actual, err := flattenContent(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []*ScraperTestMismatch
+	for field, e := range expected {
+		want := fmt.Sprint(e)
+		got, found := actual[field]
+
+		if !found {
+			ret = append(ret, &ScraperTestMismatch{
+				Field:    field,
+				Expected: want,
+			})
+		} else if got != want {
+			got := got
+			ret = append(ret, &ScraperTestMismatch{
+				Field:    field,
+				Expected: want,
+				Actual:   &got,
+			})
+		}
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Field < ret[j].Field
+	})
+
+	return ret, nil
+}
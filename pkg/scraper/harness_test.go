@@ -0,0 +1,163 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+type scrapersPathGlobalConfig struct {
+	mockGlobalConfig
+	scrapersPath string
+}
+
+func (c scrapersPathGlobalConfig) GetScrapersPath() string {
+	return c.scrapersPath
+}
+
+func TestTestScraper(t *testing.T) {
+	const yamlStr = `name: Test
+sceneByURL:
+  - action: scrapeXPath
+    url:
+      - example.com/scenes/
+    scraper: sceneScraper
+sceneByName:
+  action: script
+  script:
+    - python
+    - test.py
+xPathScrapers:
+  sceneScraper:
+    scene:
+      Title:
+        selector: //h1
+        postProcess:
+          - replace:
+              - regex: \s*-\s*Example$
+                with: ""
+      Studio:
+        Name:
+          fixed: Example Studio
+`
+
+	const sceneURL = "https://example.com/scenes/1"
+	const page = `<html><body><h1>A Scene - Example</h1></body></html>`
+
+	dir := t.TempDir()
+	ymlPath := filepath.Join(dir, "test.yml")
+	if err := os.WriteFile(ymlPath, []byte(yamlStr), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fixtures := filepath.Join(dir, fixturesDir, "test")
+	if err := os.MkdirAll(fixtures, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(fixtures, fixtureName(sceneURL)), []byte(page), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	conf, err := loadConfigFromYAMLFile(ymlPath)
+	if err != nil {
+		t.Fatalf("loading config: %v", err)
+	}
+
+	gc := scrapersPathGlobalConfig{scrapersPath: dir}
+	c := Cache{
+		client:       newClient(gc),
+		globalConfig: gc,
+		scrapers: map[string]scraper{
+			conf.ID: newGroupScraper(*conf, gc),
+		},
+	}
+
+	u := sceneURL
+	result, err := c.TestScraper(context.Background(), ScraperTestInput{
+		ScraperID:   "test",
+		ContentType: ScrapeContentTypeScene,
+		URL:         &u,
+		Expected: map[string]interface{}{
+			"title":       "A Scene",
+			"studio.name": "Other Studio",
+			"details":     "missing",
+		},
+	})
+	if err != nil {
+		t.Fatalf("TestScraper: %v", err)
+	}
+
+	if len(result.Results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(result.Results))
+	}
+	if len(result.Fixtures) != 1 {
+		t.Errorf("expected 1 fixture, got %v", result.Fixtures)
+	}
+
+	if !sort.SliceIsSorted(result.Fields, func(i, j int) bool {
+		return result.Fields[i].Field < result.Fields[j].Field
+	}) {
+		t.Errorf("expected field traces to be sorted")
+	}
+
+	var title, studioName *FieldTrace
+	for _, f := range result.Fields {
+		switch f.Field {
+		case "Title":
+			title = f
+		case "Studio.Name":
+			studioName = f
+		}
+	}
+	if title == nil {
+		t.Fatal("expected Title field trace")
+	}
+	if studioName == nil || studioName.Fixed == nil || *studioName.Fixed != "Example Studio" {
+		t.Errorf("expected Studio.Name field trace, got %+v", studioName)
+	}
+	if len(title.Found) != 1 || title.Found[0] != "A Scene - Example" {
+		t.Errorf("Title found: %v", title.Found)
+	}
+	if len(title.Steps) != 1 || title.Steps[0].Action != "replace" || title.Steps[0].Output != "A Scene" {
+		t.Errorf("Title steps: %+v", title.Steps)
+	}
+
+	if result.Passed {
+		t.Error("expected test to fail")
+	}
+	if len(result.Mismatches) != 2 {
+		t.Fatalf("expected 2 mismatches, got %d", len(result.Mismatches))
+	}
+
+	details, studio := result.Mismatches[0], result.Mismatches[1]
+	if details.Field != "details" || details.Actual != nil {
+		t.Errorf("unexpected mismatch: %+v", details)
+	}
+	if studio.Field != "studio.name" || studio.Actual == nil || *studio.Actual != "Example Studio" {
+		t.Errorf("unexpected mismatch: %+v", studio)
+	}
+
+	// fixtures outside the scrapers directory are rejected
+	outside := filepath.Join(dir, "..")
+	if _, err := c.TestScraper(context.Background(), ScraperTestInput{
+		ScraperID:    "test",
+		ContentType:  ScrapeContentTypeScene,
+		URL:          &u,
+		FixturesPath: &outside,
+	}); err == nil {
+		t.Error("expected error for fixtures path outside the scrapers directory")
+	}
+
+	// scripts cannot read the fixtures
+	query := "A Scene"
+	if _, err := c.TestScraper(context.Background(), ScraperTestInput{
+		ScraperID:   "test",
+		ContentType: ScrapeContentTypeScene,
+		Query:       &query,
+	}); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported for script scraper, got %v", err)
+	}
+}
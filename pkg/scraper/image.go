@@ -11,6 +11,25 @@ import (
 	"github.com/stashapp/stash/pkg/utils"
 )
 
+type ScrapedImage struct {
+	Title      *string                    `json:"title"`
+	Details    *string                    `json:"details"`
+	URL        *string                    `json:"url"`
+	Date       *string                    `json:"date"`
+	Studio     *models.ScrapedStudio      `json:"studio"`
+	Tags       []*models.ScrapedTag       `json:"tags"`
+	Performers []*models.ScrapedPerformer `json:"performers"`
+}
+
+func (ScrapedImage) IsScrapedContent() {}
+
+type ScrapedImageInput struct {
+	Title   *string `json:"title"`
+	Details *string `json:"details"`
+	URL     *string `json:"url"`
+	Date    *string `json:"date"`
+}
+
 func setPerformerImage(ctx context.Context, client *http.Client, p *models.ScrapedPerformer, globalConfig GlobalConfig) error {
 	if p.Image == nil || !strings.HasPrefix(*p.Image, "http") {
 		// nothing to do
@@ -80,6 +99,40 @@ func setMovieBackImage(ctx context.Context, client *http.Client, m *models.Scrap
 	return nil
 }
 
+func setStudioImage(ctx context.Context, client *http.Client, s *models.ScrapedStudio, globalConfig GlobalConfig) error {
+	// don't try to get the image if it doesn't appear to be a URL
+	if s.Image == nil || !strings.HasPrefix(*s.Image, "http") {
+		// nothing to do
+		return nil
+	}
+
+	img, err := getImage(ctx, *s.Image, client, globalConfig)
+	if err != nil {
+		return err
+	}
+
+	s.Image = img
+
+	return nil
+}
+
+func setTagImage(ctx context.Context, client *http.Client, t *models.ScrapedTag, globalConfig GlobalConfig) error {
+	// don't try to get the image if it doesn't appear to be a URL
+	if t.Image == nil || !strings.HasPrefix(*t.Image, "http") {
+		// nothing to do
+		return nil
+	}
+
+	img, err := getImage(ctx, *t.Image, client, globalConfig)
+	if err != nil {
+		return err
+	}
+
+	t.Image = img
+
+	return nil
+}
+
 func getImage(ctx context.Context, url string, client *http.Client, globalConfig GlobalConfig) (*string, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
@@ -98,6 +151,12 @@ func getImage(ctx context.Context, url string, client *http.Client, globalConfig
 		req.Header.Set("Referer", req.URL.Scheme+"://"+req.Host+"/")
 	}
 
+	release, err := waitDomainRateLimit(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -0,0 +1,332 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/robertkrimen/otto"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/plugin/js"
+)
+
+// jsScraperTimeout is the maximum time a JavaScript scraper may run.
+const jsScraperTimeout = 2 * time.Minute
+
+var errJSInterrupted = errors.New("javascript scraper interrupted")
+
+// jsScraper runs scraper scripts written in JavaScript in-process. Scripts
+// take the same input and return the same output as script scrapers: the
+// input is available as the input global, and the value of the last
+// statement of the script is the result.
+type jsScraper struct {
+	scraper      scraperTypeConfig
+	config       config
+	globalConfig GlobalConfig
+	client       *http.Client
+}
+
+func newJSScraper(scraper scraperTypeConfig, client *http.Client, config config, globalConfig GlobalConfig) *scriptScraper {
+	s := &jsScraper{
+		scraper:      scraper,
+		config:       config,
+		globalConfig: globalConfig,
+		client:       client,
+	}
+
+	return &scriptScraper{
+		scraper:      scraper,
+		config:       config,
+		globalConfig: globalConfig,
+		run:          s.run,
+	}
+}
+
+func (s *jsScraper) scriptPath() string {
+	return filepath.Join(filepath.Dir(s.config.path), s.scraper.Script[0])
+}
+
+func (s *jsScraper) run(ctx context.Context, inString string, out interface{}) error {
+	src, err := os.ReadFile(s.scriptPath())
+	if err != nil {
+		return fmt.Errorf("reading scraper script: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, jsScraperTimeout)
+	defer cancel()
+
+	vm, err := s.newVM(ctx, inString)
+	if err != nil {
+		return err
+	}
+
+	script, err := vm.Compile(s.scraper.Script[0], src)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrScraperScript, err)
+	}
+
+	logger.Debugf("JavaScript scraper <%s> started", s.scraper.Script[0])
+
+	value, err := runJS(ctx, vm, script)
+	if errors.Is(err, errJSInterrupted) {
+		return err
+	} else if err != nil {
+		return fmt.Errorf("%w: %v", ErrScraperScript, err)
+	}
+
+	logger.Debugf("JavaScript scraper finished")
+
+	return decodeJSResult(value, out)
+}
+
+func (s *jsScraper) newVM(ctx context.Context, inString string) (*otto.Otto, error) {
+	vm := otto.New()
+
+	var input interface{}
+	if err := json.Unmarshal([]byte(inString), &input); err != nil {
+		return nil, fmt.Errorf("decoding input: %w", err)
+	}
+
+	if err := vm.Set("input", input); err != nil {
+		return nil, fmt.Errorf("error setting input: %w", err)
+	}
+
+	// remaining script arguments are passed to the script
+	if err := vm.Set("args", s.scraper.Script[1:]); err != nil {
+		return nil, fmt.Errorf("error setting args: %w", err)
+	}
+
+	const scraperPrefix = "[Scrape / %s] "
+	if err := js.AddPrefixedLogAPI(vm, fmt.Sprintf(scraperPrefix, s.config.Name), nil); err != nil {
+		return nil, fmt.Errorf("error adding log API: %w", err)
+	}
+
+	if err := js.AddUtilAPI(vm); err != nil {
+		return nil, fmt.Errorf("error adding util API: %w", err)
+	}
+
+	apis := map[string]map[string]func(call otto.FunctionCall) otto.Value{
+		"http": {
+			"Get":  s.httpGet(ctx, vm),
+			"Post": s.httpPost(ctx, vm),
+		},
+		"html": {
+			"XPath": htmlXPath(vm),
+		},
+		"json": {
+			"Get": jsonGet(vm),
+		},
+		"regex": {
+			"Match":   regexMatch(vm),
+			"Replace": regexReplace(vm),
+		},
+	}
+
+	for name, funcs := range apis {
+		obj, _ := vm.Object("({})")
+		for fn, f := range funcs {
+			if err := obj.Set(fn, f); err != nil {
+				return nil, fmt.Errorf("error setting %s.%s: %w", name, fn, err)
+			}
+		}
+
+		if err := vm.Set(name, obj); err != nil {
+			return nil, fmt.Errorf("unable to set %s: %w", name, err)
+		}
+	}
+
+	return vm, nil
+}
+
+// runJS runs the script, interrupting it when the context is done.
+func runJS(ctx context.Context, vm *otto.Otto, script *otto.Script) (value otto.Value, err error) {
+	vm.Interrupt = make(chan func(), 1)
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			vm.Interrupt <- func() {
+				panic(errJSInterrupted)
+			}
+		case <-done:
+		}
+	}()
+
+	defer func() {
+		if caught := recover(); caught != nil {
+			if caught == errJSInterrupted {
+				err = fmt.Errorf("%w: %v", errJSInterrupted, ctx.Err())
+				return
+			}
+			panic(caught)
+		}
+	}()
+
+	return vm.Run(script)
+}
+
+// decodeJSResult decodes the value returned by the script into out.
+func decodeJSResult(value otto.Value, out interface{}) error {
+	if value.IsUndefined() || value.IsNull() {
+		return nil
+	}
+
+	exported, err := value.Export()
+	if err != nil {
+		return fmt.Errorf("exporting script result: %w", err)
+	}
+
+	data, err := json.Marshal(exported)
+	if err != nil {
+		return fmt.Errorf("encoding script result: %w", err)
+	}
+
+	if err := decodeScriptResult(bytes.NewReader(data), out); err != nil {
+		return fmt.Errorf("could not unmarshal script result: %w", err)
+	}
+
+	return nil
+}
+
+func jsThrow(vm *otto.Otto, str string) {
+	value, _ := vm.Call("new Error", nil, str)
+	panic(value)
+}
+
+func jsValue(vm *otto.Otto, v interface{}) otto.Value {
+	ret, err := vm.ToValue(v)
+	if err != nil {
+		jsThrow(vm, fmt.Sprintf("could not create return value: %v", err))
+	}
+	return ret
+}
+
+// httpGet returns a function which loads a URL in the same way as the other
+// scrapers, returning the response body.
+func (s *jsScraper) httpGet(ctx context.Context, vm *otto.Otto) func(call otto.FunctionCall) otto.Value {
+	return func(call otto.FunctionCall) otto.Value {
+		url := call.Argument(0).String()
+
+		r, err := loadURL(ctx, url, s.client, s.config, s.globalConfig)
+		if err != nil {
+			jsThrow(vm, fmt.Sprintf("loading %s: %v", url, err))
+		}
+
+		body, err := io.ReadAll(r)
+		if err != nil {
+			jsThrow(vm, fmt.Sprintf("reading %s: %v", url, err))
+		}
+
+		return jsValue(vm, string(body))
+	}
+}
+
+// httpPost returns a function which posts the body to a URL with the given
+// content type, returning the response body. Requests are rate limited and
+// use the login session like other requests, but are not cached.
+func (s *jsScraper) httpPost(ctx context.Context, vm *otto.Otto) func(call otto.FunctionCall) otto.Value {
+	return func(call otto.FunctionCall) otto.Value {
+		url := call.Argument(0).String()
+		body := call.Argument(1).String()
+
+		contentType := "application/json"
+		if ct := call.Argument(2); ct.IsString() {
+			contentType = ct.String()
+		}
+
+		respBody, err := postURL(ctx, url, body, contentType, s.client, s.config, s.globalConfig)
+		if err != nil {
+			jsThrow(vm, fmt.Sprintf("posting to %s: %v", url, err))
+		}
+
+		return jsValue(vm, string(respBody))
+	}
+}
+
+// htmlXPath returns a function which returns the text of the nodes of an
+// HTML document matching an xpath selector.
+func htmlXPath(vm *otto.Otto) func(call otto.FunctionCall) otto.Value {
+	return func(call otto.FunctionCall) otto.Value {
+		doc, err := htmlquery.Parse(strings.NewReader(call.Argument(0).String()))
+		if err != nil {
+			jsThrow(vm, fmt.Sprintf("parsing html: %v", err))
+		}
+
+		q := &xpathQuery{doc: doc}
+		ret, err := q.runQuery(call.Argument(1).String())
+		if err != nil {
+			jsThrow(vm, err.Error())
+		}
+
+		if ret == nil {
+			ret = []string{}
+		}
+
+		return jsValue(vm, ret)
+	}
+}
+
+// jsonGet returns a function which returns the values of a JSON document
+// matching a selector, using the same syntax as JSON scrapers.
+func jsonGet(vm *otto.Otto) func(call otto.FunctionCall) otto.Value {
+	return func(call otto.FunctionCall) otto.Value {
+		q := &jsonQuery{doc: call.Argument(0).String()}
+		ret, err := q.runQuery(call.Argument(1).String())
+		if err != nil {
+			jsThrow(vm, err.Error())
+		}
+
+		if ret == nil {
+			ret = []string{}
+		}
+
+		return jsValue(vm, ret)
+	}
+}
+
+func compileRegex(vm *otto.Otto, call otto.FunctionCall) *regexp.Regexp {
+	re, err := regexp.Compile(call.Argument(0).String())
+	if err != nil {
+		jsThrow(vm, fmt.Sprintf("compiling regex: %v", err))
+	}
+	return re
+}
+
+// regexMatch returns a function which returns the first match of a regex
+// and its submatches, or null if the regex does not match. Regexes use Go
+// syntax, which supports expressions which JavaScript regexes do not.
+func regexMatch(vm *otto.Otto) func(call otto.FunctionCall) otto.Value {
+	return func(call otto.FunctionCall) otto.Value {
+		re := compileRegex(vm, call)
+
+		ret := re.FindStringSubmatch(call.Argument(1).String())
+		if ret == nil {
+			return otto.NullValue()
+		}
+
+		return jsValue(vm, ret)
+	}
+}
+
+// regexReplace returns a function which replaces all matches of a regex,
+// expanding $1 style references to submatches.
+func regexReplace(vm *otto.Otto) func(call otto.FunctionCall) otto.Value {
+	return func(call otto.FunctionCall) otto.Value {
+		re := compileRegex(vm, call)
+		return jsValue(vm, re.ReplaceAllString(call.Argument(1).String(), call.Argument(2).String()))
+	}
+}
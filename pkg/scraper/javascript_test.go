@@ -0,0 +1,200 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const testJSPerformerHTML = `<html><body>
+<h1 class="name"> Jane  Doe </h1>
+<div class="bio">Height: 170cm</div>
+<script id="data">{"aliases": ["JD", "Janey"]}</script>
+</body></html>`
+
+// writeJSScraper writes a scrapeJS scraper using the script to a temporary
+// directory and returns its config.
+func writeJSScraper(t *testing.T, script string) *config {
+	t.Helper()
+
+	dir := t.TempDir()
+	const yamlStr = `name: Test
+performerByURL:
+  - action: scrapeJS
+    url:
+      - example.com
+    script:
+      - scraper.js
+performerByName:
+  action: scrapeJS
+  script:
+    - scraper.js
+    - byName
+`
+
+	if err := os.WriteFile(filepath.Join(dir, "scraper.js"), []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	yamlPath := filepath.Join(dir, "test.yml")
+	if err := os.WriteFile(yamlPath, []byte(yamlStr), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := loadConfigFromYAMLFile(yamlPath)
+	if err != nil {
+		t.Fatalf("loading config: %v", err)
+	}
+
+	return c
+}
+
+func jsByURLScraper(c *config) scraperActionImpl {
+	return c.getScraper(c.PerformerByURL[0].scraperTypeConfig, http.DefaultClient, mockGlobalConfig{})
+}
+
+func TestJSScraper_scrapeByURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testJSPerformerHTML)
+	}))
+	defer server.Close()
+
+	s := jsByURLScraper(writeJSScraper(t, `
+var doc = http.Get(input.url.replace("example.com", "`+server.Listener.Addr().String()+`"));
+var data = html.XPath(doc, "//script[@id='data']")[0];
+var height = regex.Match("Height: (\\d+)cm", html.XPath(doc, "//div[@class='bio']")[0]);
+log.Debug("scraped " + input.url);
+
+({
+	name: html.XPath(doc, "//h1[@class='name']")[0],
+	height: height[1],
+	aliases: json.Get(data, "aliases").join(", "),
+	url: input.url
+});
+`))
+
+	url := "http://example.com/performer"
+	got, err := s.scrapeByURL(context.Background(), url, ScrapeContentTypePerformer)
+	if err != nil {
+		t.Fatalf("scrapeByURL() error = %v", err)
+	}
+
+	p, ok := got.(*models.ScrapedPerformer)
+	if !ok || p == nil {
+		t.Fatalf("expected scraped performer, got %v", got)
+	}
+
+	verifyField(t, "Jane Doe", p.Name, "Name")
+	verifyField(t, "170", p.Height, "Height")
+	verifyField(t, "JD, Janey", p.Aliases, "Aliases")
+	verifyField(t, url, p.URL, "URL")
+}
+
+func TestJSScraper_scrapeByName(t *testing.T) {
+	c := writeJSScraper(t, `
+if (args[0] !== "byName") {
+	throw new Error("unexpected args: " + args);
+}
+
+[1, 2].map(function(i) {
+	return { name: regex.Replace("\\s+", input.name, "_") + i };
+});
+`)
+	s := c.getScraper(*c.PerformerByName, http.DefaultClient, mockGlobalConfig{})
+
+	got, err := s.scrapeByName(context.Background(), "jane doe", ScrapeContentTypePerformer)
+	if err != nil {
+		t.Fatalf("scrapeByName() error = %v", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(got))
+	}
+
+	verifyField(t, "jane_doe2", got[1].(*models.ScrapedPerformer).Name, "Name")
+}
+
+func TestJSScraper_httpPost(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" || string(body) != `{"q":"jane"}` {
+			t.Errorf("unexpected request: %s %s %s", r.Method, r.Header.Get("Content-Type"), body)
+		}
+
+		fmt.Fprintf(w, `{"name": "Jane %d"}`, requests)
+	}))
+	defer server.Close()
+
+	c := writeJSScraper(t, `
+var url = "`+server.URL+`";
+[1, 2].map(function() {
+	return { name: json.Get(http.Post(url, JSON.stringify({ q: input.name })), "name")[0] };
+});
+`)
+	s := c.getScraper(*c.PerformerByName, http.DefaultClient, mockGlobalConfig{})
+
+	got, err := s.scrapeByName(context.Background(), "jane", ScrapeContentTypePerformer)
+	if err != nil {
+		t.Fatalf("scrapeByName() error = %v", err)
+	}
+
+	// responses to POST requests are not cached
+	if len(got) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(got))
+	}
+	verifyField(t, "Jane 2", got[1].(*models.ScrapedPerformer).Name, "Name")
+}
+
+func TestJSScraper_errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		timeout time.Duration
+		wantErr error
+	}{
+		{
+			"syntax error",
+			"({",
+			time.Second,
+			ErrScraperScript,
+		},
+		{
+			"thrown error",
+			`throw new Error("failed");`,
+			time.Second,
+			ErrScraperScript,
+		},
+		{
+			"timeout",
+			"while (true) {}",
+			100 * time.Millisecond,
+			errJSInterrupted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := jsByURLScraper(writeJSScraper(t, tt.script))
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			_, err := s.scrapeByURL(ctx, "http://example.com", ScrapeContentTypePerformer)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("scrapeByURL() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -88,8 +88,12 @@ func (s *jsonScraper) scrapeByURL(ctx context.Context, url string, ty ScrapeCont
 		return scraper.scrapeScene(ctx, q)
 	case ScrapeContentTypeGallery:
 		return scraper.scrapeGallery(ctx, q)
+	case ScrapeContentTypeImage:
+		return scraper.scrapeImage(ctx, q)
 	case ScrapeContentTypeMovie:
 		return scraper.scrapeMovie(ctx, q)
+	case ScrapeContentTypeStudio:
+		return scraper.scrapeStudio(ctx, q)
 	}
 
 	return nil, ErrNotSupported
@@ -142,6 +146,28 @@ func (s *jsonScraper) scrapeByName(ctx context.Context, name string, ty ScrapeCo
 			content = append(content, s)
 		}
 
+		return content, nil
+	case ScrapeContentTypeStudio:
+		studios, err := scraper.scrapeStudios(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range studios {
+			content = append(content, s)
+		}
+
+		return content, nil
+	case ScrapeContentTypeTag:
+		tags, err := scraper.scrapeTags(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range tags {
+			content = append(content, t)
+		}
+
 		return content, nil
 	}
 
@@ -176,6 +202,8 @@ func (s *jsonScraper) scrapeByFragment(ctx context.Context, input Input) (Scrape
 	switch {
 	case input.Gallery != nil:
 		return nil, fmt.Errorf("%w: cannot use a json scraper as a gallery fragment scraper", ErrNotSupported)
+	case input.Image != nil:
+		return nil, fmt.Errorf("%w: cannot use a json scraper as an image fragment scraper", ErrNotSupported)
 	case input.Performer != nil:
 		return nil, fmt.Errorf("%w: cannot use a json scraper as a performer fragment scraper", ErrNotSupported)
 	case input.Scene == nil:
@@ -231,6 +259,30 @@ func (s *jsonScraper) scrapeGalleryByGallery(ctx context.Context, gallery *model
 	return scraper.scrapeGallery(ctx, q)
 }
 
+func (s *jsonScraper) scrapeImageByImage(ctx context.Context, image *models.Image) (*ScrapedImage, error) {
+	// construct the URL
+	queryURL := queryURLParametersFromImage(image)
+	if s.scraper.QueryURLReplacements != nil {
+		queryURL.applyReplacements(s.scraper.QueryURLReplacements)
+	}
+	url := queryURL.constructURL(s.scraper.QueryURL)
+
+	scraper := s.getJsonScraper()
+
+	if scraper == nil {
+		return nil, errors.New("json scraper with name " + s.scraper.Scraper + " not found in config")
+	}
+
+	doc, err := s.loadURL(ctx, url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	q := s.getJsonQuery(doc)
+	return scraper.scrapeImage(ctx, q)
+}
+
 func (s *jsonScraper) getJsonQuery(doc string) *jsonQuery {
 	return &jsonQuery{
 		doc:     doc,
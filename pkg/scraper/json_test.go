@@ -116,3 +116,216 @@ jsonScrapers:
 		t.Errorf("expected nil scraped performer when not found, got %v", scrapedPerformer)
 	}
 }
+
+func TestJsonImageScraper(t *testing.T) {
+	const yamlStr = `name: Test
+imageByURL:
+  - action: scrapeJson
+    url:
+      - example.com/photos/
+    scraper: imageScraper
+jsonScrapers:
+  imageScraper:
+    image:
+      Title: data.title
+      Details: data.description
+      Date: data.taken
+      Studio:
+        Name: data.site
+      Performers:
+        Name: data.models.#.name
+      Tags:
+        Name: data.keywords
+`
+
+	const json = `
+{
+	"data": {
+		"title": "Beach at sunset",
+		"description": "Taken on the last day of the trip",
+		"taken": "2021-06-05",
+		"site": "Example Studio",
+		"models": [
+			{ "name": "Jane Doe" },
+			{ "name": "John Doe" }
+		],
+		"keywords": ["Outdoors", "Sunset"]
+	}
+}
+`
+
+	c := &config{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	if !c.supports(ScrapeContentTypeImage) {
+		t.Error("expected scraper to support images")
+	}
+	if !c.matchesURL("https://example.com/photos/1", ScrapeContentTypeImage) {
+		t.Error("expected scraper to match image URL")
+	}
+	if spec := c.spec(); spec.Image == nil || len(spec.Image.SupportedScrapes) != 1 || spec.Image.SupportedScrapes[0] != ScrapeTypeURL {
+		t.Errorf("unexpected image spec: %v", spec.Image)
+	}
+
+	q := &jsonQuery{
+		doc: json,
+	}
+
+	scrapedImage, err := c.JsonScrapers["imageScraper"].scrapeImage(context.Background(), q)
+	if err != nil {
+		t.Fatalf("Error scraping image: %s", err.Error())
+	}
+
+	verifyField(t, "Beach at sunset", scrapedImage.Title, "Title")
+	verifyField(t, "Taken on the last day of the trip", scrapedImage.Details, "Details")
+	verifyField(t, "2021-06-05", scrapedImage.Date, "Date")
+
+	if scrapedImage.Studio == nil {
+		t.Fatal("expected studio")
+	}
+	if scrapedImage.Studio.Name != "Example Studio" {
+		t.Errorf("Studio: want %q, got %q", "Example Studio", scrapedImage.Studio.Name)
+	}
+
+	if len(scrapedImage.Performers) != 2 {
+		t.Fatalf("expected 2 performers, got %d", len(scrapedImage.Performers))
+	}
+	verifyField(t, "Jane Doe", scrapedImage.Performers[0].Name, "Performers[0].Name")
+	verifyField(t, "John Doe", scrapedImage.Performers[1].Name, "Performers[1].Name")
+
+	if len(scrapedImage.Tags) != 2 {
+		t.Fatalf("expected 2 tags, got %d", len(scrapedImage.Tags))
+	}
+	if scrapedImage.Tags[1].Name != "Sunset" {
+		t.Errorf("Tags[1]: want %q, got %q", "Sunset", scrapedImage.Tags[1].Name)
+	}
+}
+
+func TestJsonStudioTagScraper(t *testing.T) {
+	const yamlStr = `name: Test
+studioByName:
+  action: scrapeJson
+  queryURL: https://example.com/api/studios?q={}
+  scraper: studioScraper
+studioByURL:
+  - action: scrapeJson
+    url:
+      - example.com/studios/
+    scraper: studioScraper
+tagByName:
+  action: scrapeJson
+  queryURL: https://example.com/api/tags?q={}
+  scraper: tagScraper
+jsonScrapers:
+  studioScraper:
+    studio:
+      Name: data.name
+      URL: data.url
+      Details: data.about
+      Aliases:
+        selector: data.aliases
+        concat: ", "
+      Image: data.logo
+      Parent:
+        Name: data.network.name
+        URL: data.network.url
+  tagScraper:
+    tag:
+      Name: data.name
+      Description: data.description
+      Parents:
+        Name: data.categories
+`
+
+	const studioJSON = `
+{
+	"data": {
+		"name": "Example Studio",
+		"url": "https://example.com/studios/1",
+		"about": "An example studio",
+		"aliases": ["ExS", "Example"],
+		"logo": "https://example.com/logo.png",
+		"network": {
+			"name": "Example Network",
+			"url": "https://example.com/networks/1"
+		}
+	}
+}
+`
+
+	const tagJSON = `
+{
+	"data": {
+		"name": "Outdoors",
+		"description": "Filmed outside",
+		"categories": ["Location", "Setting"]
+	}
+}
+`
+
+	c := &config{}
+	if err := yaml.Unmarshal([]byte(yamlStr), &c); err != nil {
+		t.Fatalf("Error loading yaml: %s", err.Error())
+	}
+
+	if err := c.validate(); err != nil {
+		t.Fatalf("Error validating config: %s", err.Error())
+	}
+
+	if !c.supports(ScrapeContentTypeStudio) || !c.supports(ScrapeContentTypeTag) {
+		t.Error("expected scraper to support studios and tags")
+	}
+	if !c.matchesURL("https://example.com/studios/1", ScrapeContentTypeStudio) {
+		t.Error("expected scraper to match studio URL")
+	}
+	spec := c.spec()
+	if spec.Studio == nil || len(spec.Studio.SupportedScrapes) != 2 {
+		t.Errorf("unexpected studio spec: %v", spec.Studio)
+	}
+	if spec.Tag == nil || len(spec.Tag.SupportedScrapes) != 1 || spec.Tag.SupportedScrapes[0] != ScrapeTypeName {
+		t.Errorf("unexpected tag spec: %v", spec.Tag)
+	}
+
+	studio, err := c.JsonScrapers["studioScraper"].scrapeStudio(context.Background(), &jsonQuery{doc: studioJSON})
+	if err != nil {
+		t.Fatalf("Error scraping studio: %s", err.Error())
+	}
+
+	if studio.Name != "Example Studio" {
+		t.Errorf("Name: want %q, got %q", "Example Studio", studio.Name)
+	}
+	verifyField(t, "https://example.com/studios/1", studio.URL, "URL")
+	verifyField(t, "An example studio", studio.Details, "Details")
+	verifyField(t, "ExS, Example", studio.Aliases, "Aliases")
+	verifyField(t, "https://example.com/logo.png", studio.Image, "Image")
+
+	if studio.Parent == nil {
+		t.Fatal("expected parent studio")
+	}
+	if studio.Parent.Name != "Example Network" {
+		t.Errorf("Parent.Name: want %q, got %q", "Example Network", studio.Parent.Name)
+	}
+	verifyField(t, "https://example.com/networks/1", studio.Parent.URL, "Parent.URL")
+
+	tags, err := c.JsonScrapers["tagScraper"].scrapeTags(context.Background(), &jsonQuery{doc: tagJSON})
+	if err != nil {
+		t.Fatalf("Error scraping tag: %s", err.Error())
+	}
+
+	if len(tags) != 1 {
+		t.Fatalf("expected 1 tag, got %d", len(tags))
+	}
+	if tags[0].Name != "Outdoors" {
+		t.Errorf("Name: want %q, got %q", "Outdoors", tags[0].Name)
+	}
+	verifyField(t, "Filmed outside", tags[0].Description, "Description")
+
+	if len(tags[0].Parents) != 2 {
+		t.Fatalf("expected 2 parents, got %d", len(tags[0].Parents))
+	}
+	if tags[0].Parents[1].Name != "Setting" {
+		t.Errorf("Parents[1]: want %q, got %q", "Setting", tags[0].Parents[1].Name)
+	}
+}
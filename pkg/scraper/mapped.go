@@ -44,12 +44,21 @@ func (s mappedConfig) applyCommon(c commonMappedConfig, src string) string {
 func (s mappedConfig) process(ctx context.Context, q mappedQuery, common commonMappedConfig) mappedResults {
 	var ret mappedResults
 
+	trace := getScrapeTrace(ctx)
+
 	for k, attrConfig := range s {
 
 		if attrConfig.Fixed != "" {
 			// TODO - not sure if this needs to set _all_ indexes for the key
 			const i = 0
 			ret = ret.setKey(i, k, attrConfig.Fixed)
+
+			if trace != nil {
+				fixed := attrConfig.Fixed
+				ft := trace.addField(tracePath(ctx, k), "")
+				ft.Fixed = &fixed
+				ft.Result = []string{fixed}
+			}
 		} else {
 			selector := attrConfig.Selector
 			selector = s.applyCommon(common, selector)
@@ -59,11 +68,21 @@ func (s mappedConfig) process(ctx context.Context, q mappedQuery, common commonM
 				logger.Warnf("key '%v': %v", k, err)
 			}
 
+			var ft *FieldTrace
+			if trace != nil {
+				ft = trace.addField(tracePath(ctx, k), selector)
+				ft.Found = found
+			}
+
 			if len(found) > 0 {
-				result := s.postProcess(ctx, q, attrConfig, found)
+				result := s.postProcess(withFieldTrace(ctx, ft), q, attrConfig, found)
 				for i, text := range result {
 					ret = ret.setKey(i, k, text)
 				}
+
+				if ft != nil {
+					ft.Result = result
+				}
 			}
 		}
 	}
@@ -226,6 +245,60 @@ func (s *mappedGalleryScraperConfig) UnmarshalYAML(unmarshal func(interface{}) e
 	return nil
 }
 
+type mappedImageScraperConfig struct {
+	mappedConfig
+
+	Tags       mappedConfig `yaml:"Tags"`
+	Performers mappedConfig `yaml:"Performers"`
+	Studio     mappedConfig `yaml:"Studio"`
+}
+type _mappedImageScraperConfig mappedImageScraperConfig
+
+func (s *mappedImageScraperConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// HACK - unmarshal to map first, then remove known scene sub-fields, then
+	// remarshal to yaml and pass that down to the base map
+	parentMap := make(map[string]interface{})
+	if err := unmarshal(parentMap); err != nil {
+		return err
+	}
+
+	// move the known sub-fields to a separate map
+	thisMap := make(map[string]interface{})
+
+	thisMap[mappedScraperConfigSceneTags] = parentMap[mappedScraperConfigSceneTags]
+	thisMap[mappedScraperConfigScenePerformers] = parentMap[mappedScraperConfigScenePerformers]
+	thisMap[mappedScraperConfigSceneStudio] = parentMap[mappedScraperConfigSceneStudio]
+
+	delete(parentMap, mappedScraperConfigSceneTags)
+	delete(parentMap, mappedScraperConfigScenePerformers)
+	delete(parentMap, mappedScraperConfigSceneStudio)
+
+	// re-unmarshal the sub-fields
+	yml, err := yaml.Marshal(thisMap)
+	if err != nil {
+		return err
+	}
+
+	// needs to be a different type to prevent infinite recursion
+	c := _mappedImageScraperConfig{}
+	if err := yaml.Unmarshal(yml, &c); err != nil {
+		return err
+	}
+
+	*s = mappedImageScraperConfig(c)
+
+	yml, err = yaml.Marshal(parentMap)
+	if err != nil {
+		return err
+	}
+
+	if err := yaml.Unmarshal(yml, &s.mappedConfig); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 type mappedPerformerScraperConfig struct {
 	mappedConfig
 
@@ -330,6 +403,110 @@ func (s *mappedMovieScraperConfig) UnmarshalYAML(unmarshal func(interface{}) err
 	return nil
 }
 
+type mappedStudioScraperConfig struct {
+	mappedConfig
+
+	Parent mappedConfig `yaml:"Parent"`
+}
+type _mappedStudioScraperConfig mappedStudioScraperConfig
+
+const (
+	mappedScraperConfigStudioParent = "Parent"
+)
+
+func (s *mappedStudioScraperConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// HACK - unmarshal to map first, then remove known studio sub-fields, then
+	// remarshal to yaml and pass that down to the base map
+	parentMap := make(map[string]interface{})
+	if err := unmarshal(parentMap); err != nil {
+		return err
+	}
+
+	// move the known sub-fields to a separate map
+	thisMap := make(map[string]interface{})
+
+	thisMap[mappedScraperConfigStudioParent] = parentMap[mappedScraperConfigStudioParent]
+
+	delete(parentMap, mappedScraperConfigStudioParent)
+
+	// re-unmarshal the sub-fields
+	yml, err := yaml.Marshal(thisMap)
+	if err != nil {
+		return err
+	}
+
+	// needs to be a different type to prevent infinite recursion
+	c := _mappedStudioScraperConfig{}
+	if err := yaml.Unmarshal(yml, &c); err != nil {
+		return err
+	}
+
+	*s = mappedStudioScraperConfig(c)
+
+	yml, err = yaml.Marshal(parentMap)
+	if err != nil {
+		return err
+	}
+
+	if err := yaml.Unmarshal(yml, &s.mappedConfig); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type mappedTagScraperConfig struct {
+	mappedConfig
+
+	Parents mappedConfig `yaml:"Parents"`
+}
+type _mappedTagScraperConfig mappedTagScraperConfig
+
+const (
+	mappedScraperConfigTagParents = "Parents"
+)
+
+func (s *mappedTagScraperConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// HACK - unmarshal to map first, then remove known tag sub-fields, then
+	// remarshal to yaml and pass that down to the base map
+	parentMap := make(map[string]interface{})
+	if err := unmarshal(parentMap); err != nil {
+		return err
+	}
+
+	// move the known sub-fields to a separate map
+	thisMap := make(map[string]interface{})
+
+	thisMap[mappedScraperConfigTagParents] = parentMap[mappedScraperConfigTagParents]
+
+	delete(parentMap, mappedScraperConfigTagParents)
+
+	// re-unmarshal the sub-fields
+	yml, err := yaml.Marshal(thisMap)
+	if err != nil {
+		return err
+	}
+
+	// needs to be a different type to prevent infinite recursion
+	c := _mappedTagScraperConfig{}
+	if err := yaml.Unmarshal(yml, &c); err != nil {
+		return err
+	}
+
+	*s = mappedTagScraperConfig(c)
+
+	yml, err = yaml.Marshal(parentMap)
+	if err != nil {
+		return err
+	}
+
+	if err := yaml.Unmarshal(yml, &s.mappedConfig); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 type mappedRegexConfig struct {
 	Regex string `yaml:"regex"`
 	With  string `yaml:"with"`
@@ -448,6 +625,9 @@ func (p *postProcessSubScraper) Apply(ctx context.Context, value string, q mappe
 	subScrapeConfig := mappedScraperAttrConfig(*p)
 
 	logger.Debugf("Sub-scraping for: %s", value)
+
+	// the sub-scrape is traced as a single step of the parent field
+	ctx = withoutTrace(ctx)
 	ss := q.subScrape(ctx, value)
 
 	if ss != nil {
@@ -716,8 +896,19 @@ func (c mappedScraperAttrConfig) splitString(value string) []string {
 }
 
 func (c mappedScraperAttrConfig) postProcess(ctx context.Context, value string, q mappedQuery) string {
+	ft := getFieldTrace(ctx)
+
 	for _, action := range c.postProcessActions {
+		input := value
 		value = action.Apply(ctx, value, q)
+
+		if ft != nil {
+			ft.Steps = append(ft.Steps, &PostProcessStep{
+				Action: postProcessActionName(action),
+				Input:  input,
+				Output: value,
+			})
+		}
 	}
 
 	return value
@@ -729,8 +920,11 @@ type mappedScraper struct {
 	Common    commonMappedConfig            `yaml:"common"`
 	Scene     *mappedSceneScraperConfig     `yaml:"scene"`
 	Gallery   *mappedGalleryScraperConfig   `yaml:"gallery"`
+	Image     *mappedImageScraperConfig     `yaml:"image"`
 	Performer *mappedPerformerScraperConfig `yaml:"performer"`
 	Movie     *mappedMovieScraperConfig     `yaml:"movie"`
+	Studio    *mappedStudioScraperConfig    `yaml:"studio"`
+	Tag       *mappedTagScraperConfig       `yaml:"tag"`
 }
 
 type mappedResult map[string]string
@@ -791,7 +985,7 @@ func (s mappedScraper) scrapePerformer(ctx context.Context, q mappedQuery) (*mod
 		// now apply the tags
 		if performerTagsMap != nil {
 			logger.Debug(`Processing performer tags:`)
-			tagResults := performerTagsMap.process(ctx, q, s.Common)
+			tagResults := performerTagsMap.process(withTracePath(ctx, "Tags"), q, s.Common)
 
 			for _, p := range tagResults {
 				tag := &models.ScrapedTag{}
@@ -839,13 +1033,13 @@ func (s mappedScraper) processScene(ctx context.Context, q mappedQuery, r mapped
 	// process performer tags once
 	var performerTagResults mappedResults
 	if scenePerformerTagsMap != nil {
-		performerTagResults = scenePerformerTagsMap.process(ctx, q, s.Common)
+		performerTagResults = scenePerformerTagsMap.process(withTracePath(ctx, "Performers.Tags"), q, s.Common)
 	}
 
 	// now apply the performers and tags
 	if scenePerformersMap.mappedConfig != nil {
 		logger.Debug(`Processing scene performers:`)
-		performerResults := scenePerformersMap.process(ctx, q, s.Common)
+		performerResults := scenePerformersMap.process(withTracePath(ctx, "Performers"), q, s.Common)
 
 		for _, p := range performerResults {
 			performer := &models.ScrapedPerformer{}
@@ -863,7 +1057,7 @@ func (s mappedScraper) processScene(ctx context.Context, q mappedQuery, r mapped
 
 	if sceneTagsMap != nil {
 		logger.Debug(`Processing scene tags:`)
-		tagResults := sceneTagsMap.process(ctx, q, s.Common)
+		tagResults := sceneTagsMap.process(withTracePath(ctx, "Tags"), q, s.Common)
 
 		for _, p := range tagResults {
 			tag := &models.ScrapedTag{}
@@ -874,7 +1068,7 @@ func (s mappedScraper) processScene(ctx context.Context, q mappedQuery, r mapped
 
 	if sceneStudioMap != nil {
 		logger.Debug(`Processing scene studio:`)
-		studioResults := sceneStudioMap.process(ctx, q, s.Common)
+		studioResults := sceneStudioMap.process(withTracePath(ctx, "Studio"), q, s.Common)
 
 		if len(studioResults) > 0 && resultIndex < len(studioResults) {
 			studio := &models.ScrapedStudio{}
@@ -886,7 +1080,7 @@ func (s mappedScraper) processScene(ctx context.Context, q mappedQuery, r mapped
 
 	if sceneMoviesMap != nil {
 		logger.Debug(`Processing scene movies:`)
-		movieResults := sceneMoviesMap.process(ctx, q, s.Common)
+		movieResults := sceneMoviesMap.process(withTracePath(ctx, "Movies"), q, s.Common)
 
 		for _, p := range movieResults {
 			movie := &models.ScrapedMovie{}
@@ -958,7 +1152,7 @@ func (s mappedScraper) scrapeGallery(ctx context.Context, q mappedQuery) (*Scrap
 		// now apply the performers and tags
 		if galleryPerformersMap != nil {
 			logger.Debug(`Processing gallery performers:`)
-			performerResults := galleryPerformersMap.process(ctx, q, s.Common)
+			performerResults := galleryPerformersMap.process(withTracePath(ctx, "Performers"), q, s.Common)
 
 			for _, p := range performerResults {
 				performer := &models.ScrapedPerformer{}
@@ -969,7 +1163,7 @@ func (s mappedScraper) scrapeGallery(ctx context.Context, q mappedQuery) (*Scrap
 
 		if galleryTagsMap != nil {
 			logger.Debug(`Processing gallery tags:`)
-			tagResults := galleryTagsMap.process(ctx, q, s.Common)
+			tagResults := galleryTagsMap.process(withTracePath(ctx, "Tags"), q, s.Common)
 
 			for _, p := range tagResults {
 				tag := &models.ScrapedTag{}
@@ -980,7 +1174,65 @@ func (s mappedScraper) scrapeGallery(ctx context.Context, q mappedQuery) (*Scrap
 
 		if galleryStudioMap != nil {
 			logger.Debug(`Processing gallery studio:`)
-			studioResults := galleryStudioMap.process(ctx, q, s.Common)
+			studioResults := galleryStudioMap.process(withTracePath(ctx, "Studio"), q, s.Common)
+
+			if len(studioResults) > 0 {
+				studio := &models.ScrapedStudio{}
+				studioResults[0].apply(studio)
+				ret.Studio = studio
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+func (s mappedScraper) scrapeImage(ctx context.Context, q mappedQuery) (*ScrapedImage, error) {
+	var ret *ScrapedImage
+
+	imageScraperConfig := s.Image
+	imageMap := imageScraperConfig.mappedConfig
+	if imageMap == nil {
+		return nil, nil
+	}
+
+	imagePerformersMap := imageScraperConfig.Performers
+	imageTagsMap := imageScraperConfig.Tags
+	imageStudioMap := imageScraperConfig.Studio
+
+	logger.Debug(`Processing image:`)
+	results := imageMap.process(ctx, q, s.Common)
+	if len(results) > 0 {
+		ret = &ScrapedImage{}
+
+		results[0].apply(ret)
+
+		// now apply the performers and tags
+		if imagePerformersMap != nil {
+			logger.Debug(`Processing image performers:`)
+			performerResults := imagePerformersMap.process(withTracePath(ctx, "Performers"), q, s.Common)
+
+			for _, p := range performerResults {
+				performer := &models.ScrapedPerformer{}
+				p.apply(performer)
+				ret.Performers = append(ret.Performers, performer)
+			}
+		}
+
+		if imageTagsMap != nil {
+			logger.Debug(`Processing image tags:`)
+			tagResults := imageTagsMap.process(withTracePath(ctx, "Tags"), q, s.Common)
+
+			for _, p := range tagResults {
+				tag := &models.ScrapedTag{}
+				p.apply(tag)
+				ret.Tags = append(ret.Tags, tag)
+			}
+		}
+
+		if imageStudioMap != nil {
+			logger.Debug(`Processing image studio:`)
+			studioResults := imageStudioMap.process(withTracePath(ctx, "Studio"), q, s.Common)
 
 			if len(studioResults) > 0 {
 				studio := &models.ScrapedStudio{}
@@ -1011,7 +1263,7 @@ func (s mappedScraper) scrapeMovie(ctx context.Context, q mappedQuery) (*models.
 
 		if movieStudioMap != nil {
 			logger.Debug(`Processing movie studio:`)
-			studioResults := movieStudioMap.process(ctx, q, s.Common)
+			studioResults := movieStudioMap.process(withTracePath(ctx, "Studio"), q, s.Common)
 
 			if len(studioResults) > 0 {
 				studio := &models.ScrapedStudio{}
@@ -1023,3 +1275,86 @@ func (s mappedScraper) scrapeMovie(ctx context.Context, q mappedQuery) (*models.
 
 	return ret, nil
 }
+
+func (s mappedScraper) processStudio(ctx context.Context, q mappedQuery, r mappedResult) *models.ScrapedStudio {
+	ret := &models.ScrapedStudio{}
+	r.apply(ret)
+
+	studioParentMap := s.Studio.Parent
+	if studioParentMap != nil {
+		logger.Debug(`Processing studio parent:`)
+		parentResults := studioParentMap.process(withTracePath(ctx, "Parent"), q, s.Common)
+
+		if len(parentResults) > 0 {
+			parent := &models.ScrapedStudio{}
+			parentResults[0].apply(parent)
+			ret.Parent = parent
+		}
+	}
+
+	return ret
+}
+
+func (s mappedScraper) scrapeStudio(ctx context.Context, q mappedQuery) (*models.ScrapedStudio, error) {
+	if s.Studio == nil {
+		return nil, nil
+	}
+
+	results := s.Studio.process(ctx, q, s.Common)
+	if len(results) == 0 {
+		return nil, nil
+	}
+
+	return s.processStudio(ctx, q, results[0]), nil
+}
+
+func (s mappedScraper) scrapeStudios(ctx context.Context, q mappedQuery) ([]*models.ScrapedStudio, error) {
+	var ret []*models.ScrapedStudio
+
+	if s.Studio == nil {
+		return nil, nil
+	}
+
+	// parent studios are only applied to single results
+	results := s.Studio.process(ctx, q, s.Common)
+	for _, r := range results {
+		var studio models.ScrapedStudio
+		r.apply(&studio)
+		ret = append(ret, &studio)
+	}
+
+	return ret, nil
+}
+
+func (s mappedScraper) scrapeTags(ctx context.Context, q mappedQuery) ([]*models.ScrapedTag, error) {
+	var ret []*models.ScrapedTag
+
+	tagScraperConfig := s.Tag
+	if tagScraperConfig == nil {
+		return nil, nil
+	}
+
+	tagParentsMap := tagScraperConfig.Parents
+
+	results := tagScraperConfig.process(ctx, q, s.Common)
+	for i, r := range results {
+		tag := &models.ScrapedTag{}
+		r.apply(tag)
+
+		// parents are only applied to the first result
+		if i == 0 && tagParentsMap != nil {
+			logger.Debug(`Processing tag parents:`)
+			parentResults := tagParentsMap.process(withTracePath(ctx, "Parents"), q, s.Common)
+
+			for _, p := range parentResults {
+				parent := &models.ScrapedTag{}
+				p.apply(parent)
+				tag.Parents = append(tag.Parents, parent)
+			}
+		}
+
+		ret = append(ret, tag)
+	}
+
+	return ret, nil
+}
@@ -6,7 +6,6 @@ import (
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/match"
 	"github.com/stashapp/stash/pkg/models"
-	"github.com/stashapp/stash/pkg/tag"
 	"github.com/stashapp/stash/pkg/txn"
 )
 
@@ -34,12 +33,30 @@ func (c Cache) postScrape(ctx context.Context, content ScrapedContent) (ScrapedC
 		}
 	case ScrapedGallery:
 		return c.postScrapeGallery(ctx, v)
+	case *ScrapedImage:
+		if v != nil {
+			return c.postScrapeImage(ctx, *v)
+		}
+	case ScrapedImage:
+		return c.postScrapeImage(ctx, v)
 	case *models.ScrapedMovie:
 		if v != nil {
 			return c.postScrapeMovie(ctx, *v)
 		}
 	case models.ScrapedMovie:
 		return c.postScrapeMovie(ctx, v)
+	case *models.ScrapedStudio:
+		if v != nil {
+			return c.postScrapeStudio(ctx, *v)
+		}
+	case models.ScrapedStudio:
+		return c.postScrapeStudio(ctx, v)
+	case *models.ScrapedTag:
+		if v != nil {
+			return c.postScrapeTag(ctx, *v)
+		}
+	case models.ScrapedTag:
+		return c.postScrapeTag(ctx, v)
 	}
 
 	// If nothing matches, pass the content through
@@ -91,6 +108,58 @@ func (c Cache) postScrapeMovie(ctx context.Context, m models.ScrapedMovie) (Scra
 	return m, nil
 }
 
+func (c Cache) postScrapeStudio(ctx context.Context, s models.ScrapedStudio) (ScrapedContent, error) {
+	if err := txn.WithReadTxn(ctx, c.txnManager, func(ctx context.Context) error {
+		sqb := c.repository.StudioFinder
+
+		if err := match.ScrapedStudio(ctx, sqb, &s, nil); err != nil {
+			return err
+		}
+
+		if s.Parent != nil {
+			return match.ScrapedStudio(ctx, sqb, s.Parent, nil)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	// post-process - set the image if applicable
+	if err := setStudioImage(ctx, c.client, &s, c.globalConfig); err != nil {
+		logger.Warnf("could not set image using URL %s: %v", *s.Image, err)
+	}
+
+	return s, nil
+}
+
+func (c Cache) postScrapeTag(ctx context.Context, t models.ScrapedTag) (ScrapedContent, error) {
+	if err := txn.WithReadTxn(ctx, c.txnManager, func(ctx context.Context) error {
+		tqb := c.repository.TagFinder
+
+		if err := match.ScrapedTag(ctx, tqb, &t, nil); err != nil {
+			return err
+		}
+
+		parents, err := postProcessTags(ctx, tqb, t.Parents)
+		if err != nil {
+			return err
+		}
+		t.Parents = parents
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	// post-process - set the image if applicable
+	if err := setTagImage(ctx, c.client, &t, c.globalConfig); err != nil {
+		logger.Warnf("could not set image using URL %s: %v", *t.Image, err)
+	}
+
+	return t, nil
+}
+
 func (c Cache) postScrapeScenePerformer(ctx context.Context, p models.ScrapedPerformer) error {
 	tqb := c.repository.TagFinder
 
@@ -193,11 +262,45 @@ func (c Cache) postScrapeGallery(ctx context.Context, g ScrapedGallery) (Scraped
 	return g, nil
 }
 
-func postProcessTags(ctx context.Context, tqb tag.Queryer, scrapedTags []*models.ScrapedTag) ([]*models.ScrapedTag, error) {
+func (c Cache) postScrapeImage(ctx context.Context, i ScrapedImage) (ScrapedContent, error) {
+	if err := txn.WithReadTxn(ctx, c.txnManager, func(ctx context.Context) error {
+		pqb := c.repository.PerformerFinder
+		tqb := c.repository.TagFinder
+		sqb := c.repository.StudioFinder
+
+		for _, p := range i.Performers {
+			err := match.ScrapedPerformer(ctx, pqb, p, nil)
+			if err != nil {
+				return err
+			}
+		}
+
+		tags, err := postProcessTags(ctx, tqb, i.Tags)
+		if err != nil {
+			return err
+		}
+		i.Tags = tags
+
+		if i.Studio != nil {
+			err := match.ScrapedStudio(ctx, sqb, i.Studio, nil)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return i, nil
+}
+
+func postProcessTags(ctx context.Context, tqb match.TagFinder, scrapedTags []*models.ScrapedTag) ([]*models.ScrapedTag, error) {
 	var ret []*models.ScrapedTag
 
 	for _, t := range scrapedTags {
-		err := match.ScrapedTag(ctx, tqb, t)
+		err := match.ScrapedTag(ctx, tqb, t, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,167 @@
+package scraper
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+type rateLimitOptions struct {
+	// Maximum number of requests per second sent to a single domain. 0 means no limit.
+	RequestsPerSecond float64 `yaml:"requestsPerSecond"`
+	// Maximum number of requests in flight to a single domain. 0 means no limit.
+	Concurrency int `yaml:"concurrency"`
+}
+
+func (o *rateLimitOptions) interval() time.Duration {
+	if o == nil || o.RequestsPerSecond <= 0 {
+		return 0
+	}
+
+	return time.Duration(float64(time.Second) / o.RequestsPerSecond)
+}
+
+// domainLimiter throttles the requests sent to a single domain.
+type domainLimiter struct {
+	mutex    sync.Mutex
+	interval time.Duration
+	next     time.Time
+	slots    chan struct{}
+}
+
+// tighten applies the options to the limiter if they are more restrictive
+// than the current settings. Scrapers sharing a domain are limited to the
+// strictest configuration among them. The limiters are reset when the
+// scrapers are reloaded, so that relaxed limits take effect.
+func (l *domainLimiter) tighten(o rateLimitOptions) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if i := o.interval(); i > l.interval {
+		l.interval = i
+	}
+
+	if o.Concurrency > 0 && (l.slots == nil || o.Concurrency < cap(l.slots)) {
+		// requests in flight release the slot they acquired, so it is safe to
+		// replace the channel
+		l.slots = make(chan struct{}, o.Concurrency)
+	}
+}
+
+func (l *domainLimiter) acquire(ctx context.Context) (func(), error) {
+	l.mutex.Lock()
+	slots := l.slots
+	l.mutex.Unlock()
+
+	release := func() {}
+	if slots != nil {
+		select {
+		case slots <- struct{}{}:
+			release = func() { <-slots }
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	l.mutex.Lock()
+	now := time.Now()
+	start := l.next
+	if start.Before(now) {
+		start = now
+	}
+	l.next = start.Add(l.interval)
+	l.mutex.Unlock()
+
+	if wait := time.Until(start); wait > 0 {
+		t := time.NewTimer(wait)
+		defer t.Stop()
+
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+
+	return release, nil
+}
+
+type domainLimiters struct {
+	mutex    sync.Mutex
+	limiters map[string]*domainLimiter
+}
+
+// rateLimiters is shared by all scrapers so that limits are honoured across
+// concurrent jobs.
+var rateLimiters = &domainLimiters{
+	limiters: make(map[string]*domainLimiter),
+}
+
+// resetRateLimiters discards the limiters, so that they are created again
+// from the scraper configurations. Requests in flight release the slots of
+// the discarded limiters.
+func resetRateLimiters() {
+	rateLimiters.mutex.Lock()
+	defer rateLimiters.mutex.Unlock()
+
+	rateLimiters.limiters = make(map[string]*domainLimiter)
+}
+
+// find returns the limiter for the domain, or nil if no scraper limits it.
+func (d *domainLimiters) find(domain string) *domainLimiter {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return d.limiters[domain]
+}
+
+func (d *domainLimiters) get(domain string, o rateLimitOptions) *domainLimiter {
+	d.mutex.Lock()
+	l, found := d.limiters[domain]
+	if !found {
+		l = &domainLimiter{}
+		d.limiters[domain] = l
+	}
+	d.mutex.Unlock()
+
+	l.tighten(o)
+	return l
+}
+
+// waitRateLimit blocks until a request to the given URL is allowed by the
+// rate limit options of the scraper. The returned function must be called
+// once the request has completed.
+func waitRateLimit(ctx context.Context, u string, driverOptions *scraperDriverOptions) (func(), error) {
+	if driverOptions == nil || driverOptions.RateLimit == nil {
+		return func() {}, nil
+	}
+
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+
+	domain := strings.ToLower(parsed.Hostname())
+	return rateLimiters.get(domain, *driverOptions.RateLimit).acquire(ctx)
+}
+
+// waitDomainRateLimit blocks until a request to the given URL is allowed by
+// the limits that scrapers set for its domain. It is used for requests which
+// are not made by a specific scraper, such as fetching scraped images. The
+// returned function must be called once the request has completed.
+func waitDomainRateLimit(ctx context.Context, u string) (func(), error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+
+	l := rateLimiters.find(strings.ToLower(parsed.Hostname()))
+	if l == nil {
+		return func() {}, nil
+	}
+
+	return l.acquire(ctx)
+}
@@ -0,0 +1,216 @@
+package scraper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stashapp/stash/pkg/logger"
+)
+
+const (
+	scraperCacheDir = "scrapers"
+
+	// cached responses which have not been fetched or revalidated for this
+	// many times the ttl are deleted
+	cacheEvictTTLs = 2
+	// minimum time between evictions from a scraper's cache
+	cacheEvictInterval = time.Hour
+)
+
+type cacheOptions struct {
+	// Number of seconds a cached response is used before it is revalidated.
+	TTL int `yaml:"ttl"`
+}
+
+type cachedResponse struct {
+	URL          string    `json:"url"`
+	ContentType  string    `json:"content_type"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Fetched      time.Time `json:"fetched"`
+	Body         []byte    `json:"body"`
+}
+
+// setConditionalHeaders sets the request headers used to revalidate the
+// cached response.
+func (r *cachedResponse) setConditionalHeaders(req *http.Request) {
+	if r == nil {
+		return
+	}
+
+	if r.ETag != "" {
+		req.Header.Set("If-None-Match", r.ETag)
+	}
+	if r.LastModified != "" {
+		req.Header.Set("If-Modified-Since", r.LastModified)
+	}
+}
+
+// responseCache is an on-disk cache of the responses to a scraper's
+// requests. A nil responseCache caches nothing.
+type responseCache struct {
+	dir string
+	ttl time.Duration
+}
+
+// newResponseCache returns the response cache for the scraper, or nil if
+// the scraper does not configure caching.
+func newResponseCache(c config, globalConfig GlobalConfig) *responseCache {
+	if c.DriverOptions == nil || c.DriverOptions.Cache == nil || c.DriverOptions.Cache.TTL <= 0 {
+		return nil
+	}
+
+	cachePath := globalConfig.GetCachePath()
+	if cachePath == "" {
+		return nil
+	}
+
+	return &responseCache{
+		dir: filepath.Join(cachePath, scraperCacheDir, c.ID),
+		ttl: time.Duration(c.DriverOptions.Cache.TTL) * time.Second,
+	}
+}
+
+func (c *responseCache) path(u string) string {
+	sum := sha256.Sum256([]byte(u))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *responseCache) fresh(r *cachedResponse) bool {
+	return c != nil && r != nil && time.Since(r.Fetched) < c.ttl
+}
+
+// get returns the cached response for the URL, or nil if there is none.
+func (c *responseCache) get(u string) *cachedResponse {
+	if c == nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.path(u))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			logger.Warnf("[scraper] error reading cached response for %s: %v", u, err)
+		}
+		return nil
+	}
+
+	var ret cachedResponse
+	if err := json.Unmarshal(data, &ret); err != nil {
+		logger.Warnf("[scraper] error reading cached response for %s: %v", u, err)
+		return nil
+	}
+
+	// guard against hash collisions
+	if ret.URL != u {
+		return nil
+	}
+
+	return &ret
+}
+
+// put stores the response in the cache. Errors are logged and otherwise
+// ignored, since a failure to cache should not fail the scrape.
+func (c *responseCache) put(r *cachedResponse) {
+	if c == nil {
+		return
+	}
+
+	if err := c.write(r); err != nil {
+		logger.Warnf("[scraper] error caching response for %s: %v", r.URL, err)
+	}
+
+	if now := time.Now(); c.evictDue(now) {
+		c.evict(now)
+	}
+}
+
+// cacheEvictions holds the time each cache directory was last evicted from.
+var cacheEvictions = struct {
+	sync.Mutex
+	last map[string]time.Time
+}{
+	last: make(map[string]time.Time),
+}
+
+// evictDue returns true if entries have not been evicted from the cache
+// within cacheEvictInterval, and marks them as evicted at now.
+func (c *responseCache) evictDue(now time.Time) bool {
+	cacheEvictions.Lock()
+	defer cacheEvictions.Unlock()
+
+	if now.Sub(cacheEvictions.last[c.dir]) < cacheEvictInterval {
+		return false
+	}
+
+	cacheEvictions.last[c.dir] = now
+	return true
+}
+
+// evict deletes the entries which have not been fetched or revalidated for
+// cacheEvictTTLs times the ttl. Each fetch or revalidation rewrites the entry,
+// so the modification time of the file is used.
+func (c *responseCache) evict(now time.Time) {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			logger.Warnf("[scraper] error reading response cache %s: %v", c.dir, err)
+		}
+		return
+	}
+
+	maxAge := cacheEvictTTLs * c.ttl
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !(strings.HasSuffix(name, ".json") || strings.HasSuffix(name, ".tmp")) {
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil || now.Sub(info.ModTime()) < maxAge {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(c.dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			logger.Warnf("[scraper] error removing cached response %s: %v", name, err)
+		}
+	}
+}
+
+func (c *responseCache) write(r *cachedResponse) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+
+	// write to a temporary file first so that concurrent readers never see
+	// a partially written entry
+	f, err := os.CreateTemp(c.dir, "*.tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	return os.Rename(f.Name(), c.path(r.URL))
+}
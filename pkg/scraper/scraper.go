@@ -30,21 +30,27 @@ type ScrapeContentType string
 
 const (
 	ScrapeContentTypeGallery   ScrapeContentType = "GALLERY"
+	ScrapeContentTypeImage     ScrapeContentType = "IMAGE"
 	ScrapeContentTypeMovie     ScrapeContentType = "MOVIE"
 	ScrapeContentTypePerformer ScrapeContentType = "PERFORMER"
 	ScrapeContentTypeScene     ScrapeContentType = "SCENE"
+	ScrapeContentTypeStudio    ScrapeContentType = "STUDIO"
+	ScrapeContentTypeTag       ScrapeContentType = "TAG"
 )
 
 var AllScrapeContentType = []ScrapeContentType{
 	ScrapeContentTypeGallery,
+	ScrapeContentTypeImage,
 	ScrapeContentTypeMovie,
 	ScrapeContentTypePerformer,
 	ScrapeContentTypeScene,
+	ScrapeContentTypeStudio,
+	ScrapeContentTypeTag,
 }
 
 func (e ScrapeContentType) IsValid() bool {
 	switch e {
-	case ScrapeContentTypeGallery, ScrapeContentTypeMovie, ScrapeContentTypePerformer, ScrapeContentTypeScene:
+	case ScrapeContentTypeGallery, ScrapeContentTypeImage, ScrapeContentTypeMovie, ScrapeContentTypePerformer, ScrapeContentTypeScene, ScrapeContentTypeStudio, ScrapeContentTypeTag:
 		return true
 	}
 	return false
@@ -74,14 +80,22 @@ func (e ScrapeContentType) MarshalGQL(w io.Writer) {
 type Scraper struct {
 	ID   string `json:"id"`
 	Name string `json:"name"`
+	// Whether the scraper logs in to its site with stored credentials
+	RequiresLogin bool `json:"requires_login"`
 	// Details for performer scraper
 	Performer *ScraperSpec `json:"performer"`
 	// Details for scene scraper
 	Scene *ScraperSpec `json:"scene"`
 	// Details for gallery scraper
 	Gallery *ScraperSpec `json:"gallery"`
+	// Details for image scraper
+	Image *ScraperSpec `json:"image"`
 	// Details for movie scraper
 	Movie *ScraperSpec `json:"movie"`
+	// Details for studio scraper
+	Studio *ScraperSpec `json:"studio"`
+	// Details for tag scraper
+	Tag *ScraperSpec `json:"tag"`
 }
 
 type ScraperSpec struct {
@@ -155,6 +169,7 @@ type Input struct {
 	Performer *ScrapedPerformerInput
 	Scene     *ScrapedSceneInput
 	Gallery   *ScrapedGalleryInput
+	Image     *ScrapedImageInput
 }
 
 // simple type definitions that can help customize
@@ -212,3 +227,11 @@ type galleryScraper interface {
 
 	viaGallery(ctx context.Context, client *http.Client, gallery *models.Gallery) (*ScrapedGallery, error)
 }
+
+// imageScraper is a scraper which supports image scrapes with
+// image data as the input.
+type imageScraper interface {
+	scraper
+
+	viaImage(ctx context.Context, client *http.Client, image *models.Image) (*ScrapedImage, error)
+}
@@ -18,18 +18,27 @@ import (
 
 var ErrScraperScript = errors.New("scraper script error")
 
+// scriptRunner runs a scraper script with the JSON input, decoding its JSON
+// output into out.
+type scriptRunner func(ctx context.Context, inString string, out interface{}) error
+
 type scriptScraper struct {
 	scraper      scraperTypeConfig
 	config       config
 	globalConfig GlobalConfig
+
+	run scriptRunner
 }
 
 func newScriptScraper(scraper scraperTypeConfig, config config, globalConfig GlobalConfig) *scriptScraper {
-	return &scriptScraper{
+	ret := &scriptScraper{
 		scraper:      scraper,
 		config:       config,
 		globalConfig: globalConfig,
 	}
+	ret.run = ret.runScraperScript
+
+	return ret
 }
 
 func (s *scriptScraper) runScraperScript(ctx context.Context, inString string, out interface{}) error {
@@ -91,9 +100,27 @@ func (s *scriptScraper) runScraperScript(ctx context.Context, inString string, o
 	logger.Debugf("Scraper script <%s> started", strings.Join(cmd.Args, " "))
 
 	// TODO - add a timeout here
-	// Make a copy of stdout here. This allows us to decode it twice.
+	if err := decodeScriptResult(stdout, out); err != nil {
+		logger.Errorf("could not unmarshal json from script output: %v", err)
+		return fmt.Errorf("could not unmarshal json from script output: %w", err)
+	}
+
+	err = cmd.Wait()
+	logger.Debugf("Scraper script finished")
+
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrScraperScript, err)
+	}
+
+	return nil
+}
+
+// decodeScriptResult decodes the JSON result of a script into out. Unknown
+// fields are logged as a warning rather than failing the decode.
+func decodeScriptResult(r io.Reader, out interface{}) error {
+	// Make a copy of the input here. This allows us to decode it twice.
 	var sb strings.Builder
-	tr := io.TeeReader(stdout, &sb)
+	tr := io.TeeReader(r, &sb)
 
 	// First, perform a decode where unknown fields are disallowed.
 	d := json.NewDecoder(tr)
@@ -103,25 +130,15 @@ func (s *scriptScraper) runScraperScript(ctx context.Context, inString string, o
 	if strictErr != nil {
 		// The decode failed for some reason, use the built string
 		// and allow unknown fields in the decode.
-		s := sb.String()
-		lenientErr := json.NewDecoder(strings.NewReader(s)).Decode(out)
-		if lenientErr != nil {
+		if err := json.NewDecoder(strings.NewReader(sb.String())).Decode(out); err != nil {
 			// The error is genuine, so return it
-			logger.Errorf("could not unmarshal json from script output: %v", lenientErr)
-			return fmt.Errorf("could not unmarshal json from script output: %w", lenientErr)
+			return err
 		}
 
 		// Lenient decode succeeded, print a warning, but use the decode
 		logger.Warnf("reading script result: %v", strictErr)
 	}
 
-	err = cmd.Wait()
-	logger.Debugf("Scraper script finished")
-
-	if err != nil {
-		return fmt.Errorf("%w: %v", ErrScraperScript, err)
-	}
-
 	return nil
 }
 
@@ -133,7 +150,7 @@ func (s *scriptScraper) scrapeByName(ctx context.Context, name string, ty Scrape
 	switch ty {
 	case ScrapeContentTypePerformer:
 		var performers []models.ScrapedPerformer
-		err = s.runScraperScript(ctx, input, &performers)
+		err = s.run(ctx, input, &performers)
 		if err == nil {
 			for _, p := range performers {
 				v := p
@@ -142,13 +159,31 @@ func (s *scriptScraper) scrapeByName(ctx context.Context, name string, ty Scrape
 		}
 	case ScrapeContentTypeScene:
 		var scenes []ScrapedScene
-		err = s.runScraperScript(ctx, input, &scenes)
+		err = s.run(ctx, input, &scenes)
 		if err == nil {
 			for _, s := range scenes {
 				v := s
 				ret = append(ret, &v)
 			}
 		}
+	case ScrapeContentTypeStudio:
+		var studios []models.ScrapedStudio
+		err = s.run(ctx, input, &studios)
+		if err == nil {
+			for _, s := range studios {
+				v := s
+				ret = append(ret, &v)
+			}
+		}
+	case ScrapeContentTypeTag:
+		var tags []models.ScrapedTag
+		err = s.run(ctx, input, &tags)
+		if err == nil {
+			for _, t := range tags {
+				v := t
+				ret = append(ret, &v)
+			}
+		}
 	default:
 		return nil, ErrNotSupported
 	}
@@ -167,6 +202,9 @@ func (s *scriptScraper) scrapeByFragment(ctx context.Context, input Input) (Scra
 	case input.Gallery != nil:
 		inString, err = json.Marshal(*input.Gallery)
 		ty = ScrapeContentTypeGallery
+	case input.Image != nil:
+		inString, err = json.Marshal(*input.Image)
+		ty = ScrapeContentTypeImage
 	case input.Scene != nil:
 		inString, err = json.Marshal(*input.Scene)
 		ty = ScrapeContentTypeScene
@@ -187,20 +225,28 @@ func (s *scriptScraper) scrape(ctx context.Context, input string, ty ScrapeConte
 	switch ty {
 	case ScrapeContentTypePerformer:
 		var performer *models.ScrapedPerformer
-		err := s.runScraperScript(ctx, input, &performer)
+		err := s.run(ctx, input, &performer)
 		return performer, err
 	case ScrapeContentTypeGallery:
 		var gallery *ScrapedGallery
-		err := s.runScraperScript(ctx, input, &gallery)
+		err := s.run(ctx, input, &gallery)
 		return gallery, err
+	case ScrapeContentTypeImage:
+		var image *ScrapedImage
+		err := s.run(ctx, input, &image)
+		return image, err
 	case ScrapeContentTypeScene:
 		var scene *ScrapedScene
-		err := s.runScraperScript(ctx, input, &scene)
+		err := s.run(ctx, input, &scene)
 		return scene, err
 	case ScrapeContentTypeMovie:
 		var movie *models.ScrapedMovie
-		err := s.runScraperScript(ctx, input, &movie)
+		err := s.run(ctx, input, &movie)
 		return movie, err
+	case ScrapeContentTypeStudio:
+		var studio *models.ScrapedStudio
+		err := s.run(ctx, input, &studio)
+		return studio, err
 	}
 
 	return nil, ErrNotSupported
@@ -215,7 +261,7 @@ func (s *scriptScraper) scrapeSceneByScene(ctx context.Context, scene *models.Sc
 
 	var ret *ScrapedScene
 
-	err = s.runScraperScript(ctx, string(inString), &ret)
+	err = s.run(ctx, string(inString), &ret)
 
 	return ret, err
 }
@@ -229,7 +275,21 @@ func (s *scriptScraper) scrapeGalleryByGallery(ctx context.Context, gallery *mod
 
 	var ret *ScrapedGallery
 
-	err = s.runScraperScript(ctx, string(inString), &ret)
+	err = s.run(ctx, string(inString), &ret)
+
+	return ret, err
+}
+
+func (s *scriptScraper) scrapeImageByImage(ctx context.Context, image *models.Image) (*ScrapedImage, error) {
+	inString, err := json.Marshal(imageToUpdateInput(image))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var ret *ScrapedImage
+
+	err = s.run(ctx, string(inString), &ret)
 
 	return ret, err
 }
@@ -0,0 +1,39 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func Test_decodeScriptResult(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantName string
+		wantErr  bool
+	}{
+		{"valid", `{"name": "Jane"}`, "Jane", false},
+		{"unknown field", `{"name": "Jane", "unknown": true}`, "Jane", false},
+		{"invalid", `{"name": `, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got models.ScrapedPerformer
+			err := decodeScriptResult(strings.NewReader(tt.input), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeScriptResult() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.Name == nil || *got.Name != tt.wantName {
+				t.Errorf("decodeScriptResult() name = %v, want %v", got.Name, tt.wantName)
+			}
+		})
+	}
+}
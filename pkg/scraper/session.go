@@ -0,0 +1,664 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"golang.org/x/net/publicsuffix"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const (
+	scraperSessionDir = "scraper_sessions"
+
+	// cookies set by responses are written to disk after this delay, so that
+	// a batch of requests writes the session once
+	sessionSaveDelay = 5 * time.Second
+)
+
+var (
+	// ErrNoCredentials is returned when a scraper needs to log in, but no
+	// credentials are configured for it.
+	ErrNoCredentials = errors.New("no credentials configured")
+
+	// ErrLoginFailed is returned when the site still reports the session as
+	// logged out after logging in.
+	ErrLoginFailed = errors.New("login failed")
+)
+
+type loginField struct {
+	XPath string `yaml:"xpath"`
+	// Value typed into the field. {username} and {password} are replaced
+	// with the stored credentials.
+	Value string `yaml:"value"`
+}
+
+type loginExpiredOptions struct {
+	// Response status codes returned when the session has expired.
+	StatusCodes []int `yaml:"statusCodes,flow"`
+	// XPath which only matches when logged out, such as the login form.
+	XPath string `yaml:"xpath"`
+}
+
+type loginOptions struct {
+	// URL the form is posted to, or the login page when using CDP.
+	URL string `yaml:"url"`
+	// Form values posted to URL. {username} and {password} are replaced
+	// with the stored credentials.
+	Form map[string]string `yaml:"form"`
+	// Fields filled in on the login page when using CDP.
+	Fields []*loginField `yaml:"fields"`
+	// Elements clicked after filling in the fields when using CDP.
+	Clicks  []*clickOptions      `yaml:"clicks"`
+	Expired *loginExpiredOptions `yaml:"expired"`
+}
+
+func (o loginOptions) validate(useCDP bool) error {
+	if o.URL == "" {
+		return errors.New("url is mandatory for login")
+	}
+
+	if useCDP {
+		if len(o.Fields) == 0 && len(o.Clicks) == 0 {
+			return errors.New("fields or clicks are mandatory for CDP login")
+		}
+	} else if len(o.Form) == 0 {
+		return errors.New("form is mandatory for login")
+	}
+
+	return nil
+}
+
+func (o loginOptions) expand(s string, creds *models.ScraperCredentials) string {
+	return strings.NewReplacer("{username}", creds.Username, "{password}", creds.Password).Replace(s)
+}
+
+// isExpired returns true if the response indicates that the session is not
+// logged in. A status code of 0 only checks the body.
+func (o loginOptions) isExpired(statusCode int, body []byte) bool {
+	if o.Expired == nil {
+		return false
+	}
+
+	for _, c := range o.Expired.StatusCodes {
+		if c == statusCode {
+			return true
+		}
+	}
+
+	if o.Expired.XPath != "" && len(body) > 0 {
+		doc, err := htmlquery.Parse(bytes.NewReader(body))
+		if err != nil {
+			return false
+		}
+
+		node, err := htmlquery.Query(doc, o.Expired.XPath)
+		return err == nil && node != nil
+	}
+
+	return false
+}
+
+type storedCookie struct {
+	URL      string    `json:"url"`
+	Name     string    `json:"name"`
+	Value    string    `json:"value"`
+	Domain   string    `json:"domain,omitempty"`
+	Path     string    `json:"path,omitempty"`
+	Expires  time.Time `json:"expires,omitempty"`
+	Secure   bool      `json:"secure,omitempty"`
+	HttpOnly bool      `json:"http_only,omitempty"`
+}
+
+func (c storedCookie) key() string {
+	return strings.TrimPrefix(c.Domain, ".") + ";" + c.Path + ";" + c.Name
+}
+
+func (c storedCookie) expired() bool {
+	return !c.Expires.IsZero() && c.Expires.Before(time.Now())
+}
+
+type sessionFile struct {
+	LoggedIn time.Time      `json:"logged_in"`
+	Cookies  []storedCookie `json:"cookies"`
+}
+
+// scraperSession is the persistent cookie jar of a scraper that logs in to
+// its site. Cookies set by the site are written to disk so that the session
+// survives restarts.
+type scraperSession struct {
+	config config
+	path   string
+
+	// loginMutex serialises logins
+	loginMutex sync.Mutex
+
+	mutex    sync.Mutex
+	jar      *cookiejar.Jar
+	cookies  map[string]storedCookie
+	loggedIn time.Time
+	// pending write of the session, if any
+	saveTimer *time.Timer
+	// set once the session is no longer used, after which it is not saved
+	discarded bool
+}
+
+var sessions = struct {
+	sync.Mutex
+	m map[string]*scraperSession
+}{
+	m: make(map[string]*scraperSession),
+}
+
+func sessionPath(cachePath string, scraperID string) string {
+	if cachePath == "" {
+		return ""
+	}
+
+	return filepath.Join(cachePath, scraperSessionDir, scraperID+".json")
+}
+
+// getSession returns the session of the scraper, loading it from disk the
+// first time it is used.
+func getSession(c config, globalConfig GlobalConfig) (*scraperSession, error) {
+	sessions.Lock()
+	defer sessions.Unlock()
+
+	if s := sessions.m[c.ID]; s != nil {
+		return s, nil
+	}
+
+	s := &scraperSession{
+		config: c,
+		path:   sessionPath(globalConfig.GetCachePath(), c.ID),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	sessions.m[c.ID] = s
+	return s, nil
+}
+
+// resetSessions discards the sessions held in memory, writing any pending
+// changes to disk first.
+func resetSessions() {
+	sessions.Lock()
+	defer sessions.Unlock()
+
+	for _, s := range sessions.m {
+		s.discard(true)
+	}
+
+	sessions.m = make(map[string]*scraperSession)
+}
+
+// SaveSessions writes the pending changes of the sessions to disk. Called
+// on shutdown.
+func SaveSessions() {
+	sessions.Lock()
+	defer sessions.Unlock()
+
+	for _, s := range sessions.m {
+		s.flush()
+	}
+}
+
+// clearSession logs the scraper out, discarding its cookies.
+func clearSession(globalConfig GlobalConfig, scraperID string) error {
+	sessions.Lock()
+	if s := sessions.m[scraperID]; s != nil {
+		s.discard(false)
+	}
+	delete(sessions.m, scraperID)
+	sessions.Unlock()
+
+	path := sessionPath(globalConfig.GetCachePath(), scraperID)
+	if path == "" {
+		return nil
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// reset discards all cookies except the ones in the scraper configuration.
+// Must be called with the mutex held.
+func (s *scraperSession) reset() error {
+	jar, err := cookiejar.New(&cookiejar.Options{
+		PublicSuffixList: publicsuffix.List,
+	})
+	if err != nil {
+		return err
+	}
+
+	if s.config.DriverOptions != nil && !s.config.DriverOptions.UseCDP {
+		setConfigCookies(jar, s.config.DriverOptions)
+	}
+
+	s.jar = jar
+	s.cookies = make(map[string]storedCookie)
+	s.loggedIn = time.Time{}
+	return nil
+}
+
+func (s *scraperSession) load() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.reset(); err != nil {
+		return err
+	}
+
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var f sessionFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		logger.Warnf("[scraper] %s: discarding invalid session file %s: %v", s.config.ID, s.path, err)
+		return nil
+	}
+
+	for _, c := range f.Cookies {
+		if c.expired() {
+			continue
+		}
+
+		u, err := url.Parse(c.URL)
+		if err != nil {
+			continue
+		}
+
+		s.jar.SetCookies(u, []*http.Cookie{c.httpCookie()})
+		s.cookies[c.key()] = c
+	}
+
+	s.loggedIn = f.LoggedIn
+	return nil
+}
+
+// scheduleSave writes the session to disk after sessionSaveDelay, unless a
+// write is already pending. Must be called with the mutex held.
+func (s *scraperSession) scheduleSave() {
+	if s.path == "" || s.discarded || s.saveTimer != nil {
+		return
+	}
+
+	s.saveTimer = time.AfterFunc(sessionSaveDelay, s.flush)
+}
+
+// flush writes the pending changes to disk.
+func (s *scraperSession) flush() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// the session was saved since the write was scheduled
+	if s.saveTimer == nil {
+		return
+	}
+
+	s.save()
+}
+
+// discard stops the session from being saved, writing the pending changes
+// to disk first if flush is true.
+func (s *scraperSession) discard(flush bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if flush && s.saveTimer != nil {
+		s.save()
+	}
+	if s.saveTimer != nil {
+		s.saveTimer.Stop()
+		s.saveTimer = nil
+	}
+
+	s.discarded = true
+}
+
+// save writes the session to disk, replacing any pending write. Must be
+// called with the mutex held.
+func (s *scraperSession) save() {
+	if s.saveTimer != nil {
+		s.saveTimer.Stop()
+		s.saveTimer = nil
+	}
+
+	if s.path == "" || s.discarded {
+		return
+	}
+
+	f := sessionFile{
+		LoggedIn: s.loggedIn,
+	}
+	for _, c := range s.cookies {
+		if !c.expired() {
+			f.Cookies = append(f.Cookies, c)
+		}
+	}
+
+	data, err := json.Marshal(f)
+	if err != nil {
+		logger.Warnf("[scraper] %s: error encoding session: %v", s.config.ID, err)
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		logger.Warnf("[scraper] %s: error creating session directory: %v", s.config.ID, err)
+		return
+	}
+
+	// the session contains login cookies, so keep it private
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		logger.Warnf("[scraper] %s: error writing session: %v", s.config.ID, err)
+		return
+	}
+
+	if err := os.Rename(tmp, s.path); err != nil {
+		logger.Warnf("[scraper] %s: error writing session: %v", s.config.ID, err)
+	}
+}
+
+func (c storedCookie) httpCookie() *http.Cookie {
+	return &http.Cookie{
+		Name:     c.Name,
+		Value:    c.Value,
+		Domain:   c.Domain,
+		Path:     c.Path,
+		Expires:  c.Expires,
+		Secure:   c.Secure,
+		HttpOnly: c.HttpOnly,
+	}
+}
+
+// SetCookies implements http.CookieJar.
+func (s *scraperSession) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.jar.SetCookies(u, cookies)
+
+	for _, c := range cookies {
+		stored := storedCookie{
+			URL:      u.String(),
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Expires:  c.Expires,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+		}
+		if stored.Domain == "" {
+			stored.Domain = u.Hostname()
+		}
+
+		switch {
+		case c.MaxAge < 0:
+			stored.Expires = time.Unix(1, 0)
+		case c.MaxAge > 0:
+			stored.Expires = time.Now().Add(time.Duration(c.MaxAge) * time.Second)
+		}
+
+		if stored.expired() {
+			delete(s.cookies, stored.key())
+		} else {
+			s.cookies[stored.key()] = stored
+		}
+	}
+
+	s.scheduleSave()
+}
+
+// Cookies implements http.CookieJar.
+func (s *scraperSession) Cookies(u *url.URL) []*http.Cookie {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.jar.Cookies(u)
+}
+
+func (s *scraperSession) loggedInAt() time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.loggedIn
+}
+
+// client returns a copy of the client which stores its cookies in the
+// session.
+func (s *scraperSession) client(client *http.Client) *http.Client {
+	ret := *client
+	ret.Jar = s
+	return &ret
+}
+
+// ensureLogin logs in if the session has never logged in.
+func (s *scraperSession) ensureLogin(login func() error) error {
+	s.loginMutex.Lock()
+	defer s.loginMutex.Unlock()
+
+	if !s.loggedInAt().IsZero() {
+		return nil
+	}
+
+	return s.doLogin(login)
+}
+
+// relogin logs in again, unless another request has already done so since
+// the session was found to be expired.
+func (s *scraperSession) relogin(since time.Time, login func() error) error {
+	s.loginMutex.Lock()
+	defer s.loginMutex.Unlock()
+
+	if s.loggedInAt().After(since) {
+		return nil
+	}
+
+	logger.Infof("[scraper] %s: session expired, logging in again", s.config.ID)
+	return s.doLogin(login)
+}
+
+func (s *scraperSession) doLogin(login func() error) error {
+	s.mutex.Lock()
+	err := s.reset()
+	s.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := login(); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.loggedIn = time.Now()
+	s.save()
+
+	logger.Debugf("[scraper] %s: logged in", s.config.ID)
+	return nil
+}
+
+func (s *scraperSession) credentials(globalConfig GlobalConfig) (*models.ScraperCredentials, error) {
+	creds := globalConfig.GetScraperCredentials(s.config.ID)
+	if creds == nil || creds.Username == "" {
+		return nil, fmt.Errorf("%w for scraper %s", ErrNoCredentials, s.config.ID)
+	}
+
+	return creds, nil
+}
+
+// login posts the login form. The client must use the session as its
+// cookie jar.
+func (s *scraperSession) login(ctx context.Context, client *http.Client, globalConfig GlobalConfig) error {
+	creds, err := s.credentials(globalConfig)
+	if err != nil {
+		return err
+	}
+
+	driverOptions := s.config.DriverOptions
+	o := driverOptions.Login
+
+	form := url.Values{}
+	for k, v := range o.Form {
+		form.Set(k, o.expand(v, creds))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	setRequestHeaders(req, driverOptions, globalConfig)
+
+	release, err := waitRateLimit(ctx, o.URL, driverOptions)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 400 || o.isExpired(resp.StatusCode, body) {
+		return fmt.Errorf("%w for scraper %s: http status %d", ErrLoginFailed, s.config.ID, resp.StatusCode)
+	}
+
+	return nil
+}
+
+// loginCDP fills in the login page in the browser and stores the resulting
+// cookies in the session.
+func (s *scraperSession) loginCDP(ctx context.Context, globalConfig GlobalConfig) error {
+	creds, err := s.credentials(globalConfig)
+	if err != nil {
+		return err
+	}
+
+	driverOptions := s.config.DriverOptions
+	o := driverOptions.Login
+
+	sleepDuration := scrapeDefaultSleep
+	if driverOptions.Sleep > 0 {
+		sleepDuration = time.Duration(driverOptions.Sleep) * time.Second
+	}
+
+	tasks := chromedp.Tasks{
+		chromedp.Navigate(o.URL),
+		chromedp.Sleep(sleepDuration),
+	}
+	for _, f := range o.Fields {
+		tasks = append(tasks, chromedp.SendKeys(f.XPath, o.expand(f.Value, creds)))
+	}
+
+	var res string
+	tasks = append(tasks,
+		setCDPClicks(o.Clicks),
+		chromedp.OuterHTML("html", &res, chromedp.ByQuery),
+	)
+
+	if err := chromedp.Run(ctx, tasks); err != nil {
+		return err
+	}
+
+	if o.isExpired(0, []byte(res)) {
+		return fmt.Errorf("%w for scraper %s", ErrLoginFailed, s.config.ID)
+	}
+
+	return chromedp.Run(ctx, s.storeCDPCookies(o.URL))
+}
+
+// setCDPCookies sets the session cookies for the given URL in the browser.
+func (s *scraperSession) setCDPCookies(u string) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		parsed, err := url.Parse(u)
+		if err != nil {
+			return err
+		}
+
+		for _, c := range s.Cookies(parsed) {
+			if err := network.SetCookie(c.Name, c.Value).WithURL(u).Do(ctx); err != nil {
+				return fmt.Errorf("could not set chrome cookie %s: %w", c.Name, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// storeCDPCookies stores the browser's cookies for the given URL in the
+// session.
+func (s *scraperSession) storeCDPCookies(u string) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		parsed, err := url.Parse(u)
+		if err != nil {
+			return err
+		}
+
+		chromeCookies, err := network.GetCookies().WithUrls([]string{u}).Do(ctx)
+		if err != nil {
+			return err
+		}
+
+		var cookies []*http.Cookie
+		for _, c := range chromeCookies {
+			cookie := &http.Cookie{
+				Name:     c.Name,
+				Value:    c.Value,
+				Domain:   c.Domain,
+				Path:     c.Path,
+				Secure:   c.Secure,
+				HttpOnly: c.HTTPOnly,
+			}
+			if !c.Session && c.Expires > 0 {
+				cookie.Expires = time.Unix(int64(c.Expires), 0)
+			}
+			cookies = append(cookies, cookie)
+		}
+
+		s.SetCookies(parsed, cookies)
+		return nil
+	})
+}
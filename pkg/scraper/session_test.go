@@ -0,0 +1,198 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+type credentialsGlobalConfig struct {
+	cachePathGlobalConfig
+	credentials *models.ScraperCredentials
+}
+
+func (c credentialsGlobalConfig) GetScraperCredentials(scraperID string) *models.ScraperCredentials {
+	return c.credentials
+}
+
+func TestLoadURLLogin(t *testing.T) {
+	var mutex sync.Mutex
+	token := "first"
+	var logins int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.FormValue("user") != "alice" || r.FormValue("pass") != "secret" {
+			http.Error(w, "bad credentials", http.StatusForbidden)
+			return
+		}
+
+		atomic.AddInt32(&logins, 1)
+		mutex.Lock()
+		http.SetCookie(w, &http.Cookie{Name: "sid", Value: token, Path: "/"})
+		mutex.Unlock()
+		http.Redirect(w, r, "/", http.StatusFound)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		valid := token
+		mutex.Unlock()
+
+		if c, err := r.Cookie("sid"); err != nil || c.Value != valid {
+			_, _ = io.WriteString(w, `<html><form id="login"></form></html>`)
+			return
+		}
+		_, _ = io.WriteString(w, "<html>members</html>")
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	resetSessions()
+
+	c := config{
+		ID: "login",
+		DriverOptions: &scraperDriverOptions{
+			Login: &loginOptions{
+				URL: srv.URL + "/login",
+				Form: map[string]string{
+					"user": "{username}",
+					"pass": "{password}",
+				},
+				Expired: &loginExpiredOptions{
+					XPath: `//form[@id="login"]`,
+				},
+			},
+		},
+	}
+	if err := c.DriverOptions.Login.validate(false); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	cachePath := t.TempDir()
+	gc := credentialsGlobalConfig{
+		cachePathGlobalConfig: cachePathGlobalConfig{cachePath: cachePath},
+		credentials:           &models.ScraperCredentials{ScraperID: "login", Username: "alice", Password: "secret"},
+	}
+	ctx := context.Background()
+
+	load := func() string {
+		t.Helper()
+		r, err := loadURL(ctx, srv.URL+"/scene", srv.Client(), c, gc)
+		if err != nil {
+			t.Fatalf("loadURL: %v", err)
+		}
+		b, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("reading body: %v", err)
+		}
+		return string(b)
+	}
+
+	if got := load(); got != "<html>members</html>" {
+		t.Errorf("first load: got %q", got)
+	}
+	if got := load(); got != "<html>members</html>" {
+		t.Errorf("second load: got %q", got)
+	}
+	if n := atomic.LoadInt32(&logins); n != 1 {
+		t.Errorf("expected 1 login, got %d", n)
+	}
+
+	// the session is stored privately and survives a reload
+	fi, err := os.Stat(sessionPath(cachePath, "login"))
+	if err != nil {
+		t.Fatalf("session file: %v", err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Errorf("session file mode: %v", fi.Mode().Perm())
+	}
+
+	resetSessions()
+	if got := load(); got != "<html>members</html>" {
+		t.Errorf("load after reset: got %q", got)
+	}
+	if n := atomic.LoadInt32(&logins); n != 1 {
+		t.Errorf("expected stored session to be reused, got %d logins", n)
+	}
+
+	// expired sessions log in again
+	mutex.Lock()
+	token = "second"
+	mutex.Unlock()
+
+	if got := load(); got != "<html>members</html>" {
+		t.Errorf("load after expiry: got %q", got)
+	}
+	if n := atomic.LoadInt32(&logins); n != 2 {
+		t.Errorf("expected 2 logins, got %d", n)
+	}
+
+	// missing credentials are reported
+	if err := clearSession(gc, "login"); err != nil {
+		t.Fatalf("clearSession: %v", err)
+	}
+	gc.credentials = nil
+	if _, err := loadURL(ctx, srv.URL+"/scene", srv.Client(), c, gc); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("expected ErrNoCredentials, got %v", err)
+	}
+
+	// rejected credentials are reported
+	gc.credentials = &models.ScraperCredentials{ScraperID: "login", Username: "alice", Password: "wrong"}
+	if _, err := loadURL(ctx, srv.URL+"/scene", srv.Client(), c, gc); !errors.Is(err, ErrLoginFailed) {
+		t.Errorf("expected ErrLoginFailed, got %v", err)
+	}
+}
+
+func TestSessionSaveDelayed(t *testing.T) {
+	resetSessions()
+	defer resetSessions()
+
+	c := config{ID: "delayed"}
+	gc := cachePathGlobalConfig{cachePath: t.TempDir()}
+	u, _ := url.Parse("https://example.com/")
+
+	s, err := getSession(c, gc)
+	if err != nil {
+		t.Fatalf("getSession: %v", err)
+	}
+
+	s.SetCookies(u, []*http.Cookie{{Name: "a", Value: "1"}})
+	s.SetCookies(u, []*http.Cookie{{Name: "b", Value: "2"}})
+
+	// cookies are written once the delay has passed
+	if _, err := os.Stat(s.path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected the session not to be written yet, got %v", err)
+	}
+
+	// reloading the scrapers writes the pending changes
+	resetSessions()
+
+	loaded, err := getSession(c, gc)
+	if err != nil {
+		t.Fatalf("getSession: %v", err)
+	}
+	if got := len(loaded.Cookies(u)); got != 2 {
+		t.Errorf("expected 2 cookies, got %d", got)
+	}
+
+	// clearing the session discards the pending changes
+	loaded.SetCookies(u, []*http.Cookie{{Name: "c", Value: "3"}})
+	if err := clearSession(gc, c.ID); err != nil {
+		t.Fatalf("clearSession: %v", err)
+	}
+
+	loaded.flush()
+	if _, err := os.Stat(loaded.path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected the cleared session not to be written, got %v", err)
+	}
+}
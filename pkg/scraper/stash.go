@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/jinzhu/copier"
 	"github.com/shurcooL/graphql"
@@ -132,6 +133,77 @@ type scrapedStudioStash struct {
 	URL  *string `graphql:"url" json:"url"`
 }
 
+type stashFindStudioNameStudio struct {
+	Name         string              `graphql:"name"`
+	URL          *string             `graphql:"url"`
+	Details      *string             `graphql:"details"`
+	Aliases      []string            `graphql:"aliases"`
+	ImagePath    *string             `graphql:"image_path"`
+	ParentStudio *scrapedStudioStash `graphql:"parent_studio"`
+}
+
+func (s stashFindStudioNameStudio) toStudio() *models.ScrapedStudio {
+	ret := &models.ScrapedStudio{
+		Name:    s.Name,
+		URL:     s.URL,
+		Details: s.Details,
+		Image:   s.ImagePath,
+	}
+
+	if len(s.Aliases) > 0 {
+		aliases := strings.Join(s.Aliases, ", ")
+		ret.Aliases = &aliases
+	}
+
+	if s.ParentStudio != nil {
+		ret.Parent = &models.ScrapedStudio{
+			Name: s.ParentStudio.Name,
+			URL:  s.ParentStudio.URL,
+		}
+	}
+
+	return ret
+}
+
+type stashFindStudioNamesResultType struct {
+	Count   int                          `graphql:"count"`
+	Studios []*stashFindStudioNameStudio `graphql:"studios"`
+}
+
+type stashFindTagNameTag struct {
+	Name        string             `graphql:"name"`
+	Description *string            `graphql:"description"`
+	Aliases     []string           `graphql:"aliases"`
+	ImagePath   *string            `graphql:"image_path"`
+	Parents     []*scrapedTagStash `graphql:"parents"`
+}
+
+func (t stashFindTagNameTag) toTag() *models.ScrapedTag {
+	ret := &models.ScrapedTag{
+		Name:        t.Name,
+		Description: t.Description,
+		Image:       t.ImagePath,
+	}
+
+	if len(t.Aliases) > 0 {
+		aliases := strings.Join(t.Aliases, ", ")
+		ret.Aliases = &aliases
+	}
+
+	for _, p := range t.Parents {
+		ret.Parents = append(ret.Parents, &models.ScrapedTag{
+			Name: p.Name,
+		})
+	}
+
+	return ret
+}
+
+type stashFindTagNamesResultType struct {
+	Count int                    `graphql:"count"`
+	Tags  []*stashFindTagNameTag `graphql:"tags"`
+}
+
 type stashFindSceneNamesResultType struct {
 	Count  int                  `graphql:"count"`
 	Scenes []*scrapedSceneStash `graphql:"scenes"`
@@ -202,6 +274,36 @@ func (s *stashScraper) scrapeByName(ctx context.Context, name string, ty ScrapeC
 			ret = append(ret, p.toPerformer())
 		}
 
+		return ret, nil
+	case ScrapeContentTypeStudio:
+		var q struct {
+			FindStudios stashFindStudioNamesResultType `graphql:"findStudios(filter: $f)"`
+		}
+
+		err := client.Query(ctx, &q, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, studio := range q.FindStudios.Studios {
+			ret = append(ret, studio.toStudio())
+		}
+
+		return ret, nil
+	case ScrapeContentTypeTag:
+		var q struct {
+			FindTags stashFindTagNamesResultType `graphql:"findTags(filter: $f)"`
+		}
+
+		err := client.Query(ctx, &q, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range q.FindTags.Tags {
+			ret = append(ret, t.toTag())
+		}
+
 		return ret, nil
 	}
 
@@ -307,6 +409,43 @@ func (s *stashScraper) scrapeGalleryByGallery(ctx context.Context, gallery *mode
 	return &ret, nil
 }
 
+type scrapedImageStash struct {
+	ID         string                   `graphql:"id" json:"id"`
+	Title      *string                  `graphql:"title" json:"title"`
+	URL        *string                  `graphql:"url" json:"url"`
+	Date       *string                  `graphql:"date" json:"date"`
+	Studio     *scrapedStudioStash      `graphql:"studio" json:"studio"`
+	Tags       []*scrapedTagStash       `graphql:"tags" json:"tags"`
+	Performers []*scrapedPerformerStash `graphql:"performers" json:"performers"`
+}
+
+func (s *stashScraper) scrapeImageByImage(ctx context.Context, image *models.Image) (*ScrapedImage, error) {
+	var q struct {
+		FindImage *scrapedImageStash `graphql:"findImage(checksum: $c)"`
+	}
+
+	vars := map[string]interface{}{
+		"c": graphql.String(image.Checksum),
+	}
+
+	client := s.getStashClient()
+	if err := client.Query(ctx, &q, vars); err != nil {
+		return nil, err
+	}
+
+	if q.FindImage == nil {
+		return nil, nil
+	}
+
+	// need to copy back to a scraped image
+	ret := ScrapedImage{}
+	if err := copier.Copy(&ret, q.FindImage); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
 func (s *stashScraper) scrapeByURL(_ context.Context, _ string, _ ScrapeContentType) (ScrapedContent, error) {
 	return nil, ErrNotSupported
 }
@@ -354,3 +493,24 @@ func galleryToUpdateInput(gallery *models.Gallery) models.GalleryUpdateInput {
 		Date:    dateToStringPtr(gallery.Date),
 	}
 }
+
+func imageToUpdateInput(image *models.Image) models.ImageUpdateInput {
+	dateToStringPtr := func(s *models.Date) *string {
+		if s != nil {
+			v := s.String()
+			return &v
+		}
+
+		return nil
+	}
+
+	// fallback to file basename if title is empty
+	title := image.GetTitle()
+
+	return models.ImageUpdateInput{
+		ID:    strconv.Itoa(image.ID),
+		Title: &title,
+		URL:   &image.URL,
+		Date:  dateToStringPtr(image.Date),
+	}
+}
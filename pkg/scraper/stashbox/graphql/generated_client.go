@@ -17,6 +17,10 @@ type StashBoxGraphQLClient interface {
 	SearchPerformer(ctx context.Context, term string, httpRequestOptions ...client.HTTPRequestOption) (*SearchPerformer, error)
 	FindPerformerByID(ctx context.Context, id string, httpRequestOptions ...client.HTTPRequestOption) (*FindPerformerByID, error)
 	FindSceneByID(ctx context.Context, id string, httpRequestOptions ...client.HTTPRequestOption) (*FindSceneByID, error)
+	FindStudio(ctx context.Context, id *string, name *string, httpRequestOptions ...client.HTTPRequestOption) (*FindStudio, error)
+	QueryStudios(ctx context.Context, input StudioQueryInput, httpRequestOptions ...client.HTTPRequestOption) (*QueryStudios, error)
+	FindTag(ctx context.Context, id *string, name *string, httpRequestOptions ...client.HTTPRequestOption) (*FindTag, error)
+	SearchTag(ctx context.Context, term string, httpRequestOptions ...client.HTTPRequestOption) (*SearchTag, error)
 	SubmitFingerprint(ctx context.Context, input FingerprintSubmission, httpRequestOptions ...client.HTTPRequestOption) (*SubmitFingerprint, error)
 	Me(ctx context.Context, httpRequestOptions ...client.HTTPRequestOption) (*Me, error)
 	SubmitSceneDraft(ctx context.Context, input SceneDraftInput, httpRequestOptions ...client.HTTPRequestOption) (*SubmitSceneDraft, error)
@@ -125,15 +129,32 @@ type ImageFragment struct {
 	Height int    "json:\"height\" graphql:\"height\""
 }
 type StudioFragment struct {
-	Name   string           "json:\"name\" graphql:\"name\""
-	ID     string           "json:\"id\" graphql:\"id\""
-	Urls   []*URLFragment   "json:\"urls\" graphql:\"urls\""
-	Images []*ImageFragment "json:\"images\" graphql:\"images\""
+	Name    string           "json:\"name\" graphql:\"name\""
+	ID      string           "json:\"id\" graphql:\"id\""
+	Deleted bool             "json:\"deleted\" graphql:\"deleted\""
+	Urls    []*URLFragment   "json:\"urls\" graphql:\"urls\""
+	Images  []*ImageFragment "json:\"images\" graphql:\"images\""
+	Parent  *struct {
+		Name string "json:\"name\" graphql:\"name\""
+		ID   string "json:\"id\" graphql:\"id\""
+	} "json:\"parent\" graphql:\"parent\""
 }
 type TagFragment struct {
 	Name string "json:\"name\" graphql:\"name\""
 	ID   string "json:\"id\" graphql:\"id\""
 }
+type FullTagFragment struct {
+	Name        string   "json:\"name\" graphql:\"name\""
+	ID          string   "json:\"id\" graphql:\"id\""
+	Deleted     bool     "json:\"deleted\" graphql:\"deleted\""
+	Description *string  "json:\"description\" graphql:\"description\""
+	Aliases     []string "json:\"aliases\" graphql:\"aliases\""
+	Category    *struct {
+		ID          string  "json:\"id\" graphql:\"id\""
+		Name        string  "json:\"name\" graphql:\"name\""
+		Description *string "json:\"description\" graphql:\"description\""
+	} "json:\"category\" graphql:\"category\""
+}
 type FuzzyDateFragment struct {
 	Date     string           "json:\"date\" graphql:\"date\""
 	Accuracy DateAccuracyEnum "json:\"accuracy\" graphql:\"accuracy\""
@@ -155,6 +176,7 @@ type PerformerFragment struct {
 	Aliases         []string                    "json:\"aliases\" graphql:\"aliases\""
 	Gender          *GenderEnum                 "json:\"gender\" graphql:\"gender\""
 	MergedIds       []string                    "json:\"merged_ids\" graphql:\"merged_ids\""
+	Deleted         bool                        "json:\"deleted\" graphql:\"deleted\""
 	Urls            []*URLFragment              "json:\"urls\" graphql:\"urls\""
 	Images          []*ImageFragment            "json:\"images\" graphql:\"images\""
 	Birthdate       *FuzzyDateFragment          "json:\"birthdate\" graphql:\"birthdate\""
@@ -181,6 +203,7 @@ type FingerprintFragment struct {
 }
 type SceneFragment struct {
 	ID           string                         "json:\"id\" graphql:\"id\""
+	Deleted      bool                           "json:\"deleted\" graphql:\"deleted\""
 	Title        *string                        "json:\"title\" graphql:\"title\""
 	Code         *string                        "json:\"code\" graphql:\"code\""
 	Details      *string                        "json:\"details\" graphql:\"details\""
@@ -215,6 +238,21 @@ type FindPerformerByID struct {
 type FindSceneByID struct {
 	FindScene *SceneFragment "json:\"findScene\" graphql:\"findScene\""
 }
+type FindStudio struct {
+	FindStudio *StudioFragment "json:\"findStudio\" graphql:\"findStudio\""
+}
+type QueryStudios struct {
+	QueryStudios struct {
+		Count   int               "json:\"count\" graphql:\"count\""
+		Studios []*StudioFragment "json:\"studios\" graphql:\"studios\""
+	} "json:\"queryStudios\" graphql:\"queryStudios\""
+}
+type FindTag struct {
+	FindTag *FullTagFragment "json:\"findTag\" graphql:\"findTag\""
+}
+type SearchTag struct {
+	SearchTag []*FullTagFragment "json:\"searchTag\" graphql:\"searchTag\""
+}
 type SubmitFingerprint struct {
 	SubmitFingerprint bool "json:\"submitFingerprint\" graphql:\"submitFingerprint\""
 }
@@ -252,6 +290,7 @@ fragment PerformerFragment on Performer {
 	aliases
 	gender
 	merged_ids
+	deleted
 	urls {
 		... URLFragment
 	}
@@ -281,6 +320,7 @@ fragment PerformerFragment on Performer {
 }
 fragment SceneFragment on Scene {
 	id
+	deleted
 	title
 	code
 	details
@@ -338,12 +378,17 @@ fragment FingerprintFragment on Fingerprint {
 fragment StudioFragment on Studio {
 	name
 	id
+	deleted
 	urls {
 		... URLFragment
 	}
 	images {
 		... ImageFragment
 	}
+	parent {
+		name
+		id
+	}
 }
 fragment TagFragment on Tag {
 	name
@@ -376,6 +421,7 @@ fragment PerformerFragment on Performer {
 	aliases
 	gender
 	merged_ids
+	deleted
 	urls {
 		... URLFragment
 	}
@@ -415,6 +461,7 @@ fragment MeasurementsFragment on Measurements {
 }
 fragment SceneFragment on Scene {
 	id
+	deleted
 	title
 	code
 	details
@@ -462,12 +509,17 @@ fragment FingerprintFragment on Fingerprint {
 fragment StudioFragment on Studio {
 	name
 	id
+	deleted
 	urls {
 		... URLFragment
 	}
 	images {
 		... ImageFragment
 	}
+	parent {
+		name
+		id
+	}
 }
 fragment TagFragment on Tag {
 	name
@@ -502,12 +554,17 @@ const FindScenesBySceneFingerprintsDocument = `query FindScenesBySceneFingerprin
 fragment StudioFragment on Studio {
 	name
 	id
+	deleted
 	urls {
 		... URLFragment
 	}
 	images {
 		... ImageFragment
 	}
+	parent {
+		name
+		id
+	}
 }
 fragment FuzzyDateFragment on FuzzyDate {
 	date
@@ -541,6 +598,7 @@ fragment PerformerFragment on Performer {
 	aliases
 	gender
 	merged_ids
+	deleted
 	urls {
 		... URLFragment
 	}
@@ -580,6 +638,7 @@ fragment BodyModificationFragment on BodyModification {
 }
 fragment SceneFragment on Scene {
 	id
+	deleted
 	title
 	code
 	details
@@ -631,6 +690,7 @@ const SearchSceneDocument = `query SearchScene ($term: String!) {
 }
 fragment SceneFragment on Scene {
 	id
+	deleted
 	title
 	code
 	details
@@ -683,12 +743,17 @@ fragment ImageFragment on Image {
 fragment StudioFragment on Studio {
 	name
 	id
+	deleted
 	urls {
 		... URLFragment
 	}
 	images {
 		... ImageFragment
 	}
+	parent {
+		name
+		id
+	}
 }
 fragment PerformerAppearanceFragment on PerformerAppearance {
 	as
@@ -703,6 +768,7 @@ fragment PerformerFragment on Performer {
 	aliases
 	gender
 	merged_ids
+	deleted
 	urls {
 		... URLFragment
 	}
@@ -780,6 +846,7 @@ fragment PerformerFragment on Performer {
 	aliases
 	gender
 	merged_ids
+	deleted
 	urls {
 		... URLFragment
 	}
@@ -844,6 +911,7 @@ fragment PerformerFragment on Performer {
 	aliases
 	gender
 	merged_ids
+	deleted
 	urls {
 		... URLFragment
 	}
@@ -947,6 +1015,7 @@ fragment PerformerFragment on Performer {
 	aliases
 	gender
 	merged_ids
+	deleted
 	urls {
 		... URLFragment
 	}
@@ -984,6 +1053,7 @@ fragment BodyModificationFragment on BodyModification {
 }
 fragment SceneFragment on Scene {
 	id
+	deleted
 	title
 	code
 	details
@@ -1018,12 +1088,17 @@ fragment ImageFragment on Image {
 fragment StudioFragment on Studio {
 	name
 	id
+	deleted
 	urls {
 		... URLFragment
 	}
 	images {
 		... ImageFragment
 	}
+	parent {
+		name
+		id
+	}
 }
 `
 
@@ -1040,6 +1115,165 @@ func (c *Client) FindSceneByID(ctx context.Context, id string, httpRequestOption
 	return &res, nil
 }
 
+const FindStudioDocument = `query FindStudio ($id: ID, $name: String) {
+	findStudio(id: $id, name: $name) {
+		... StudioFragment
+	}
+}
+fragment StudioFragment on Studio {
+	name
+	id
+	deleted
+	urls {
+		... URLFragment
+	}
+	images {
+		... ImageFragment
+	}
+	parent {
+		name
+		id
+	}
+}
+fragment URLFragment on URL {
+	url
+	type
+}
+fragment ImageFragment on Image {
+	id
+	url
+	width
+	height
+}
+`
+
+func (c *Client) FindStudio(ctx context.Context, id *string, name *string, httpRequestOptions ...client.HTTPRequestOption) (*FindStudio, error) {
+	vars := map[string]interface{}{
+		"id":   id,
+		"name": name,
+	}
+
+	var res FindStudio
+	if err := c.Client.Post(ctx, "FindStudio", FindStudioDocument, &res, vars, httpRequestOptions...); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+const QueryStudiosDocument = `query QueryStudios ($input: StudioQueryInput!) {
+	queryStudios(input: $input) {
+		count
+		studios {
+			... StudioFragment
+		}
+	}
+}
+fragment StudioFragment on Studio {
+	name
+	id
+	deleted
+	urls {
+		... URLFragment
+	}
+	images {
+		... ImageFragment
+	}
+	parent {
+		name
+		id
+	}
+}
+fragment URLFragment on URL {
+	url
+	type
+}
+fragment ImageFragment on Image {
+	id
+	url
+	width
+	height
+}
+`
+
+func (c *Client) QueryStudios(ctx context.Context, input StudioQueryInput, httpRequestOptions ...client.HTTPRequestOption) (*QueryStudios, error) {
+	vars := map[string]interface{}{
+		"input": input,
+	}
+
+	var res QueryStudios
+	if err := c.Client.Post(ctx, "QueryStudios", QueryStudiosDocument, &res, vars, httpRequestOptions...); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+const FindTagDocument = `query FindTag ($id: ID, $name: String) {
+	findTag(id: $id, name: $name) {
+		... FullTagFragment
+	}
+}
+fragment FullTagFragment on Tag {
+	name
+	id
+	deleted
+	description
+	aliases
+	category {
+		id
+		name
+		description
+	}
+}
+`
+
+func (c *Client) FindTag(ctx context.Context, id *string, name *string, httpRequestOptions ...client.HTTPRequestOption) (*FindTag, error) {
+	vars := map[string]interface{}{
+		"id":   id,
+		"name": name,
+	}
+
+	var res FindTag
+	if err := c.Client.Post(ctx, "FindTag", FindTagDocument, &res, vars, httpRequestOptions...); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+const SearchTagDocument = `query SearchTag ($term: String!) {
+	searchTag(term: $term) {
+		... FullTagFragment
+	}
+}
+fragment FullTagFragment on Tag {
+	name
+	id
+	deleted
+	description
+	aliases
+	category {
+		id
+		name
+		description
+	}
+}
+`
+
+func (c *Client) SearchTag(ctx context.Context, term string, httpRequestOptions ...client.HTTPRequestOption) (*SearchTag, error) {
+	vars := map[string]interface{}{
+		"term": term,
+	}
+
+	var res SearchTag
+	if err := c.Client.Post(ctx, "SearchTag", SearchTagDocument, &res, vars, httpRequestOptions...); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
 const SubmitFingerprintDocument = `mutation SubmitFingerprint ($input: FingerprintSubmission!) {
 	submitFingerprint(input: $input)
 }
@@ -26,7 +26,6 @@ import (
 	"github.com/stashapp/stash/pkg/scraper/stashbox/graphql"
 	"github.com/stashapp/stash/pkg/sliceutil/stringslice"
 	"github.com/stashapp/stash/pkg/studio"
-	"github.com/stashapp/stash/pkg/tag"
 	"github.com/stashapp/stash/pkg/txn"
 	"github.com/stashapp/stash/pkg/utils"
 )
@@ -52,15 +51,24 @@ type StudioReader interface {
 	models.StashIDLoader
 }
 type TagFinder interface {
-	tag.Queryer
+	match.TagFinder
 	FindBySceneID(ctx context.Context, sceneID int) ([]*models.Tag, error)
 }
 
+// FingerprintHistoryReader provides the fingerprints that files had before
+// their contents were replaced.
+type FingerprintHistoryReader interface {
+	GetFingerprintHistory(ctx context.Context, fileID file.ID) ([]file.Fingerprint, error)
+}
+
 type Repository struct {
 	Scene     SceneReader
 	Performer PerformerReader
 	Tag       TagFinder
 	Studio    StudioReader
+	// File is optional. If set, the previous fingerprints of files are
+	// queried and submitted along with the current fingerprints.
+	File FingerprintHistoryReader
 }
 
 // Client represents the client interface to a stash-box server instance.
@@ -114,6 +122,22 @@ func (c Client) QueryStashBoxScene(ctx context.Context, queryStr string) ([]*scr
 	return ret, nil
 }
 
+// FindStashBoxSceneByID returns the scene with the given stash-box id, or nil
+// if it was not found or was deleted. If the scene was merged into another
+// scene, the scene it was merged into is returned.
+func (c Client) FindStashBoxSceneByID(ctx context.Context, id string) (*scraper.ScrapedScene, error) {
+	scene, err := c.client.FindSceneByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if scene.FindScene == nil || scene.FindScene.Deleted {
+		return nil, nil
+	}
+
+	return c.sceneFragmentToScrapedScene(ctx, scene.FindScene)
+}
+
 // FindStashBoxScenesByFingerprints queries stash-box for a scene using the
 // scene's MD5/OSHASH checksum, or PHash.
 func (c Client) FindStashBoxSceneByFingerprints(ctx context.Context, sceneID int) ([]*scraper.ScrapedScene, error) {
@@ -174,6 +198,20 @@ func (c Client) FindStashBoxScenesByFingerprints(ctx context.Context, ids []int)
 						Algorithm: graphql.FingerprintAlgorithmPhash,
 					})
 				}
+
+				history, err := c.fingerprintHistory(ctx, f.ID)
+				if err != nil {
+					return err
+				}
+
+				for _, fp := range history {
+					if hash, algorithm, ok := fingerprintHash(fp); ok {
+						sceneFPs = append(sceneFPs, &graphql.FingerprintQueryInput{
+							Hash:      hash,
+							Algorithm: algorithm,
+						})
+					}
+				}
 			}
 
 			fingerprints = append(fingerprints, sceneFPs)
@@ -313,6 +351,29 @@ func (c Client) SubmitStashBoxFingerprints(ctx context.Context, sceneIDs []strin
 								Fingerprint: &fingerprint,
 							})
 						}
+
+						// the previous contents of the file had the same duration
+						history, err := c.fingerprintHistory(ctx, f.ID)
+						if err != nil {
+							return err
+						}
+
+						for _, fp := range history {
+							hash, algorithm, ok := fingerprintHash(fp)
+							if !ok {
+								continue
+							}
+
+							fingerprint := graphql.FingerprintInput{
+								Hash:      hash,
+								Algorithm: algorithm,
+								Duration:  int(duration),
+							}
+							fingerprints = append(fingerprints, graphql.FingerprintSubmission{
+								SceneID:     sceneStashID,
+								Fingerprint: &fingerprint,
+							})
+						}
 					}
 				}
 			}
@@ -326,6 +387,37 @@ func (c Client) SubmitStashBoxFingerprints(ctx context.Context, sceneIDs []strin
 	return c.submitStashBoxFingerprints(ctx, fingerprints)
 }
 
+// fingerprintHistory returns the previous fingerprints of the file, if the
+// repository provides them.
+func (c Client) fingerprintHistory(ctx context.Context, fileID file.ID) ([]file.Fingerprint, error) {
+	if c.repository.File == nil {
+		return nil, nil
+	}
+
+	return c.repository.File.GetFingerprintHistory(ctx, fileID)
+}
+
+// fingerprintHash returns the stash-box hash and algorithm of the
+// fingerprint. Returns false if stash-box does not support the fingerprint.
+func fingerprintHash(fp file.Fingerprint) (string, graphql.FingerprintAlgorithm, bool) {
+	switch fp.Type {
+	case file.FingerprintTypeMD5:
+		if v, ok := fp.Fingerprint.(string); ok && v != "" {
+			return v, graphql.FingerprintAlgorithmMd5, true
+		}
+	case file.FingerprintTypeOshash:
+		if v, ok := fp.Fingerprint.(string); ok && v != "" {
+			return v, graphql.FingerprintAlgorithmOshash, true
+		}
+	case file.FingerprintTypePhash:
+		if v, ok := fp.Fingerprint.(int64); ok && v != 0 {
+			return utils.PhashToString(v), graphql.FingerprintAlgorithmPhash, true
+		}
+	}
+
+	return "", "", false
+}
+
 func (c Client) submitStashBoxFingerprints(ctx context.Context, fingerprints []graphql.FingerprintSubmission) (bool, error) {
 	for _, fingerprint := range fingerprints {
 		_, err := c.client.SubmitFingerprint(ctx, fingerprint)
@@ -716,12 +808,7 @@ func (c Client) sceneFragmentToScrapedScene(ctx context.Context, s *graphql.Scen
 		tqb := c.repository.Tag
 
 		if s.Studio != nil {
-			studioID := s.Studio.ID
-			ss.Studio = &models.ScrapedStudio{
-				Name:         s.Studio.Name,
-				URL:          findURL(s.Studio.Urls, "HOME"),
-				RemoteSiteID: &studioID,
-			}
+			ss.Studio = studioFragmentToScrapedStudio(*s.Studio)
 
 			err := match.ScrapedStudio(ctx, c.repository.Studio, ss.Studio, &c.box.Endpoint)
 			if err != nil {
@@ -741,11 +828,13 @@ func (c Client) sceneFragmentToScrapedScene(ctx context.Context, s *graphql.Scen
 		}
 
 		for _, t := range s.Tags {
+			tagID := t.ID
 			st := &models.ScrapedTag{
-				Name: t.Name,
+				Name:         t.Name,
+				RemoteSiteID: &tagID,
 			}
 
-			err := match.ScrapedTag(ctx, tqb, st)
+			err := match.ScrapedTag(ctx, tqb, st, &c.box.Endpoint)
 			if err != nil {
 				return err
 			}
@@ -761,12 +850,19 @@ func (c Client) sceneFragmentToScrapedScene(ctx context.Context, s *graphql.Scen
 	return ss, nil
 }
 
+// FindStashBoxPerformerByID returns the performer with the given stash-box
+// id, or nil if it was not found or was deleted. If the performer was merged
+// into another performer, the performer it was merged into is returned.
 func (c Client) FindStashBoxPerformerByID(ctx context.Context, id string) (*models.ScrapedPerformer, error) {
 	performer, err := c.client.FindPerformerByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
+	if performer.FindPerformer == nil || performer.FindPerformer.Deleted {
+		return nil, nil
+	}
+
 	ret := performerFragmentToScrapedScenePerformer(*performer.FindPerformer)
 	return ret, nil
 }
@@ -787,6 +883,265 @@ func (c Client) FindStashBoxPerformerByName(ctx context.Context, name string) (*
 	return ret, nil
 }
 
+func studioFragmentToScrapedStudio(s graphql.StudioFragment) *models.ScrapedStudio {
+	studioID := s.ID
+	ret := &models.ScrapedStudio{
+		Name:         s.Name,
+		URL:          findURL(s.Urls, "HOME"),
+		RemoteSiteID: &studioID,
+	}
+
+	if s.Parent != nil {
+		parentID := s.Parent.ID
+		ret.Parent = &models.ScrapedStudio{
+			Name:         s.Parent.Name,
+			RemoteSiteID: &parentID,
+		}
+	}
+
+	return ret
+}
+
+func (c Client) studioFragmentsToScrapedStudios(ctx context.Context, fragments []*graphql.StudioFragment) ([]*models.ScrapedStudio, error) {
+	var ret []*models.ScrapedStudio
+	for _, fragment := range fragments {
+		studio := studioFragmentToScrapedStudio(*fragment)
+		if len(fragment.Images) > 0 {
+			studio.Image = getFirstImage(ctx, c.getHTTPClient(), fragment.Images)
+		}
+		ret = append(ret, studio)
+	}
+
+	if err := txn.WithReadTxn(ctx, c.txnManager, func(ctx context.Context) error {
+		for _, studio := range ret {
+			if err := match.ScrapedStudio(ctx, c.repository.Studio, studio, &c.box.Endpoint); err != nil {
+				return err
+			}
+
+			if studio.Parent != nil {
+				if err := match.ScrapedStudio(ctx, c.repository.Studio, studio.Parent, &c.box.Endpoint); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// QueryStashBoxStudio queries stash-box for studios using a query string.
+func (c Client) QueryStashBoxStudio(ctx context.Context, queryStr string) ([]*models.ScrapedStudio, error) {
+	studios, err := c.client.QueryStudios(ctx, graphql.StudioQueryInput{
+		Name:      &queryStr,
+		Page:      1,
+		PerPage:   25,
+		Direction: graphql.SortDirectionEnumAsc,
+		Sort:      graphql.StudioSortEnumName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.studioFragmentsToScrapedStudios(ctx, studios.QueryStudios.Studios)
+}
+
+// FindStashBoxStudio returns the studio with the given stash-box id, or nil
+// if not found or deleted.
+func (c Client) FindStashBoxStudio(ctx context.Context, id string) (*models.ScrapedStudio, error) {
+	return c.findStashBoxStudio(ctx, &id, nil)
+}
+
+// FindStashBoxStudioByName returns the studio with the given name, or nil
+// if not found.
+func (c Client) FindStashBoxStudioByName(ctx context.Context, name string) (*models.ScrapedStudio, error) {
+	return c.findStashBoxStudio(ctx, nil, &name)
+}
+
+// FindStashBoxStudioByURL returns the studio with a URL matching the given
+// URL, or nil if not found.
+func (c Client) FindStashBoxStudioByURL(ctx context.Context, url string) (*models.ScrapedStudio, error) {
+	studios, err := c.client.QueryStudios(ctx, graphql.StudioQueryInput{
+		URL:       &url,
+		Page:      1,
+		PerPage:   25,
+		Direction: graphql.SortDirectionEnumAsc,
+		Sort:      graphql.StudioSortEnumName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, studio := range studios.QueryStudios.Studios {
+		if studio.Deleted {
+			continue
+		}
+
+		for _, u := range studio.Urls {
+			if u != nil && sameURL(u.URL, url) {
+				ret, err := c.studioFragmentsToScrapedStudios(ctx, []*graphql.StudioFragment{studio})
+				if err != nil {
+					return nil, err
+				}
+
+				return ret[0], nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// sameURL compares URLs ignoring case, scheme and trailing slashes.
+func sameURL(a, b string) bool {
+	normalise := func(u string) string {
+		u = strings.ToLower(u)
+		u = strings.TrimPrefix(u, "https://")
+		u = strings.TrimPrefix(u, "http://")
+		u = strings.TrimPrefix(u, "www.")
+		return strings.TrimRight(u, "/")
+	}
+
+	return normalise(a) == normalise(b)
+}
+
+func (c Client) findStashBoxStudio(ctx context.Context, id *string, name *string) (*models.ScrapedStudio, error) {
+	studio, err := c.client.FindStudio(ctx, id, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if studio.FindStudio == nil || studio.FindStudio.Deleted {
+		return nil, nil
+	}
+
+	ret, err := c.studioFragmentsToScrapedStudios(ctx, []*graphql.StudioFragment{studio.FindStudio})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret[0], nil
+}
+
+func tagFragmentToScrapedTag(t graphql.FullTagFragment) *models.ScrapedTag {
+	tagID := t.ID
+	ret := &models.ScrapedTag{
+		Name:         t.Name,
+		Description:  t.Description,
+		RemoteSiteID: &tagID,
+	}
+
+	if len(t.Aliases) > 0 {
+		aliases := strings.Join(t.Aliases, ", ")
+		ret.Aliases = &aliases
+	}
+
+	// the category is returned as the parent of the tag
+	if t.Category != nil {
+		ret.Parents = []*models.ScrapedTag{
+			{
+				Name:        t.Category.Name,
+				Description: t.Category.Description,
+			},
+		}
+	}
+
+	return ret
+}
+
+func (c Client) tagFragmentsToScrapedTags(ctx context.Context, fragments []*graphql.FullTagFragment) ([]*models.ScrapedTag, error) {
+	var ret []*models.ScrapedTag
+	for _, fragment := range fragments {
+		ret = append(ret, tagFragmentToScrapedTag(*fragment))
+	}
+
+	if err := txn.WithReadTxn(ctx, c.txnManager, func(ctx context.Context) error {
+		for _, t := range ret {
+			if err := match.ScrapedTag(ctx, c.repository.Tag, t, &c.box.Endpoint); err != nil {
+				return err
+			}
+
+			for _, p := range t.Parents {
+				if err := match.ScrapedTag(ctx, c.repository.Tag, p, nil); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// QueryStashBoxTag queries stash-box for tags using a query string.
+func (c Client) QueryStashBoxTag(ctx context.Context, queryStr string) ([]*models.ScrapedTag, error) {
+	tags, err := c.client.SearchTag(ctx, queryStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.tagFragmentsToScrapedTags(ctx, tags.SearchTag)
+}
+
+// FindStashBoxTag returns the tag with the given stash-box id, or nil if not
+// found or deleted.
+func (c Client) FindStashBoxTag(ctx context.Context, id string) (*models.ScrapedTag, error) {
+	t, err := c.client.FindTag(ctx, &id, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.findTagResult(ctx, t.FindTag)
+}
+
+// FindStashBoxTagByName returns the tag with the given name or alias, or nil
+// if not found.
+func (c Client) FindStashBoxTagByName(ctx context.Context, name string) (*models.ScrapedTag, error) {
+	t, err := c.client.FindTag(ctx, nil, &name)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.FindTag != nil {
+		return c.findTagResult(ctx, t.FindTag)
+	}
+
+	// fall back to searching for the alias
+	tags, err := c.client.SearchTag(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tag := range tags.SearchTag {
+		for _, alias := range tag.Aliases {
+			if strings.EqualFold(alias, name) {
+				return c.findTagResult(ctx, tag)
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+func (c Client) findTagResult(ctx context.Context, t *graphql.FullTagFragment) (*models.ScrapedTag, error) {
+	if t == nil || t.Deleted {
+		return nil, nil
+	}
+
+	ret, err := c.tagFragmentsToScrapedTags(ctx, []*graphql.FullTagFragment{t})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret[0], nil
+}
+
 func (c Client) GetUser(ctx context.Context) (*graphql.Me, error) {
 	return c.client.Me(ctx)
 }
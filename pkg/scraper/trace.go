@@ -0,0 +1,113 @@
+package scraper
+
+import (
+	"context"
+	"sync"
+)
+
+// PostProcessStep is the result of a single post-process action applied to
+// a scraped value.
+type PostProcessStep struct {
+	Action string `json:"action"`
+	Input  string `json:"input"`
+	Output string `json:"output"`
+}
+
+// FieldTrace records how the value of a mapped field was extracted. Field is
+// the full path of the field, e.g. "Title" or "Performers.Name".
+type FieldTrace struct {
+	Field    string             `json:"field"`
+	Selector string             `json:"selector"`
+	Fixed    *string            `json:"fixed"`
+	Found    []string           `json:"found"`
+	Steps    []*PostProcessStep `json:"steps"`
+	Result   []string           `json:"result"`
+}
+
+// scrapeTrace collects the field traces of a scrape.
+type scrapeTrace struct {
+	mutex  sync.Mutex
+	fields []*FieldTrace
+}
+
+func (t *scrapeTrace) addField(field, selector string) *FieldTrace {
+	ret := &FieldTrace{
+		Field:    field,
+		Selector: selector,
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.fields = append(t.fields, ret)
+
+	return ret
+}
+
+type traceContextKey struct{}
+type fieldTraceContextKey struct{}
+type tracePathContextKey struct{}
+
+func withScrapeTrace(ctx context.Context, t *scrapeTrace) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, t)
+}
+
+func getScrapeTrace(ctx context.Context) *scrapeTrace {
+	t, _ := ctx.Value(traceContextKey{}).(*scrapeTrace)
+	return t
+}
+
+// withTracePath returns a context in which traced fields are nested under
+// the given field.
+func withTracePath(ctx context.Context, field string) context.Context {
+	return context.WithValue(ctx, tracePathContextKey{}, tracePath(ctx, field))
+}
+
+// tracePath returns the full path of the field in the current context.
+func tracePath(ctx context.Context, field string) string {
+	if p, _ := ctx.Value(tracePathContextKey{}).(string); p != "" {
+		return p + "." + field
+	}
+
+	return field
+}
+
+func withFieldTrace(ctx context.Context, t *FieldTrace) context.Context {
+	if t == nil {
+		return ctx
+	}
+
+	return context.WithValue(ctx, fieldTraceContextKey{}, t)
+}
+
+func getFieldTrace(ctx context.Context) *FieldTrace {
+	t, _ := ctx.Value(fieldTraceContextKey{}).(*FieldTrace)
+	return t
+}
+
+// withoutTrace returns a context in which nothing is traced. Used for
+// nested scrapes, which are recorded as a single post-process step.
+func withoutTrace(ctx context.Context) context.Context {
+	ctx = context.WithValue(ctx, traceContextKey{}, (*scrapeTrace)(nil))
+	return context.WithValue(ctx, fieldTraceContextKey{}, (*FieldTrace)(nil))
+}
+
+func postProcessActionName(a postProcessAction) string {
+	switch a.(type) {
+	case *postProcessParseDate:
+		return "parseDate"
+	case *postProcessSubtractDays:
+		return "subtractDays"
+	case *postProcessReplace:
+		return "replace"
+	case *postProcessSubScraper:
+		return "subScraper"
+	case *postProcessMap:
+		return "map"
+	case *postProcessFeetToCm:
+		return "feetToCm"
+	case *postProcessLbToKg:
+		return "lbToKg"
+	}
+
+	return "unknown"
+}
@@ -5,16 +5,14 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
+	"net/http/cookiejar"
 	"net/url"
-	"os"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/chromedp/cdproto/cdp"
-	"github.com/chromedp/cdproto/fetch"
 	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/chromedp"
 	jsoniter "github.com/json-iterator/go"
@@ -28,31 +26,111 @@ const scrapeDefaultSleep = time.Second * 2
 func loadURL(ctx context.Context, loadURL string, client *http.Client, scraperConfig config, globalConfig GlobalConfig) (io.Reader, error) {
 	driverOptions := scraperConfig.DriverOptions
 	if driverOptions != nil && driverOptions.UseCDP {
+		release, err := waitRateLimit(ctx, loadURL, driverOptions)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+
 		// get the page using chrome dp
-		return urlFromCDP(ctx, loadURL, *driverOptions, globalConfig)
+		return urlFromCDP(ctx, loadURL, scraperConfig, globalConfig)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, loadURL, nil)
+	cache := newResponseCache(scraperConfig, globalConfig)
+	cached := cache.get(loadURL)
+	if cache.fresh(cached) {
+		logger.Debugf("[scraper] using cached response for %s", loadURL)
+		return charset.NewReader(bytes.NewReader(cached.Body), cached.ContentType)
+	}
+
+	req := scrapeRequest{
+		method: http.MethodGet,
+		url:    loadURL,
+	}
+	resp, body, err := doSessionRequest(ctx, client, scraperConfig, globalConfig, func(client *http.Client) (*http.Response, []byte, error) {
+		return doScrapeRequest(ctx, req, client, scraperConfig, globalConfig, cached)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	jar, err := scraperConfig.jar()
+	return handleScrapeResponse(loadURL, resp, body, cache, cached)
+}
+
+// postURL posts the body to the url with the given content type, returning
+// the response body. Responses to POST requests are not cached.
+func postURL(ctx context.Context, postURL string, body string, contentType string, client *http.Client, scraperConfig config, globalConfig GlobalConfig) ([]byte, error) {
+	req := scrapeRequest{
+		method:      http.MethodPost,
+		url:         postURL,
+		body:        body,
+		contentType: contentType,
+	}
+	resp, respBody, err := doSessionRequest(ctx, client, scraperConfig, globalConfig, func(client *http.Client) (*http.Response, []byte, error) {
+		return doScrapeRequest(ctx, req, client, scraperConfig, globalConfig, nil)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error creating cookie jar: %w", err)
+		return nil, err
 	}
 
-	u, err := url.Parse(loadURL)
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("http error %d:%s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	return respBody, nil
+}
+
+// doSessionRequest sends a request using do. Scrapers which log in keep their
+// cookies in a persistent session, and the request is sent again after
+// logging in if the session has expired.
+func doSessionRequest(ctx context.Context, client *http.Client, scraperConfig config, globalConfig GlobalConfig, do func(client *http.Client) (*http.Response, []byte, error)) (*http.Response, []byte, error) {
+	driverOptions := scraperConfig.DriverOptions
+	if driverOptions == nil || driverOptions.Login == nil {
+		return do(client)
+	}
+
+	session, err := getSession(scraperConfig, globalConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client = session.client(client)
+	login := func() error {
+		return session.login(ctx, client, globalConfig)
+	}
+
+	if err := session.ensureLogin(login); err != nil {
+		return nil, nil, err
+	}
+
+	since := session.loggedInAt()
+
+	resp, body, err := do(client)
 	if err != nil {
-		return nil, fmt.Errorf("error parsing url %s: %w", loadURL, err)
+		return nil, nil, err
 	}
 
-	// Fetch relevant cookies from the jar for url u and add them to the request
-	cookies := jar.Cookies(u)
-	for _, cookie := range cookies {
-		req.AddCookie(cookie)
+	if driverOptions.Login.isExpired(resp.StatusCode, body) {
+		if err := session.relogin(since, login); err != nil {
+			return nil, nil, err
+		}
+
+		resp, body, err = do(client)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if driverOptions.Login.isExpired(resp.StatusCode, body) {
+			return nil, nil, fmt.Errorf("%w for scraper %s: still logged out after logging in", ErrLoginFailed, scraperConfig.ID)
+		}
 	}
 
+	return resp, body, nil
+}
+
+// setRequestHeaders sets the user agent and the headers listed in the
+// driver options
+func setRequestHeaders(req *http.Request, driverOptions *scraperDriverOptions, globalConfig GlobalConfig) {
 	userAgent := globalConfig.GetScraperUserAgent()
 	if userAgent != "" {
 		req.Header.Set("User-Agent", userAgent)
@@ -66,176 +144,230 @@ func loadURL(ctx context.Context, loadURL string, client *http.Client, scraperCo
 			}
 		}
 	}
+}
 
-	resp, err := client.Do(req)
+// scrapeRequest is a request sent by a scraper. The body and content type
+// are only set for POST requests.
+type scrapeRequest struct {
+	method      string
+	url         string
+	body        string
+	contentType string
+}
+
+// doScrapeRequest sends the request and reads the response body. Cookies
+// from the scraper configuration are added to the request unless the client
+// has its own cookie jar.
+func doScrapeRequest(ctx context.Context, r scrapeRequest, client *http.Client, scraperConfig config, globalConfig GlobalConfig, cached *cachedResponse) (*http.Response, []byte, error) {
+	loadURL := r.url
+
+	var reqBody io.Reader
+	if r.method == http.MethodPost {
+		reqBody = strings.NewReader(r.body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.method, loadURL, reqBody)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("http error %d:%s", resp.StatusCode, http.StatusText(resp.StatusCode))
+
+	var jar *cookiejar.Jar
+	if client.Jar == nil {
+		jar, err = scraperConfig.jar()
+		if err != nil {
+			return nil, nil, fmt.Errorf("error creating cookie jar: %w", err)
+		}
+
+		u, err := url.Parse(loadURL)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error parsing url %s: %w", loadURL, err)
+		}
+
+		// Fetch relevant cookies from the jar for url u and add them to the request
+		cookies := jar.Cookies(u)
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+	}
+
+	driverOptions := scraperConfig.DriverOptions
+	setRequestHeaders(req, driverOptions, globalConfig)
+	if r.contentType != "" {
+		req.Header.Set("Content-Type", r.contentType)
+	}
+
+	// revalidate the stale cached response if present
+	cached.setConditionalHeaders(req)
+
+	release, err := waitRateLimit(ctx, loadURL, driverOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	if jar != nil {
+		printCookies(jar, scraperConfig, "Jar cookies found for scraper urls")
 	}
 
-	bodyReader := bytes.NewReader(body)
-	printCookies(jar, scraperConfig, "Jar cookies found for scraper urls")
-	return charset.NewReader(bodyReader, resp.Header.Get("Content-Type"))
+	return resp, body, nil
 }
 
-// func urlFromCDP uses chrome cdp and DOM to load and process the url
-// if remote is set as true in the scraperConfig  it will try to use localhost:9222
-// else it will look for google-chrome in path
-func urlFromCDP(ctx context.Context, urlCDP string, driverOptions scraperDriverOptions, globalConfig GlobalConfig) (io.Reader, error) {
+// handleScrapeResponse caches the response and returns a reader of its body.
+func handleScrapeResponse(loadURL string, resp *http.Response, body []byte, cache *responseCache, cached *cachedResponse) (io.Reader, error) {
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		logger.Debugf("[scraper] cached response for %s not modified", loadURL)
+		cached.Fetched = time.Now()
+		cache.put(cached)
+		return charset.NewReader(bytes.NewReader(cached.Body), cached.ContentType)
+	}
 
-	if !driverOptions.UseCDP {
-		return nil, fmt.Errorf("url shouldn't be fetched through CDP")
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("http error %d:%s", resp.StatusCode, http.StatusText(resp.StatusCode))
 	}
 
-	sleepDuration := scrapeDefaultSleep
+	contentType := resp.Header.Get("Content-Type")
+	cache.put(&cachedResponse{
+		URL:          loadURL,
+		ContentType:  contentType,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Fetched:      time.Now(),
+		Body:         body,
+	})
+
+	return charset.NewReader(bytes.NewReader(body), contentType)
+}
 
-	if driverOptions.Sleep > 0 {
-		sleepDuration = time.Duration(driverOptions.Sleep) * time.Second
+// func urlFromCDP uses chrome cdp and DOM to load and process the url
+// the page is loaded in a tab of the browser pool, which uses the remote
+// browser if scraperCDPPath is a remote address, or runs the chrome
+// executable otherwise
+func urlFromCDP(ctx context.Context, urlCDP string, scraperConfig config, globalConfig GlobalConfig) (io.Reader, error) {
+	if scraperConfig.DriverOptions == nil || !scraperConfig.DriverOptions.UseCDP {
+		return nil, fmt.Errorf("url shouldn't be fetched through CDP")
 	}
 
-	// if scraperCDPPath is a remote address, then allocate accordingly
-	cdpPath := globalConfig.GetScraperCDPPath()
-	if cdpPath != "" {
-		var cancelAct context.CancelFunc
-
-		if isCDPPathHTTP(globalConfig) || isCDPPathWS(globalConfig) {
-			remote := cdpPath
+	var res string
+	if err := getCDPPool(globalConfig).run(ctx, func(ctx context.Context) error {
+		// add a fixed timeout for the http request
+		ctx, cancel := context.WithTimeout(ctx, scrapeGetTimeout)
+		defer cancel()
+
+		var err error
+		res, err = loadCDPPage(ctx, urlCDP, scraperConfig, globalConfig)
+		return err
+	}); err != nil {
+		return nil, err
+	}
 
-			// -------------------------------------------------------------------
-			// #1023
-			// when chromium is listening over RDP it only accepts requests
-			// with host headers that are either IPs or `localhost`
-			cdpURL, err := url.Parse(remote)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse CDP Path: %v", err)
-			}
-			hostname := cdpURL.Hostname()
-			if hostname != "localhost" {
-				if net.ParseIP(hostname) == nil { // not an IP
-					addr, err := net.LookupIP(hostname)
-					if err != nil || len(addr) == 0 { // can not resolve to IP
-						return nil, fmt.Errorf("CDP: hostname <%s> can not be resolved", hostname)
-					}
-					if len(addr[0]) == 0 { // nil IP
-						return nil, fmt.Errorf("CDP: hostname <%s> resolved to nil", hostname)
-					}
-					// addr is a valid IP
-					// replace the host part of the cdpURL with the IP
-					cdpURL.Host = strings.Replace(cdpURL.Host, hostname, addr[0].String(), 1)
-					// use that for remote
-					remote = cdpURL.String()
-				}
-			}
-			// --------------------------------------------------------------------
-
-			// if CDPPath is http(s) then we need to get the websocket URL
-			if isCDPPathHTTP(globalConfig) {
-				var err error
-				remote, err = getRemoteCDPWSAddress(ctx, remote)
-				if err != nil {
-					return nil, err
-				}
-			}
+	return strings.NewReader(res), nil
+}
 
-			ctx, cancelAct = chromedp.NewRemoteAllocator(ctx, remote)
-		} else {
-			// use a temporary user directory for chrome
-			dir, err := os.MkdirTemp("", "stash-chromedp")
-			if err != nil {
-				return nil, err
-			}
-			defer os.RemoveAll(dir)
-
-			opts := append(chromedp.DefaultExecAllocatorOptions[:],
-				chromedp.UserDataDir(dir),
-				chromedp.ExecPath(cdpPath),
-			)
-			if globalConfig.GetProxy() != "" {
-				url, _, _ := splitProxyAuth(globalConfig.GetProxy())
-				opts = append(opts, chromedp.ProxyServer(url))
-			}
+// loadCDPPage loads the url in the tab of the context, returning the html of
+// the page.
+func loadCDPPage(ctx context.Context, urlCDP string, scraperConfig config, globalConfig GlobalConfig) (string, error) {
+	driverOptions := *scraperConfig.DriverOptions
 
-			ctx, cancelAct = chromedp.NewExecAllocator(ctx, opts...)
-		}
+	sleepDuration := scrapeDefaultSleep
 
-		defer cancelAct()
+	if driverOptions.Sleep > 0 {
+		sleepDuration = time.Duration(driverOptions.Sleep) * time.Second
 	}
 
-	ctx, cancel := chromedp.NewContext(ctx)
-	defer cancel()
-
-	// add a fixed timeout for the http request
-	ctx, cancel = context.WithTimeout(ctx, scrapeGetTimeout)
-	defer cancel()
-
 	var res string
 	headers := cdpHeaders(driverOptions)
 
-	if proxyUsesAuth(globalConfig.GetProxy()) {
-		_, user, pass := splitProxyAuth(globalConfig.GetProxy())
-
-		// Based on https://github.com/chromedp/examples/blob/master/proxy/main.go
-		lctx, lcancel := context.WithCancel(ctx)
-		chromedp.ListenTarget(lctx, func(ev interface{}) {
-			switch ev := ev.(type) {
-			case *fetch.EventRequestPaused:
-				go func() {
-					_ = chromedp.Run(ctx, fetch.ContinueRequest(ev.RequestID))
-				}()
-			case *fetch.EventAuthRequired:
-				if ev.AuthChallenge.Source == fetch.AuthChallengeSourceProxy {
-					go func() {
-						_ = chromedp.Run(ctx,
-							fetch.ContinueWithAuth(ev.RequestID, &fetch.AuthChallengeResponse{
-								Response: fetch.AuthChallengeResponseResponseProvideCredentials,
-								Username: user,
-								Password: pass,
-							}),
-							// Chrome will remember the credential for the current instance,
-							// so we can disable the fetch domain once credential is provided.
-							// Please file an issue if Chrome does not work in this way.
-							fetch.Disable(),
-						)
-						// and cancel the event handler too.
-						lcancel()
-					}()
-				}
-			}
-		})
-	}
-
 	err := chromedp.Run(ctx,
 		network.Enable(),
 		setCDPCookies(driverOptions),
 		printCDPCookies(driverOptions, "Cookies found"),
 		network.SetExtraHTTPHeaders(network.Headers(headers)),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	page := chromedp.Tasks{
 		chromedp.Navigate(urlCDP),
 		chromedp.Sleep(sleepDuration),
-		setCDPClicks(driverOptions),
+		setCDPClicks(driverOptions.Clicks),
 		chromedp.OuterHTML("html", &res, chromedp.ByQuery),
 		printCDPCookies(driverOptions, "Cookies set"),
-	)
+	}
+
+	if driverOptions.Login == nil {
+		if err := chromedp.Run(ctx, page); err != nil {
+			return "", err
+		}
+
+		return res, nil
+	}
 
+	// scrapers which log in keep their cookies in a persistent session
+	session, err := getSession(scraperConfig, globalConfig)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	return strings.NewReader(res), nil
+	if err := chromedp.Run(ctx,
+		session.setCDPCookies(driverOptions.Login.URL),
+		session.setCDPCookies(urlCDP),
+	); err != nil {
+		return "", err
+	}
+
+	login := func() error {
+		return session.loginCDP(ctx, globalConfig)
+	}
+
+	if err := session.ensureLogin(login); err != nil {
+		return "", err
+	}
+
+	since := session.loggedInAt()
+	if err := chromedp.Run(ctx, page); err != nil {
+		return "", err
+	}
+
+	// status codes are not available through the browser, so only the
+	// expired xpath is checked
+	if driverOptions.Login.isExpired(0, []byte(res)) {
+		if err := session.relogin(since, login); err != nil {
+			return "", err
+		}
+
+		if err := chromedp.Run(ctx, page); err != nil {
+			return "", err
+		}
+
+		if driverOptions.Login.isExpired(0, []byte(res)) {
+			return "", fmt.Errorf("%w for scraper %s: still logged out after logging in", ErrLoginFailed, scraperConfig.ID)
+		}
+	}
+
+	if err := chromedp.Run(ctx, session.storeCDPCookies(urlCDP)); err != nil {
+		return "", err
+	}
+
+	return res, nil
 }
 
 // click all xpaths listed in the scraper config
-func setCDPClicks(driverOptions scraperDriverOptions) chromedp.Tasks {
+func setCDPClicks(clicks []*clickOptions) chromedp.Tasks {
 	var tasks chromedp.Tasks
-	for _, click := range driverOptions.Clicks { // for each click element find the node from the xpath and add a click action
+	for _, click := range clicks { // for each click element find the node from the xpath and add a click action
 		if click.XPath != "" {
 			xpath := click.XPath
 			waitDuration := scrapeDefaultSleep
@@ -0,0 +1,207 @@
+package scraper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type cachePathGlobalConfig struct {
+	mockGlobalConfig
+	cachePath string
+}
+
+func (c cachePathGlobalConfig) GetCachePath() string {
+	return c.cachePath
+}
+
+func TestLoadURLCache(t *testing.T) {
+	const etag = `"v1"`
+	var requests, notModified int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Header.Get("If-None-Match") == etag {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = io.WriteString(w, "<html>cached</html>")
+	}))
+	defer srv.Close()
+
+	c := config{
+		ID: "test",
+		DriverOptions: &scraperDriverOptions{
+			Cache: &cacheOptions{TTL: 60},
+		},
+	}
+	gc := cachePathGlobalConfig{cachePath: t.TempDir()}
+	ctx := context.Background()
+
+	load := func() string {
+		t.Helper()
+		r, err := loadURL(ctx, srv.URL, srv.Client(), c, gc)
+		if err != nil {
+			t.Fatalf("loadURL: %v", err)
+		}
+		b, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("reading body: %v", err)
+		}
+		return string(b)
+	}
+
+	if got := load(); got != "<html>cached</html>" {
+		t.Errorf("first load: got %q", got)
+	}
+
+	// fresh entry is served without a request
+	if got := load(); got != "<html>cached</html>" {
+		t.Errorf("cached load: got %q", got)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("expected 1 request, got %d", n)
+	}
+
+	// stale entry is revalidated
+	cache := newResponseCache(c, gc)
+	entry := cache.get(srv.URL)
+	if entry == nil {
+		t.Fatal("expected cached entry")
+	}
+	entry.Fetched = time.Now().Add(-time.Hour)
+	cache.put(entry)
+
+	if got := load(); got != "<html>cached</html>" {
+		t.Errorf("revalidated load: got %q", got)
+	}
+	if n := atomic.LoadInt32(&notModified); n != 1 {
+		t.Errorf("expected 1 conditional request, got %d", n)
+	}
+	if entry := cache.get(srv.URL); !cache.fresh(entry) {
+		t.Error("expected revalidated entry to be fresh")
+	}
+}
+
+func TestResponseCacheEvict(t *testing.T) {
+	cache := &responseCache{
+		dir: t.TempDir(),
+		ttl: time.Minute,
+	}
+
+	cache.put(&cachedResponse{URL: "http://example.com/old", Fetched: time.Now()})
+	cache.put(&cachedResponse{URL: "http://example.com/new", Fetched: time.Now()})
+
+	now := time.Now()
+
+	old := now.Add(-cacheEvictTTLs * cache.ttl)
+	if err := os.Chtimes(cache.path("http://example.com/old"), old, old); err != nil {
+		t.Fatalf("setting modification time: %v", err)
+	}
+
+	// eviction has just run when the first entry was put
+	if cache.evictDue(now) {
+		t.Error("expected eviction not to be due")
+	}
+
+	cache.evict(now)
+
+	if cache.get("http://example.com/old") != nil {
+		t.Error("expected old entry to be evicted")
+	}
+	if cache.get("http://example.com/new") == nil {
+		t.Error("expected new entry to be kept")
+	}
+
+	if !cache.evictDue(now.Add(cacheEvictInterval)) {
+		t.Error("expected eviction to be due after the interval")
+	}
+}
+
+func TestRateLimitInterval(t *testing.T) {
+	l := &domainLimiter{}
+	l.tighten(rateLimitOptions{RequestsPerSecond: 20})
+
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		release, err := l.acquire(ctx)
+		if err != nil {
+			t.Fatalf("acquire: %v", err)
+		}
+		release()
+	}
+
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("expected requests to be spaced out, took %v", elapsed)
+	}
+}
+
+func TestRateLimitConcurrency(t *testing.T) {
+	l := &domainLimiter{}
+	l.tighten(rateLimitOptions{Concurrency: 2})
+	// a looser setting from another scraper must not raise the limit
+	l.tighten(rateLimitOptions{Concurrency: 4})
+	l.tighten(rateLimitOptions{Concurrency: 1})
+
+	release, err := l.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.acquire(ctx); err == nil {
+		t.Error("expected second acquire to block until the context expired")
+	}
+
+	release()
+
+	release, err = l.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+	release()
+}
+
+func TestResetRateLimiters(t *testing.T) {
+	defer resetRateLimiters()
+
+	const domain = "example.com"
+	strict := rateLimiters.get(domain, rateLimitOptions{Concurrency: 1})
+	if rateLimiters.get(domain, rateLimitOptions{Concurrency: 4}) != strict {
+		t.Fatal("expected the limiter to be shared")
+	}
+	if cap(strict.slots) != 1 {
+		t.Errorf("expected concurrency 1, got %d", cap(strict.slots))
+	}
+
+	// a relaxed limit applies once the scrapers are reloaded
+	resetRateLimiters()
+	if rateLimiters.find(domain) != nil {
+		t.Error("expected the limiter to be discarded")
+	}
+
+	relaxed := rateLimiters.get(domain, rateLimitOptions{Concurrency: 4})
+	if cap(relaxed.slots) != 4 {
+		t.Errorf("expected concurrency 4, got %d", cap(relaxed.slots))
+	}
+
+	release, err := waitDomainRateLimit(context.Background(), "https://"+domain+"/image.jpg")
+	if err != nil {
+		t.Fatalf("waitDomainRateLimit: %v", err)
+	}
+	if len(relaxed.slots) != 1 {
+		t.Error("expected the image request to use the limiter of its domain")
+	}
+	release()
+}
@@ -69,8 +69,12 @@ func (s *xpathScraper) scrapeByURL(ctx context.Context, url string, ty ScrapeCon
 		return scraper.scrapeScene(ctx, q)
 	case ScrapeContentTypeGallery:
 		return scraper.scrapeGallery(ctx, q)
+	case ScrapeContentTypeImage:
+		return scraper.scrapeImage(ctx, q)
 	case ScrapeContentTypeMovie:
 		return scraper.scrapeMovie(ctx, q)
+	case ScrapeContentTypeStudio:
+		return scraper.scrapeStudio(ctx, q)
 	}
 
 	return nil, ErrNotSupported
@@ -121,6 +125,26 @@ func (s *xpathScraper) scrapeByName(ctx context.Context, name string, ty ScrapeC
 			content = append(content, s)
 		}
 
+		return content, nil
+	case ScrapeContentTypeStudio:
+		studios, err := scraper.scrapeStudios(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range studios {
+			content = append(content, s)
+		}
+
+		return content, nil
+	case ScrapeContentTypeTag:
+		tags, err := scraper.scrapeTags(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tags {
+			content = append(content, t)
+		}
+
 		return content, nil
 	}
 
@@ -155,6 +179,8 @@ func (s *xpathScraper) scrapeByFragment(ctx context.Context, input Input) (Scrap
 	switch {
 	case input.Gallery != nil:
 		return nil, fmt.Errorf("%w: cannot use an xpath scraper as a gallery fragment scraper", ErrNotSupported)
+	case input.Image != nil:
+		return nil, fmt.Errorf("%w: cannot use an xpath scraper as an image fragment scraper", ErrNotSupported)
 	case input.Performer != nil:
 		return nil, fmt.Errorf("%w: cannot use an xpath scraper as a performer fragment scraper", ErrNotSupported)
 	case input.Scene == nil:
@@ -210,6 +236,30 @@ func (s *xpathScraper) scrapeGalleryByGallery(ctx context.Context, gallery *mode
 	return scraper.scrapeGallery(ctx, q)
 }
 
+func (s *xpathScraper) scrapeImageByImage(ctx context.Context, image *models.Image) (*ScrapedImage, error) {
+	// construct the URL
+	queryURL := queryURLParametersFromImage(image)
+	if s.scraper.QueryURLReplacements != nil {
+		queryURL.applyReplacements(s.scraper.QueryURLReplacements)
+	}
+	url := queryURL.constructURL(s.scraper.QueryURL)
+
+	scraper := s.getXpathScraper()
+
+	if scraper == nil {
+		return nil, errors.New("xpath scraper with name " + s.scraper.Scraper + " not found in config")
+	}
+
+	doc, err := s.loadURL(ctx, url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	q := s.getXPathQuery(doc)
+	return scraper.scrapeImage(ctx, q)
+}
+
 func (s *xpathScraper) loadURL(ctx context.Context, url string) (*html.Node, error) {
 	r, err := loadURL(ctx, url, s.client, s.config, s.globalConfig)
 	if err != nil {
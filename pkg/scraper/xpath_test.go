@@ -822,6 +822,22 @@ func (mockGlobalConfig) GetScraperCDPPath() string {
 	return ""
 }
 
+func (mockGlobalConfig) GetScraperCDPPoolMin() int {
+	return 0
+}
+
+func (mockGlobalConfig) GetScraperCDPPoolMax() int {
+	return 0
+}
+
+func (mockGlobalConfig) GetScraperCDPIdleTimeout() int {
+	return 0
+}
+
+func (mockGlobalConfig) GetScraperCDPBlockResources() bool {
+	return false
+}
+
 func (mockGlobalConfig) GetScraperCertCheck() bool {
 	return false
 }
@@ -838,6 +854,14 @@ func (mockGlobalConfig) GetProxy() string {
 	return ""
 }
 
+func (mockGlobalConfig) GetCachePath() string {
+	return ""
+}
+
+func (mockGlobalConfig) GetScraperCredentials(scraperID string) *models.ScraperCredentials {
+	return nil
+}
+
 func TestSubScrape(t *testing.T) {
 	retHTML := `
 	<div>
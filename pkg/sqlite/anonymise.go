@@ -47,6 +47,10 @@ func (db *Anonymiser) Anonymise(ctx context.Context) error {
 		return utils.Do([]func() error{
 			func() error { return db.deleteBlobs() },
 			func() error { return db.deleteStashIDs() },
+			// pending reviews contain scraped metadata
+			func() error { return db.truncateTable(identifyReviewTable) },
+			func() error { return db.truncateTable(deletedStashIDTable) },
+			func() error { return db.truncateTable(fingerprintHistoryTable) },
 			func() error { return db.anonymiseFolders(ctx) },
 			func() error { return db.anonymiseFiles(ctx) },
 			func() error { return db.anonymiseFingerprints(ctx) },
@@ -98,6 +102,7 @@ func (db *Anonymiser) deleteStashIDs() error {
 		func() error { return db.truncateTable("scene_stash_ids") },
 		func() error { return db.truncateTable("studio_stash_ids") },
 		func() error { return db.truncateTable("performer_stash_ids") },
+		func() error { return db.truncateTable("tag_stash_ids") },
 	})
 }
 
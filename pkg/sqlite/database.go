@@ -32,7 +32,7 @@ const (
 	dbConnTimeout = 30
 )
 
-var appSchemaVersion uint = 46
+var appSchemaVersion uint = 51
 
 //go:embed migrations/*.sql
 var migrationsBox embed.FS
@@ -77,6 +77,8 @@ type Database struct {
 	Tag            *TagStore
 	Movie          *MovieStore
 	SavedFilter    *SavedFilterStore
+	IdentifyReview *IdentifyReviewStore
+	DeletedStashID *DeletedStashIDStore
 
 	db     *sqlx.DB
 	dbPath string
@@ -105,6 +107,8 @@ func NewDatabase() *Database {
 		Tag:            NewTagStore(blobStore),
 		Movie:          NewMovieStore(blobStore),
 		SavedFilter:    NewSavedFilterStore(),
+		IdentifyReview: NewIdentifyReviewStore(),
+		DeletedStashID: NewDeletedStashIDStore(),
 		lockChan:       make(chan struct{}, 1),
 	}
 
@@ -0,0 +1,167 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/jmoiron/sqlx"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const (
+	deletedStashIDTable = "deleted_stash_ids"
+)
+
+type deletedStashIDRow struct {
+	ID          int       `db:"id" goqu:"skipinsert"`
+	SceneID     null.Int  `db:"scene_id,omitempty"`
+	PerformerID null.Int  `db:"performer_id,omitempty"`
+	StudioID    null.Int  `db:"studio_id,omitempty"`
+	Endpoint    string    `db:"endpoint"`
+	StashID     string    `db:"stash_id"`
+	CreatedAt   Timestamp `db:"created_at"`
+}
+
+func (r *deletedStashIDRow) fromDeletedStashID(o models.DeletedStashID) {
+	r.ID = o.ID
+	r.SceneID = intFromPtr(o.SceneID)
+	r.PerformerID = intFromPtr(o.PerformerID)
+	r.StudioID = intFromPtr(o.StudioID)
+	r.Endpoint = o.Endpoint
+	r.StashID = o.StashID
+	r.CreatedAt = Timestamp{Timestamp: o.CreatedAt}
+}
+
+func (r *deletedStashIDRow) resolve() *models.DeletedStashID {
+	return &models.DeletedStashID{
+		ID:          r.ID,
+		SceneID:     nullIntPtr(r.SceneID),
+		PerformerID: nullIntPtr(r.PerformerID),
+		StudioID:    nullIntPtr(r.StudioID),
+		Endpoint:    r.Endpoint,
+		StashID:     r.StashID,
+		CreatedAt:   r.CreatedAt.Timestamp,
+	}
+}
+
+type DeletedStashIDStore struct {
+	repository
+
+	tableMgr *table
+}
+
+func NewDeletedStashIDStore() *DeletedStashIDStore {
+	return &DeletedStashIDStore{
+		repository: repository{
+			tableName: deletedStashIDTable,
+			idColumn:  idColumn,
+		},
+		tableMgr: deletedStashIDTableMgr,
+	}
+}
+
+func (qb *DeletedStashIDStore) table() exp.IdentifierExpression {
+	return qb.tableMgr.table
+}
+
+func (qb *DeletedStashIDStore) selectDataset() *goqu.SelectDataset {
+	return dialect.From(qb.table()).Select(qb.table().All())
+}
+
+func (qb *DeletedStashIDStore) Create(ctx context.Context, newObject *models.DeletedStashID) error {
+	var r deletedStashIDRow
+	r.fromDeletedStashID(*newObject)
+
+	id, err := qb.tableMgr.insertID(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	updated, err := qb.find(ctx, id)
+	if err != nil {
+		return fmt.Errorf("finding after create: %w", err)
+	}
+
+	*newObject = *updated
+
+	return nil
+}
+
+func (qb *DeletedStashIDStore) Destroy(ctx context.Context, id int) error {
+	return qb.tableMgr.destroyExisting(ctx, []int{id})
+}
+
+func (qb *DeletedStashIDStore) DestroyByStashID(ctx context.Context, stashID models.StashID) error {
+	table := qb.table()
+	q := dialect.Delete(table).Where(
+		table.Col("endpoint").Eq(stashID.Endpoint),
+		table.Col("stash_id").Eq(stashID.StashID),
+	)
+
+	if _, err := exec(ctx, q); err != nil {
+		return fmt.Errorf("destroying %s: %w", deletedStashIDTable, err)
+	}
+
+	return nil
+}
+
+// returns nil, nil if not found
+func (qb *DeletedStashIDStore) Find(ctx context.Context, id int) (*models.DeletedStashID, error) {
+	ret, err := qb.find(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return ret, err
+}
+
+// returns nil, sql.ErrNoRows if not found
+func (qb *DeletedStashIDStore) find(ctx context.Context, id int) (*models.DeletedStashID, error) {
+	q := qb.selectDataset().Where(qb.tableMgr.byID(id))
+
+	ret, err := qb.getMany(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ret) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return ret[0], nil
+}
+
+func (qb *DeletedStashIDStore) getMany(ctx context.Context, q *goqu.SelectDataset) ([]*models.DeletedStashID, error) {
+	const single = false
+	var ret []*models.DeletedStashID
+	if err := queryFunc(ctx, q, single, func(r *sqlx.Rows) error {
+		var f deletedStashIDRow
+		if err := r.StructScan(&f); err != nil {
+			return err
+		}
+
+		ret = append(ret, f.resolve())
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (qb *DeletedStashIDStore) FindByEndpoint(ctx context.Context, endpoint string) ([]*models.DeletedStashID, error) {
+	table := qb.table()
+	q := qb.selectDataset().Prepared(true).Where(table.Col("endpoint").Eq(endpoint)).Order(table.Col(idColumn).Asc())
+	return qb.getMany(ctx, q)
+}
+
+func (qb *DeletedStashIDStore) All(ctx context.Context) ([]*models.DeletedStashID, error) {
+	table := qb.table()
+	q := qb.selectDataset().Order(table.Col("endpoint").Asc(), table.Col(idColumn).Asc())
+	return qb.getMany(ctx, q)
+}
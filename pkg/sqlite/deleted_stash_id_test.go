@@ -0,0 +1,78 @@
+//go:build integration
+// +build integration
+
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeletedStashIDCreateFind(t *testing.T) {
+	const (
+		endpoint      = "https://stashdb.example/graphql"
+		otherEndpoint = "https://other.example/graphql"
+	)
+
+	sceneID := sceneIDs[sceneIdxWithGallery]
+	studioID := studioIDs[studioIdxWithScene]
+
+	withRollbackTxn(func(ctx context.Context) error {
+		scene := models.DeletedStashID{
+			SceneID:   &sceneID,
+			Endpoint:  endpoint,
+			StashID:   "scene-stash-id",
+			CreatedAt: time.Now(),
+		}
+		studio := models.DeletedStashID{
+			StudioID:  &studioID,
+			Endpoint:  otherEndpoint,
+			StashID:   "studio-stash-id",
+			CreatedAt: time.Now(),
+		}
+
+		for _, d := range []*models.DeletedStashID{&scene, &studio} {
+			if err := db.DeletedStashID.Create(ctx, d); err != nil {
+				t.Errorf("Error creating deleted stash id: %v", err)
+				return nil
+			}
+		}
+
+		found, err := db.DeletedStashID.Find(ctx, scene.ID)
+		if err != nil {
+			t.Errorf("Error finding deleted stash id: %v", err)
+		}
+		assert.Equal(t, &sceneID, found.SceneID)
+		assert.Nil(t, found.PerformerID)
+		assert.Nil(t, found.StudioID)
+
+		byEndpoint, err := db.DeletedStashID.FindByEndpoint(ctx, otherEndpoint)
+		if err != nil {
+			t.Errorf("Error finding deleted stash ids by endpoint: %v", err)
+		}
+		if assert.Len(t, byEndpoint, 1) {
+			assert.Equal(t, studio.ID, byEndpoint[0].ID)
+		}
+
+		if err := db.DeletedStashID.DestroyByStashID(ctx, models.StashID{
+			Endpoint: endpoint,
+			StashID:  scene.StashID,
+		}); err != nil {
+			t.Errorf("Error destroying deleted stash id: %v", err)
+		}
+
+		all, err := db.DeletedStashID.All(ctx)
+		if err != nil {
+			t.Errorf("Error finding all deleted stash ids: %v", err)
+		}
+		if assert.Len(t, all, 1) {
+			assert.Equal(t, studio.ID, all[0].ID)
+		}
+
+		return nil
+	})
+}
@@ -703,6 +703,18 @@ func (qb *FileStore) FindByFingerprint(ctx context.Context, fp file.Fingerprint)
 	return qb.findBySubquery(ctx, sq)
 }
 
+// AddFingerprintHistory records fingerprints which the file had before its
+// contents were replaced, so that scenes can still be found by them.
+func (qb *FileStore) AddFingerprintHistory(ctx context.Context, fileID file.ID, fp []file.Fingerprint) error {
+	return fingerprintHistoryReaderWriter.insertJoins(ctx, fileID, fp)
+}
+
+// GetFingerprintHistory returns the previous fingerprints of the file, oldest
+// first.
+func (qb *FileStore) GetFingerprintHistory(ctx context.Context, fileID file.ID) ([]file.Fingerprint, error) {
+	return fingerprintHistoryReaderWriter.get(ctx, fileID)
+}
+
 func (qb *FileStore) FindByZipFileID(ctx context.Context, zipFileID file.ID) ([]file.File, error) {
 	table := qb.table()
 
@@ -906,6 +918,12 @@ func (qb *FileStore) GetCaptions(ctx context.Context, fileID file.ID) ([]*models
 	return qb.captionRepository().get(ctx, fileID)
 }
 
+// GetManyCaptions returns the captions of each file, in the order of the
+// file ids.
+func (qb *FileStore) GetManyCaptions(ctx context.Context, fileIDs []file.ID) ([][]*models.VideoCaption, error) {
+	return qb.captionRepository().getMany(ctx, fileIDs)
+}
+
 func (qb *FileStore) UpdateCaptions(ctx context.Context, fileID file.ID, captions []*models.VideoCaption) error {
 	return qb.captionRepository().replace(ctx, fileID, captions)
 }
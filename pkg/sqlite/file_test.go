@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/models"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -662,3 +663,33 @@ func TestFileStore_IsPrimary(t *testing.T) {
 		})
 	}
 }
+
+func TestFileStore_GetManyCaptions(t *testing.T) {
+	withRollbackTxn(func(ctx context.Context) error {
+		qb := db.File
+
+		captions := []*models.VideoCaption{
+			{LanguageCode: "en", Filename: "scene.en.srt", CaptionType: "srt"},
+			{LanguageCode: "de", Filename: "scene.de.vtt", CaptionType: "vtt"},
+		}
+		withCaptions := sceneFileIDs[sceneIdx1WithPerformer]
+		if err := qb.UpdateCaptions(ctx, withCaptions, captions); err != nil {
+			t.Errorf("FileStore.UpdateCaptions() error = %v", err)
+			return nil
+		}
+
+		withoutCaptions := sceneFileIDs[sceneIdxWithGallery]
+		got, err := qb.GetManyCaptions(ctx, []file.ID{withoutCaptions, withCaptions, invalidFileID})
+		if err != nil {
+			t.Errorf("FileStore.GetManyCaptions() error = %v", err)
+			return nil
+		}
+
+		if assert.Len(t, got, 3) {
+			assert.Empty(t, got[0])
+			assert.ElementsMatch(t, captions, got[1])
+			assert.Empty(t, got[2])
+		}
+		return nil
+	})
+}
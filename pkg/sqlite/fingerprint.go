@@ -3,15 +3,18 @@ package sqlite
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/doug-martin/goqu/v9"
 	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/jmoiron/sqlx"
 	"github.com/stashapp/stash/pkg/file"
 	"gopkg.in/guregu/null.v4"
 )
 
 const (
-	fingerprintTable = "files_fingerprints"
+	fingerprintTable        = "files_fingerprints"
+	fingerprintHistoryTable = "files_fingerprint_history"
 )
 
 type fingerprintQueryRow struct {
@@ -79,3 +82,86 @@ func (qb *fingerprintQueryBuilder) replaceJoins(ctx context.Context, fileID file
 func (qb *fingerprintQueryBuilder) table() exp.IdentifierExpression {
 	return qb.tableMgr.table
 }
+
+// fingerprintHistoryQueryBuilder stores the previous fingerprints of files
+// whose contents were replaced, so that they can still be matched.
+type fingerprintHistoryQueryBuilder struct {
+	repository
+
+	tableMgr *table
+}
+
+var fingerprintHistoryReaderWriter = &fingerprintHistoryQueryBuilder{
+	repository: repository{
+		tableName: fingerprintHistoryTable,
+		idColumn:  fileIDColumn,
+	},
+
+	tableMgr: fingerprintHistoryTableMgr,
+}
+
+func (qb *fingerprintHistoryQueryBuilder) table() exp.IdentifierExpression {
+	return qb.tableMgr.table
+}
+
+func (qb *fingerprintHistoryQueryBuilder) insertJoins(ctx context.Context, fileID file.ID, f []file.Fingerprint) error {
+	table := qb.table()
+	now := Timestamp{Timestamp: time.Now()}
+
+	for _, ff := range f {
+		q := dialect.Insert(table).Cols(fileIDColumn, "type", "fingerprint", "created_at").Vals(
+			goqu.Vals{fileID, ff.Type, ff.Fingerprint, now},
+		).OnConflict(goqu.DoNothing())
+
+		if _, err := exec(ctx, q); err != nil {
+			return fmt.Errorf("inserting into %s: %w", table.GetTable(), err)
+		}
+	}
+
+	return nil
+}
+
+func (qb *fingerprintHistoryQueryBuilder) get(ctx context.Context, fileID file.ID) ([]file.Fingerprint, error) {
+	table := qb.table()
+	q := dialect.From(table).Select(
+		table.Col("type").As("fingerprint_type"),
+		table.Col("fingerprint"),
+	).Where(table.Col(fileIDColumn).Eq(fileID)).Order(table.Col("created_at").Asc())
+
+	var ret []file.Fingerprint
+	const single = false
+	if err := queryFunc(ctx, q, single, func(rows *sqlx.Rows) error {
+		var r fingerprintQueryRow
+		if err := rows.StructScan(&r); err != nil {
+			return err
+		}
+
+		ret = append(ret, r.resolve())
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("getting fingerprint history for file %d: %w", fileID, err)
+	}
+
+	return ret, nil
+}
+
+// hasFingerprints returns an expression matching the file ids in col of
+// files which have, or had, any of the provided fingerprints.
+func hasFingerprints(col exp.IdentifierExpression, fp []file.Fingerprint) exp.Expression {
+	matches := func(table exp.IdentifierExpression) *goqu.SelectDataset {
+		var ex []exp.Expression
+		for _, v := range fp {
+			ex = append(ex, goqu.And(
+				table.Col("type").Eq(v.Type),
+				table.Col("fingerprint").Eq(v.Fingerprint),
+			))
+		}
+
+		return dialect.From(table).Select(table.Col(fileIDColumn)).Where(goqu.Or(ex...))
+	}
+
+	return goqu.Or(
+		col.In(matches(fingerprintTableMgr.table)),
+		col.In(matches(fingerprintHistoryTableMgr.table)),
+	)
+}
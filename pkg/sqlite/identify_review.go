@@ -0,0 +1,160 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const (
+	identifyReviewTable = "identify_reviews"
+)
+
+type identifyReviewRow struct {
+	ID         int       `db:"id" goqu:"skipinsert"`
+	SceneID    int       `db:"scene_id"`
+	Source     string    `db:"source"`
+	Confidence float64   `db:"confidence"`
+	Data       string    `db:"data"`
+	CreatedAt  Timestamp `db:"created_at"`
+}
+
+func (r *identifyReviewRow) fromIdentifyReview(o models.IdentifyReview) {
+	r.ID = o.ID
+	r.SceneID = o.SceneID
+	r.Source = o.Source
+	r.Confidence = o.Confidence
+	r.Data = o.Data
+	r.CreatedAt = Timestamp{Timestamp: o.CreatedAt}
+}
+
+func (r *identifyReviewRow) resolve() *models.IdentifyReview {
+	return &models.IdentifyReview{
+		ID:         r.ID,
+		SceneID:    r.SceneID,
+		Source:     r.Source,
+		Confidence: r.Confidence,
+		Data:       r.Data,
+		CreatedAt:  r.CreatedAt.Timestamp,
+	}
+}
+
+type IdentifyReviewStore struct {
+	repository
+
+	tableMgr *table
+}
+
+func NewIdentifyReviewStore() *IdentifyReviewStore {
+	return &IdentifyReviewStore{
+		repository: repository{
+			tableName: identifyReviewTable,
+			idColumn:  idColumn,
+		},
+		tableMgr: identifyReviewTableMgr,
+	}
+}
+
+func (qb *IdentifyReviewStore) table() exp.IdentifierExpression {
+	return qb.tableMgr.table
+}
+
+func (qb *IdentifyReviewStore) selectDataset() *goqu.SelectDataset {
+	return dialect.From(qb.table()).Select(qb.table().All())
+}
+
+func (qb *IdentifyReviewStore) Create(ctx context.Context, newObject *models.IdentifyReview) error {
+	var r identifyReviewRow
+	r.fromIdentifyReview(*newObject)
+
+	id, err := qb.tableMgr.insertID(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	updated, err := qb.find(ctx, id)
+	if err != nil {
+		return fmt.Errorf("finding after create: %w", err)
+	}
+
+	*newObject = *updated
+
+	return nil
+}
+
+func (qb *IdentifyReviewStore) Destroy(ctx context.Context, id int) error {
+	return qb.tableMgr.destroyExisting(ctx, []int{id})
+}
+
+func (qb *IdentifyReviewStore) DestroyBySceneID(ctx context.Context, sceneID int) error {
+	q := dialect.Delete(qb.table()).Where(qb.table().Col("scene_id").Eq(sceneID))
+
+	if _, err := exec(ctx, q); err != nil {
+		return fmt.Errorf("destroying %s: %w", identifyReviewTable, err)
+	}
+
+	return nil
+}
+
+// returns nil, nil if not found
+func (qb *IdentifyReviewStore) Find(ctx context.Context, id int) (*models.IdentifyReview, error) {
+	ret, err := qb.find(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return ret, err
+}
+
+// returns nil, sql.ErrNoRows if not found
+func (qb *IdentifyReviewStore) find(ctx context.Context, id int) (*models.IdentifyReview, error) {
+	q := qb.selectDataset().Where(qb.tableMgr.byID(id))
+
+	ret, err := qb.getMany(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ret) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return ret[0], nil
+}
+
+func (qb *IdentifyReviewStore) getMany(ctx context.Context, q *goqu.SelectDataset) ([]*models.IdentifyReview, error) {
+	const single = false
+	var ret []*models.IdentifyReview
+	if err := queryFunc(ctx, q, single, func(r *sqlx.Rows) error {
+		var f identifyReviewRow
+		if err := r.StructScan(&f); err != nil {
+			return err
+		}
+
+		ret = append(ret, f.resolve())
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (qb *IdentifyReviewStore) FindBySceneID(ctx context.Context, sceneID int) ([]*models.IdentifyReview, error) {
+	table := qb.table()
+	q := qb.selectDataset().Prepared(true).Where(table.Col("scene_id").Eq(sceneID)).Order(table.Col(idColumn).Asc())
+	return qb.getMany(ctx, q)
+}
+
+// All returns all pending reviews, lowest confidence first.
+func (qb *IdentifyReviewStore) All(ctx context.Context) ([]*models.IdentifyReview, error) {
+	table := qb.table()
+	q := qb.selectDataset().Order(table.Col("confidence").Asc(), table.Col(idColumn).Asc())
+	return qb.getMany(ctx, q)
+}
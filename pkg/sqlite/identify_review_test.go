@@ -0,0 +1,66 @@
+//go:build integration
+// +build integration
+
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentifyReviewCreateFind(t *testing.T) {
+	sceneID := sceneIDs[sceneIdxWithGallery]
+
+	withRollbackTxn(func(ctx context.Context) error {
+		low := models.IdentifyReview{
+			SceneID:    sceneID,
+			Source:     "low",
+			Confidence: 0.2,
+			Data:       "{}",
+			CreatedAt:  time.Now(),
+		}
+		high := low
+		high.Source = "high"
+		high.Confidence = 0.6
+
+		for _, r := range []*models.IdentifyReview{&high, &low} {
+			if err := db.IdentifyReview.Create(ctx, r); err != nil {
+				t.Errorf("Error creating review: %v", err)
+				return nil
+			}
+		}
+
+		found, err := db.IdentifyReview.Find(ctx, low.ID)
+		if err != nil {
+			t.Errorf("Error finding review: %v", err)
+		}
+		assert.Equal(t, "low", found.Source)
+
+		all, err := db.IdentifyReview.All(ctx)
+		if err != nil {
+			t.Errorf("Error finding all reviews: %v", err)
+		}
+
+		// lowest confidence first
+		if assert.Len(t, all, 2) {
+			assert.Equal(t, low.ID, all[0].ID)
+			assert.Equal(t, high.ID, all[1].ID)
+		}
+
+		if err := db.IdentifyReview.DestroyBySceneID(ctx, sceneID); err != nil {
+			t.Errorf("Error destroying reviews: %v", err)
+		}
+
+		bySceneID, err := db.IdentifyReview.FindBySceneID(ctx, sceneID)
+		if err != nil {
+			t.Errorf("Error finding reviews by scene id: %v", err)
+		}
+		assert.Len(t, bySceneID, 0)
+
+		return nil
+	})
+}
@@ -0,0 +1,4 @@
+ALTER TABLE `scenes` ADD COLUMN `intro_start` float;
+ALTER TABLE `scenes` ADD COLUMN `intro_end` float;
+ALTER TABLE `scenes` ADD COLUMN `outro_start` float;
+ALTER TABLE `scenes` ADD COLUMN `outro_end` float;
@@ -0,0 +1,11 @@
+CREATE TABLE `identify_reviews` (
+  `id` integer not null primary key autoincrement,
+  `scene_id` integer not null,
+  `source` varchar(255) not null,
+  `confidence` real not null,
+  `data` text not null,
+  `created_at` datetime not null,
+  foreign key(`scene_id`) references `scenes`(`id`) on delete CASCADE
+);
+
+CREATE INDEX `index_identify_reviews_on_scene_id` on `identify_reviews` (`scene_id`);
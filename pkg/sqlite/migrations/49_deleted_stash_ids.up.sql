@@ -0,0 +1,14 @@
+CREATE TABLE `deleted_stash_ids` (
+  `id` integer not null primary key autoincrement,
+  `scene_id` integer,
+  `performer_id` integer,
+  `studio_id` integer,
+  `endpoint` varchar(255) not null,
+  `stash_id` varchar(36) not null,
+  `created_at` datetime not null,
+  foreign key(`scene_id`) references `scenes`(`id`) on delete CASCADE,
+  foreign key(`performer_id`) references `performers`(`id`) on delete CASCADE,
+  foreign key(`studio_id`) references `studios`(`id`) on delete CASCADE
+);
+
+CREATE INDEX `index_deleted_stash_ids_on_endpoint_stash_id` on `deleted_stash_ids` (`endpoint`, `stash_id`);
@@ -0,0 +1,9 @@
+CREATE TABLE `tag_stash_ids` (
+  `tag_id` integer NOT NULL,
+  `endpoint` varchar(255) NOT NULL,
+  `stash_id` varchar(36) NOT NULL,
+  foreign key(`tag_id`) references `tags`(`id`) on delete CASCADE,
+  PRIMARY KEY(`tag_id`, `endpoint`)
+);
+
+CREATE INDEX `index_tag_stash_ids_on_endpoint_stash_id` ON `tag_stash_ids` (`endpoint`, `stash_id`);
@@ -0,0 +1,10 @@
+CREATE TABLE `files_fingerprint_history` (
+  `file_id` integer NOT NULL,
+  `type` varchar(255) NOT NULL,
+  `fingerprint` blob NOT NULL,
+  `created_at` datetime NOT NULL,
+  foreign key(`file_id`) references `files`(`id`) on delete CASCADE,
+  PRIMARY KEY(`file_id`, `type`, `fingerprint`)
+);
+
+CREATE INDEX `index_files_fingerprint_history_on_type_fingerprint` ON `files_fingerprint_history` (`type`, `fingerprint`);
@@ -414,6 +414,43 @@ func (r *captionRepository) get(ctx context.Context, id file.ID) ([]*models.Vide
 	return ret, err
 }
 
+// getMany returns the captions of each file, in the order of the file ids.
+func (r *captionRepository) getMany(ctx context.Context, ids []file.ID) ([][]*models.VideoCaption, error) {
+	ret := make([][]*models.VideoCaption, len(ids))
+	if len(ids) == 0 {
+		return ret, nil
+	}
+
+	query := fmt.Sprintf("SELECT %s, %s, %s, %s from %s WHERE %[1]s IN %[6]s", r.idColumn, captionCodeColumn, captionFilenameColumn, captionTypeColumn, r.tableName, getInBinding(len(ids)))
+
+	idi := make([]interface{}, len(ids))
+	idToIndex := make(map[file.ID]int)
+	for i, id := range ids {
+		idi[i] = id
+		idToIndex[id] = i
+	}
+
+	err := r.queryFunc(ctx, query, idi, false, func(rows *sqlx.Rows) error {
+		var fileID file.ID
+		var captionCode string
+		var captionFilename string
+		var captionType string
+
+		if err := rows.Scan(&fileID, &captionCode, &captionFilename, &captionType); err != nil {
+			return err
+		}
+
+		i := idToIndex[fileID]
+		ret[i] = append(ret[i], &models.VideoCaption{
+			LanguageCode: captionCode,
+			Filename:     captionFilename,
+			CaptionType:  captionType,
+		})
+		return nil
+	})
+	return ret, err
+}
+
 func (r *captionRepository) insert(ctx context.Context, id file.ID, caption *models.VideoCaption) (sql.Result, error) {
 	stmt := fmt.Sprintf("INSERT INTO %s (%s, %s, %s, %s) VALUES (?, ?, ?, ?)", r.tableName, r.idColumn, captionCodeColumn, captionFilenameColumn, captionTypeColumn)
 	return r.tx.Exec(ctx, stmt, id, caption.LanguageCode, caption.Filename, caption.CaptionType)
@@ -89,6 +89,10 @@ type sceneRow struct {
 	ResumeTime   float64       `db:"resume_time"`
 	PlayDuration float64       `db:"play_duration"`
 	PlayCount    int           `db:"play_count"`
+	IntroStart   null.Float    `db:"intro_start"`
+	IntroEnd     null.Float    `db:"intro_end"`
+	OutroStart   null.Float    `db:"outro_start"`
+	OutroEnd     null.Float    `db:"outro_end"`
 
 	// not used in resolutions or updates
 	CoverBlob zero.String `db:"cover_blob"`
@@ -112,6 +116,10 @@ func (r *sceneRow) fromScene(o models.Scene) {
 	r.ResumeTime = o.ResumeTime
 	r.PlayDuration = o.PlayDuration
 	r.PlayCount = o.PlayCount
+	r.IntroStart = null.FloatFromPtr(o.IntroStart)
+	r.IntroEnd = null.FloatFromPtr(o.IntroEnd)
+	r.OutroStart = null.FloatFromPtr(o.OutroStart)
+	r.OutroEnd = null.FloatFromPtr(o.OutroEnd)
 }
 
 type sceneQueryRow struct {
@@ -148,6 +156,11 @@ func (r *sceneQueryRow) resolve() *models.Scene {
 		ResumeTime:   r.ResumeTime,
 		PlayDuration: r.PlayDuration,
 		PlayCount:    r.PlayCount,
+
+		IntroStart: nullFloatPtr(r.IntroStart),
+		IntroEnd:   nullFloatPtr(r.IntroEnd),
+		OutroStart: nullFloatPtr(r.OutroStart),
+		OutroEnd:   nullFloatPtr(r.OutroEnd),
 	}
 
 	if r.PrimaryFileFolderPath.Valid && r.PrimaryFileBasename.Valid {
@@ -178,6 +191,10 @@ func (r *sceneRowRecord) fromPartial(o models.ScenePartial) {
 	r.setFloat64("resume_time", o.ResumeTime)
 	r.setFloat64("play_duration", o.PlayDuration)
 	r.setInt("play_count", o.PlayCount)
+	r.setNullFloat64("intro_start", o.IntroStart)
+	r.setNullFloat64("intro_end", o.IntroEnd)
+	r.setNullFloat64("outro_start", o.OutroStart)
+	r.setNullFloat64("outro_end", o.OutroEnd)
 }
 
 type SceneStore struct {
@@ -585,24 +602,12 @@ func (qb *SceneStore) CountByFileID(ctx context.Context, fileID file.ID) (int, e
 	return count(ctx, q)
 }
 
+// FindByFingerprints returns the scenes with a file which has, or had before
+// its contents were replaced, any of the provided fingerprints.
 func (qb *SceneStore) FindByFingerprints(ctx context.Context, fp []file.Fingerprint) ([]*models.Scene, error) {
-	fingerprintTable := fingerprintTableMgr.table
-
-	var ex []exp.Expression
-
-	for _, v := range fp {
-		ex = append(ex, goqu.And(
-			fingerprintTable.Col("type").Eq(v.Type),
-			fingerprintTable.Col("fingerprint").Eq(v.Fingerprint),
-		))
-	}
-
 	sq := dialect.From(scenesFilesJoinTable).
-		InnerJoin(
-			fingerprintTable,
-			goqu.On(fingerprintTable.Col(fileIDColumn).Eq(scenesFilesJoinTable.Col(fileIDColumn))),
-		).
-		Select(scenesFilesJoinTable.Col(sceneIDColumn)).Where(goqu.Or(ex...))
+		Select(scenesFilesJoinTable.Col(sceneIDColumn)).
+		Where(hasFingerprints(scenesFilesJoinTable.Col(fileIDColumn), fp))
 
 	ret, err := qb.findBySubquery(ctx, sq)
 	if err != nil {
@@ -537,6 +537,10 @@ func Test_sceneQueryBuilder_UpdatePartial(t *testing.T) {
 		resumeTime   = 10.0
 		playCount    = 3
 		playDuration = 34.0
+		introStart   = 0.0
+		introEnd     = 5.0
+		outroStart   = 50.0
+		outroEnd     = 60.0
 		createdAt    = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
 		updatedAt    = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
 		sceneIndex   = 123
@@ -614,6 +618,10 @@ func Test_sceneQueryBuilder_UpdatePartial(t *testing.T) {
 				ResumeTime:   models.NewOptionalFloat64(resumeTime),
 				PlayCount:    models.NewOptionalInt(playCount),
 				PlayDuration: models.NewOptionalFloat64(playDuration),
+				IntroStart:   models.NewOptionalFloat64(introStart),
+				IntroEnd:     models.NewOptionalFloat64(introEnd),
+				OutroStart:   models.NewOptionalFloat64(outroStart),
+				OutroEnd:     models.NewOptionalFloat64(outroEnd),
 			},
 			models.Scene{
 				ID: sceneIDs[sceneIdxWithSpacedName],
@@ -659,6 +667,10 @@ func Test_sceneQueryBuilder_UpdatePartial(t *testing.T) {
 				ResumeTime:   resumeTime,
 				PlayCount:    playCount,
 				PlayDuration: playDuration,
+				IntroStart:   &introStart,
+				IntroEnd:     &introEnd,
+				OutroStart:   &outroStart,
+				OutroEnd:     &outroEnd,
 			},
 			false,
 		},
@@ -1738,6 +1750,63 @@ func Test_sceneQueryBuilder_FindByOSHash(t *testing.T) {
 	}
 }
 
+func Test_sceneQueryBuilder_FindByOSHash_FingerprintHistory(t *testing.T) {
+	runWithRollbackTxn(t, "replaced file", func(t *testing.T, ctx context.Context) {
+		assert := assert.New(t)
+
+		const sceneIdx = sceneIdxWithSpacedName
+		fileID := sceneFileIDs[sceneIdx]
+		oldOSHash := getSceneStringValue(sceneIdx, "oshash")
+		const newOSHash = "replaced oshash"
+
+		files, err := db.File.Find(ctx, fileID)
+		if err != nil {
+			t.Errorf("FileStore.Find() error = %v", err)
+			return
+		}
+
+		// replace the contents of the file, as when re-encoding
+		f := files[0].(*file.VideoFile)
+		if err := db.File.AddFingerprintHistory(ctx, fileID, f.Fingerprints); err != nil {
+			t.Errorf("FileStore.AddFingerprintHistory() error = %v", err)
+			return
+		}
+
+		f.Fingerprints = []file.Fingerprint{
+			{
+				Type:        file.FingerprintTypeOshash,
+				Fingerprint: newOSHash,
+			},
+		}
+		if err := db.File.Update(ctx, f); err != nil {
+			t.Errorf("FileStore.Update() error = %v", err)
+			return
+		}
+
+		history, err := db.File.GetFingerprintHistory(ctx, fileID)
+		if err != nil {
+			t.Errorf("FileStore.GetFingerprintHistory() error = %v", err)
+			return
+		}
+		assert.Contains(history, file.Fingerprint{
+			Type:        file.FingerprintTypeOshash,
+			Fingerprint: oldOSHash,
+		})
+
+		for _, oshash := range []string{oldOSHash, newOSHash} {
+			got, err := db.Scene.FindByOSHash(ctx, oshash)
+			if err != nil {
+				t.Errorf("sceneQueryBuilder.FindByOSHash() error = %v", err)
+				return
+			}
+
+			if assert.Len(got, 1, oshash) {
+				assert.Equal(sceneIDs[sceneIdx], got[0].ID, oshash)
+			}
+		}
+	})
+}
+
 func Test_sceneQueryBuilder_FindByPath(t *testing.T) {
 	getPath := func(index int) string {
 		return getFilePath(folderIdxWithSceneFiles, getSceneBasename(index))
@@ -187,6 +187,11 @@ var (
 		table:    goqu.T(fingerprintTable),
 		idColumn: goqu.T(fingerprintTable).Col(idColumn),
 	}
+
+	fingerprintHistoryTableMgr = &table{
+		table:    goqu.T(fingerprintHistoryTable),
+		idColumn: goqu.T(fingerprintHistoryTable).Col(fileIDColumn),
+	}
 )
 
 var (
@@ -252,4 +257,14 @@ var (
 		table:    goqu.T(savedFilterTable),
 		idColumn: goqu.T(savedFilterTable).Col(idColumn),
 	}
+
+	identifyReviewTableMgr = &table{
+		table:    goqu.T(identifyReviewTable),
+		idColumn: goqu.T(identifyReviewTable).Col(idColumn),
+	}
+
+	deletedStashIDTableMgr = &table{
+		table:    goqu.T(deletedStashIDTable),
+		idColumn: goqu.T(deletedStashIDTable).Col(idColumn),
+	}
 )
@@ -17,10 +17,11 @@ import (
 )
 
 const (
-	tagTable        = "tags"
-	tagIDColumn     = "tag_id"
-	tagAliasesTable = "tag_aliases"
-	tagAliasColumn  = "alias"
+	tagTable         = "tags"
+	tagIDColumn      = "tag_id"
+	tagAliasesTable  = "tag_aliases"
+	tagAliasColumn   = "alias"
+	tagStashIDsTable = "tag_stash_ids"
 
 	tagImageBlobColumn = "image_blob"
 )
@@ -373,6 +374,16 @@ func (qb *TagStore) FindByNames(ctx context.Context, names []string, nocase bool
 	return ret, nil
 }
 
+func (qb *TagStore) FindByStashID(ctx context.Context, stashID models.StashID) ([]*models.Tag, error) {
+	query := selectAll(tagTable) + `
+		LEFT JOIN tag_stash_ids on tag_stash_ids.tag_id = tags.id
+		WHERE tag_stash_ids.stash_id = ?
+		AND tag_stash_ids.endpoint = ?
+	`
+	args := []interface{}{stashID.StashID, stashID.Endpoint}
+	return qb.queryTags(ctx, query, args)
+}
+
 func (qb *TagStore) FindByParentTagID(ctx context.Context, parentID int) ([]*models.Tag, error) {
 	query := `
 		SELECT tags.* FROM tags
@@ -944,6 +955,24 @@ func (qb *TagStore) UpdateAliases(ctx context.Context, tagID int, aliases []stri
 	return qb.aliasRepository().replace(ctx, tagID, aliases)
 }
 
+func (qb *TagStore) stashIDRepository() *stashIDRepository {
+	return &stashIDRepository{
+		repository{
+			tx:        qb.tx,
+			tableName: tagStashIDsTable,
+			idColumn:  tagIDColumn,
+		},
+	}
+}
+
+func (qb *TagStore) GetStashIDs(ctx context.Context, tagID int) ([]models.StashID, error) {
+	return qb.stashIDRepository().get(ctx, tagID)
+}
+
+func (qb *TagStore) UpdateStashIDs(ctx context.Context, tagID int, stashIDs []models.StashID) error {
+	return qb.stashIDRepository().replace(ctx, tagID, stashIDs)
+}
+
 func (qb *TagStore) Merge(ctx context.Context, source []int, destination int) error {
 	if len(source) == 0 {
 		return nil
@@ -1003,6 +1032,12 @@ AND NOT EXISTS(SELECT 1 FROM `+table+` o WHERE o.`+idColumn+` = `+table+`.`+idCo
 		return err
 	}
 
+	// stash ids for endpoints which the destination already has are dropped
+	_, err = qb.tx.Exec(ctx, "UPDATE OR IGNORE "+tagStashIDsTable+" SET tag_id = ? WHERE tag_id IN "+inBinding, args...)
+	if err != nil {
+		return err
+	}
+
 	for _, id := range source {
 		err = qb.Destroy(ctx, id)
 		if err != nil {
@@ -826,6 +826,58 @@ func TestTagUpdateAlias(t *testing.T) {
 	}
 }
 
+func TestTagUpdateStashIDs(t *testing.T) {
+	if err := withRollbackTxn(func(ctx context.Context) error {
+		qb := db.Tag
+
+		const (
+			endpoint = "https://stashdb.example/graphql"
+			stashID  = "tag-stash-id"
+		)
+
+		tagID := tagIDs[tagIdxWithScene]
+		stashIDs := []models.StashID{
+			{
+				Endpoint: endpoint,
+				StashID:  stashID,
+			},
+		}
+		if err := qb.UpdateStashIDs(ctx, tagID, stashIDs); err != nil {
+			return fmt.Errorf("Error updating tag stash ids: %s", err.Error())
+		}
+
+		stored, err := qb.GetStashIDs(ctx, tagID)
+		if err != nil {
+			return fmt.Errorf("Error getting stash ids: %s", err.Error())
+		}
+		assert.Equal(t, stashIDs, stored)
+
+		found, err := qb.FindByStashID(ctx, stashIDs[0])
+		if err != nil {
+			return fmt.Errorf("Error finding tag by stash id: %s", err.Error())
+		}
+		if assert.Len(t, found, 1) {
+			assert.Equal(t, tagID, found[0].ID)
+		}
+
+		// stash ids are moved to the destination of a merge
+		destID := tagIDs[tagIdx1WithScene]
+		if err := qb.Merge(ctx, []int{tagID}, destID); err != nil {
+			return err
+		}
+
+		stored, err = qb.GetStashIDs(ctx, destID)
+		if err != nil {
+			return fmt.Errorf("Error getting stash ids: %s", err.Error())
+		}
+		assert.Equal(t, stashIDs, stored)
+
+		return nil
+	}); err != nil {
+		t.Error(err.Error())
+	}
+}
+
 func TestTagMerge(t *testing.T) {
 	assert := assert.New(t)
 
@@ -139,5 +139,7 @@ func (db *Database) TxnRepository() models.Repository {
 		Studio:         db.Studio,
 		Tag:            db.Tag,
 		SavedFilter:    db.SavedFilter,
+		IdentifyReview: db.IdentifyReview,
+		DeletedStashID: db.DeletedStashID,
 	}
 }
@@ -6,6 +6,8 @@ import { Helmet } from "react-helmet";
 import * as GQL from "src/core/generated-graphql";
 import {
   mutateMetadataScan,
+  mutateSceneSplit,
+  mutateSceneTrim,
   useFindScene,
   useSceneIncrementO,
   useSceneDecrementO,
@@ -246,6 +248,20 @@ const ScenePage: React.FC<IProps> = ({
     });
   }
 
+  async function onSplit(input: Omit<GQL.SceneSplitInput, "id">) {
+    await mutateSceneSplit({ id: scene.id, ...input });
+    Toast.success({
+      content: intl.formatMessage({ id: "toast.started_splitting" }),
+    });
+  }
+
+  async function onTrim(input: Omit<GQL.SceneTrimInput, "id">) {
+    await mutateSceneTrim({ id: scene.id, ...input });
+    Toast.success({
+      content: intl.formatMessage({ id: "toast.started_trimming" }),
+    });
+  }
+
   function onDeleteDialogClosed(deleted: boolean) {
     setIsDeleteAlertOpen(false);
     if (deleted) {
@@ -315,6 +331,40 @@ const ScenePage: React.FC<IProps> = ({
         >
           <FormattedMessage id="actions.generate_thumb_default" />
         </Dropdown.Item>
+        {!!scene.files.length && (
+          <>
+            <Dropdown.Item
+              key="split-current"
+              className="bg-secondary text-white"
+              onClick={() => onSplit({ times: [getPlayerPosition() ?? 0] })}
+            >
+              <FormattedMessage id="actions.split_at_current" />
+            </Dropdown.Item>
+            {scene.scene_markers.length > 0 && (
+              <Dropdown.Item
+                key="split-markers"
+                className="bg-secondary text-white"
+                onClick={() => onSplit({ markers: true })}
+              >
+                <FormattedMessage id="actions.split_at_markers" />
+              </Dropdown.Item>
+            )}
+            <Dropdown.Item
+              key="trim-start"
+              className="bg-secondary text-white"
+              onClick={() => onTrim({ start: getPlayerPosition() })}
+            >
+              <FormattedMessage id="actions.trim_start_at_current" />
+            </Dropdown.Item>
+            <Dropdown.Item
+              key="trim-end"
+              className="bg-secondary text-white"
+              onClick={() => onTrim({ end: getPlayerPosition() })}
+            >
+              <FormattedMessage id="actions.trim_end_at_current" />
+            </Dropdown.Item>
+          </>
+        )}
         {boxes.length > 0 && (
           <Dropdown.Item
             key="submit"
@@ -15,7 +15,12 @@ import { Icon } from "../Shared/Icon";
 import { LoadingIndicator } from "../Shared/LoadingIndicator";
 import { ScrapeType } from "src/core/generated-graphql";
 import { SettingSection } from "./SettingSection";
-import { BooleanSetting, StringListSetting, StringSetting } from "./Inputs";
+import {
+  BooleanSetting,
+  NumberSetting,
+  StringListSetting,
+  StringSetting,
+} from "./Inputs";
 import { SettingStateContext } from "./context";
 import { StashBoxSetting } from "./StashBoxConfiguration";
 import { faSyncAlt } from "@fortawesome/free-solid-svg-icons";
@@ -329,6 +334,38 @@ export const SettingsScrapingPanel: React.FC = () => {
           onChange={(v) => saveScraping({ scraperCDPPath: v })}
         />
 
+        <NumberSetting
+          id="scraperCDPPoolMin"
+          headingID="config.scraping.cdp_pool_min_head"
+          subHeadingID="config.scraping.cdp_pool_min_desc"
+          value={scraping.scraperCDPPoolMin ?? undefined}
+          onChange={(v) => saveScraping({ scraperCDPPoolMin: v })}
+        />
+
+        <NumberSetting
+          id="scraperCDPPoolMax"
+          headingID="config.scraping.cdp_pool_max_head"
+          subHeadingID="config.scraping.cdp_pool_max_desc"
+          value={scraping.scraperCDPPoolMax ?? undefined}
+          onChange={(v) => saveScraping({ scraperCDPPoolMax: v })}
+        />
+
+        <NumberSetting
+          id="scraperCDPIdleTimeout"
+          headingID="config.scraping.cdp_idle_timeout_head"
+          subHeadingID="config.scraping.cdp_idle_timeout_desc"
+          value={scraping.scraperCDPIdleTimeout ?? undefined}
+          onChange={(v) => saveScraping({ scraperCDPIdleTimeout: v })}
+        />
+
+        <BooleanSetting
+          id="scraper-cdp-block-resources"
+          headingID="config.scraping.cdp_block_resources_head"
+          subHeadingID="config.scraping.cdp_block_resources_desc"
+          checked={scraping.scraperCDPBlockResources ?? undefined}
+          onChange={(v) => saveScraping({ scraperCDPBlockResources: v })}
+        />
+
         <BooleanSetting
           id="scraper-cert-check"
           headingID="config.general.check_for_insecure_certificates"
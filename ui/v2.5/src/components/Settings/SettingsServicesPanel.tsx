@@ -455,6 +455,18 @@ export const SettingsServicesPanel: React.FC = () => {
             onChange={(v) => saveDLNA({ whitelistedIPs: v })}
           />
 
+          <StringListSetting
+            id="dlna-activity-excluded-ips"
+            headingID="config.dlna.activity_excluded_ips"
+            subHeading={intl.formatMessage(
+              { id: "config.dlna.activity_excluded_ips_desc" },
+              { wildcard: <code>*</code> }
+            )}
+            defaultNewValue="*"
+            value={dlna.activityExcludedIPs ?? undefined}
+            onChange={(v) => saveDLNA({ activityExcludedIPs: v })}
+          />
+
           <SelectSetting
             id="video-sort-order"
             headingID="config.dlna.video_sort_order"
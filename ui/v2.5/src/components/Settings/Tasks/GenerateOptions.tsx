@@ -26,6 +26,13 @@ export const GenerateOptions: React.FC<IGenerateOptions> = ({
 
   return (
     <>
+      <BooleanSetting
+        id="scene-cuts-task"
+        checked={options.sceneCuts ?? false}
+        headingID="dialogs.scene_gen.scene_cuts"
+        tooltipID="dialogs.scene_gen.scene_cuts_tooltip"
+        onChange={(v) => setOptions({ sceneCuts: v })}
+      />
       <BooleanSetting
         id="covers-task"
         headingID="dialogs.scene_gen.covers"
@@ -129,6 +136,14 @@ export const GenerateOptions: React.FC<IGenerateOptions> = ({
         />
       ) : undefined}
 
+      <BooleanSetting
+        id="remux-task"
+        checked={options.remuxes ?? false}
+        headingID="dialogs.scene_gen.remuxes"
+        tooltipID="dialogs.scene_gen.remuxes_tooltip"
+        onChange={(v) => setOptions({ remuxes: v })}
+      />
+
       <BooleanSetting
         id="phash-task"
         checked={options.phashes ?? false}
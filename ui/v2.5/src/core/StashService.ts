@@ -553,6 +553,18 @@ export const mutateSceneMerge = (
     refetchQueries: getQueryNames([GQL.FindSceneDocument]),
   });
 
+export const mutateSceneSplit = (input: GQL.SceneSplitInput) =>
+  client.mutate<GQL.SceneSplitMutation>({
+    mutation: GQL.SceneSplitDocument,
+    variables: { input },
+  });
+
+export const mutateSceneTrim = (input: GQL.SceneTrimInput) =>
+  client.mutate<GQL.SceneTrimMutation>({
+    mutation: GQL.SceneTrimDocument,
+    variables: { input },
+  });
+
 export const mutateCreateScene = (input: GQL.SceneCreateInput) =>
   client.mutate<GQL.SceneCreateMutation>({
     mutation: GQL.SceneCreateDocument,